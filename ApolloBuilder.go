@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/Salvionied/cbor/v2"
 	"github.com/SundaeSwap-finance/apollo/apollotypes"
@@ -13,7 +14,11 @@ import (
 	"github.com/SundaeSwap-finance/apollo/serialization"
 	"github.com/SundaeSwap-finance/apollo/serialization/Address"
 	"github.com/SundaeSwap-finance/apollo/serialization/Amount"
+	"github.com/SundaeSwap-finance/apollo/serialization/BootstrapWitness"
+	"github.com/SundaeSwap-finance/apollo/serialization/CIP68"
 	"github.com/SundaeSwap-finance/apollo/serialization/Certificate"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/Governance"
 	"github.com/SundaeSwap-finance/apollo/serialization/HDWallet"
 	"github.com/SundaeSwap-finance/apollo/serialization/Key"
 	"github.com/SundaeSwap-finance/apollo/serialization/Metadata"
@@ -32,7 +37,11 @@ import (
 	"github.com/SundaeSwap-finance/apollo/serialization/Withdrawal"
 	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
 	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/BlockFrostChainContext"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/CoinSelection"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Errors"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/SlotConfig"
 	"github.com/SundaeSwap-finance/apollo/txBuilding/Utils"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Validation"
 )
 
 const (
@@ -77,6 +86,16 @@ type Apollo struct {
 	scriptHashes       []string
 	forceFee           bool
 	referencedScriptVersions map[string]bool
+	votingProcedures   Governance.VotingProcedures
+	proposalProcedures Governance.ProposalProcedures
+	voteRedeemers      map[Governance.Voter]Redeemer.Redeemer
+	proposalRedeemers  map[string]Redeemer.Redeemer
+	certRedeemers      map[string]Redeemer.Redeemer
+	treasuryValue      *int64
+	donation           int64
+	coinSelector       CoinSelection.UTxOSelector
+	maxInputCount      int
+	slotConfig         *SlotConfig.SlotConfig
 }
 
 const PlutusV1 = "V1"
@@ -121,6 +140,11 @@ func New(cc Base.ChainContext) *Apollo {
 		referenceInputs:    make([]TransactionInput.TransactionInput, 0),
 		referenceScripts:   make([]PlutusData.ScriptHashable, 0),
 		referencedScriptVersions: make(map[string]bool),
+		votingProcedures:   make(Governance.VotingProcedures),
+		proposalProcedures: make(Governance.ProposalProcedures, 0),
+		voteRedeemers:      make(map[Governance.Voter]Redeemer.Redeemer),
+		proposalRedeemers:  make(map[string]Redeemer.Redeemer),
+		certRedeemers:      make(map[string]Redeemer.Redeemer),
 	}
 }
 
@@ -301,15 +325,67 @@ func (b *Apollo) buildFakeWitnessSet() TransactionWitnessSet.TransactionWitnessS
 			Vkey:      constants.FAKE_VKEY,
 			Signature: constants.FAKE_SIGNATURE})
 	}
+	for voter := range b.votingProcedures {
+		if !voter.IsScript() {
+			fakeVkWitnesses = append(fakeVkWitnesses, VerificationKeyWitness.VerificationKeyWitness{
+				Vkey:      constants.FAKE_VKEY,
+				Signature: constants.FAKE_SIGNATURE})
+		}
+	}
+	nativeSigners := make(map[serialization.PubKeyHash]bool)
+	for _, script := range b.nativescripts {
+		for _, pkh := range script.KeyHashes() {
+			if !nativeSigners[pkh] {
+				nativeSigners[pkh] = true
+				fakeVkWitnesses = append(fakeVkWitnesses, VerificationKeyWitness.VerificationKeyWitness{
+					Vkey:      constants.FAKE_VKEY,
+					Signature: constants.FAKE_SIGNATURE})
+			}
+		}
+	}
+	if b.certificates != nil {
+		certSigners := make(map[Credential.Credential]bool)
+		for _, cert := range *b.certificates {
+			for _, cred := range cert.Witnesses() {
+				if !cred.IsScript() && !certSigners[cred] {
+					certSigners[cred] = true
+					fakeVkWitnesses = append(fakeVkWitnesses, VerificationKeyWitness.VerificationKeyWitness{
+						Vkey:      constants.FAKE_VKEY,
+						Signature: constants.FAKE_SIGNATURE})
+				}
+			}
+		}
+	}
 	return TransactionWitnessSet.TransactionWitnessSet{
-		NativeScripts:  b.nativescripts,
-		PlutusV1Script: b.v1scripts,
-		PlutusV2Script: b.v2scripts,
-		PlutusV3Script: b.v3scripts,
-		PlutusData:     PlutusData.PlutusIndefArray(plutusdata),
-		Redeemer:       b.redeemers,
-		VkeyWitnesses:  fakeVkWitnesses,
+		NativeScripts:      b.nativescripts,
+		PlutusV1Script:     b.v1scripts,
+		PlutusV2Script:     b.v2scripts,
+		PlutusV3Script:     b.v3scripts,
+		PlutusData:         PlutusData.PlutusIndefArray(plutusdata),
+		Redeemer:           b.redeemers,
+		VkeyWitnesses:      fakeVkWitnesses,
+		BootstrapWitnesses: b.fakeBootstrapWitnesses(),
+	}
+}
+
+// fakeBootstrapWitnesses returns a witness per bootstrap address spent
+// from, whose attributes weigh on the fee.
+func (b *Apollo) fakeBootstrapWitnesses() []BootstrapWitness.BootstrapWitness {
+	witnesses := make([]BootstrapWitness.BootstrapWitness, 0)
+	seen := make(map[string]bool)
+	for _, utxos := range [][]UTxO.UTxO{b.preselectedUtxos, b.collaterals} {
+		for _, utxo := range utxos {
+			address := utxo.Output.GetAddress()
+			if address.AddressType != Address.BYRON || seen[string(address.Bytes())] {
+				continue
+			}
+			seen[string(address.Bytes())] = true
+			if byron, err := address.Byron(); err == nil {
+				witnesses = append(witnesses, BootstrapWitness.Fake(byron))
+			}
+		}
 	}
+	return witnesses
 }
 
 func (b *Apollo) scriptDataHash() *serialization.ScriptDataHash {
@@ -420,6 +496,14 @@ func (b *Apollo) buildTxBody() TransactionBody.TransactionBody {
 		Certificates:      b.certificates,
 		Withdrawals:       withdrawals,
 		ReferenceInputs:   b.referenceInputs}
+	if len(b.votingProcedures) > 0 {
+		txb.VotingProcedures = &b.votingProcedures
+	}
+	if len(b.proposalProcedures) > 0 {
+		txb.ProposalProcedures = b.proposalProcedures
+	}
+	txb.TreasuryValue = b.treasuryValue
+	txb.Donation = b.donation
 	if b.totalCollateral != 0 {
 		txb.TotalCollateral = b.totalCollateral
 		txb.CollateralReturn = b.collateralReturn
@@ -501,6 +585,12 @@ func (b *Apollo) setRedeemerIndexes() *Apollo {
 			//TODO: IMPLEMENT FOR MINTS
 		}
 	}
+	for i, voter := range b.votingProcedures.Voters() {
+		if redeem, ok := b.voteRedeemers[voter]; ok {
+			redeem.Index = i
+			b.voteRedeemers[voter] = redeem
+		}
+	}
 	return b
 }
 
@@ -516,6 +606,7 @@ func (b *Apollo) setCollateral() *Apollo {
 	witnesses := b.buildWitnessSet()
 	if len(witnesses.PlutusV1Script) == 0 &&
 		len(witnesses.PlutusV2Script) == 0 &&
+		len(witnesses.PlutusV3Script) == 0 &&
 		len(b.referenceInputs) == 0 {
 		return b
 	}
@@ -586,6 +677,27 @@ func (b *Apollo) updateExUnits() (*Apollo, []byte, error) {
 				b.mintRedeemers[k] = redeemer
 			}
 		}
+		for k, redeemer := range b.voteRedeemers {
+			key := fmt.Sprintf("%s:%d", Redeemer.RedeemerTagNames[redeemer.Tag], redeemer.Index)
+			if _, ok := estimated_execution_units[key]; ok {
+				redeemer.ExUnits = estimated_execution_units[key]
+				b.voteRedeemers[k] = redeemer
+			}
+		}
+		for k, redeemer := range b.proposalRedeemers {
+			key := fmt.Sprintf("%s:%d", Redeemer.RedeemerTagNames[redeemer.Tag], redeemer.Index)
+			if _, ok := estimated_execution_units[key]; ok {
+				redeemer.ExUnits = estimated_execution_units[key]
+				b.proposalRedeemers[k] = redeemer
+			}
+		}
+		for k, redeemer := range b.certRedeemers {
+			key := fmt.Sprintf("%s:%d", Redeemer.RedeemerTagNames[redeemer.Tag], redeemer.Index)
+			if _, ok := estimated_execution_units[key]; ok {
+				redeemer.ExUnits = estimated_execution_units[key]
+				b.certRedeemers[k] = redeemer
+			}
+		}
 		for _, redeemer := range b.redeemersToUTxO {
 			b.redeemers = append(b.redeemers, redeemer)
 		}
@@ -595,6 +707,15 @@ func (b *Apollo) updateExUnits() (*Apollo, []byte, error) {
 		for _, redeemer := range b.mintRedeemers {
 			b.redeemers = append(b.redeemers, redeemer)
 		}
+		for _, redeemer := range b.voteRedeemers {
+			b.redeemers = append(b.redeemers, redeemer)
+		}
+		for _, redeemer := range b.proposalRedeemers {
+			b.redeemers = append(b.redeemers, redeemer)
+		}
+		for _, redeemer := range b.certRedeemers {
+			b.redeemers = append(b.redeemers, redeemer)
+		}
 	} else {
 		for _, redeemer := range b.redeemersToUTxO {
 			b.redeemers = append(b.redeemers, redeemer)
@@ -605,6 +726,15 @@ func (b *Apollo) updateExUnits() (*Apollo, []byte, error) {
 		for _, redeemer := range b.mintRedeemers {
 			b.redeemers = append(b.redeemers, redeemer)
 		}
+		for _, redeemer := range b.voteRedeemers {
+			b.redeemers = append(b.redeemers, redeemer)
+		}
+		for _, redeemer := range b.proposalRedeemers {
+			b.redeemers = append(b.redeemers, redeemer)
+		}
+		for _, redeemer := range b.certRedeemers {
+			b.redeemers = append(b.redeemers, redeemer)
+		}
 	}
 	return b, nil, nil
 }
@@ -616,6 +746,9 @@ func (b *Apollo) GetTx() *Transaction.Transaction {
 // If this fails due to a script failure, it returns the failed tx cbor as
 // bytes for diagnostic purposes.
 func (b *Apollo) Complete() (*Apollo, []byte, error) {
+	if err := b.checkNativeScripts(); err != nil {
+		return nil, nil, err
+	}
 	selectedUtxos := make([]UTxO.UTxO, 0)
 	selectedAmount := Value.Value{}
 	for _, utxo := range b.preselectedUtxos {
@@ -623,6 +756,11 @@ func (b *Apollo) Complete() (*Apollo, []byte, error) {
 	}
 	mintedValue := b.GetMints()
 	selectedAmount = selectedAmount.Add(mintedValue)
+	refunds, err := b.getRefunds()
+	if err != nil {
+		return nil, nil, err
+	}
+	selectedAmount.AddLovelace(refunds)
 	requestedAmount := Value.Value{}
 	for _, payment := range b.payments {
 		payment.EnsureMinUTXO(b.Context)
@@ -633,32 +771,57 @@ func (b *Apollo) Complete() (*Apollo, []byte, error) {
 		return nil, nil, err
 	}
 	requestedAmount.AddLovelace(estimatedFee + constants.MIN_LOVELACE)
+	deposits, err := b.getDeposits()
+	if err != nil {
+		return nil, nil, err
+	}
+	requestedAmount.AddLovelace(deposits + b.donation)
 	unfulfilledAmount := requestedAmount.Sub(selectedAmount)
 	unfulfilledAmount = unfulfilledAmount.RemoveZeroAssets()
 	available_utxos := SortUtxos(b.getAvailableUtxos())
 	//BALANCE TX
-	if !unfulfilledAmount.Less(Value.Value{}) {
+	if b.coinSelector != nil {
+		if !unfulfilledAmount.Less(Value.Value{}) {
+			selectedUtxos, err = b.selectWithCoinSelector(unfulfilledAmount, available_utxos)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	} else if !unfulfilledAmount.Less(Value.Value{}) {
 		//BALANCE
 		if len(unfulfilledAmount.GetAssets()) > 0 {
 			//BALANCE WITH ASSETS
+			pickedAmount := Value.Value{}
 			for pol, assets := range unfulfilledAmount.GetAssets() {
 				for asset, amt := range assets {
 					if amt <= 0 {
 						continue
 					}
-					found := false
-					selectedSoFar := int64(0)
+					// utxos picked for an earlier asset may already cover this one
+					selectedSoFar := pickedAmount.GetAssets().GetByPolicyAndId(pol, asset)
+					found := selectedSoFar >= amt
+					if found {
+						continue
+					}
 					for _, utxo := range available_utxos {
+						if b.usedUtxos[utxo.GetKey()] {
+							continue
+						}
+						if b.exceedsMaxInputCount(len(selectedUtxos) + 1) {
+							return nil, nil, &CoinSelection.MaxInputCountExceededError{MaxInputCount: b.maxInputCount}
+						}
 						ma := utxo.Output.GetValue().GetAssets()
 						if ma.GetByPolicyAndId(pol, asset) >= amt {
 							selectedUtxos = append(selectedUtxos, utxo)
 							selectedAmount = selectedAmount.Add(utxo.Output.GetValue())
+							pickedAmount = pickedAmount.Add(utxo.Output.GetValue())
 							b.usedUtxos[utxo.GetKey()] = true
 							found = true
 							break
 						} else if ma.GetByPolicyAndId(pol, asset) > 0 {
 							selectedUtxos = append(selectedUtxos, utxo)
 							selectedAmount = selectedAmount.Add(utxo.Output.GetValue())
+							pickedAmount = pickedAmount.Add(utxo.Output.GetValue())
 							b.usedUtxos[utxo.GetKey()] = true
 							selectedSoFar += ma.GetByPolicyAndId(pol, asset)
 							if selectedSoFar >= amt {
@@ -668,7 +831,9 @@ func (b *Apollo) Complete() (*Apollo, []byte, error) {
 						}
 					}
 					if !found {
-						return nil, nil, fmt.Errorf("missing required assets: %v", unfulfilledAmount)
+						return nil, nil, &CoinSelection.InsufficientUtxoBalanceError{
+							Msg: fmt.Sprintf(" missing required assets: %v", unfulfilledAmount),
+						}
 					}
 
 				}
@@ -679,12 +844,20 @@ func (b *Apollo) Complete() (*Apollo, []byte, error) {
 				break
 			}
 			if len(available_utxos) == 0 {
-				return nil, nil, errors.New("not enough funds")
+				return nil, nil, &CoinSelection.InsufficientUtxoBalanceError{
+					Msg: fmt.Sprintf(" requested %v, available %v", requestedAmount, selectedAmount),
+				}
 			}
 			utxo := available_utxos[0]
+			available_utxos = available_utxos[1:]
+			if b.usedUtxos[utxo.GetKey()] {
+				continue
+			}
+			if b.exceedsMaxInputCount(len(selectedUtxos) + 1) {
+				return nil, nil, &CoinSelection.MaxInputCountExceededError{MaxInputCount: b.maxInputCount}
+			}
 			selectedUtxos = append(selectedUtxos, utxo)
 			selectedAmount = selectedAmount.Add(utxo.Output.GetValue())
-			available_utxos = available_utxos[1:]
 			b.usedUtxos[utxo.GetKey()] = true
 		}
 
@@ -703,6 +876,9 @@ func (b *Apollo) Complete() (*Apollo, []byte, error) {
 	}
 	//ADDCHANGEANDFEE
 	b, err = b.addChangeAndFee()
+	if err != nil {
+		return nil, nil, err
+	}
 	//FINALIZE TX
 	body := b.buildTxBody()
 	witnessSet := b.buildWitnessSet()
@@ -804,6 +980,11 @@ func (b *Apollo) addChangeAndFee() (*Apollo, error) {
 		providedAmount = providedAmount.Add(utxo.Output.GetValue())
 	}
 	providedAmount = providedAmount.Sub(burns)
+	refunds, err := b.getRefunds()
+	if err != nil {
+		return nil, err
+	}
+	providedAmount.AddLovelace(refunds)
 	requestedAmount := Value.Value{}
 	for _, payment := range b.payments {
 		requestedAmount = requestedAmount.Add(payment.ToValue())
@@ -814,6 +995,11 @@ func (b *Apollo) addChangeAndFee() (*Apollo, error) {
 	}
 	b.Fee = estimatedFee
 	requestedAmount.AddLovelace(b.Fee)
+	deposits, err := b.getDeposits()
+	if err != nil {
+		return nil, err
+	}
+	requestedAmount.AddLovelace(deposits + b.donation)
 	change := providedAmount.Sub(requestedAmount)
 
 	if change.GetCoin() < Utils.MinLovelacePostAlonzo(
@@ -821,6 +1007,14 @@ func (b *Apollo) addChangeAndFee() (*Apollo, error) {
 		b.Context,
 	) {
 		sortedUtxos := SortUtxos(b.getAvailableUtxos())
+		if len(sortedUtxos) == 0 {
+			return nil, &CoinSelection.InsufficientUtxoBalanceError{
+				Msg: fmt.Sprintf(" change %v is below the minimum UTxO value", change),
+			}
+		}
+		if b.exceedsMaxInputCount(1) {
+			return nil, &CoinSelection.MaxInputCountExceededError{MaxInputCount: b.maxInputCount}
+		}
 		b.preselectedUtxos = append(b.preselectedUtxos, sortedUtxos[0])
 		b.usedUtxos[sortedUtxos[0].GetKey()] = true
 		return b.addChangeAndFee()
@@ -933,19 +1127,129 @@ func (b *Apollo) AttachV3Script(script PlutusData.PlutusV3Script) *Apollo {
 	return b
 }
 
+// AttachNativeScript adds a native script to the witness set, for
+// spending from its address or minting under its policy.
+func (b *Apollo) AttachNativeScript(script NativeScript.NativeScript) *Apollo {
+	hash := script.Hash()
+	for _, scriptHash := range b.scriptHashes {
+		if scriptHash == hex.EncodeToString(hash.Bytes()) {
+			return b
+		}
+	}
+	b.nativescripts = append(b.nativescripts, script)
+	b.scriptHashes = append(b.scriptHashes, hex.EncodeToString(hash.Bytes()))
+	return b
+}
+
+// MintAssetsWithNativeScript mints mintUnit under the policy of script,
+// which is attached to the transaction. The policy id of mintUnit is
+// replaced by the hash of script.
+func (b *Apollo) MintAssetsWithNativeScript(mintUnit Unit, script NativeScript.NativeScript) *Apollo {
+	hash := script.Hash()
+	mintUnit.PolicyId = hex.EncodeToString(hash.Bytes())
+	b.mint = append(b.mint, mintUnit)
+	return b.AttachNativeScript(script)
+}
+
+/*
+MintCIP68 mints the CIP-68 reference token of name under policyId and
+quantity user tokens with label, paying the reference token to
+referenceAddress with datum inline. The user tokens go to the change
+address unless paid elsewhere, and the minting policy has to be
+attached like for MintAssets.
+*/
+func (b *Apollo) MintCIP68(policyId string, label int, name string, quantity int, datum CIP68.Datum, referenceAddress Address.Address) (*Apollo, error) {
+	referenceName, err := CIP68.LabelledName(CIP68.REFERENCE_LABEL, name)
+	if err != nil {
+		return b, err
+	}
+	userName, err := CIP68.LabelledName(label, name)
+	if err != nil {
+		return b, err
+	}
+	pd, err := datum.ToPlutusData()
+	if err != nil {
+		return b, err
+	}
+	referenceToken := NewUnit(policyId, referenceName, 1)
+	b = b.MintAssets(referenceToken).MintAssets(NewUnit(policyId, userName, quantity))
+	return b.PayToContract(referenceAddress, &pd, 0, true, referenceToken), nil
+}
+
+// CollectFromNativeScript spends a UTxO locked at the address of a
+// native script, attaching the script.
+func (b *Apollo) CollectFromNativeScript(inputUtxo UTxO.UTxO, script NativeScript.NativeScript) *Apollo {
+	b.preselectedUtxos = append(b.preselectedUtxos, inputUtxo)
+	b.usedUtxos[inputUtxo.GetKey()] = true
+	return b.AttachNativeScript(script)
+}
+
+// checkNativeScripts fails when an attached native script can't
+// validate within the validity interval of the transaction.
+func (b *Apollo) checkNativeScripts() error {
+	for _, script := range b.nativescripts {
+		if !script.ValidInInterval(b.ValidityStart, b.Ttl) {
+			return &Errors.NativeScriptIntervalError{
+				ScriptHash:    script.Hash(),
+				ValidityStart: b.ValidityStart,
+				Ttl:           b.Ttl,
+			}
+		}
+	}
+	return nil
+}
+
 
 
+/*
+SetWalletFromMnemonic sets the wallet to the first address of account 0
+of mnemonic, on the network of the chain context: the payment key
+m/1852'/1815'/0'/0/0 with the stake key m/1852'/1815'/0'/2/0.
+*/
 func (a *Apollo) SetWalletFromMnemonic(mnemonic string) *Apollo {
-	paymentPath := "m/1852'/1815'/0'/0/0"
-	stakingPath := "m/1852'/1815'/0'/2/0"
-	hdWall := HDWallet.NewHDWalletFromMnemonic(mnemonic, "")
-	paymentKeyPath := hdWall.DerivePath(paymentPath)
+	return a.setWalletFromMnemonic(mnemonic, "", a.contextNetwork())
+}
+
+// SetWalletFromMnemonicWithNetwork is SetWalletFromMnemonic on network,
+// failing on an invalid mnemonic.
+func (a *Apollo) SetWalletFromMnemonicWithNetwork(mnemonic string, network constants.Network) (*Apollo, error) {
+	return a.SetWalletFromMnemonicWithPassphrase(mnemonic, "", network)
+}
+
+// SetWalletFromMnemonicWithPassphrase is SetWalletFromMnemonicWithNetwork
+// for a mnemonic protected by a BIP-39 passphrase.
+func (a *Apollo) SetWalletFromMnemonicWithPassphrase(mnemonic string, passphrase string, network constants.Network) (*Apollo, error) {
+	if !HDWallet.IsMnemonic(mnemonic) {
+		return a, errors.New("invalid mnemonic")
+	}
+	return a.setWalletFromMnemonic(mnemonic, passphrase, network), nil
+}
+
+/*
+contextNetwork returns the network of the chain context, mainnet or
+testnet, whose Network is either a constants.Network or the network
+magic.
+*/
+func (a *Apollo) contextNetwork() constants.Network {
+	if a.Context == nil {
+		return constants.MAINNET
+	}
+	switch a.Context.Network() {
+	case int(constants.MAINNET), constants.MAINNET_PROTOCOL_MAGIC:
+		return constants.MAINNET
+	default:
+		return constants.TESTNET
+	}
+}
+
+func (a *Apollo) setWalletFromMnemonic(mnemonic string, passphrase string, network constants.Network) *Apollo {
+	hdWall := HDWallet.NewHDWalletFromMnemonic(mnemonic, passphrase)
+	paymentKeyPath := hdWall.DeriveKey(0, HDWallet.EXTERNAL_CHAIN, 0)
 	verificationKey_bytes := paymentKeyPath.XPrivKey.PublicKey()
 	signingKey_bytes := paymentKeyPath.XPrivKey.Bytes()
-	stakingKeyPath := hdWall.DerivePath(stakingPath)
+	stakingKeyPath := hdWall.DeriveKey(0, HDWallet.STAKING_CHAIN, 0)
 	stakeVerificationKey_bytes := stakingKeyPath.XPrivKey.PublicKey()
 	stakeSigningKey_bytes := stakingKeyPath.XPrivKey.Bytes()
-	//stake := stakingKeyPath.RootXprivKey.Bytes()
 	signingKey := Key.SigningKey{Payload: signingKey_bytes}
 	verificationKey := Key.VerificationKey{Payload: verificationKey_bytes}
 	stakeSigningKey := Key.StakeSigningKey{Payload: stakeSigningKey_bytes}
@@ -954,12 +1258,40 @@ func (a *Apollo) SetWalletFromMnemonic(mnemonic string) *Apollo {
 	skh, _ := stakeVerKey.Hash()
 	vkh, _ := verificationKey.Hash()
 
-	addr := Address.Address{StakingPart: skh[:], PaymentPart: vkh[:], Network: 1, AddressType: Address.KEY_KEY, HeaderByte: 0b00000001, Hrp: "addr"}
-	wallet := apollotypes.GenericWallet{SigningKey: signingKey, VerificationKey: verificationKey, Address: addr, StakeSigningKey: stakeSigningKey, StakeVerificationKey: stakeVerificationKey}
+	addr := Address.AddressFromBytes(vkh[:], false, skh[:], false, network)
+	wallet := apollotypes.GenericWallet{SigningKey: signingKey, VerificationKey: verificationKey, Address: *addr, StakeSigningKey: stakeSigningKey, StakeVerificationKey: stakeVerificationKey}
 	a.wallet = &wallet
 	return a
 }
 
+/*
+SetIcarusWalletFromMnemonic sets the wallet to the first Icarus
+bootstrap address of mnemonic on network, m/44'/1815'/0'/0/0, whose
+inputs are signed with bootstrap witnesses.
+*/
+func (a *Apollo) SetIcarusWalletFromMnemonic(mnemonic string, network constants.Network) (*Apollo, error) {
+	if !HDWallet.IsMnemonic(mnemonic) {
+		return a, errors.New("invalid mnemonic")
+	}
+	hdWall := HDWallet.NewHDWalletFromMnemonic(mnemonic, "")
+	a.wallet = apollotypes.NewIcarusWallet(hdWall, 0, HDWallet.EXTERNAL_CHAIN, 0, network)
+	return a, nil
+}
+
+/*
+SetHDWallet uses a discovered HD account as wallet: its UTxOs are
+loaded, change goes to its first unused internal address and signing
+uses the key of each address spent from. Unless the wallet has a
+HasHistory, emptied addresses count as unused, so change may go to an
+address used before.
+*/
+func (a *Apollo) SetHDWallet(wallet *apollotypes.HDAccountWallet) *Apollo {
+	a.wallet = wallet
+	a = a.AddLoadedUTxOs(wallet.Utxos()...)
+	a.inputAddresses = append(a.inputAddresses, wallet.ChangeAddress())
+	return a
+}
+
 // For use with key pairs generated by cardano-cli
 func (a *Apollo) SetWalletFromKeypair(vkey string, skey string, network constants.Network) *Apollo {
 	verificationKey_bytes, err := hex.DecodeString(vkey)
@@ -1035,9 +1367,67 @@ func (b *Apollo) Submit() (serialization.TransactionId, error) {
 	return b.Context.SubmitTx(*b.tx)
 }
 
+// resolveTxInputs resolves the inputs, collaterals and reference inputs
+// of the transaction from the loaded UTxOs first and from the chain
+// context otherwise, skipping the ones it can't find.
+func (b *Apollo) resolveTxInputs() []UTxO.UTxO {
+	known := make(map[string]UTxO.UTxO)
+	for _, utxos := range [][]UTxO.UTxO{b.utxos, b.preselectedUtxos, b.collaterals} {
+		for _, utxo := range utxos {
+			known[utxo.GetKey()] = utxo
+		}
+	}
+	body := b.tx.TransactionBody
+	resolved := make([]UTxO.UTxO, 0)
+	for _, inputs := range [][]TransactionInput.TransactionInput{body.Inputs, body.Collateral, body.ReferenceInputs} {
+		for _, input := range inputs {
+			txHash := hex.EncodeToString(input.TransactionId)
+			utxo, ok := known[fmt.Sprintf("%s:%d", txHash, input.Index)]
+			if !ok {
+				fetched, err := b.Context.GetUtxoFromRef(txHash, input.Index)
+				if err != nil || fetched.Input.TransactionId == nil {
+					continue
+				}
+				utxo = fetched
+			}
+			resolved = append(resolved, utxo)
+		}
+	}
+	return resolved
+}
+
+/*
+Validate runs the local phase-1 ledger checks on the built transaction
+against the current slot of the chain context. Inputs are resolved from
+the loaded UTxOs first and from the chain context otherwise.
+*/
+func (b *Apollo) Validate() error {
+	if b.tx == nil {
+		return errors.New("no transaction to validate, call Complete first")
+	}
+	resolved := b.resolveTxInputs()
+	pp := b.Context.GetProtocolParams()
+	if pp.CostModels == nil {
+		pp.CostModels = map[Base.CostModelsPlutusVersion]PlutusData.CostModel{
+			Base.CostModelsPlutusV1: b.Context.CostModelsV1(),
+			Base.CostModelsPlutusV2: b.Context.CostModelsV2(),
+			Base.CostModelsPlutusV3: b.Context.CostModelsV3(),
+		}
+	}
+	return Validation.ValidateAtSlot(*b.tx, resolved, pp, int64(b.Context.LastBlockSlot()))
+}
+
+/*
+LoadTxCbor loads a transaction, typically built elsewhere to be signed,
+from its hex encoded cbor. Raw cbor held in a string is accepted too.
+*/
 func (b *Apollo) LoadTxCbor(txCbor string) (*Apollo, error) {
+	txBytes, err := hex.DecodeString(txCbor)
+	if err != nil {
+		txBytes = []byte(txCbor)
+	}
 	tx := Transaction.Transaction{}
-	err := cbor.Unmarshal([]byte(txCbor), &tx)
+	err = cbor.Unmarshal(txBytes, &tx)
 	if err != nil {
 		return b, err
 	}
@@ -1054,9 +1444,115 @@ func (b *Apollo) UtxoFromRef(txHash string, txIndex int) (UTxO.UTxO, error) {
 
 }
 
-func (b *Apollo) AddVerificationKeyWitness(vkw VerificationKeyWitness.VerificationKeyWitness) *Apollo {
-	b.tx.TransactionWitnessSet.VkeyWitnesses = append(b.tx.TransactionWitnessSet.VkeyWitnesses, vkw)
-	return b
+// AddVerificationKeyWitness adds vkw to the transaction built by
+// Complete. It replaces a witness of the same key unless only that one
+// signs the body.
+func (b *Apollo) AddVerificationKeyWitness(vkw VerificationKeyWitness.VerificationKeyWitness) (*Apollo, error) {
+	return b.AddWitnessSets(TransactionWitnessSet.TransactionWitnessSet{
+		VkeyWitnesses: []VerificationKeyWitness.VerificationKeyWitness{vkw},
+	})
+}
+
+// AddWitnessSets merges the witness sets collected from the parties
+// signing the transaction, dropping duplicate witnesses and keeping the
+// ones that sign the body when two share a key.
+func (b *Apollo) AddWitnessSets(witnessSets ...TransactionWitnessSet.TransactionWitnessSet) (*Apollo, error) {
+	if b.tx == nil {
+		return b, errors.New("no transaction to add witnesses to, call Complete first")
+	}
+	b.tx.TransactionWitnessSet.MergeVerified(b.tx.TransactionBody.Hash(), witnessSets...)
+	return b, nil
+}
+
+// ExportSigningRequest returns what the other parties need to sign the
+// built transaction with VerificationKeyWitness.SignBodyHash.
+func (b *Apollo) ExportSigningRequest() (SigningRequest, error) {
+	if b.tx == nil {
+		return SigningRequest{}, errors.New("no transaction to export, call Complete first")
+	}
+	txCbor, err := cbor.Marshal(b.tx)
+	if err != nil {
+		return SigningRequest{}, err
+	}
+	missing, err := b.MissingSigners()
+	if err != nil {
+		return SigningRequest{}, err
+	}
+	signers := make([]string, 0)
+	for _, pkh := range missing {
+		signers = append(signers, hex.EncodeToString(pkh[:]))
+	}
+	return SigningRequest{
+		BodyHash:        hex.EncodeToString(b.tx.TransactionBody.Hash()),
+		TxCbor:          hex.EncodeToString(txCbor),
+		RequiredSigners: signers,
+	}, nil
+}
+
+// VerifyWitnesses checks every vkey and bootstrap witness of the
+// transaction signs its body, failing with an *Errors.InvalidSignatureError otherwise.
+func (b *Apollo) VerifyWitnesses() error {
+	if b.tx == nil {
+		return errors.New("no transaction to verify, call Complete first")
+	}
+	bodyHash := b.tx.TransactionBody.Hash()
+	for _, vkw := range b.tx.TransactionWitnessSet.VkeyWitnesses {
+		if !vkw.Verify(bodyHash) {
+			return &Errors.InvalidSignatureError{Vkey: vkw.Vkey.Payload}
+		}
+	}
+	for _, bw := range b.tx.TransactionWitnessSet.BootstrapWitnesses {
+		if !bw.Verify(bodyHash) {
+			return &Errors.InvalidSignatureError{Vkey: bw.PublicKey}
+		}
+	}
+	return nil
+}
+
+/*
+MissingSigners returns the keys still expected to sign the transaction:
+the required keys without a valid witness, followed by the keys whose
+signature could make an unsatisfied native script of the witness set
+validate.
+*/
+func (b *Apollo) MissingSigners() ([]serialization.PubKeyHash, error) {
+	if b.tx == nil {
+		return nil, errors.New("no transaction to check signers of, call Complete first")
+	}
+	bodyHash := b.tx.TransactionBody.Hash()
+	signers := make([]serialization.PubKeyHash, 0)
+	signed := make(map[serialization.PubKeyHash]bool)
+	for _, vkw := range b.tx.TransactionWitnessSet.VkeyWitnesses {
+		pkh, err := vkw.Vkey.Hash()
+		if err == nil && vkw.Verify(bodyHash) {
+			signers = append(signers, pkh)
+			signed[pkh] = true
+		}
+	}
+	for _, bw := range b.tx.TransactionWitnessSet.BootstrapWitnesses {
+		if bw.Verify(bodyHash) {
+			var root serialization.PubKeyHash
+			copy(root[:], bw.Root())
+			signed[root] = true
+		}
+	}
+	missing := make([]serialization.PubKeyHash, 0)
+	for _, pkh := range Validation.RequiredKeyHashes(*b.tx, b.resolveTxInputs()) {
+		if !signed[pkh] {
+			signed[pkh] = true
+			missing = append(missing, pkh)
+		}
+	}
+	body := b.tx.TransactionBody
+	for _, script := range b.tx.TransactionWitnessSet.NativeScripts {
+		for _, pkh := range script.UnsignedKeyHashes(signers, body.ValidityStart, body.Ttl) {
+			if !signed[pkh] {
+				signed[pkh] = true
+				missing = append(missing, pkh)
+			}
+		}
+	}
+	return missing, nil
 }
 
 func (b *Apollo) SetChangeAddressBech32(address string) *Apollo {
@@ -1083,6 +1579,49 @@ func (b *Apollo) SetValidityStart(invalidBefore int64) *Apollo {
 	return b
 }
 
+// SetSlotConfig overrides the slot config used by SetValidFrom and
+// SetValidUntil.
+func (b *Apollo) SetSlotConfig(config SlotConfig.SlotConfig) *Apollo {
+	b.slotConfig = &config
+	return b
+}
+
+/*
+GetSlotConfig returns the slot config set with SetSlotConfig. Otherwise
+it is taken from the chain context, preferring era summaries when the
+context provides them over the genesis parameters.
+*/
+func (b *Apollo) GetSlotConfig() SlotConfig.SlotConfig {
+	if b.slotConfig != nil {
+		return *b.slotConfig
+	}
+	if provider, ok := b.Context.(interface {
+		SlotConfig() (SlotConfig.SlotConfig, error)
+	}); ok {
+		config, err := provider.SlotConfig()
+		if err == nil {
+			b.slotConfig = &config
+			return config
+		}
+	}
+	config := SlotConfig.FromGenesis(b.Context.GetGenesisParams())
+	b.slotConfig = &config
+	return config
+}
+
+// SetValidFrom sets the validity start to the first slot that begins
+// at or after validFrom, so validators never see an earlier lower bound.
+func (b *Apollo) SetValidFrom(validFrom time.Time) *Apollo {
+	return b.SetValidityStart(b.GetSlotConfig().TimeToSlotCeil(validFrom))
+}
+
+// SetValidUntil sets the ttl to the slot containing validUntil. The
+// upper bound validators see is the start of that slot, which is never
+// after validUntil.
+func (b *Apollo) SetValidUntil(validUntil time.Time) *Apollo {
+	return b.SetTtl(b.GetSlotConfig().TimeToSlot(validUntil))
+}
+
 func (b *Apollo) SetShelleyMetadata(metadata Metadata.ShelleyMaryMetadata) *Apollo {
 	if b.auxiliaryData == nil {
 		b.auxiliaryData = &Metadata.AuxiliaryData{}
@@ -1093,6 +1632,41 @@ func (b *Apollo) SetShelleyMetadata(metadata Metadata.ShelleyMaryMetadata) *Apol
 	return b
 }
 
+/*
+AddCIP25Metadata validates nft and adds it to the metadata of the
+transaction under label 721, next to the CIP-25 metadata already added
+with the same version. It fails rather than overwrite label 721 when
+that doesn't hold valid CIP-25 metadata.
+*/
+func (b *Apollo) AddCIP25Metadata(nft Metadata.CIP25Metadata) (*Apollo, error) {
+	if b.auxiliaryData == nil {
+		b.auxiliaryData = &Metadata.AuxiliaryData{}
+	}
+	if err := nft.Validate(); err != nil {
+		return b, err
+	}
+	metadata := b.auxiliaryData.GetMetadata()
+	if _, ok := metadata[Metadata.CIP25_LABEL]; ok {
+		existing, err := Metadata.ParseCIP25(metadata)
+		if err != nil {
+			return b, err
+		}
+		if existing.Version != max(nft.Version, 1) {
+			return b, fmt.Errorf("can't add CIP-25 version %d metadata to version %d metadata", max(nft.Version, 1), existing.Version)
+		}
+		for policyId, assets := range nft.Policies {
+			if existing.Policies[policyId] == nil {
+				existing.Policies[policyId] = make(map[string]Metadata.CIP25Asset)
+			}
+			for assetName, asset := range assets {
+				existing.Policies[policyId][assetName] = asset
+			}
+		}
+		nft = *existing
+	}
+	return b, nft.AddTo(b.auxiliaryData)
+}
+
 func (b *Apollo) GetUsedUTxOs() map[string]bool {
 	return b.usedUtxos
 }
@@ -1157,3 +1731,211 @@ func (b *Apollo) AddWithdrawal(address Address.Address, amount int, redeemerData
 	b.stakeRedeemers[fmt.Sprint(b.withdrawals.Size()-1)] = newRedeemer
 	return b
 }
+
+// getDeposits returns the lovelace locked as deposits by the
+// transaction, which has to be covered by the inputs on top of the
+// payments and fee.
+func (b *Apollo) getDeposits() (int64, error) {
+	return Validation.Deposits(b.certificates, b.proposalProcedures, b.Context.GetProtocolParams())
+}
+
+// getRefunds returns the lovelace released by deregistration
+// certificates, which is added to the value available for outputs.
+func (b *Apollo) getRefunds() (int64, error) {
+	return Validation.Refunds(b.certificates, b.Context.GetProtocolParams())
+}
+
+// rewardAccount returns the 29 byte reward account for the staking
+// part of the given address.
+func rewardAccount(address Address.Address) ([29]byte, error) {
+	var account [29]byte
+	if len(address.StakingPart) != 28 {
+		return account, fmt.Errorf("address has invalid or missing staking part: %v", address.StakingPart)
+	}
+	switch address.AddressType {
+	case Address.KEY_KEY, Address.SCRIPT_KEY, Address.NONE_KEY:
+		account[0] = Address.NONE_KEY<<4 | address.Network
+	case Address.KEY_SCRIPT, Address.SCRIPT_SCRIPT, Address.NONE_SCRIPT:
+		account[0] = Address.NONE_SCRIPT<<4 | address.Network
+	default:
+		return account, fmt.Errorf("address type %d has no reward account", address.AddressType)
+	}
+	copy(account[1:], address.StakingPart)
+	return account, nil
+}
+
+// AddVote casts the vote of voter on the governance action govActionId,
+// with an optional anchor justifying it. Key based voters add a
+// signature to the fee estimation; script based voters should use
+// AddVoteWithRedeemer instead.
+func (b *Apollo) AddVote(voter Governance.Voter, govActionId Governance.GovActionId, vote Governance.Vote, anchor *Governance.Anchor) *Apollo {
+	b.votingProcedures.Add(voter, govActionId, Governance.VotingProcedure{Vote: vote, Anchor: anchor})
+	return b
+}
+
+// AddVoteWithRedeemer is AddVote for a script based voter, whose script
+// is run with redeemerData.
+func (b *Apollo) AddVoteWithRedeemer(voter Governance.Voter, govActionId Governance.GovActionId, vote Governance.Vote, anchor *Governance.Anchor, redeemerData PlutusData.PlutusData) *Apollo {
+	b.AddVote(voter, govActionId, vote, anchor)
+	b.voteRedeemers[voter] = Redeemer.Redeemer{
+		Tag:     Redeemer.VOTE,
+		Index:   0, // This will be computed later in setRedeemerIndexes
+		Data:    redeemerData,
+		ExUnits: Redeemer.ExecutionUnits{},
+	}
+	return b
+}
+
+// ProposeGovernanceAction submits a governance action. The deposit is
+// taken from the inputs and returned to the reward account of
+// returnAddr once the action is enacted or expires, failing when
+// returnAddr has no staking part.
+func (b *Apollo) ProposeGovernanceAction(action Governance.GovAction, deposit int, returnAddr Address.Address, anchor Governance.Anchor) (*Apollo, error) {
+	account, err := rewardAccount(returnAddr)
+	if err != nil {
+		return b, err
+	}
+	b.proposalProcedures = append(b.proposalProcedures, Governance.ProposalProcedure{
+		Deposit:       int64(deposit),
+		RewardAccount: account,
+		GovAction:     action,
+		Anchor:        anchor,
+	})
+	return b, nil
+}
+
+// ProposeGovernanceActionWithRedeemer is used for actions guarded by
+// the constitution script (parameter changes and treasury withdrawals).
+func (b *Apollo) ProposeGovernanceActionWithRedeemer(action Governance.GovAction, deposit int, returnAddr Address.Address, anchor Governance.Anchor, redeemerData PlutusData.PlutusData) (*Apollo, error) {
+	proposals := len(b.proposalProcedures)
+	if _, err := b.ProposeGovernanceAction(action, deposit, returnAddr, anchor); err != nil {
+		return b, err
+	}
+	b.proposalRedeemers[fmt.Sprint(proposals)] = Redeemer.Redeemer{
+		Tag:     Redeemer.PROPOSE,
+		Index:   proposals,
+		Data:    redeemerData,
+		ExUnits: Redeemer.ExecutionUnits{},
+	}
+	return b, nil
+}
+
+func (b *Apollo) AddCertificate(cert Certificate.Certificate) *Apollo {
+	if b.certificates == nil {
+		b.certificates = &Certificate.Certificates{}
+	}
+	*b.certificates = append(*b.certificates, &cert)
+	return b
+}
+
+// AddCertificateWithRedeemer adds a certificate that is authorized by
+// a script credential.
+func (b *Apollo) AddCertificateWithRedeemer(cert Certificate.Certificate, redeemerData PlutusData.PlutusData) *Apollo {
+	b.AddCertificate(cert)
+	index := len(*b.certificates) - 1
+	b.certRedeemers[fmt.Sprint(index)] = Redeemer.Redeemer{
+		Tag:     Redeemer.CERT,
+		Index:   index,
+		Data:    redeemerData,
+		ExUnits: Redeemer.ExecutionUnits{},
+	}
+	return b
+}
+
+// RegisterStake registers a stake credential, taking the key deposit
+// from the protocol parameters.
+func (b *Apollo) RegisterStake(stakeCredential Credential.Credential) *Apollo {
+	return b.AddCertificate(Certificate.NewStakeRegistration(stakeCredential))
+}
+
+// DeregisterStake deregisters a stake credential and returns the key
+// deposit to the change output.
+func (b *Apollo) DeregisterStake(stakeCredential Credential.Credential) *Apollo {
+	return b.AddCertificate(Certificate.NewStakeDeregistration(stakeCredential))
+}
+
+func (b *Apollo) DeregisterStakeWithRedeemer(stakeCredential Credential.Credential, redeemerData PlutusData.PlutusData) *Apollo {
+	return b.AddCertificateWithRedeemer(Certificate.NewStakeDeregistration(stakeCredential), redeemerData)
+}
+
+func (b *Apollo) DelegateStake(stakeCredential Credential.Credential, pool serialization.PubKeyHash) *Apollo {
+	return b.AddCertificate(Certificate.NewStakeDelegation(stakeCredential, pool))
+}
+
+func (b *Apollo) DelegateStakeWithRedeemer(stakeCredential Credential.Credential, pool serialization.PubKeyHash, redeemerData PlutusData.PlutusData) *Apollo {
+	return b.AddCertificateWithRedeemer(Certificate.NewStakeDelegation(stakeCredential, pool), redeemerData)
+}
+
+func (b *Apollo) DelegateVote(stakeCredential Credential.Credential, drep Certificate.DRep) *Apollo {
+	return b.AddCertificate(Certificate.NewVoteDelegation(stakeCredential, drep))
+}
+
+func (b *Apollo) DelegateVoteWithRedeemer(stakeCredential Credential.Credential, drep Certificate.DRep, redeemerData PlutusData.PlutusData) *Apollo {
+	return b.AddCertificateWithRedeemer(Certificate.NewVoteDelegation(stakeCredential, drep), redeemerData)
+}
+
+// Donate moves the given amount of lovelace to the treasury. The
+// donation is taken from the inputs like any other payment.
+func (b *Apollo) Donate(lovelace int) *Apollo {
+	b.donation += int64(lovelace)
+	return b
+}
+
+// SetCurrentTreasuryValue asserts the treasury value the transaction
+// expects; the ledger rejects it if the actual value differs.
+func (b *Apollo) SetCurrentTreasuryValue(treasury int64) *Apollo {
+	b.treasuryValue = &treasury
+	return b
+}
+
+// SetCoinSelector makes Complete delegate input selection to the given
+// selector instead of the built-in greedy selection.
+func (b *Apollo) SetCoinSelector(selector CoinSelection.UTxOSelector) *Apollo {
+	b.coinSelector = selector
+	return b
+}
+
+// SetMaxInputCount limits the total number of inputs, preselected ones
+// included. Zero or less means no limit.
+func (b *Apollo) SetMaxInputCount(count int) *Apollo {
+	b.maxInputCount = count
+	return b
+}
+
+func (b *Apollo) exceedsMaxInputCount(additional int) bool {
+	return b.maxInputCount > 0 && len(b.preselectedUtxos)+additional > b.maxInputCount
+}
+
+func (b *Apollo) selectWithCoinSelector(unfulfilled Value.Value, available []UTxO.UTxO) ([]UTxO.UTxO, error) {
+	maxInputCount := -1
+	if b.maxInputCount > 0 {
+		maxInputCount = b.maxInputCount - len(b.preselectedUtxos)
+		if maxInputCount < 0 {
+			return nil, &CoinSelection.MaxInputCountExceededError{MaxInputCount: b.maxInputCount}
+		}
+	}
+	request := TransactionOutput.SimpleTransactionOutput(b.inputAddresses[0], positiveValue(unfulfilled))
+	selected, _, err := b.coinSelector.Select(
+		available,
+		[]TransactionOutput.TransactionOutput{request},
+		b.Context,
+		maxInputCount,
+		false,
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+	selectedUtxos := make([]UTxO.UTxO, 0)
+	for _, utxo := range selected {
+		if b.usedUtxos[utxo.GetKey()] {
+			continue
+		}
+		b.usedUtxos[utxo.GetKey()] = true
+		selectedUtxos = append(selectedUtxos, utxo)
+	}
+	if b.exceedsMaxInputCount(len(selectedUtxos)) {
+		return nil, &CoinSelection.MaxInputCountExceededError{MaxInputCount: b.maxInputCount}
+	}
+	return selectedUtxos, nil
+}
@@ -37,6 +37,17 @@ func NewUnit(policyId string, name string, quantity int) Unit {
 	}
 }
 
+/*
+SigningRequest is what the parties of a multi-party transaction need to
+sign it: the hash of its body, the hex encoded transaction to review
+and the hex encoded key hashes still expected to sign.
+*/
+type SigningRequest struct {
+	BodyHash        string   `json:"bodyHash"`
+	TxCbor          string   `json:"txCbor"`
+	RequiredSigners []string `json:"requiredSigners"`
+}
+
 type PaymentI interface {
 	EnsureMinUTXO(cc Base.ChainContext)
 	ToTxOut() *TransactionOutput.TransactionOutput
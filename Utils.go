@@ -4,7 +4,10 @@ import (
 	"encoding/hex"
 	"sort"
 
+	"github.com/SundaeSwap-finance/apollo/serialization/Asset"
+	"github.com/SundaeSwap-finance/apollo/serialization/MultiAsset"
 	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/Value"
 )
 
 func SortUtxos(utxos []UTxO.UTxO) []UTxO.UTxO {
@@ -42,3 +45,25 @@ func SortInputs(inputs []UTxO.UTxO) []UTxO.UTxO {
 	})
 	return sortedInputs
 }
+
+// positiveValue drops every negative or zero quantity from a value, so
+// that what is left can be used as a selection target.
+func positiveValue(val Value.Value) Value.Value {
+	coin := val.GetCoin()
+	if coin < 0 {
+		coin = 0
+	}
+	assets := MultiAsset.MultiAsset[int64]{}
+	for policy, asset := range val.GetAssets() {
+		for name, amount := range asset {
+			if amount <= 0 {
+				continue
+			}
+			if _, ok := assets[policy]; !ok {
+				assets[policy] = Asset.Asset[int64]{}
+			}
+			assets[policy][name] = amount
+		}
+	}
+	return Value.SimpleValue(coin, assets)
+}
@@ -0,0 +1,614 @@
+package apollotypes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/UPLC"
+)
+
+const DEFINITIONS_REF_PREFIX = "#/definitions/"
+
+// Blueprint is a CIP-57 plutus blueprint, as produced by aiken build.
+type Blueprint struct {
+	Preamble    Preamble          `json:"preamble"`
+	Validators  []Validator       `json:"validators"`
+	Definitions map[string]Schema `json:"definitions"`
+}
+
+type Preamble struct {
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	Version       string `json:"version"`
+	PlutusVersion string `json:"plutusVersion"`
+	Compiler      struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"compiler"`
+	License string `json:"license"`
+}
+
+type Validator struct {
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	Datum        *Argument  `json:"datum"`
+	Redeemer     *Argument  `json:"redeemer"`
+	Parameters   []Argument `json:"parameters"`
+	CompiledCode string     `json:"compiledCode"`
+	Hash         string     `json:"hash"`
+}
+
+// Argument is a datum, redeemer or parameter of a validator.
+type Argument struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Purpose     Purpose `json:"purpose"`
+	Schema      Schema  `json:"schema"`
+}
+
+// Purpose lists the script purposes an argument is used for, empty
+// when the blueprint doesn't restrict it.
+type Purpose []string
+
+func (p *Purpose) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*p = Purpose{single}
+		return nil
+	}
+	var many struct {
+		OneOf []string `json:"oneOf"`
+	}
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*p = Purpose(many.OneOf)
+	return nil
+}
+
+/*
+Schema is a CIP-57 data schema. A schema without a data type, a
+reference or any combinator accepts any plutus data.
+*/
+type Schema struct {
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	DataType    string `json:"dataType,omitempty"`
+
+	AnyOf []Schema `json:"anyOf,omitempty"`
+	OneOf []Schema `json:"oneOf,omitempty"`
+	AllOf []Schema `json:"allOf,omitempty"`
+	Not   *Schema  `json:"not,omitempty"`
+
+	// constructor
+	Index  *uint64  `json:"index,omitempty"`
+	Fields []Schema `json:"fields,omitempty"`
+
+	// integer
+	MultipleOf       json.Number `json:"multipleOf,omitempty"`
+	Minimum          json.Number `json:"minimum,omitempty"`
+	Maximum          json.Number `json:"maximum,omitempty"`
+	ExclusiveMinimum json.Number `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum json.Number `json:"exclusiveMaximum,omitempty"`
+
+	// bytes
+	Enum      []string `json:"enum,omitempty"`
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+
+	// list and map
+	Items       *SchemaItems `json:"items,omitempty"`
+	MinItems    *int         `json:"minItems,omitempty"`
+	MaxItems    *int         `json:"maxItems,omitempty"`
+	UniqueItems bool         `json:"uniqueItems,omitempty"`
+	Keys        *Schema      `json:"keys,omitempty"`
+	Values      *Schema      `json:"values,omitempty"`
+
+	// #pair
+	Left  *Schema `json:"left,omitempty"`
+	Right *Schema `json:"right,omitempty"`
+}
+
+// SchemaItems are the items of a list schema: a single schema for every
+// element, or one schema per element for tuples.
+type SchemaItems struct {
+	Schemas []Schema
+	Tuple   bool
+}
+
+func (si *SchemaItems) UnmarshalJSON(data []byte) error {
+	if len(bytes.TrimSpace(data)) > 0 && bytes.TrimSpace(data)[0] == '[' {
+		si.Tuple = true
+		return json.Unmarshal(data, &si.Schemas)
+	}
+	var single Schema
+	if err := json.Unmarshal(data, &single); err != nil {
+		return err
+	}
+	si.Schemas = []Schema{single}
+	return nil
+}
+
+func (si SchemaItems) MarshalJSON() ([]byte, error) {
+	if si.Tuple {
+		return json.Marshal(si.Schemas)
+	}
+	if len(si.Schemas) == 0 {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(si.Schemas[0])
+}
+
+// SchemaError is a plutus data value that doesn't match its schema.
+type SchemaError struct {
+	Path   string
+	Reason string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// Validator returns the validator with the given title.
+func (bp *Blueprint) Validator(title string) (*Validator, error) {
+	for i := range bp.Validators {
+		if bp.Validators[i].Title == title {
+			return &bp.Validators[i], nil
+		}
+	}
+	return nil, fmt.Errorf("validator %s not found", title)
+}
+
+/*
+GetScript returns the compiled code of a validator as a
+PlutusV1Script, PlutusV2Script or PlutusV3Script, according to the
+plutus version of the blueprint.
+*/
+func (bp *Blueprint) GetScript(title string) (PlutusData.ScriptHashable, error) {
+	validator, err := bp.Validator(title)
+	if err != nil {
+		return nil, err
+	}
+	code, err := hex.DecodeString(validator.CompiledCode)
+	if err != nil {
+		return nil, err
+	}
+	switch bp.Preamble.PlutusVersion {
+	case "v1":
+		return PlutusData.PlutusV1Script(code), nil
+	case "v2":
+		return PlutusData.PlutusV2Script(code), nil
+	case "v3":
+		return PlutusData.PlutusV3Script(code), nil
+	}
+	return nil, fmt.Errorf("unsupported plutus version %q", bp.Preamble.PlutusVersion)
+}
+
+/*
+ApplyParams checks params against the parameter schemas of a
+validator and returns its script with the parameters applied.
+*/
+func (bp *Blueprint) ApplyParams(title string, params ...PlutusData.PlutusData) (PlutusData.ScriptHashable, error) {
+	validator, err := bp.Validator(title)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) != len(validator.Parameters) {
+		return nil, fmt.Errorf("validator %s takes %d parameters, got %d", title, len(validator.Parameters), len(params))
+	}
+	for i, param := range params {
+		path := fmt.Sprintf("parameters[%d]", i)
+		if validator.Parameters[i].Title != "" {
+			path = validator.Parameters[i].Title
+		}
+		if err := bp.Validate(validator.Parameters[i].Schema, param, path); err != nil {
+			return nil, err
+		}
+	}
+	script, err := bp.GetScript(title)
+	if err != nil {
+		return nil, err
+	}
+	var applied PlutusData.ScriptHashable
+	switch s := script.(type) {
+	case PlutusData.PlutusV1Script:
+		applied, err = UPLC.ApplyParams(s, params...)
+	case PlutusData.PlutusV2Script:
+		applied, err = UPLC.ApplyParams(s, params...)
+	case PlutusData.PlutusV3Script:
+		applied, err = UPLC.ApplyParams(s, params...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+/*
+Purposes returns the script purposes of a validator, taken from its
+arguments or, for blueprints that don't list them, from the suffix of
+its title (aiken names handlers <module>.<validator>.<purpose>).
+*/
+func (v *Validator) Purposes() []string {
+	for _, arg := range append([]*Argument{v.Datum, v.Redeemer}, argumentPointers(v.Parameters)...) {
+		if arg != nil && len(arg.Purpose) > 0 {
+			return arg.Purpose
+		}
+	}
+	parts := strings.Split(v.Title, ".")
+	switch last := parts[len(parts)-1]; last {
+	case "spend", "mint", "withdraw", "publish", "vote", "propose", "else":
+		return []string{last}
+	}
+	return nil
+}
+
+func argumentPointers(args []Argument) []*Argument {
+	res := make([]*Argument, len(args))
+	for i := range args {
+		res[i] = &args[i]
+	}
+	return res
+}
+
+// Resolve follows the references of schema to its definition.
+func (bp *Blueprint) Resolve(schema Schema) (Schema, error) {
+	seen := map[string]bool{}
+	for schema.Ref != "" {
+		if seen[schema.Ref] {
+			return Schema{}, fmt.Errorf("circular reference %s", schema.Ref)
+		}
+		seen[schema.Ref] = true
+		name, ok := strings.CutPrefix(schema.Ref, DEFINITIONS_REF_PREFIX)
+		if !ok {
+			return Schema{}, fmt.Errorf("unsupported reference %s", schema.Ref)
+		}
+		// references are json pointers
+		name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+		def, ok := bp.Definitions[name]
+		if !ok {
+			return Schema{}, fmt.Errorf("definition %s not found", name)
+		}
+		schema = def
+	}
+	return schema, nil
+}
+
+// ValidateDatum checks datum against the datum schema of a validator.
+func (bp *Blueprint) ValidateDatum(validatorTitle string, datum PlutusData.PlutusData) error {
+	validator, err := bp.Validator(validatorTitle)
+	if err != nil {
+		return err
+	}
+	if validator.Datum == nil {
+		return fmt.Errorf("validator %s takes no datum", validatorTitle)
+	}
+	return bp.Validate(validator.Datum.Schema, datum, "datum")
+}
+
+// ValidateRedeemer checks redeemer against the redeemer schema of a
+// validator.
+func (bp *Blueprint) ValidateRedeemer(validatorTitle string, redeemer PlutusData.PlutusData) error {
+	validator, err := bp.Validator(validatorTitle)
+	if err != nil {
+		return err
+	}
+	if validator.Redeemer == nil {
+		return fmt.Errorf("validator %s takes no redeemer", validatorTitle)
+	}
+	return bp.Validate(validator.Redeemer.Schema, redeemer, "redeemer")
+}
+
+func schemaErr(path string, format string, args ...any) error {
+	return &SchemaError{Path: path, Reason: fmt.Sprintf(format, args...)}
+}
+
+/*
+Validate checks pd against schema. Errors are SchemaErrors whose path
+starts at path and names constructor fields by their title, or by their
+position when they have none.
+*/
+func (bp *Blueprint) Validate(schema Schema, pd PlutusData.PlutusData, path string) error {
+	schema, err := bp.Resolve(schema)
+	if err != nil {
+		return schemaErr(path, "%v", err)
+	}
+	for _, sub := range schema.AllOf {
+		if err := bp.Validate(sub, pd, path); err != nil {
+			return err
+		}
+	}
+	if schema.Not != nil && bp.Validate(*schema.Not, pd, path) == nil {
+		return schemaErr(path, "matches a schema it must not match")
+	}
+	if len(schema.OneOf) > 0 {
+		matches := 0
+		for _, sub := range schema.OneOf {
+			if bp.Validate(sub, pd, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return schemaErr(path, "matches %d of the oneOf schemas instead of one", matches)
+		}
+	}
+	if len(schema.AnyOf) > 0 {
+		return bp.validateAnyOf(schema, pd, path)
+	}
+
+	switch schema.DataType {
+	case "":
+		return nil
+	case "integer", "#integer":
+		return validateInteger(schema, pd, path)
+	case "bytes", "#bytes":
+		return validateBytes(schema, pd, path)
+	case "#string":
+		b, ok := pd.Bytes()
+		if !ok || !utf8.Valid(b) {
+			return schemaErr(path, "expected a string, got %s", describe(pd))
+		}
+		return nil
+	case "#unit":
+		index, fields, ok := pd.Constr()
+		if !ok || index != 0 || len(fields) != 0 {
+			return schemaErr(path, "expected unit, got %s", describe(pd))
+		}
+		return nil
+	case "#boolean":
+		index, fields, ok := pd.Constr()
+		if !ok || index > 1 || len(fields) != 0 {
+			return schemaErr(path, "expected a boolean, got %s", describe(pd))
+		}
+		return nil
+	case "list", "#list":
+		return bp.validateList(schema, pd, path)
+	case "map":
+		return bp.validateMap(schema, pd, path)
+	case "#pair":
+		list, ok := pd.List()
+		if !ok || len(list) != 2 {
+			return schemaErr(path, "expected a pair, got %s", describe(pd))
+		}
+		if schema.Left != nil {
+			if err := bp.Validate(*schema.Left, list[0], path+".left"); err != nil {
+				return err
+			}
+		}
+		if schema.Right != nil {
+			return bp.Validate(*schema.Right, list[1], path+".right")
+		}
+		return nil
+	case "constructor":
+		return bp.validateConstructor(schema, pd, path)
+	}
+	return schemaErr(path, "unsupported data type %s", schema.DataType)
+}
+
+func (bp *Blueprint) validateAnyOf(schema Schema, pd PlutusData.PlutusData, path string) error {
+	index, _, isConstr := pd.Constr()
+	var errs []error
+	for _, sub := range schema.AnyOf {
+		resolved, err := bp.Resolve(sub)
+		if err != nil {
+			return schemaErr(path, "%v", err)
+		}
+		// report the errors of the matching constructor directly
+		if isConstr && resolved.DataType == "constructor" && resolved.Index != nil && *resolved.Index == index {
+			return bp.Validate(resolved, pd, path)
+		}
+		err = bp.Validate(resolved, pd, path)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	name := schema.Title
+	if name == "" {
+		name = "any of the alternatives"
+	}
+	if isConstr {
+		return schemaErr(path, "constructor %d does not match %s", index, name)
+	}
+	return schemaErr(path, "%s does not match %s", describe(pd), name)
+}
+
+func (bp *Blueprint) validateConstructor(schema Schema, pd PlutusData.PlutusData, path string) error {
+	index, fields, ok := pd.Constr()
+	if !ok {
+		return schemaErr(path, "expected a constructor, got %s", describe(pd))
+	}
+	if schema.Index != nil && index != *schema.Index {
+		return schemaErr(path, "expected constructor %d, got %d", *schema.Index, index)
+	}
+	if len(fields) != len(schema.Fields) {
+		return schemaErr(path, "expected %d fields, got %d", len(schema.Fields), len(fields))
+	}
+	for i, field := range schema.Fields {
+		fieldPath := fmt.Sprintf("%s[%d]", path, i)
+		if field.Title != "" {
+			fieldPath = path + "." + field.Title
+		}
+		if err := bp.Validate(field, fields[i], fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bp *Blueprint) validateList(schema Schema, pd PlutusData.PlutusData, path string) error {
+	list, ok := pd.List()
+	if !ok {
+		return schemaErr(path, "expected a list, got %s", describe(pd))
+	}
+	if err := checkItemCount(schema, len(list), path); err != nil {
+		return err
+	}
+	if schema.Items != nil && schema.Items.Tuple && len(list) != len(schema.Items.Schemas) {
+		return schemaErr(path, "expected %d items, got %d", len(schema.Items.Schemas), len(list))
+	}
+	for i, el := range list {
+		elPath := fmt.Sprintf("%s[%d]", path, i)
+		if schema.Items != nil && len(schema.Items.Schemas) > 0 {
+			itemSchema := schema.Items.Schemas[0]
+			if schema.Items.Tuple {
+				itemSchema = schema.Items.Schemas[i]
+			}
+			if err := bp.Validate(itemSchema, el, elPath); err != nil {
+				return err
+			}
+		}
+		if schema.UniqueItems {
+			for j := 0; j < i; j++ {
+				if list[j].Equal(el) {
+					return schemaErr(elPath, "duplicate of item %d", j)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (bp *Blueprint) validateMap(schema Schema, pd PlutusData.PlutusData, path string) error {
+	entries, err := pd.MapEntries()
+	if err != nil {
+		return schemaErr(path, "expected a map, got %s", describe(pd))
+	}
+	if err := checkItemCount(schema, len(entries), path); err != nil {
+		return err
+	}
+	for i, entry := range entries {
+		if schema.Keys != nil {
+			if err := bp.Validate(*schema.Keys, entry.Key, fmt.Sprintf("%s.keys[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		if schema.Values != nil {
+			if err := bp.Validate(*schema.Values, entry.Value, fmt.Sprintf("%s.values[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkItemCount(schema Schema, count int, path string) error {
+	if schema.MinItems != nil && count < *schema.MinItems {
+		return schemaErr(path, "expected at least %d items, got %d", *schema.MinItems, count)
+	}
+	if schema.MaxItems != nil && count > *schema.MaxItems {
+		return schemaErr(path, "expected at most %d items, got %d", *schema.MaxItems, count)
+	}
+	return nil
+}
+
+func validateInteger(schema Schema, pd PlutusData.PlutusData, path string) error {
+	n, ok := pd.BigInt()
+	if !ok {
+		return schemaErr(path, "expected an integer, got %s", describe(pd))
+	}
+	bound := func(number json.Number) (*big.Int, error) {
+		b, ok := new(big.Int).SetString(number.String(), 10)
+		if !ok {
+			return nil, schemaErr(path, "invalid integer bound %s in schema", number)
+		}
+		return b, nil
+	}
+	checks := []struct {
+		number json.Number
+		fails  func(cmp int) bool
+		reason string
+	}{
+		{schema.Minimum, func(cmp int) bool { return cmp < 0 }, "less than"},
+		{schema.ExclusiveMinimum, func(cmp int) bool { return cmp <= 0 }, "not greater than"},
+		{schema.Maximum, func(cmp int) bool { return cmp > 0 }, "greater than"},
+		{schema.ExclusiveMaximum, func(cmp int) bool { return cmp >= 0 }, "not less than"},
+	}
+	for _, check := range checks {
+		if check.number == "" {
+			continue
+		}
+		b, err := bound(check.number)
+		if err != nil {
+			return err
+		}
+		if check.fails(n.Cmp(b)) {
+			return schemaErr(path, "%s is %s %s", n, check.reason, b)
+		}
+	}
+	if schema.MultipleOf != "" {
+		b, err := bound(schema.MultipleOf)
+		if err != nil {
+			return err
+		}
+		if b.Sign() != 0 && new(big.Int).Rem(n, b).Sign() != 0 {
+			return schemaErr(path, "%s is not a multiple of %s", n, b)
+		}
+	}
+	return nil
+}
+
+func validateBytes(schema Schema, pd PlutusData.PlutusData, path string) error {
+	b, ok := pd.Bytes()
+	if !ok {
+		return schemaErr(path, "expected bytes, got %s", describe(pd))
+	}
+	if schema.MinLength != nil && len(b) < *schema.MinLength {
+		return schemaErr(path, "expected at least %d bytes, got %d", *schema.MinLength, len(b))
+	}
+	if schema.MaxLength != nil && len(b) > *schema.MaxLength {
+		return schemaErr(path, "expected at most %d bytes, got %d", *schema.MaxLength, len(b))
+	}
+	if len(schema.Enum) > 0 {
+		encoded := hex.EncodeToString(b)
+		for _, allowed := range schema.Enum {
+			if strings.EqualFold(allowed, encoded) {
+				return nil
+			}
+		}
+		return schemaErr(path, "%s is not one of %s", encoded, strings.Join(schema.Enum, ", "))
+	}
+	return nil
+}
+
+// describe names the kind of plutus data value for error messages.
+func describe(pd PlutusData.PlutusData) string {
+	if index, _, ok := pd.Constr(); ok {
+		return fmt.Sprintf("constructor %d", index)
+	}
+	switch pd.PlutusDataType {
+	case PlutusData.PlutusInt:
+		return "an integer"
+	case PlutusData.PlutusBytes:
+		return "bytes"
+	case PlutusData.PlutusMap:
+		return "a map"
+	case PlutusData.PlutusArray:
+		return "a list"
+	}
+	return "unknown data"
+}
+
+// ParseBlueprint reads a CIP-57 blueprint, the plutus.json of an aiken
+// project.
+func ParseBlueprint(data []byte) (*Blueprint, error) {
+	var bp Blueprint
+	if err := json.Unmarshal(data, &bp); err != nil {
+		return nil, err
+	}
+	if bp.Preamble.PlutusVersion == "" && len(bp.Validators) == 0 {
+		return nil, errors.New("not a plutus blueprint")
+	}
+	return &bp, nil
+}
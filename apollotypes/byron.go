@@ -0,0 +1,64 @@
+package apollotypes
+
+import (
+	"errors"
+
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	serAddress "github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/BootstrapWitness"
+	"github.com/SundaeSwap-finance/apollo/serialization/HDWallet"
+	"github.com/SundaeSwap-finance/apollo/serialization/Key"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionWitnessSet"
+)
+
+/*
+IcarusWallet holds the key of an Icarus bootstrap address, signing its
+inputs with bootstrap witnesses. XPub is the public key followed by its
+chain code.
+*/
+type IcarusWallet struct {
+	SigningKey   Key.SigningKey
+	XPub         []byte
+	ByronAddress serAddress.ByronAddress
+	Address      serAddress.Address
+}
+
+// NewIcarusWallet derives the key at index of the role chain of the
+// Icarus account of hd, m/44'/1815'/account'/role/index, on network.
+func NewIcarusWallet(hd *HDWallet.HDWallet, account uint32, role uint32, index uint32, network constants.Network) *IcarusWallet {
+	key := hd.DeriveIcarusKey(account, role, index).XPrivKey
+	xpub := key.XPub().Bytes()
+	byron := serAddress.NewIcarusAddress(xpub, network.ProtocolMagic())
+	return &IcarusWallet{
+		SigningKey:   Key.SigningKey{Payload: key.Bytes()},
+		XPub:         xpub,
+		ByronAddress: byron,
+		Address:      byron.ToAddress(),
+	}
+}
+
+func (iw *IcarusWallet) GetAddress() *serAddress.Address {
+	return &iw.Address
+}
+
+// PkeyHash returns the root of the bootstrap address, which is what its
+// witnesses hash to.
+func (iw *IcarusWallet) PkeyHash() serialization.PubKeyHash {
+	var res serialization.PubKeyHash
+	copy(res[:], iw.ByronAddress.Root)
+	return res
+}
+
+func (iw *IcarusWallet) SignTx(tx Transaction.Transaction) TransactionWitnessSet.TransactionWitnessSet {
+	witnessSet := tx.TransactionWitnessSet
+	witness := BootstrapWitness.SignBodyHash(tx.TransactionBody.Hash(), iw.XPub, iw.SigningKey, iw.ByronAddress)
+	witnessSet.BootstrapWitnesses = append(witnessSet.BootstrapWitnesses, witness)
+	return witnessSet
+}
+
+// SignMessage fails, CIP-30 signData being defined for Shelley addresses.
+func (iw *IcarusWallet) SignMessage(address serAddress.Address, message []uint8) (DataSignature, error) {
+	return DataSignature{}, errors.New("bootstrap addresses can't sign messages")
+}
@@ -0,0 +1,254 @@
+package apollotypes
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/crypto/bip32"
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	serAddress "github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/HDWallet"
+	"github.com/SundaeSwap-finance/apollo/serialization/Key"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionWitnessSet"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/VerificationKeyWitness"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Validation"
+)
+
+// DEFAULT_GAP_LIMIT is the number of consecutive unused addresses after
+// which discovery stops scanning a chain, as recommended by CIP-1852.
+const DEFAULT_GAP_LIMIT = 20
+
+/*
+DerivedAddress is the base address of the key at Index of the Role
+chain of an account, with the UTxOs found there during discovery.
+InHistory is set when HDAccountWallet.HasHistory found the address in
+past transactions.
+*/
+type DerivedAddress struct {
+	Role            uint32
+	Index           uint32
+	Address         serAddress.Address
+	VerificationKey Key.VerificationKey
+	Utxos           []UTxO.UTxO
+	InHistory       bool
+}
+
+func (da DerivedAddress) Used() bool {
+	return len(da.Utxos) > 0 || da.InHistory
+}
+
+/*
+HDAccountWallet is a CIP-1852 account of an HD wallet. Its addresses
+are the base addresses of the external and internal chain keys, all
+delegated to the account's stake key, and signing picks the derived
+key of each address spent from.
+
+	hd := HDWallet.NewHDWalletFromMnemonic(mnemonic, "")
+	wallet := apollotypes.NewHDAccountWallet(hd, 0, constants.MAINNET)
+	utxos := wallet.Discover(context)
+*/
+type HDAccountWallet struct {
+	Account              uint32
+	Network              constants.Network
+	GapLimit             int
+	HasHistory           func(serAddress.Address) bool
+	Addresses            []DerivedAddress
+	StakeSigningKey      Key.StakeSigningKey
+	StakeVerificationKey Key.StakeVerificationKey
+
+	hd        *HDWallet.HDWallet
+	xpub      bip32.XPub
+	nextIndex map[uint32]uint32
+}
+
+// NewHDAccountWallet returns the wallet of account, knowing only its
+// first external address until Discover is called.
+func NewHDAccountWallet(hd *HDWallet.HDWallet, account uint32, network constants.Network) *HDAccountWallet {
+	stakeKey := hd.DeriveKey(account, HDWallet.STAKING_CHAIN, 0).XPrivKey
+	w := &HDAccountWallet{
+		Account:              account,
+		Network:              network,
+		GapLimit:             DEFAULT_GAP_LIMIT,
+		StakeSigningKey:      Key.StakeSigningKey{Payload: stakeKey.Bytes()},
+		StakeVerificationKey: Key.StakeVerificationKey{Payload: stakeKey.PublicKey()},
+		hd:                   hd,
+		xpub:                 hd.AccountXPub(account),
+		nextIndex:            map[uint32]uint32{},
+	}
+	w.Addresses = []DerivedAddress{w.DeriveAddress(HDWallet.EXTERNAL_CHAIN, 0)}
+	return w
+}
+
+// DeriveAddress derives the base address at index of the role chain
+// from the account public key.
+func (w *HDAccountWallet) DeriveAddress(role uint32, index uint32) DerivedAddress {
+	verificationKey := Key.VerificationKey{Payload: HDWallet.DeriveXPub(w.xpub, role, index).PublicKey()}
+	paymentHash, _ := verificationKey.Hash()
+	stakeHash, _ := Key.VerificationKey(w.StakeVerificationKey).Hash()
+	return DerivedAddress{
+		Role:            role,
+		Index:           index,
+		Address:         *serAddress.AddressFromBytes(paymentHash[:], false, stakeHash[:], false, w.Network),
+		VerificationKey: verificationKey,
+	}
+}
+
+/*
+Discover scans the external and internal chains until GapLimit
+consecutive addresses are unused, and returns the UTxOs of the used
+ones. Addresses then holds the first external address and every used
+one.
+
+The chain contexts expose no transaction history, so an address is
+only used while it holds UTxOs, unless HasHistory is set to tell which
+addresses appear in past transactions. Without it an emptied address
+counts as unused: funds beyond GapLimit emptied addresses are missed,
+and UnusedAddress may return an address used before.
+*/
+func (w *HDAccountWallet) Discover(context Base.ChainContext) []UTxO.UTxO {
+	gapLimit := w.GapLimit
+	if gapLimit <= 0 {
+		gapLimit = DEFAULT_GAP_LIMIT
+	}
+	addresses := make([]DerivedAddress, 0)
+	nextIndex := map[uint32]uint32{}
+	for _, role := range []uint32{HDWallet.EXTERNAL_CHAIN, HDWallet.INTERNAL_CHAIN} {
+		gap := 0
+		for index := uint32(0); gap < gapLimit; index++ {
+			derived := w.DeriveAddress(role, index)
+			derived.Utxos = context.Utxos(derived.Address)
+			derived.InHistory = w.HasHistory != nil && w.HasHistory(derived.Address)
+			if derived.Used() {
+				gap = 0
+				nextIndex[role] = index + 1
+			} else {
+				gap++
+			}
+			if derived.Used() || (role == HDWallet.EXTERNAL_CHAIN && index == 0) {
+				addresses = append(addresses, derived)
+			}
+		}
+	}
+	w.Addresses = addresses
+	w.nextIndex = nextIndex
+	return w.Utxos()
+}
+
+// Utxos returns the UTxOs of all the discovered addresses.
+func (w *HDAccountWallet) Utxos() []UTxO.UTxO {
+	utxos := make([]UTxO.UTxO, 0)
+	for _, derived := range w.Addresses {
+		utxos = append(utxos, derived.Utxos...)
+	}
+	return utxos
+}
+
+// UnusedAddress returns the address following the last used one of the
+// role chain.
+func (w *HDAccountWallet) UnusedAddress(role uint32) DerivedAddress {
+	return w.DeriveAddress(role, w.nextIndex[role])
+}
+
+// ChangeAddress returns the first unused address of the internal chain.
+func (w *HDAccountWallet) ChangeAddress() serAddress.Address {
+	return w.UnusedAddress(HDWallet.INTERNAL_CHAIN).Address
+}
+
+func (w *HDAccountWallet) signingKey(role uint32, index uint32) Key.SigningKey {
+	return Key.SigningKey{Payload: w.hd.DeriveKey(w.Account, role, index).XPrivKey.Bytes()}
+}
+
+func (w *HDAccountWallet) GetAddress() *serAddress.Address {
+	return &w.Addresses[0].Address
+}
+
+func (w *HDAccountWallet) PkeyHash() serialization.PubKeyHash {
+	res, _ := w.Addresses[0].VerificationKey.Hash()
+	return res
+}
+
+/*
+SignTx signs tx with the key of every discovered address it spends from
+or lists as a required signer, and with the stake key when withdrawals,
+certificates or required signers need it. A transaction needing none of
+the wallet's keys is signed with the first external key.
+*/
+func (w *HDAccountWallet) SignTx(tx Transaction.Transaction) TransactionWitnessSet.TransactionWitnessSet {
+	witnessSet := tx.TransactionWitnessSet
+	txHash := tx.TransactionBody.Hash()
+	required := make(map[serialization.PubKeyHash]bool)
+	for _, pkh := range Validation.RequiredKeyHashes(tx, w.Utxos()) {
+		required[pkh] = true
+	}
+	signed := false
+	for _, derived := range w.Addresses {
+		pkh, _ := derived.VerificationKey.Hash()
+		if !required[pkh] {
+			continue
+		}
+		delete(required, pkh)
+		witnessSet.VkeyWitnesses = append(witnessSet.VkeyWitnesses,
+			VerificationKeyWitness.SignBodyHash(txHash, derived.VerificationKey, w.signingKey(derived.Role, derived.Index)))
+		signed = true
+	}
+	stakeVerificationKey := Key.VerificationKey(w.StakeVerificationKey)
+	if stakeHash, _ := stakeVerificationKey.Hash(); required[stakeHash] {
+		witnessSet.VkeyWitnesses = append(witnessSet.VkeyWitnesses,
+			VerificationKeyWitness.SignBodyHash(txHash, stakeVerificationKey, Key.SigningKey(w.StakeSigningKey)))
+		signed = true
+	}
+	if !signed {
+		first := w.Addresses[0]
+		witnessSet.VkeyWitnesses = append(witnessSet.VkeyWitnesses,
+			VerificationKeyWitness.SignBodyHash(txHash, first.VerificationKey, w.signingKey(first.Role, first.Index)))
+	}
+	return witnessSet
+}
+
+// SignMessage signs payload like CIP-30 signData, with the derived key of
+// a discovered address or with the stake key.
+func (w *HDAccountWallet) SignMessage(address serAddress.Address, payload []uint8) (DataSignature, error) {
+	if hasPaymentKey(address) {
+		for _, derived := range w.Addresses {
+			paymentHash, _ := derived.VerificationKey.Hash()
+			if bytes.Equal(address.PaymentPart, paymentHash[:]) {
+				return signMessage(address, payload, w.signingKey(derived.Role, derived.Index), derived.VerificationKey)
+			}
+		}
+	}
+	stakeHash, _ := Key.VerificationKey(w.StakeVerificationKey).Hash()
+	if hasStakeKey(address) && bytes.Equal(address.StakingPart, stakeHash[:]) {
+		return signMessage(address, payload, Key.SigningKey(w.StakeSigningKey), Key.VerificationKey(w.StakeVerificationKey))
+	}
+	return DataSignature{}, errors.New("the wallet holds no key of the address")
+}
+
+/*
+DiscoverAccounts discovers the accounts of hd in order, stopping at the
+first one without used addresses as BIP-44 account discovery does.
+Account 0 is always returned, used or not. hasHistory, which may be
+nil, is the HasHistory of the accounts.
+*/
+func DiscoverAccounts(hd *HDWallet.HDWallet, network constants.Network, context Base.ChainContext, gapLimit int, hasHistory func(serAddress.Address) bool) []*HDAccountWallet {
+	accounts := make([]*HDAccountWallet, 0)
+	for account := uint32(0); ; account++ {
+		wallet := NewHDAccountWallet(hd, account, network)
+		wallet.GapLimit = gapLimit
+		wallet.HasHistory = hasHistory
+		wallet.Discover(context)
+		used := false
+		for _, derived := range wallet.Addresses {
+			used = used || derived.Used()
+		}
+		if used || account == 0 {
+			accounts = append(accounts, wallet)
+		}
+		if !used {
+			return accounts
+		}
+	}
+}
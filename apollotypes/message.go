@@ -0,0 +1,159 @@
+package apollotypes
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+
+	serAddress "github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/COSE"
+	"github.com/SundaeSwap-finance/apollo/serialization/Key"
+
+	"github.com/Salvionied/cbor/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// DataSignature is the result of CIP-30 signData: the hex encoded
+// COSE_Sign1 message and COSE_Key.
+type DataSignature struct {
+	Signature string `json:"signature"`
+	Key       string `json:"key"`
+}
+
+func hasPaymentKey(address serAddress.Address) bool {
+	switch address.AddressType {
+	case serAddress.KEY_KEY, serAddress.KEY_SCRIPT, serAddress.KEY_POINTER, serAddress.KEY_NONE:
+		return true
+	}
+	return false
+}
+
+func hasStakeKey(address serAddress.Address) bool {
+	return address.AddressType == serAddress.KEY_KEY ||
+		address.AddressType == serAddress.SCRIPT_KEY ||
+		address.AddressType == serAddress.NONE_KEY
+}
+
+/*
+SignMessage signs payload like CIP-30 signData, with the payment key
+when it is the payment credential of address and with the stake key
+when it is its stake credential.
+*/
+func (gw *GenericWallet) SignMessage(address serAddress.Address, payload []uint8) (DataSignature, error) {
+	var signingKey Key.SigningKey
+	var verificationKey Key.VerificationKey
+	paymentHash, _ := gw.VerificationKey.Hash()
+	stakeHash, _ := Key.VerificationKey(gw.StakeVerificationKey).Hash()
+	switch {
+	case hasPaymentKey(address) && bytes.Equal(address.PaymentPart, paymentHash[:]):
+		signingKey, verificationKey = gw.SigningKey, gw.VerificationKey
+	case hasStakeKey(address) && bytes.Equal(address.StakingPart, stakeHash[:]):
+		signingKey, verificationKey = Key.SigningKey(gw.StakeSigningKey), Key.VerificationKey(gw.StakeVerificationKey)
+	default:
+		return DataSignature{}, errors.New("the wallet holds no key of the address")
+	}
+	return signMessage(address, payload, signingKey, verificationKey)
+}
+
+func signMessage(address serAddress.Address, payload []uint8, signingKey Key.SigningKey, verificationKey Key.VerificationKey) (DataSignature, error) {
+	message, err := COSE.NewSign1(address.Bytes(), payload)
+	if err != nil {
+		return DataSignature{}, err
+	}
+	sigStructure, err := message.SigStructure()
+	if err != nil {
+		return DataSignature{}, err
+	}
+	message.Signature = signingKey.Sign(sigStructure)
+	encodedMessage, err := cbor.Marshal(message)
+	if err != nil {
+		return DataSignature{}, err
+	}
+	encodedKey, err := cbor.Marshal(COSE.Key{PublicKey: verificationKey.Payload[:ed25519.PublicKeySize]})
+	if err != nil {
+		return DataSignature{}, err
+	}
+	return DataSignature{Signature: hex.EncodeToString(encodedMessage), Key: hex.EncodeToString(encodedKey)}, nil
+}
+
+/*
+VerifySignedMessage checks a CIP-30 signData signature, given as the
+hex encoded COSE_Sign1 and COSE_Key, was made for expectedAddress by
+the key of its payment or stake credential, and returns the signed
+payload. Messages signing the hash of their payload are rejected, as
+the payload can't be recovered; VerifySignedMessagePayload checks them.
+*/
+func VerifySignedMessage(coseSign1 string, coseKey string, expectedAddress serAddress.Address) ([]byte, error) {
+	message, err := verifySign1(coseSign1, coseKey, expectedAddress)
+	if err != nil {
+		return nil, err
+	}
+	if message.Hashed {
+		return nil, errors.New("message signs the hash of its payload, verify it with VerifySignedMessagePayload")
+	}
+	return message.Payload, nil
+}
+
+/*
+VerifySignedMessagePayload is VerifySignedMessage for a known payload,
+which it checks was signed, or its blake2b-224 hash when the message is
+hashed.
+*/
+func VerifySignedMessagePayload(coseSign1 string, coseKey string, expectedAddress serAddress.Address, payload []byte) error {
+	message, err := verifySign1(coseSign1, coseKey, expectedAddress)
+	if err != nil {
+		return err
+	}
+	expected := payload
+	if message.Hashed {
+		hash, err := blake2b.New(28, nil)
+		if err != nil {
+			return err
+		}
+		hash.Write(payload)
+		expected = hash.Sum(nil)
+	}
+	if !bytes.Equal(message.Payload, expected) {
+		return errors.New("message signs another payload")
+	}
+	return nil
+}
+
+func verifySign1(coseSign1 string, coseKey string, expectedAddress serAddress.Address) (*COSE.Sign1, error) {
+	decodedMessage, err := hex.DecodeString(coseSign1)
+	if err != nil {
+		return nil, err
+	}
+	decodedKey, err := hex.DecodeString(coseKey)
+	if err != nil {
+		return nil, err
+	}
+	message := COSE.Sign1{}
+	if err := cbor.Unmarshal(decodedMessage, &message); err != nil {
+		return nil, err
+	}
+	key := COSE.Key{}
+	if err := cbor.Unmarshal(decodedKey, &key); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(message.Address, expectedAddress.Bytes()) {
+		return nil, errors.New("message was signed for another address")
+	}
+	keyHash, err := Key.VerificationKey{Payload: key.PublicKey}.Hash()
+	if err != nil {
+		return nil, err
+	}
+	if !(hasPaymentKey(expectedAddress) && bytes.Equal(expectedAddress.PaymentPart, keyHash[:])) &&
+		!(hasStakeKey(expectedAddress) && bytes.Equal(expectedAddress.StakingPart, keyHash[:])) {
+		return nil, errors.New("key is not a credential of the address")
+	}
+	sigStructure, err := message.SigStructure()
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(key.PublicKey, sigStructure, message.Signature) {
+		return nil, errors.New("invalid message signature")
+	}
+	return &message, nil
+}
@@ -7,6 +7,10 @@ import (
 	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
 )
 
+// AikenPlutusJSON is the plutus.json of an aiken project.
+//
+// Deprecated: use Blueprint, which handles every plutus version and the
+// schemas of the validators.
 type AikenPlutusJSON struct {
 	Preamble struct {
 		Title         string `json:"title"`
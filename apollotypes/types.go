@@ -1,6 +1,9 @@
 package apollotypes
 
 import (
+	"errors"
+
+	"github.com/SundaeSwap-finance/apollo/constants"
 	"github.com/SundaeSwap-finance/apollo/serialization"
 	serAddress "github.com/SundaeSwap-finance/apollo/serialization/Address"
 	"github.com/SundaeSwap-finance/apollo/serialization/Key"
@@ -14,7 +17,7 @@ type Wallet interface {
 	GetAddress() *serAddress.Address
 	SignTx(tx Transaction.Transaction) TransactionWitnessSet.TransactionWitnessSet
 	PkeyHash() serialization.PubKeyHash
-	//SignMessage(address serAddress.Address, message []uint8) []uint8
+	SignMessage(address serAddress.Address, message []uint8) (DataSignature, error)
 }
 
 type ExternalWallet struct {
@@ -29,6 +32,11 @@ func (ew *ExternalWallet) SignTx(tx Transaction.Transaction) TransactionWitnessS
 	return tx.TransactionWitnessSet
 }
 
+// SignMessage fails, external wallets signing their messages themselves.
+func (ew *ExternalWallet) SignMessage(address serAddress.Address, message []uint8) (DataSignature, error) {
+	return DataSignature{}, errors.New("external wallets can't sign messages")
+}
+
 func (ew *ExternalWallet) PkeyHash() serialization.PubKeyHash {
 	res := serialization.PubKeyHash(ew.Address.PaymentPart)
 	return res
@@ -51,6 +59,30 @@ func (gw *GenericWallet) GetAddress() *serAddress.Address {
 	return &gw.Address
 }
 
+func (gw *GenericWallet) network() constants.Network {
+	if gw.Address.Network == serAddress.MAINNET {
+		return constants.MAINNET
+	}
+	return constants.TESTNET
+}
+
+// EnterpriseAddress returns the address of the payment key alone, on
+// the network of the wallet's address.
+func (gw *GenericWallet) EnterpriseAddress() *serAddress.Address {
+	vkh, _ := gw.VerificationKey.Hash()
+	return serAddress.AddressFromBytes(vkh[:], false, nil, false, gw.network())
+}
+
+// RewardAddress returns the stake address of the wallet, or nil when it
+// has no stake key.
+func (gw *GenericWallet) RewardAddress() *serAddress.Address {
+	if len(gw.StakeVerificationKey.Payload) == 0 {
+		return nil
+	}
+	skh, _ := Key.VerificationKey(gw.StakeVerificationKey).Hash()
+	return serAddress.AddressFromBytes(nil, false, skh[:], false, gw.network())
+}
+
 func (wallet *GenericWallet) SignTx(tx Transaction.Transaction) TransactionWitnessSet.TransactionWitnessSet {
 	witness_set := tx.TransactionWitnessSet
 	txHash := tx.TransactionBody.Hash()
@@ -1,9 +1,12 @@
 package apollo
 
 import (
+	"errors"
+
 	"github.com/SundaeSwap-finance/apollo/constants"
 	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/BlockFrostChainContext"
 	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/FixedChainContext"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/MaestroChainContext"
 )
 
 func NewEmptyBackend() FixedChainContext.FixedChainContext {
@@ -44,3 +47,31 @@ func NewBlockfrostBackend(
 		panic("Invalid network")
 	}
 }
+
+func NewMaestroBackend(
+	apiKey string,
+	network constants.Network,
+) (MaestroChainContext.MaestroChainContext, error) {
+	switch network {
+	case constants.MAINNET:
+		return MaestroChainContext.NewMaestroChainContext(
+			constants.MAESTRO_BASE_URL_MAINNET,
+			int(constants.MAINNET),
+			apiKey,
+		)
+	case constants.PREVIEW:
+		return MaestroChainContext.NewMaestroChainContext(
+			constants.MAESTRO_BASE_URL_PREVIEW,
+			int(constants.TESTNET),
+			apiKey,
+		)
+	case constants.PREPROD:
+		return MaestroChainContext.NewMaestroChainContext(
+			constants.MAESTRO_BASE_URL_PREPROD,
+			int(constants.TESTNET),
+			apiKey,
+		)
+	default:
+		return MaestroChainContext.MaestroChainContext{}, errors.New("invalid network")
+	}
+}
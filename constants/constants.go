@@ -13,11 +13,37 @@ const (
 	PREPROD
 )
 
+// Protocol magics of the networks, which bootstrap addresses carry
+// outside mainnet.
+const (
+	MAINNET_PROTOCOL_MAGIC = 764824073
+	TESTNET_PROTOCOL_MAGIC = 1097911063
+	PREPROD_PROTOCOL_MAGIC = 1
+	PREVIEW_PROTOCOL_MAGIC = 2
+)
+
+func (n Network) ProtocolMagic() uint32 {
+	switch n {
+	case TESTNET:
+		return TESTNET_PROTOCOL_MAGIC
+	case PREPROD:
+		return PREPROD_PROTOCOL_MAGIC
+	case PREVIEW:
+		return PREVIEW_PROTOCOL_MAGIC
+	default:
+		return MAINNET_PROTOCOL_MAGIC
+	}
+}
+
 const BLOCKFROST_BASE_URL_MAINNET = "https://cardano-mainnet.blockfrost.io/api"
 const BLOCKFROST_BASE_URL_TESTNET = "https://cardano-testnet.blockfrost.io/api"
 const BLOCKFROST_BASE_URL_PREVIEW = "https://cardano-preview.blockfrost.io/api"
 const BLOCKFROST_BASE_URL_PREPROD = "https://cardano-preprod.blockfrost.io/api"
 
+const MAESTRO_BASE_URL_MAINNET = "https://mainnet.gomaestro-api.org/v1"
+const MAESTRO_BASE_URL_PREVIEW = "https://preview.gomaestro-api.org/v1"
+const MAESTRO_BASE_URL_PREPROD = "https://preprod.gomaestro-api.org/v1"
+
 var FAKE_VKEY = Key.VerificationKey{Payload: []byte("5797dc2cc919dfec0bb849551ebdf30d96e5cbe0f33f734a87fe826db30f7ef9")}
 
 var FAKE_SIGNATURE = []byte("577ccb5b487b64e396b0976c6f71558e52e44ad254db7d06dfb79843e5441a5d763dd42adcf5e8805d70373722ebbce62a58e3f30dd4560b9a898b8ceeab6a03")
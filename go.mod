@@ -6,13 +6,13 @@ require (
 	github.com/Salvionied/cbor/v2 v2.6.0
 	github.com/SundaeSwap-finance/kugo v1.3.0
 	github.com/SundaeSwap-finance/ogmigo/v6 v6.1.0
+	github.com/btcsuite/btcutil v1.0.2
 	github.com/tyler-smith/go-bip39 v1.1.0
 	golang.org/x/text v0.26.0
 )
 
 require (
 	github.com/aws/aws-sdk-go v1.55.6 // indirect
-	github.com/btcsuite/btcutil v1.0.2 // indirect
 	github.com/buger/jsonparser v1.1.1 // indirect
 	github.com/fxamacker/cbor/v2 v2.7.0 // indirect
 	github.com/gorilla/websocket v1.5.3 // indirect
@@ -2,15 +2,14 @@ package Address
 
 import (
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"log"
 
 	"github.com/SundaeSwap-finance/apollo/constants"
 	"github.com/SundaeSwap-finance/apollo/crypto/bech32"
-	"github.com/SundaeSwap-finance/apollo/serialization"
 
 	"github.com/Salvionied/cbor/v2"
+	"github.com/btcsuite/btcutil/base58"
 )
 
 const (
@@ -99,23 +98,22 @@ func (addr *Address) MarshalCBOR() ([]byte, error) {
 }
 func (addr *Address) UnmarshalCBOR(value []byte) error {
 	res := make([]byte, 0)
-	err := cbor.Unmarshal(value, &res)
-	header := res[0]
-	payload := res[1:]
-	addr.PaymentPart = payload[:serialization.VERIFICATION_KEY_HASH_SIZE]
-	addr.StakingPart = payload[serialization.VERIFICATION_KEY_HASH_SIZE:]
-	addr.Network = (header & 0x0F)
-	addr.AddressType = (header & 0xF0) >> 4
-	addr.HeaderByte = header
-	addr.Hrp = ComputeHrp(addr.AddressType, addr.Network)
-	return err
+	if err := cbor.Unmarshal(value, &res); err != nil {
+		return err
+	}
+	decoded, err := DecodeAddressBytes(res)
+	if err != nil {
+		return err
+	}
+	*addr = decoded
+	return nil
 }
 
 func (addr Address) Bytes() []byte {
 	var payment []byte
 	var staking []byte
 	payment = addr.PaymentPart
-	if len(addr.StakingPart) == 28 {
+	if len(addr.StakingPart) == 28 || addr.AddressType == KEY_POINTER || addr.AddressType == SCRIPT_POINTER {
 		staking = addr.StakingPart
 	} else {
 		staking = make([]byte, 0)
@@ -128,6 +126,9 @@ func (addr Address) Bytes() []byte {
 }
 
 func (addr Address) String() string {
+	if addr.AddressType == BYRON {
+		return base58.Encode(addr.Bytes())
+	}
 	byteaddress, err := bech32.ConvertBits(addr.Bytes(), 8, 5, true)
 	if err != nil {
 		log.Fatal(err)
@@ -156,35 +157,18 @@ func ComputeHrp(address_type uint8, network uint8) string {
 func DecodeAddress(value string) (Address, error) {
 	_, data, err := bech32.Decode(value)
 	if err != nil {
+		// base58 of a cbor array is a byron address, report why it is invalid
+		if raw := base58.Decode(value); len(raw) > 0 && raw[0] == 0x82 {
+			if _, byronErr := DecodeByronAddress(raw); byronErr != nil {
+				return Address{}, byronErr
+			}
+			return byronFromBytes(raw), nil
+		}
 		return Address{}, err
 	}
 
 	decoded_value, _ := bech32.ConvertBits(data, 5, 8, false)
-
-	header := decoded_value[0]
-	payload := decoded_value[1:]
-	network := (header & 0x0F)
-	addr_type := (header & 0xF0) >> 4
-	if !(network == 0b0000 || network == 0b0001) {
-		return Address{}, errors.New("invalid network tag")
-	}
-	if addr_type == KEY_KEY {
-		return Address{payload[:serialization.VERIFICATION_KEY_HASH_SIZE], payload[serialization.VERIFICATION_KEY_HASH_SIZE:], network, addr_type, header, ComputeHrp(addr_type, network)}, nil
-	} else if addr_type == SCRIPT_KEY {
-		return Address{payload[:serialization.VERIFICATION_KEY_HASH_SIZE], payload[serialization.VERIFICATION_KEY_HASH_SIZE:], network, addr_type, header, ComputeHrp(addr_type, network)}, nil
-	} else if addr_type == KEY_SCRIPT {
-		return Address{payload[:serialization.VERIFICATION_KEY_HASH_SIZE], payload[serialization.VERIFICATION_KEY_HASH_SIZE:], network, addr_type, header, ComputeHrp(addr_type, network)}, nil
-	} else if addr_type == KEY_NONE {
-		return Address{payload[:serialization.VERIFICATION_KEY_HASH_SIZE], make([]byte, 0), network, addr_type, header, ComputeHrp(addr_type, network)}, nil
-	} else if addr_type == SCRIPT_SCRIPT {
-		return Address{payload[:serialization.VERIFICATION_KEY_HASH_SIZE], payload[serialization.VERIFICATION_KEY_HASH_SIZE:], network, addr_type, header, ComputeHrp(addr_type, network)}, nil
-	} else if addr_type == SCRIPT_NONE {
-		return Address{payload[:serialization.VERIFICATION_KEY_HASH_SIZE], make([]byte, 0), network, addr_type, header, ComputeHrp(addr_type, network)}, nil
-	} else if addr_type == NONE_KEY {
-		return Address{make([]byte, 0), payload[:serialization.VERIFICATION_KEY_HASH_SIZE], network, addr_type, header, ComputeHrp(addr_type, network)}, nil
-	} else {
-		return Address{make([]byte, 0), payload[:serialization.VERIFICATION_KEY_HASH_SIZE], network, addr_type, header, ComputeHrp(addr_type, network)}, nil
-	}
+	return DecodeAddressBytes(decoded_value)
 }
 
 func (a Address) IsPublicKeyAddress() bool {
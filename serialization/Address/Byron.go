@@ -0,0 +1,192 @@
+package Address
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// Byron address types and attribute keys.
+const (
+	BYRON_PUBKEY = 0
+	BYRON_SCRIPT = 1
+	BYRON_REDEEM = 2
+
+	BYRON_ATTR_DERIVATION_PATH = 1
+	BYRON_ATTR_PROTOCOL_MAGIC  = 2
+)
+
+/*
+ByronAddress is a bootstrap address, the base58 encoded
+
+	[#6.24(bytes .cbor [root, attributes, type]), crc32]
+
+Root hashes the spending data of the address. DerivationPath is the
+encrypted HD payload of legacy Daedalus addresses and ProtocolMagic the
+network of non mainnet ones, 0 when absent.
+*/
+type ByronAddress struct {
+	Root           []byte
+	DerivationPath []byte
+	ProtocolMagic  uint32
+	Type           uint64
+}
+
+// EncodedAttributes returns the cbor encoded attributes map, as carried
+// by bootstrap witnesses.
+func (ba ByronAddress) EncodedAttributes() []byte {
+	attributes := make(map[uint64][]byte)
+	if ba.DerivationPath != nil {
+		attributes[BYRON_ATTR_DERIVATION_PATH], _ = cbor.Marshal(ba.DerivationPath)
+	}
+	if ba.ProtocolMagic != 0 {
+		attributes[BYRON_ATTR_PROTOCOL_MAGIC], _ = cbor.Marshal(ba.ProtocolMagic)
+	}
+	em, _ := cbor.EncOptions{Sort: cbor.SortCanonical}.EncMode()
+	encoded, _ := em.Marshal(attributes)
+	return encoded
+}
+
+func (ba ByronAddress) Bytes() []byte {
+	payload, _ := cbor.Marshal([]any{ba.Root, cbor.RawMessage(ba.EncodedAttributes()), ba.Type})
+	encoded, _ := cbor.Marshal([]any{cbor.Tag{Number: 24, Content: payload}, crc32.ChecksumIEEE(payload)})
+	return encoded
+}
+
+func (ba ByronAddress) String() string {
+	return base58.Encode(ba.Bytes())
+}
+
+// ToAddress returns ba as an Address, its raw bytes following the 0x82
+// header byte in PaymentPart.
+func (ba ByronAddress) ToAddress() Address {
+	return byronFromBytes(ba.Bytes())
+}
+
+// byronFromBytes wraps the raw bytes of a bootstrap address, kept as is
+// so that they serialize back identically.
+func byronFromBytes(raw []byte) Address {
+	network := byte(MAINNET)
+	if ba, err := DecodeByronAddress(raw); err == nil &&
+		ba.ProtocolMagic != 0 && ba.ProtocolMagic != constants.MAINNET_PROTOCOL_MAGIC {
+		network = TESTNET
+	}
+	return Address{
+		PaymentPart: raw[1:],
+		StakingPart: make([]byte, 0),
+		Network:     network,
+		AddressType: BYRON,
+		HeaderByte:  raw[0],
+	}
+}
+
+/*
+ByronRoot returns the root of the public key address of an extended
+public key (the key followed by its chain code) with the given cbor
+encoded attributes:
+
+	blake2b_224(sha3_256([0, [0, xpub], attributes]))
+*/
+func ByronRoot(xpub []byte, attributes []byte) []byte {
+	spending, _ := cbor.Marshal([]any{BYRON_PUBKEY, []any{BYRON_PUBKEY, xpub}, cbor.RawMessage(attributes)})
+	sha := sha3.Sum256(spending)
+	hash, _ := blake2b.New(28, nil)
+	hash.Write(sha[:])
+	return hash.Sum(nil)
+}
+
+/*
+NewIcarusAddress returns the Icarus style bootstrap address of an
+extended public key, with no derivation path. protocolMagic is left
+out of mainnet addresses.
+*/
+func NewIcarusAddress(xpub []byte, protocolMagic uint32) ByronAddress {
+	if protocolMagic == constants.MAINNET_PROTOCOL_MAGIC {
+		protocolMagic = 0
+	}
+	ba := ByronAddress{ProtocolMagic: protocolMagic, Type: BYRON_PUBKEY}
+	ba.Root = ByronRoot(xpub, ba.EncodedAttributes())
+	return ba
+}
+
+// DecodeByronAddress parses the raw bytes of a bootstrap address,
+// checking its crc.
+func DecodeByronAddress(raw []byte) (ByronAddress, error) {
+	var parts []cbor.RawMessage
+	if err := cbor.Unmarshal(raw, &parts); err != nil {
+		return ByronAddress{}, err
+	}
+	if len(parts) != 2 {
+		return ByronAddress{}, errors.New("byron address must have 2 elements")
+	}
+	var tag cbor.RawTag
+	if err := cbor.Unmarshal(parts[0], &tag); err != nil || tag.Number != 24 {
+		return ByronAddress{}, errors.New("byron address payload is not cbor in cbor")
+	}
+	var payload []byte
+	if err := cbor.Unmarshal(tag.Content, &payload); err != nil {
+		return ByronAddress{}, err
+	}
+	var crc uint32
+	if err := cbor.Unmarshal(parts[1], &crc); err != nil {
+		return ByronAddress{}, err
+	}
+	if crc32.ChecksumIEEE(payload) != crc {
+		return ByronAddress{}, errors.New("invalid byron address crc")
+	}
+	var content []cbor.RawMessage
+	if err := cbor.Unmarshal(payload, &content); err != nil {
+		return ByronAddress{}, err
+	}
+	if len(content) != 3 {
+		return ByronAddress{}, errors.New("byron address payload must have 3 elements")
+	}
+	ba := ByronAddress{}
+	if err := cbor.Unmarshal(content[0], &ba.Root); err != nil {
+		return ByronAddress{}, err
+	}
+	if len(ba.Root) != 28 {
+		return ByronAddress{}, fmt.Errorf("invalid byron address root length %d", len(ba.Root))
+	}
+	attributes := make(map[uint64][]byte)
+	if err := cbor.Unmarshal(content[1], &attributes); err != nil {
+		return ByronAddress{}, err
+	}
+	if path, ok := attributes[BYRON_ATTR_DERIVATION_PATH]; ok {
+		if err := cbor.Unmarshal(path, &ba.DerivationPath); err != nil {
+			return ByronAddress{}, err
+		}
+	}
+	if magic, ok := attributes[BYRON_ATTR_PROTOCOL_MAGIC]; ok {
+		if err := cbor.Unmarshal(magic, &ba.ProtocolMagic); err != nil {
+			return ByronAddress{}, err
+		}
+	}
+	if err := cbor.Unmarshal(content[2], &ba.Type); err != nil {
+		return ByronAddress{}, err
+	}
+	return ba, nil
+}
+
+// DecodeByronAddressBase58 parses a base58 bootstrap address.
+func DecodeByronAddressBase58(value string) (ByronAddress, error) {
+	raw := base58.Decode(value)
+	if len(raw) == 0 {
+		return ByronAddress{}, errors.New("invalid base58 address")
+	}
+	return DecodeByronAddress(raw)
+}
+
+// Byron parses addr back into a bootstrap address.
+func (addr Address) Byron() (ByronAddress, error) {
+	if addr.AddressType != BYRON {
+		return ByronAddress{}, errors.New("not a byron address")
+	}
+	return DecodeByronAddress(addr.Bytes())
+}
@@ -0,0 +1,274 @@
+package Address
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+)
+
+/*
+Pointer locates the certificate registering the stake credential of a
+pointer address: its slot, the index of its transaction in the block
+and its index in the transaction.
+*/
+type Pointer struct {
+	Slot      uint64
+	TxIndex   uint64
+	CertIndex uint64
+}
+
+// encodeVarUint encodes n big endian on 7 bits per byte, the high bit
+// set on every byte but the last.
+func encodeVarUint(n uint64) []byte {
+	res := []byte{byte(n & 0x7f)}
+	for n >>= 7; n > 0; n >>= 7 {
+		res = append([]byte{byte(n&0x7f) | 0x80}, res...)
+	}
+	return res
+}
+
+func decodeVarUint(data []byte) (uint64, []byte, error) {
+	var n uint64
+	for i, b := range data {
+		if n > (1<<64-1)>>7 {
+			return 0, nil, errors.New("pointer value overflows")
+		}
+		n = n<<7 | uint64(b&0x7f)
+		if b&0x80 == 0 {
+			return n, data[i+1:], nil
+		}
+	}
+	return 0, nil, errors.New("truncated pointer")
+}
+
+func (p Pointer) Bytes() []byte {
+	res := encodeVarUint(p.Slot)
+	res = append(res, encodeVarUint(p.TxIndex)...)
+	return append(res, encodeVarUint(p.CertIndex)...)
+}
+
+// DecodePointer parses the stake part of a pointer address.
+func DecodePointer(data []byte) (Pointer, error) {
+	var p Pointer
+	var err error
+	rest := data
+	for _, field := range []*uint64{&p.Slot, &p.TxIndex, &p.CertIndex} {
+		if *field, rest, err = decodeVarUint(rest); err != nil {
+			return Pointer{}, err
+		}
+	}
+	if len(rest) != 0 {
+		return Pointer{}, errors.New("trailing bytes after pointer")
+	}
+	return p, nil
+}
+
+/*
+StakeReference is the delegation part of an address: a stake
+credential, a pointer to the certificate registering one, or neither
+for enterprise addresses.
+*/
+type StakeReference struct {
+	Credential *Credential.Credential
+	Pointer    *Pointer
+}
+
+// NoStake is the stake reference of enterprise addresses.
+var NoStake = StakeReference{}
+
+func StakeCredential(cred Credential.Credential) StakeReference {
+	return StakeReference{Credential: &cred}
+}
+
+func StakePointer(pointer Pointer) StakeReference {
+	return StakeReference{Pointer: &pointer}
+}
+
+// StakeKeyHash returns the stake reference of a stake key hash, or
+// NoStake when hash is empty.
+func StakeKeyHash(hash []byte) (StakeReference, error) {
+	if len(hash) == 0 {
+		return NoStake, nil
+	}
+	cred, err := Credential.FromBytes(hash, false)
+	if err != nil {
+		return NoStake, err
+	}
+	return StakeCredential(cred), nil
+}
+
+/*
+ScriptAddress returns the mainnet address of a script, delegated to the
+stake key hash stakingCredential unless it is empty. It backs the
+ToAddress methods of scripts, which can't report errors: a
+stakingCredential that isn't a key hash is kept as the staking part
+as is, giving an address that won't decode. ToNetworkAddress with the
+stake reference from StakeKeyHash reports it instead.
+*/
+func ScriptAddress(hash serialization.ScriptHash, stakingCredential []byte) Address {
+	payment := Credential.FromScriptHash(hash)
+	stake, err := StakeKeyHash(stakingCredential)
+	if err != nil {
+		return newAddress(SCRIPT_KEY, payment.Bytes(), stakingCredential, constants.MAINNET)
+	}
+	return New(payment, stake, constants.MAINNET)
+}
+
+func networkTag(network constants.Network) byte {
+	if network == constants.MAINNET {
+		return MAINNET
+	}
+	return TESTNET
+}
+
+func newAddress(addressType byte, payment []byte, staking []byte, network constants.Network) Address {
+	tag := networkTag(network)
+	return Address{
+		PaymentPart: payment,
+		StakingPart: staking,
+		Network:     tag,
+		AddressType: addressType,
+		HeaderByte:  addressType<<4 | tag,
+		Hrp:         ComputeHrp(addressType, tag),
+	}
+}
+
+/*
+New returns the Shelley address of payment and stake on network, its
+type, header byte and hrp following from them:
+
+	addr := Address.New(Credential.FromScriptHash(hash), Address.StakePointer(Address.Pointer{Slot: 2498243, TxIndex: 27, CertIndex: 3}), constants.MAINNET)
+*/
+func New(payment Credential.Credential, stake StakeReference, network constants.Network) Address {
+	switch {
+	case stake.Credential != nil:
+		addressType := byte(KEY_KEY)
+		if payment.IsScript() {
+			addressType |= SCRIPT_KEY
+		}
+		if stake.Credential.IsScript() {
+			addressType |= KEY_SCRIPT
+		}
+		return newAddress(addressType, payment.Bytes(), stake.Credential.Bytes(), network)
+	case stake.Pointer != nil:
+		addressType := byte(KEY_POINTER)
+		if payment.IsScript() {
+			addressType = SCRIPT_POINTER
+		}
+		return newAddress(addressType, payment.Bytes(), stake.Pointer.Bytes(), network)
+	default:
+		addressType := byte(KEY_NONE)
+		if payment.IsScript() {
+			addressType = SCRIPT_NONE
+		}
+		return newAddress(addressType, payment.Bytes(), make([]byte, 0), network)
+	}
+}
+
+// NewRewardAddress returns the stake address of stake on network.
+func NewRewardAddress(stake Credential.Credential, network constants.Network) Address {
+	addressType := byte(NONE_KEY)
+	if stake.IsScript() {
+		addressType = NONE_SCRIPT
+	}
+	return newAddress(addressType, make([]byte, 0), stake.Bytes(), network)
+}
+
+// PaymentCredential returns the payment credential of a Shelley address
+// that has one.
+func (addr Address) PaymentCredential() (Credential.Credential, error) {
+	switch addr.AddressType {
+	case KEY_KEY, KEY_SCRIPT, KEY_POINTER, KEY_NONE:
+		return Credential.FromBytes(addr.PaymentPart, false)
+	case SCRIPT_KEY, SCRIPT_SCRIPT, SCRIPT_POINTER, SCRIPT_NONE:
+		return Credential.FromBytes(addr.PaymentPart, true)
+	}
+	return Credential.Credential{}, fmt.Errorf("address type %d has no payment credential", addr.AddressType)
+}
+
+// StakeReference returns the stake credential or pointer of addr, if any.
+func (addr Address) StakeReference() (StakeReference, error) {
+	switch addr.AddressType {
+	case KEY_KEY, SCRIPT_KEY, NONE_KEY:
+		cred, err := Credential.FromBytes(addr.StakingPart, false)
+		return StakeCredential(cred), err
+	case KEY_SCRIPT, SCRIPT_SCRIPT, NONE_SCRIPT:
+		cred, err := Credential.FromBytes(addr.StakingPart, true)
+		return StakeCredential(cred), err
+	case KEY_POINTER, SCRIPT_POINTER:
+		pointer, err := DecodePointer(addr.StakingPart)
+		return StakePointer(pointer), err
+	}
+	return NoStake, nil
+}
+
+/*
+DecodeAddressBytes parses the raw bytes of an address, as found in
+transaction outputs, checking their length against the address type.
+*/
+func DecodeAddressBytes(raw []byte) (Address, error) {
+	if len(raw) == 0 {
+		return Address{}, errors.New("empty address")
+	}
+	header := raw[0]
+	payload := raw[1:]
+	network := header & 0x0F
+	addrType := (header & 0xF0) >> 4
+	if addrType == BYRON {
+		if _, err := DecodeByronAddress(raw); err != nil {
+			return Address{}, err
+		}
+		return byronFromBytes(raw), nil
+	}
+	if !(network == 0b0000 || network == 0b0001) {
+		return Address{}, errors.New("invalid network tag")
+	}
+	size := serialization.VERIFICATION_KEY_HASH_SIZE
+	addr := Address{Network: network, AddressType: addrType, HeaderByte: header, Hrp: ComputeHrp(addrType, network)}
+	switch addrType {
+	case KEY_KEY, SCRIPT_KEY, KEY_SCRIPT, SCRIPT_SCRIPT:
+		if len(payload) != 2*size {
+			return Address{}, fmt.Errorf("invalid address length %d", len(raw))
+		}
+		addr.PaymentPart, addr.StakingPart = payload[:size], payload[size:]
+	case KEY_POINTER, SCRIPT_POINTER:
+		if len(payload) <= size {
+			return Address{}, fmt.Errorf("invalid address length %d", len(raw))
+		}
+		if _, err := DecodePointer(payload[size:]); err != nil {
+			return Address{}, err
+		}
+		addr.PaymentPart, addr.StakingPart = payload[:size], payload[size:]
+	case KEY_NONE, SCRIPT_NONE:
+		if len(payload) != size {
+			return Address{}, fmt.Errorf("invalid address length %d", len(raw))
+		}
+		addr.PaymentPart, addr.StakingPart = payload, make([]byte, 0)
+	case NONE_KEY, NONE_SCRIPT:
+		if len(payload) != size {
+			return Address{}, fmt.Errorf("invalid address length %d", len(raw))
+		}
+		addr.PaymentPart, addr.StakingPart = make([]byte, 0), payload
+	default:
+		return Address{}, fmt.Errorf("unknown address type %d", addrType)
+	}
+	return addr, nil
+}
+
+// DecodeAddressHex parses the hex encoded raw bytes of an address.
+func DecodeAddressHex(value string) (Address, error) {
+	raw, err := hex.DecodeString(value)
+	if err != nil {
+		return Address{}, err
+	}
+	return DecodeAddressBytes(raw)
+}
+
+// Hex returns the hex encoded raw bytes of addr.
+func (addr Address) Hex() string {
+	return hex.EncodeToString(addr.Bytes())
+}
@@ -0,0 +1,53 @@
+package BootstrapWitness
+
+import (
+	"crypto/ed25519"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Key"
+)
+
+/*
+BootstrapWitness signs for the inputs of a bootstrap address: the
+public key and chain code with the encoded attributes of the address
+let the ledger recompute its root.
+*/
+type BootstrapWitness struct {
+	_          struct{} `cbor:",toarray"`
+	PublicKey  []byte
+	Signature  []byte
+	ChainCode  []byte
+	Attributes []byte
+}
+
+// SignBodyHash signs the hash of a transaction body for address with the
+// key of extended public key xpub.
+func SignBodyHash(bodyHash []byte, xpub []byte, skey Key.SigningKey, address Address.ByronAddress) BootstrapWitness {
+	return BootstrapWitness{
+		PublicKey:  xpub[:ed25519.PublicKeySize],
+		Signature:  skey.Sign(bodyHash),
+		ChainCode:  xpub[ed25519.PublicKeySize:],
+		Attributes: address.EncodedAttributes(),
+	}
+}
+
+// Verify checks the witness signs the transaction body of hash bodyHash.
+func (bw BootstrapWitness) Verify(bodyHash []byte) bool {
+	return Key.VerificationKey{Payload: bw.PublicKey}.Verify(bodyHash, bw.Signature)
+}
+
+// Root returns the root of the bootstrap address the witness signs for.
+func (bw BootstrapWitness) Root() []byte {
+	return Address.ByronRoot(append(append([]byte{}, bw.PublicKey...), bw.ChainCode...), bw.Attributes)
+}
+
+// Fake returns a witness of the size of a real one for address, to
+// estimate fees before signing.
+func Fake(address Address.ByronAddress) BootstrapWitness {
+	return BootstrapWitness{
+		PublicKey:  make([]byte, ed25519.PublicKeySize),
+		Signature:  make([]byte, ed25519.SignatureSize),
+		ChainCode:  make([]byte, 32),
+		Attributes: address.EncodedAttributes(),
+	}
+}
@@ -0,0 +1,165 @@
+package CIP68
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/AssetName"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+)
+
+// Asset name labels of CIP-67 used by CIP-68.
+const (
+	REFERENCE_LABEL = 100
+	NFT_LABEL       = 222
+	FT_LABEL        = 333
+	RFT_LABEL       = 444
+)
+
+// crc8 is the CRC-8 of CIP-67, with polynomial 0x07 and no reflection.
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+/*
+LabelPrefix returns the 4 bytes CIP-67 prefix of label: a zero nibble,
+the label on 2 bytes, their CRC-8 and a closing zero nibble, e.g.
+000de140 for 222.
+*/
+func LabelPrefix(label int) ([]byte, error) {
+	if label < 0 || label > 0xffff {
+		return nil, fmt.Errorf("label %d out of range", label)
+	}
+	labelBytes := []byte{byte(label >> 8), byte(label)}
+	crc := crc8(labelBytes)
+	return []byte{
+		labelBytes[0] >> 4,
+		labelBytes[0]<<4 | labelBytes[1]>>4,
+		labelBytes[1]<<4 | crc>>4,
+		crc << 4,
+	}, nil
+}
+
+// LabelledName returns name prefixed with label, as the raw asset name
+// an apollo.Unit takes.
+func LabelledName(label int, name string) (string, error) {
+	prefix, err := LabelPrefix(label)
+	if err != nil {
+		return "", err
+	}
+	if len(prefix)+len(name) > 32 {
+		return "", fmt.Errorf("labelled asset name %s is longer than 32 bytes", name)
+	}
+	return string(prefix) + name, nil
+}
+
+func NewAssetName(label int, name string) (AssetName.AssetName, error) {
+	labelled, err := LabelledName(label, name)
+	if err != nil {
+		return AssetName.AssetName{}, err
+	}
+	return AssetName.NewAssetNameFromString(labelled), nil
+}
+
+/*
+ParseAssetName returns the label and the name following it, failing
+when the asset name doesn't start with a valid CIP-67 prefix.
+*/
+func ParseAssetName(assetName AssetName.AssetName) (int, string, error) {
+	raw, err := hex.DecodeString(assetName.HexString())
+	if err != nil {
+		return 0, "", err
+	}
+	if len(raw) < 4 || raw[0]&0xf0 != 0 || raw[3]&0x0f != 0 {
+		return 0, "", errors.New("asset name has no CIP-67 label")
+	}
+	label := int(raw[0])<<12 | int(raw[1])<<4 | int(raw[2])>>4
+	prefix, _ := LabelPrefix(label)
+	if !bytes.Equal(prefix, raw[:4]) {
+		return 0, "", errors.New("invalid CIP-67 label checksum")
+	}
+	return label, string(raw[4:]), nil
+}
+
+/*
+Datum is the datum held with a reference token: the metadata map, the
+version of CIP-68 and extra data free for the token's own use.
+*/
+type Datum struct {
+	_        struct{} `plutus:"constr=0"`
+	Metadata PlutusData.PlutusData
+	Version  int64
+	Extra    PlutusData.PlutusData
+}
+
+/*
+NewDatum builds a datum from metadata converted as PlutusData.Marshal
+does, strings becoming bytes. A nil extra stands for the unit
+constructor.
+
+	datum, err := CIP68.NewDatum(map[string]any{"name": "Token", "image": "ipfs://..."}, 1, nil)
+*/
+func NewDatum(metadata map[string]any, version int64, extra *PlutusData.PlutusData) (Datum, error) {
+	pd, err := PlutusData.Marshal(metadata)
+	if err != nil {
+		return Datum{}, err
+	}
+	datum := Datum{Metadata: pd, Version: version, Extra: PlutusData.NewConstr(0)}
+	if extra != nil {
+		datum.Extra = *extra
+	}
+	return datum, nil
+}
+
+func (d Datum) ToPlutusData() (PlutusData.PlutusData, error) {
+	return PlutusData.Marshal(d)
+}
+
+// ParseDatum reads the datum of a reference token.
+func ParseDatum(pd PlutusData.PlutusData) (*Datum, error) {
+	datum := Datum{}
+	if err := PlutusData.Unmarshal(pd, &datum); err != nil {
+		return nil, err
+	}
+	if _, err := datum.Metadata.MapEntries(); err != nil {
+		return nil, errors.New("CIP-68 metadata is not a map")
+	}
+	return &datum, nil
+}
+
+// Field returns the metadata under key, if any.
+func (d Datum) Field(key string) (PlutusData.PlutusData, bool) {
+	entries, err := d.Metadata.MapEntries()
+	if err != nil {
+		return PlutusData.PlutusData{}, false
+	}
+	for _, entry := range entries {
+		if k, ok := entry.Key.Bytes(); ok && string(k) == key {
+			return entry.Value, true
+		}
+	}
+	return PlutusData.PlutusData{}, false
+}
+
+// Text returns the metadata under key as text, if it holds bytes.
+func (d Datum) Text(key string) (string, bool) {
+	value, ok := d.Field(key)
+	if !ok {
+		return "", false
+	}
+	b, ok := value.Bytes()
+	return string(b), ok
+}
@@ -0,0 +1,183 @@
+package COSE
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+// COSE header and key parameters used by CIP-8 / CIP-30 signData.
+const (
+	HEADER_ALGORITHM = 1
+	ALGORITHM_EDDSA  = -8
+	SIGN1_TAG        = 18
+
+	KEY_TYPE       = 1
+	KEY_TYPE_OKP   = 1
+	KEY_ALGORITHM  = 3
+	KEY_CURVE      = -1
+	CURVE_ED25519  = 6
+	KEY_X          = -2
+	HEADER_ADDRESS = "address"
+	HEADER_HASHED  = "hashed"
+)
+
+type entry struct {
+	key   any
+	value any
+}
+
+// encodeMap encodes entries as a cbor map, in the given order.
+func encodeMap(entries ...entry) ([]byte, error) {
+	if len(entries) >= 24 {
+		return nil, errors.New("too many map entries")
+	}
+	res := []byte{0xa0 | byte(len(entries))}
+	for _, e := range entries {
+		key, err := cbor.Marshal(e.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := cbor.Marshal(e.value)
+		if err != nil {
+			return nil, err
+		}
+		res = append(append(res, key...), value...)
+	}
+	return res, nil
+}
+
+/*
+Sign1 is a COSE_Sign1 message as produced by CIP-30 signData. Protected
+holds the serialized protected header, which is what the signature
+covers, so that parsed messages verify against their exact bytes.
+*/
+type Sign1 struct {
+	Protected []byte
+	Address   []byte
+	Hashed    bool
+	Payload   []byte
+	Signature []byte
+}
+
+// NewSign1 builds the unsigned message of payload for address, with the
+// protected header {1: -8, "address": address}.
+func NewSign1(address []byte, payload []byte) (*Sign1, error) {
+	protected, err := encodeMap(
+		entry{HEADER_ALGORITHM, ALGORITHM_EDDSA},
+		entry{HEADER_ADDRESS, address},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Sign1{Protected: protected, Address: address, Payload: payload}, nil
+}
+
+// SigStructure returns the Sig_structure the signature is made over,
+// with an empty external aad.
+func (s Sign1) SigStructure() ([]byte, error) {
+	return cbor.Marshal([]any{"Signature1", s.Protected, []byte{}, s.Payload})
+}
+
+// MarshalCBOR encodes the message untagged, like CIP-30 wallets do.
+func (s Sign1) MarshalCBOR() ([]byte, error) {
+	unprotected, err := encodeMap(entry{HEADER_HASHED, s.Hashed})
+	if err != nil {
+		return nil, err
+	}
+	return cbor.Marshal([]any{s.Protected, cbor.RawMessage(unprotected), s.Payload, s.Signature})
+}
+
+func (s *Sign1) UnmarshalCBOR(value []byte) error {
+	var tag cbor.RawTag
+	if err := cbor.Unmarshal(value, &tag); err == nil {
+		if tag.Number != SIGN1_TAG {
+			return fmt.Errorf("unexpected cbor tag %d", tag.Number)
+		}
+		value = tag.Content
+	}
+	var parts []cbor.RawMessage
+	if err := cbor.Unmarshal(value, &parts); err != nil {
+		return err
+	}
+	if len(parts) != 4 {
+		return errors.New("COSE_Sign1 must have 4 elements")
+	}
+	res := Sign1{}
+	if err := cbor.Unmarshal(parts[0], &res.Protected); err != nil {
+		return err
+	}
+	protected := make(map[any]any)
+	if len(res.Protected) > 0 {
+		if err := cbor.Unmarshal(res.Protected, &protected); err != nil {
+			return err
+		}
+	}
+	if alg, ok := intOf(protected[uint64(HEADER_ALGORITHM)]); !ok || alg != ALGORITHM_EDDSA {
+		return errors.New("COSE_Sign1 algorithm is not EdDSA")
+	}
+	res.Address, _ = protected[HEADER_ADDRESS].([]byte)
+	unprotected := make(map[string]any)
+	if err := cbor.Unmarshal(parts[1], &unprotected); err == nil {
+		res.Hashed, _ = unprotected[HEADER_HASHED].(bool)
+	}
+	if err := cbor.Unmarshal(parts[2], &res.Payload); err != nil {
+		return err
+	}
+	if res.Payload == nil {
+		return errors.New("COSE_Sign1 payload is detached")
+	}
+	if err := cbor.Unmarshal(parts[3], &res.Signature); err != nil {
+		return err
+	}
+	*s = res
+	return nil
+}
+
+func intOf(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case uint64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// Key is an Ed25519 COSE_Key.
+type Key struct {
+	PublicKey []byte
+}
+
+// MarshalCBOR encodes {1: 1, 3: -8, -1: 6, -2: public key}.
+func (k Key) MarshalCBOR() ([]byte, error) {
+	return encodeMap(
+		entry{KEY_TYPE, KEY_TYPE_OKP},
+		entry{KEY_ALGORITHM, ALGORITHM_EDDSA},
+		entry{KEY_CURVE, CURVE_ED25519},
+		entry{KEY_X, k.PublicKey},
+	)
+}
+
+func (k *Key) UnmarshalCBOR(value []byte) error {
+	params := make(map[int]any)
+	if err := cbor.Unmarshal(value, &params); err != nil {
+		return err
+	}
+	if kty, ok := intOf(params[KEY_TYPE]); !ok || kty != KEY_TYPE_OKP {
+		return errors.New("COSE_Key is not an OKP key")
+	}
+	if alg, ok := intOf(params[KEY_ALGORITHM]); ok && alg != ALGORITHM_EDDSA {
+		return errors.New("COSE_Key algorithm is not EdDSA")
+	}
+	if crv, ok := intOf(params[KEY_CURVE]); !ok || crv != CURVE_ED25519 {
+		return errors.New("COSE_Key curve is not Ed25519")
+	}
+	publicKey, ok := params[KEY_X].([]byte)
+	if !ok || len(publicKey) != 32 {
+		return errors.New("invalid COSE_Key public key")
+	}
+	k.PublicKey = publicKey
+	return nil
+}
@@ -1,18 +1,500 @@
 package Certificate
 
-import "github.com/SundaeSwap-finance/apollo/serialization"
+import (
+	"errors"
+	"fmt"
 
-type StakeCredential struct {
-	_          struct{} `cbor:"toarray"`
-	_CODE      int      `cbor:",omitempty"`
-	Credential serialization.ConstrainedBytes
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/Governance"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+type StakeCredential = Credential.Credential
+
+type CertificateType int
+
+const (
+	StakeRegistration CertificateType = iota
+	StakeDeregistration
+	StakeDelegation
+	PoolRegistration
+	PoolRetirement
+	GenesisKeyDelegation
+	MoveInstantaneousRewards
+	Registration
+	Unregistration
+	VoteDelegation
+	StakeVoteDelegation
+	StakeRegistrationDelegation
+	VoteRegistrationDelegation
+	StakeVoteRegistrationDelegation
+	AuthCommitteeHot
+	ResignCommitteeCold
+	RegisterDRep
+	UnregisterDRep
+	UpdateDRep
+)
+
+type DRepType int
+
+const (
+	DRepKeyHash DRepType = iota
+	DRepScriptHash
+	AlwaysAbstain
+	AlwaysNoConfidence
+)
+
+type DRep struct {
+	Type DRepType
+	Hash [28]byte
+}
+
+func DRepFromCredential(cred Credential.Credential) DRep {
+	if cred.IsScript() {
+		return DRep{Type: DRepScriptHash, Hash: cred.Hash}
+	}
+	return DRep{Type: DRepKeyHash, Hash: cred.Hash}
+}
+
+func (d DRep) MarshalCBOR() ([]byte, error) {
+	switch d.Type {
+	case DRepKeyHash, DRepScriptHash:
+		return cbor.Marshal([]any{d.Type, d.Hash})
+	case AlwaysAbstain, AlwaysNoConfidence:
+		return cbor.Marshal([]any{d.Type})
+	default:
+		return nil, fmt.Errorf("unknown drep type: %d", d.Type)
+	}
+}
+
+func (d *DRep) UnmarshalCBOR(data []byte) error {
+	var fields []cbor.RawMessage
+	err := cbor.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return errors.New("empty drep")
+	}
+	res := DRep{}
+	err = cbor.Unmarshal(fields[0], &res.Type)
+	if err != nil {
+		return err
+	}
+	switch res.Type {
+	case DRepKeyHash, DRepScriptHash:
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid drep length: %d", len(fields))
+		}
+		err = cbor.Unmarshal(fields[1], &res.Hash)
+		if err != nil {
+			return err
+		}
+	case AlwaysAbstain, AlwaysNoConfidence:
+		if len(fields) != 1 {
+			return fmt.Errorf("invalid drep length: %d", len(fields))
+		}
+	default:
+		return fmt.Errorf("unknown drep type: %d", res.Type)
+	}
+	*d = res
+	return nil
+}
+
+type RelayType int
+
+const (
+	SingleHostAddr RelayType = iota
+	SingleHostName
+	MultiHostName
+)
+
+type Relay struct {
+	Type    RelayType
+	Port    *uint16
+	Ipv4    []byte
+	Ipv6    []byte
+	DnsName string
+}
+
+func (r Relay) MarshalCBOR() ([]byte, error) {
+	switch r.Type {
+	case SingleHostAddr:
+		return cbor.Marshal([]any{r.Type, r.Port, r.Ipv4, r.Ipv6})
+	case SingleHostName:
+		return cbor.Marshal([]any{r.Type, r.Port, r.DnsName})
+	case MultiHostName:
+		return cbor.Marshal([]any{r.Type, r.DnsName})
+	default:
+		return nil, fmt.Errorf("unknown relay type: %d", r.Type)
+	}
 }
 
-// TODO
+func (r *Relay) UnmarshalCBOR(data []byte) error {
+	var fields []cbor.RawMessage
+	err := cbor.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return errors.New("empty relay")
+	}
+	res := Relay{}
+	err = cbor.Unmarshal(fields[0], &res.Type)
+	if err != nil {
+		return err
+	}
+	switch {
+	case res.Type == SingleHostAddr && len(fields) == 4:
+		err = unmarshalFields(fields[1:], &res.Port, &res.Ipv4, &res.Ipv6)
+	case res.Type == SingleHostName && len(fields) == 3:
+		err = unmarshalFields(fields[1:], &res.Port, &res.DnsName)
+	case res.Type == MultiHostName && len(fields) == 2:
+		err = unmarshalFields(fields[1:], &res.DnsName)
+	default:
+		err = fmt.Errorf("invalid relay type %d with %d fields", res.Type, len(fields))
+	}
+	if err != nil {
+		return err
+	}
+	*r = res
+	return nil
+}
+
+type PoolMetadata struct {
+	_    struct{} `cbor:",toarray"`
+	Url  string
+	Hash []byte
+}
+
+type PoolParams struct {
+	Operator      serialization.PubKeyHash
+	VrfKeyHash    []byte
+	Pledge        int64
+	Cost          int64
+	Margin        Governance.UnitInterval
+	RewardAccount [29]byte
+	PoolOwners    []serialization.PubKeyHash
+	Relays        []Relay
+	PoolMetadata  *PoolMetadata
+}
+
+// MoveInstantaneousReward either moves coins from a pot to reward
+// accounts or, when Rewards is empty, to the other pot.
+type MoveInstantaneousReward struct {
+	Pot      int
+	Rewards  map[Credential.Credential]int64
+	OtherPot int64
+}
+
+func (mir MoveInstantaneousReward) MarshalCBOR() ([]byte, error) {
+	if len(mir.Rewards) > 0 {
+		em, err := cbor.EncOptions{Sort: cbor.SortCoreDeterministic}.EncMode()
+		if err != nil {
+			return nil, err
+		}
+		return em.Marshal([]any{mir.Pot, mir.Rewards})
+	}
+	return cbor.Marshal([]any{mir.Pot, mir.OtherPot})
+}
+
+func (mir *MoveInstantaneousReward) UnmarshalCBOR(data []byte) error {
+	var fields []cbor.RawMessage
+	err := cbor.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+	if len(fields) != 2 {
+		return fmt.Errorf("invalid move instantaneous reward length: %d", len(fields))
+	}
+	res := MoveInstantaneousReward{}
+	err = cbor.Unmarshal(fields[0], &res.Pot)
+	if err != nil {
+		return err
+	}
+	if len(fields[1]) > 0 && fields[1][0]>>5 == 5 {
+		err = cbor.Unmarshal(fields[1], &res.Rewards)
+	} else {
+		err = cbor.Unmarshal(fields[1], &res.OtherPot)
+	}
+	if err != nil {
+		return err
+	}
+	*mir = res
+	return nil
+}
+
+// Certificate holds any of the Shelley or Conway certificates. Only
+// the fields used by Type are serialized.
 type Certificate struct {
-	_               struct{} `cbor:"toarray"`
-	_CODE           int
-	StakeCredential *StakeCredential
+	Type                CertificateType
+	StakeCredential     Credential.Credential
+	PoolKeyHash         serialization.PubKeyHash
+	PoolParams          PoolParams
+	Epoch               uint64
+	DRep                DRep
+	Deposit             int64
+	DRepCredential      Credential.Credential
+	ColdCredential      Credential.Credential
+	HotCredential       Credential.Credential
+	Anchor              *Governance.Anchor
+	GenesisHash         []byte
+	GenesisDelegateHash []byte
+	VrfKeyHash          []byte
+	MoveInstantaneous   MoveInstantaneousReward
+}
+
+func NewStakeRegistration(cred Credential.Credential) Certificate {
+	return Certificate{Type: StakeRegistration, StakeCredential: cred}
+}
+
+func NewStakeDeregistration(cred Credential.Credential) Certificate {
+	return Certificate{Type: StakeDeregistration, StakeCredential: cred}
+}
+
+func NewStakeDelegation(cred Credential.Credential, pool serialization.PubKeyHash) Certificate {
+	return Certificate{Type: StakeDelegation, StakeCredential: cred, PoolKeyHash: pool}
+}
+
+func NewPoolRegistration(params PoolParams) Certificate {
+	return Certificate{Type: PoolRegistration, PoolParams: params}
+}
+
+func NewPoolRetirement(pool serialization.PubKeyHash, epoch uint64) Certificate {
+	return Certificate{Type: PoolRetirement, PoolKeyHash: pool, Epoch: epoch}
+}
+
+func NewRegistration(cred Credential.Credential, deposit int64) Certificate {
+	return Certificate{Type: Registration, StakeCredential: cred, Deposit: deposit}
+}
+
+func NewUnregistration(cred Credential.Credential, refund int64) Certificate {
+	return Certificate{Type: Unregistration, StakeCredential: cred, Deposit: refund}
+}
+
+func NewVoteDelegation(cred Credential.Credential, drep DRep) Certificate {
+	return Certificate{Type: VoteDelegation, StakeCredential: cred, DRep: drep}
+}
+
+func NewStakeVoteDelegation(cred Credential.Credential, pool serialization.PubKeyHash, drep DRep) Certificate {
+	return Certificate{Type: StakeVoteDelegation, StakeCredential: cred, PoolKeyHash: pool, DRep: drep}
+}
+
+func NewStakeRegistrationDelegation(cred Credential.Credential, pool serialization.PubKeyHash, deposit int64) Certificate {
+	return Certificate{Type: StakeRegistrationDelegation, StakeCredential: cred, PoolKeyHash: pool, Deposit: deposit}
+}
+
+func NewVoteRegistrationDelegation(cred Credential.Credential, drep DRep, deposit int64) Certificate {
+	return Certificate{Type: VoteRegistrationDelegation, StakeCredential: cred, DRep: drep, Deposit: deposit}
+}
+
+func NewStakeVoteRegistrationDelegation(cred Credential.Credential, pool serialization.PubKeyHash, drep DRep, deposit int64) Certificate {
+	return Certificate{Type: StakeVoteRegistrationDelegation, StakeCredential: cred, PoolKeyHash: pool, DRep: drep, Deposit: deposit}
+}
+
+func NewAuthCommitteeHot(cold Credential.Credential, hot Credential.Credential) Certificate {
+	return Certificate{Type: AuthCommitteeHot, ColdCredential: cold, HotCredential: hot}
+}
+
+func NewResignCommitteeCold(cold Credential.Credential, anchor *Governance.Anchor) Certificate {
+	return Certificate{Type: ResignCommitteeCold, ColdCredential: cold, Anchor: anchor}
+}
+
+func NewRegisterDRep(cred Credential.Credential, deposit int64, anchor *Governance.Anchor) Certificate {
+	return Certificate{Type: RegisterDRep, DRepCredential: cred, Deposit: deposit, Anchor: anchor}
+}
+
+func NewUnregisterDRep(cred Credential.Credential, refund int64) Certificate {
+	return Certificate{Type: UnregisterDRep, DRepCredential: cred, Deposit: refund}
+}
+
+func NewUpdateDRep(cred Credential.Credential, anchor *Governance.Anchor) Certificate {
+	return Certificate{Type: UpdateDRep, DRepCredential: cred, Anchor: anchor}
+}
+
+// Witnesses returns the credentials that have to authorize the
+// certificate: the operator and every owner of a registered pool, a
+// single credential for the other certificates that need one.
+func (c Certificate) Witnesses() []Credential.Credential {
+	switch c.Type {
+	case StakeDeregistration, StakeDelegation, Registration, Unregistration,
+		VoteDelegation, StakeVoteDelegation, StakeRegistrationDelegation,
+		VoteRegistrationDelegation, StakeVoteRegistrationDelegation:
+		return []Credential.Credential{c.StakeCredential}
+	case PoolRegistration:
+		witnesses := []Credential.Credential{Credential.FromKeyHash(c.PoolParams.Operator)}
+		for _, owner := range c.PoolParams.PoolOwners {
+			witnesses = append(witnesses, Credential.FromKeyHash(owner))
+		}
+		return witnesses
+	case PoolRetirement:
+		return []Credential.Credential{Credential.FromKeyHash(c.PoolKeyHash)}
+	case AuthCommitteeHot, ResignCommitteeCold:
+		return []Credential.Credential{c.ColdCredential}
+	case RegisterDRep, UnregisterDRep, UpdateDRep:
+		return []Credential.Credential{c.DRepCredential}
+	default:
+		return nil
+	}
+}
+
+func (c Certificate) MarshalCBOR() ([]byte, error) {
+	var fields []any
+	switch c.Type {
+	case StakeRegistration, StakeDeregistration:
+		fields = []any{c.Type, c.StakeCredential}
+	case StakeDelegation:
+		fields = []any{c.Type, c.StakeCredential, c.PoolKeyHash}
+	case PoolRegistration:
+		owners := c.PoolParams.PoolOwners
+		if owners == nil {
+			owners = make([]serialization.PubKeyHash, 0)
+		}
+		relays := c.PoolParams.Relays
+		if relays == nil {
+			relays = make([]Relay, 0)
+		}
+		fields = []any{c.Type,
+			c.PoolParams.Operator,
+			c.PoolParams.VrfKeyHash,
+			c.PoolParams.Pledge,
+			c.PoolParams.Cost,
+			c.PoolParams.Margin,
+			c.PoolParams.RewardAccount,
+			owners,
+			relays,
+			c.PoolParams.PoolMetadata,
+		}
+	case PoolRetirement:
+		fields = []any{c.Type, c.PoolKeyHash, c.Epoch}
+	case GenesisKeyDelegation:
+		fields = []any{c.Type, c.GenesisHash, c.GenesisDelegateHash, c.VrfKeyHash}
+	case MoveInstantaneousRewards:
+		fields = []any{c.Type, c.MoveInstantaneous}
+	case Registration, Unregistration:
+		fields = []any{c.Type, c.StakeCredential, c.Deposit}
+	case VoteDelegation:
+		fields = []any{c.Type, c.StakeCredential, c.DRep}
+	case StakeVoteDelegation:
+		fields = []any{c.Type, c.StakeCredential, c.PoolKeyHash, c.DRep}
+	case StakeRegistrationDelegation:
+		fields = []any{c.Type, c.StakeCredential, c.PoolKeyHash, c.Deposit}
+	case VoteRegistrationDelegation:
+		fields = []any{c.Type, c.StakeCredential, c.DRep, c.Deposit}
+	case StakeVoteRegistrationDelegation:
+		fields = []any{c.Type, c.StakeCredential, c.PoolKeyHash, c.DRep, c.Deposit}
+	case AuthCommitteeHot:
+		fields = []any{c.Type, c.ColdCredential, c.HotCredential}
+	case ResignCommitteeCold:
+		fields = []any{c.Type, c.ColdCredential, c.Anchor}
+	case RegisterDRep:
+		fields = []any{c.Type, c.DRepCredential, c.Deposit, c.Anchor}
+	case UnregisterDRep:
+		fields = []any{c.Type, c.DRepCredential, c.Deposit}
+	case UpdateDRep:
+		fields = []any{c.Type, c.DRepCredential, c.Anchor}
+	default:
+		return nil, fmt.Errorf("unknown certificate type: %d", c.Type)
+	}
+	return cbor.Marshal(fields)
+}
+
+func (c *Certificate) UnmarshalCBOR(data []byte) error {
+	var fields []cbor.RawMessage
+	err := cbor.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return errors.New("empty certificate")
+	}
+	res := Certificate{}
+	err = cbor.Unmarshal(fields[0], &res.Type)
+	if err != nil {
+		return err
+	}
+	rest := fields[1:]
+	switch res.Type {
+	case StakeRegistration, StakeDeregistration:
+		err = unmarshalFields(rest, &res.StakeCredential)
+	case StakeDelegation:
+		err = unmarshalFields(rest, &res.StakeCredential, &res.PoolKeyHash)
+	case PoolRegistration:
+		if len(rest) == 9 {
+			rest[6] = serialization.StripSetTag(rest[6])
+		}
+		err = unmarshalFields(rest,
+			&res.PoolParams.Operator,
+			&res.PoolParams.VrfKeyHash,
+			&res.PoolParams.Pledge,
+			&res.PoolParams.Cost,
+			&res.PoolParams.Margin,
+			&res.PoolParams.RewardAccount,
+			&res.PoolParams.PoolOwners,
+			&res.PoolParams.Relays,
+			&res.PoolParams.PoolMetadata,
+		)
+	case PoolRetirement:
+		err = unmarshalFields(rest, &res.PoolKeyHash, &res.Epoch)
+	case GenesisKeyDelegation:
+		err = unmarshalFields(rest, &res.GenesisHash, &res.GenesisDelegateHash, &res.VrfKeyHash)
+	case MoveInstantaneousRewards:
+		err = unmarshalFields(rest, &res.MoveInstantaneous)
+	case Registration, Unregistration:
+		err = unmarshalFields(rest, &res.StakeCredential, &res.Deposit)
+	case VoteDelegation:
+		err = unmarshalFields(rest, &res.StakeCredential, &res.DRep)
+	case StakeVoteDelegation:
+		err = unmarshalFields(rest, &res.StakeCredential, &res.PoolKeyHash, &res.DRep)
+	case StakeRegistrationDelegation:
+		err = unmarshalFields(rest, &res.StakeCredential, &res.PoolKeyHash, &res.Deposit)
+	case VoteRegistrationDelegation:
+		err = unmarshalFields(rest, &res.StakeCredential, &res.DRep, &res.Deposit)
+	case StakeVoteRegistrationDelegation:
+		err = unmarshalFields(rest, &res.StakeCredential, &res.PoolKeyHash, &res.DRep, &res.Deposit)
+	case AuthCommitteeHot:
+		err = unmarshalFields(rest, &res.ColdCredential, &res.HotCredential)
+	case ResignCommitteeCold:
+		err = unmarshalFields(rest, &res.ColdCredential, &res.Anchor)
+	case RegisterDRep:
+		err = unmarshalFields(rest, &res.DRepCredential, &res.Deposit, &res.Anchor)
+	case UnregisterDRep:
+		err = unmarshalFields(rest, &res.DRepCredential, &res.Deposit)
+	case UpdateDRep:
+		err = unmarshalFields(rest, &res.DRepCredential, &res.Anchor)
+	default:
+		err = fmt.Errorf("unknown certificate type: %d", res.Type)
+	}
+	if err != nil {
+		return err
+	}
+	*c = res
+	return nil
+}
+
+func unmarshalFields(fields []cbor.RawMessage, targets ...any) error {
+	if len(fields) != len(targets) {
+		return fmt.Errorf("expected %d fields, got %d", len(targets), len(fields))
+	}
+	for i, target := range targets {
+		err := cbor.Unmarshal(fields[i], target)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type Certificates []*Certificate
+
+func (cs *Certificates) UnmarshalCBOR(data []byte) error {
+	res := make([]*Certificate, 0)
+	err := cbor.Unmarshal(serialization.StripSetTag(data), &res)
+	if err != nil {
+		return err
+	}
+	*cs = res
+	return nil
+}
@@ -0,0 +1,69 @@
+package Credential
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/SundaeSwap-finance/apollo/serialization"
+)
+
+type CredentialType int
+
+const (
+	KeyHashCredential CredentialType = iota
+	ScriptHashCredential
+)
+
+// Credential is either a verification key hash or a script hash,
+// encoded as [0, addr_keyhash] / [1, scripthash].
+type Credential struct {
+	_    struct{} `cbor:",toarray"`
+	Type CredentialType
+	Hash [28]byte
+}
+
+func FromKeyHash(pkh serialization.PubKeyHash) Credential {
+	return Credential{Type: KeyHashCredential, Hash: pkh}
+}
+
+func FromScriptHash(sh serialization.ScriptHash) Credential {
+	return Credential{Type: ScriptHashCredential, Hash: sh}
+}
+
+// FromBytes builds a credential from a raw 28 byte hash.
+func FromBytes(hash []byte, isScript bool) (Credential, error) {
+	if len(hash) != serialization.VERIFICATION_KEY_HASH_SIZE {
+		return Credential{}, fmt.Errorf("invalid credential hash length: %d", len(hash))
+	}
+	cred := Credential{Type: KeyHashCredential}
+	if isScript {
+		cred.Type = ScriptHashCredential
+	}
+	copy(cred.Hash[:], hash)
+	return cred, nil
+}
+
+func (c Credential) IsScript() bool {
+	return c.Type == ScriptHashCredential
+}
+
+func (c Credential) Bytes() []byte {
+	return c.Hash[:]
+}
+
+func (c Credential) String() string {
+	if c.IsScript() {
+		return "script:" + hex.EncodeToString(c.Hash[:])
+	}
+	return "key:" + hex.EncodeToString(c.Hash[:])
+}
+
+// Less orders credentials the way the ledger does: script hashes
+// before key hashes, then by hash bytes.
+func (c Credential) Less(other Credential) bool {
+	if c.IsScript() != other.IsScript() {
+		return c.IsScript()
+	}
+	return bytes.Compare(c.Hash[:], other.Hash[:]) < 0
+}
@@ -0,0 +1,396 @@
+package Governance
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/Withdrawal"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+// Maps inside governance structures are encoded with sorted keys so
+// the body hash does not depend on map iteration order.
+var sortedEncMode, _ = cbor.EncOptions{Sort: cbor.SortCoreDeterministic}.EncMode()
+
+type Vote int
+
+const (
+	VoteNo Vote = iota
+	VoteYes
+	VoteAbstain
+)
+
+type VoterType int
+
+const (
+	CommitteeHotKeyHash VoterType = iota
+	CommitteeHotScriptHash
+	DRepKeyHash
+	DRepScriptHash
+	StakingPoolKeyHash
+)
+
+type Voter struct {
+	_    struct{} `cbor:",toarray"`
+	Type VoterType
+	Hash [28]byte
+}
+
+func CommitteeVoter(cred Credential.Credential) Voter {
+	if cred.IsScript() {
+		return Voter{Type: CommitteeHotScriptHash, Hash: cred.Hash}
+	}
+	return Voter{Type: CommitteeHotKeyHash, Hash: cred.Hash}
+}
+
+func DRepVoter(cred Credential.Credential) Voter {
+	if cred.IsScript() {
+		return Voter{Type: DRepScriptHash, Hash: cred.Hash}
+	}
+	return Voter{Type: DRepKeyHash, Hash: cred.Hash}
+}
+
+func StakePoolVoter(poolKeyHash [28]byte) Voter {
+	return Voter{Type: StakingPoolKeyHash, Hash: poolKeyHash}
+}
+
+func (v Voter) IsScript() bool {
+	return v.Type == CommitteeHotScriptHash || v.Type == DRepScriptHash
+}
+
+// Less orders voters the way the ledger does (committee, then dreps,
+// then pools; scripts before keys within a role). Redeemer indexes
+// for the VOTE purpose follow this order.
+func (v Voter) Less(other Voter) bool {
+	if v.Type/2 != other.Type/2 {
+		return v.Type < other.Type
+	}
+	if v.IsScript() != other.IsScript() {
+		return v.IsScript()
+	}
+	return bytes.Compare(v.Hash[:], other.Hash[:]) < 0
+}
+
+type Anchor struct {
+	_        struct{} `cbor:",toarray"`
+	Url      string
+	DataHash []byte
+}
+
+type GovActionId struct {
+	_             struct{} `cbor:",toarray"`
+	TransactionId [32]byte
+	Index         uint32
+}
+
+func NewGovActionId(txHash string, index int) (GovActionId, error) {
+	decoded, err := hex.DecodeString(txHash)
+	if err != nil {
+		return GovActionId{}, err
+	}
+	if len(decoded) != 32 {
+		return GovActionId{}, fmt.Errorf("invalid transaction id length: %d", len(decoded))
+	}
+	id := GovActionId{Index: uint32(index)}
+	copy(id.TransactionId[:], decoded)
+	return id, nil
+}
+
+func (id GovActionId) Less(other GovActionId) bool {
+	cmp := bytes.Compare(id.TransactionId[:], other.TransactionId[:])
+	if cmp != 0 {
+		return cmp < 0
+	}
+	return id.Index < other.Index
+}
+
+type VotingProcedure struct {
+	_      struct{} `cbor:",toarray"`
+	Vote   Vote
+	Anchor *Anchor
+}
+
+type VotingProcedures map[Voter]map[GovActionId]VotingProcedure
+
+func (vp *VotingProcedures) Add(voter Voter, govActionId GovActionId, procedure VotingProcedure) {
+	if *vp == nil {
+		*vp = make(VotingProcedures)
+	}
+	if _, ok := (*vp)[voter]; !ok {
+		(*vp)[voter] = make(map[GovActionId]VotingProcedure)
+	}
+	(*vp)[voter][govActionId] = procedure
+}
+
+// Voters returns the voters in ledger order.
+func (vp VotingProcedures) Voters() []Voter {
+	voters := make([]Voter, 0, len(vp))
+	for voter := range vp {
+		voters = append(voters, voter)
+	}
+	sort.Slice(voters, func(i, j int) bool {
+		return voters[i].Less(voters[j])
+	})
+	return voters
+}
+
+func (vp VotingProcedures) MarshalCBOR() ([]byte, error) {
+	return sortedEncMode.Marshal(map[Voter]map[GovActionId]VotingProcedure(vp))
+}
+
+func (vp *VotingProcedures) UnmarshalCBOR(data []byte) error {
+	res := make(map[Voter]map[GovActionId]VotingProcedure)
+	err := cbor.Unmarshal(data, &res)
+	if err != nil {
+		return err
+	}
+	*vp = res
+	return nil
+}
+
+type UnitInterval struct {
+	Numerator   uint64
+	Denominator uint64
+}
+
+type unitIntervalArray struct {
+	_           struct{} `cbor:",toarray"`
+	Numerator   uint64
+	Denominator uint64
+}
+
+func (ui UnitInterval) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(cbor.Tag{
+		Number:  30,
+		Content: unitIntervalArray{Numerator: ui.Numerator, Denominator: ui.Denominator},
+	})
+}
+
+func (ui *UnitInterval) UnmarshalCBOR(data []byte) error {
+	var tag cbor.RawTag
+	err := cbor.Unmarshal(data, &tag)
+	if err != nil {
+		return err
+	}
+	if tag.Number != 30 {
+		return fmt.Errorf("invalid unit interval tag: %d", tag.Number)
+	}
+	var arr unitIntervalArray
+	err = cbor.Unmarshal(tag.Content, &arr)
+	if err != nil {
+		return err
+	}
+	ui.Numerator = arr.Numerator
+	ui.Denominator = arr.Denominator
+	return nil
+}
+
+type ProtocolVersion struct {
+	_     struct{} `cbor:",toarray"`
+	Major uint64
+	Minor uint64
+}
+
+type Constitution struct {
+	_          struct{} `cbor:",toarray"`
+	Anchor     Anchor
+	ScriptHash []byte
+}
+
+type GovActionType int
+
+const (
+	ParameterChangeAction GovActionType = iota
+	HardForkInitiationAction
+	TreasuryWithdrawalsAction
+	NoConfidenceAction
+	UpdateCommitteeAction
+	NewConstitutionAction
+	InfoAction
+)
+
+// GovAction holds any of the Conway governance actions. Only the
+// fields relevant to Type are serialized.
+type GovAction struct {
+	Type            GovActionType
+	PrevActionId    *GovActionId
+	ParameterUpdate map[uint64]cbor.RawMessage
+	PolicyHash      []byte
+	ProtocolVersion ProtocolVersion
+	Withdrawals     Withdrawal.Withdrawal
+	RemovedMembers  []Credential.Credential
+	AddedMembers    map[Credential.Credential]uint64
+	Quorum          UnitInterval
+	Constitution    Constitution
+}
+
+func NewParameterChangeAction(prev *GovActionId, update map[uint64]cbor.RawMessage, policyHash []byte) GovAction {
+	return GovAction{Type: ParameterChangeAction, PrevActionId: prev, ParameterUpdate: update, PolicyHash: policyHash}
+}
+
+func NewHardForkInitiationAction(prev *GovActionId, version ProtocolVersion) GovAction {
+	return GovAction{Type: HardForkInitiationAction, PrevActionId: prev, ProtocolVersion: version}
+}
+
+func NewTreasuryWithdrawalsAction(withdrawals Withdrawal.Withdrawal, policyHash []byte) GovAction {
+	return GovAction{Type: TreasuryWithdrawalsAction, Withdrawals: withdrawals, PolicyHash: policyHash}
+}
+
+func NewNoConfidenceAction(prev *GovActionId) GovAction {
+	return GovAction{Type: NoConfidenceAction, PrevActionId: prev}
+}
+
+func NewUpdateCommitteeAction(prev *GovActionId, removed []Credential.Credential, added map[Credential.Credential]uint64, quorum UnitInterval) GovAction {
+	return GovAction{Type: UpdateCommitteeAction, PrevActionId: prev, RemovedMembers: removed, AddedMembers: added, Quorum: quorum}
+}
+
+func NewConstitutionUpdateAction(prev *GovActionId, constitution Constitution) GovAction {
+	return GovAction{Type: NewConstitutionAction, PrevActionId: prev, Constitution: constitution}
+}
+
+func NewInfoAction() GovAction {
+	return GovAction{Type: InfoAction}
+}
+
+func (ga GovAction) MarshalCBOR() ([]byte, error) {
+	var fields []any
+	switch ga.Type {
+	case ParameterChangeAction:
+		update := ga.ParameterUpdate
+		if update == nil {
+			update = make(map[uint64]cbor.RawMessage)
+		}
+		fields = []any{ga.Type, ga.PrevActionId, update, ga.PolicyHash}
+	case HardForkInitiationAction:
+		fields = []any{ga.Type, ga.PrevActionId, ga.ProtocolVersion}
+	case TreasuryWithdrawalsAction:
+		withdrawals := ga.Withdrawals
+		if withdrawals == nil {
+			withdrawals = Withdrawal.New()
+		}
+		fields = []any{ga.Type, map[[29]byte]int(withdrawals), ga.PolicyHash}
+	case NoConfidenceAction:
+		fields = []any{ga.Type, ga.PrevActionId}
+	case UpdateCommitteeAction:
+		removed := make([]Credential.Credential, len(ga.RemovedMembers))
+		copy(removed, ga.RemovedMembers)
+		sort.Slice(removed, func(i, j int) bool {
+			return removed[i].Less(removed[j])
+		})
+		added := ga.AddedMembers
+		if added == nil {
+			added = make(map[Credential.Credential]uint64)
+		}
+		fields = []any{ga.Type, ga.PrevActionId, removed, added, ga.Quorum}
+	case NewConstitutionAction:
+		fields = []any{ga.Type, ga.PrevActionId, ga.Constitution}
+	case InfoAction:
+		fields = []any{ga.Type}
+	default:
+		return nil, fmt.Errorf("unknown governance action type: %d", ga.Type)
+	}
+	return sortedEncMode.Marshal(fields)
+}
+
+func (ga *GovAction) UnmarshalCBOR(data []byte) error {
+	var fields []cbor.RawMessage
+	err := cbor.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return errors.New("empty governance action")
+	}
+	var actionType GovActionType
+	err = cbor.Unmarshal(fields[0], &actionType)
+	if err != nil {
+		return err
+	}
+	expected := map[GovActionType]int{
+		ParameterChangeAction:     4,
+		HardForkInitiationAction:  3,
+		TreasuryWithdrawalsAction: 3,
+		NoConfidenceAction:        2,
+		UpdateCommitteeAction:     5,
+		NewConstitutionAction:     3,
+		InfoAction:                1,
+	}
+	length, ok := expected[actionType]
+	if !ok {
+		return fmt.Errorf("unknown governance action type: %d", actionType)
+	}
+	if len(fields) != length {
+		return fmt.Errorf("invalid governance action %d: expected %d fields, got %d", actionType, length, len(fields))
+	}
+	res := GovAction{Type: actionType}
+	if actionType != TreasuryWithdrawalsAction && actionType != InfoAction {
+		err = cbor.Unmarshal(fields[1], &res.PrevActionId)
+		if err != nil {
+			return err
+		}
+	}
+	switch actionType {
+	case ParameterChangeAction:
+		err = cbor.Unmarshal(fields[2], &res.ParameterUpdate)
+		if err == nil {
+			err = cbor.Unmarshal(fields[3], &res.PolicyHash)
+		}
+	case HardForkInitiationAction:
+		err = cbor.Unmarshal(fields[2], &res.ProtocolVersion)
+	case TreasuryWithdrawalsAction:
+		err = cbor.Unmarshal(fields[1], &res.Withdrawals)
+		if err == nil {
+			err = cbor.Unmarshal(fields[2], &res.PolicyHash)
+		}
+	case UpdateCommitteeAction:
+		err = cbor.Unmarshal(serialization.StripSetTag(fields[2]), &res.RemovedMembers)
+		if err == nil {
+			err = cbor.Unmarshal(fields[3], &res.AddedMembers)
+		}
+		if err == nil {
+			err = cbor.Unmarshal(fields[4], &res.Quorum)
+		}
+	case NewConstitutionAction:
+		err = cbor.Unmarshal(fields[2], &res.Constitution)
+	}
+	if err != nil {
+		return err
+	}
+	*ga = res
+	return nil
+}
+
+type ProposalProcedure struct {
+	_             struct{} `cbor:",toarray"`
+	Deposit       int64
+	RewardAccount [29]byte
+	GovAction     GovAction
+	Anchor        Anchor
+}
+
+type ProposalProcedures []ProposalProcedure
+
+func (pp *ProposalProcedures) UnmarshalCBOR(data []byte) error {
+	res := make([]ProposalProcedure, 0)
+	err := cbor.Unmarshal(serialization.StripSetTag(data), &res)
+	if err != nil {
+		return err
+	}
+	*pp = res
+	return nil
+}
+
+// TotalDeposit sums the deposits of every proposal.
+func (pp ProposalProcedures) TotalDeposit() int64 {
+	total := int64(0)
+	for _, proposal := range pp {
+		total += proposal.Deposit
+	}
+	return total
+}
@@ -0,0 +1,65 @@
+package HDWallet
+
+import (
+	"fmt"
+
+	"github.com/SundaeSwap-finance/apollo/crypto/bip32"
+)
+
+// CIP-1852 derivation path constants: m/purpose'/coin_type'/account'/role/index.
+const (
+	PURPOSE        = 1852
+	BYRON_PURPOSE  = 44
+	COIN_TYPE      = 1815
+	EXTERNAL_CHAIN = 0
+	INTERNAL_CHAIN = 1
+	STAKING_CHAIN  = 2
+)
+
+// AccountPath returns the CIP-1852 path of account, m/1852'/1815'/account'.
+func AccountPath(account uint32) string {
+	return fmt.Sprintf("m/%d'/%d'/%d'", PURPOSE, COIN_TYPE, account)
+}
+
+// KeyPath returns the path of the key at index of the role chain of account.
+func KeyPath(account uint32, role uint32, index uint32) string {
+	return fmt.Sprintf("%s/%d/%d", AccountPath(account), role, index)
+}
+
+/*
+IcarusKeyPath returns the BIP-44 path of the Icarus (Yoroi, Daedalus
+Byron-era Icarus) bootstrap address keys, m/44'/1815'/account'/role/index.
+The root key is the same as for Shelley keys, only the purpose differs.
+*/
+func IcarusKeyPath(account uint32, role uint32, index uint32) string {
+	return fmt.Sprintf("m/%d'/%d'/%d'/%d/%d", BYRON_PURPOSE, COIN_TYPE, account, role, index)
+}
+
+func (hd *HDWallet) DeriveIcarusKey(account uint32, role uint32, index uint32) *HDWallet {
+	return hd.DerivePath(IcarusKeyPath(account, role, index))
+}
+
+func (hd *HDWallet) DeriveAccount(account uint32) *HDWallet {
+	return hd.DerivePath(AccountPath(account))
+}
+
+func (hd *HDWallet) DeriveKey(account uint32, role uint32, index uint32) *HDWallet {
+	return hd.DerivePath(KeyPath(account, role, index))
+}
+
+/*
+AccountXPub returns the extended public key of account, from which the
+keys of its chains derive without the private key:
+
+	xpub := hd.AccountXPub(0)
+	paymentKey := HDWallet.DeriveXPub(xpub, HDWallet.EXTERNAL_CHAIN, 3).PublicKey()
+*/
+func (hd *HDWallet) AccountXPub(account uint32) bip32.XPub {
+	return hd.DeriveAccount(account).XPrivKey.XPub()
+}
+
+// DeriveXPub derives the public key at index of the role chain of an
+// account extended public key.
+func DeriveXPub(accountXPub bip32.XPub, role uint32, index uint32) bip32.XPub {
+	return accountXPub.Derive(role).Derive(index)
+}
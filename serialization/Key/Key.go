@@ -76,6 +76,14 @@ func (vk VerificationKey) Hash() (serialization.PubKeyHash, error) {
 	return r, nil
 }
 
+// Verify checks an ed25519 signature over message made with the key.
+func (vk VerificationKey) Verify(message []byte, signature []byte) bool {
+	if len(vk.Payload) < ed25519.PublicKeySize || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(vk.Payload[:ed25519.PublicKeySize], message, signature)
+}
+
 type PaymentKeyPair struct {
 	VerificationKey VerificationKey
 	SigningKey      SigningKey
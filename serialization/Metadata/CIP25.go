@@ -0,0 +1,407 @@
+package Metadata
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+// CIP25_LABEL is the metadata label of CIP-25 NFT metadata.
+const CIP25_LABEL = 721
+
+// MAX_METADATA_STRING is the largest text or bytes metadatum the ledger
+// accepts, longer strings being split into arrays of chunks.
+const MAX_METADATA_STRING = 64
+
+var cip25AssetKeys = map[string]bool{"name": true, "image": true, "mediaType": true, "description": true, "files": true}
+var cip25FileKeys = map[string]bool{"name": true, "mediaType": true, "src": true}
+
+type CIP25File struct {
+	Name      string
+	MediaType string
+	Src       string
+	Extra     map[string]any
+}
+
+type CIP25Asset struct {
+	Name        string
+	Image       string
+	MediaType   string
+	Description string
+	Files       []CIP25File
+	Extra       map[string]any
+}
+
+/*
+CIP25Metadata is the NFT metadata of CIP-25, keyed by hex policy id and
+then by asset name. Version 1 keys policies and asset names by text and
+version 2 by their bytes; 0 stands for version 1.
+
+	nft := Metadata.CIP25Metadata{Policies: map[string]map[string]Metadata.CIP25Asset{
+		policyId: {"Token": {Name: "Token", Image: "ipfs://..."}},
+	}}
+*/
+type CIP25Metadata struct {
+	Version  int
+	Policies map[string]map[string]CIP25Asset
+}
+
+// SplitString returns s, or the chunks of at most 64 bytes it is split
+// into when longer, never splitting a character.
+func SplitString(s string) any {
+	if len(s) <= MAX_METADATA_STRING {
+		return s
+	}
+	chunks := make([]any, 0, len(s)/MAX_METADATA_STRING+1)
+	for len(s) > MAX_METADATA_STRING {
+		end := MAX_METADATA_STRING
+		for end > 0 && !utf8.RuneStart(s[end]) {
+			end--
+		}
+		chunks = append(chunks, s[:end])
+		s = s[end:]
+	}
+	return append(chunks, s)
+}
+
+func splitValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return SplitString(v)
+	case []any:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[i] = splitValue(item)
+		}
+		return res
+	case []string:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[i] = SplitString(item)
+		}
+		return res
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for key, item := range v {
+			res[key] = splitValue(item)
+		}
+		return res
+	default:
+		return value
+	}
+}
+
+func (f CIP25File) metadatum() map[string]any {
+	res := make(map[string]any, len(f.Extra)+3)
+	for key, value := range f.Extra {
+		res[key] = splitValue(value)
+	}
+	if f.Name != "" {
+		res["name"] = f.Name
+	}
+	res["mediaType"] = f.MediaType
+	res["src"] = SplitString(f.Src)
+	return res
+}
+
+func (a CIP25Asset) metadatum() map[string]any {
+	res := make(map[string]any, len(a.Extra)+5)
+	for key, value := range a.Extra {
+		res[key] = splitValue(value)
+	}
+	res["name"] = SplitString(a.Name)
+	res["image"] = SplitString(a.Image)
+	if a.MediaType != "" {
+		res["mediaType"] = a.MediaType
+	}
+	if a.Description != "" {
+		res["description"] = SplitString(a.Description)
+	}
+	if len(a.Files) > 0 {
+		files := make([]any, len(a.Files))
+		for i, file := range a.Files {
+			files[i] = file.metadatum()
+		}
+		res["files"] = files
+	}
+	return res
+}
+
+func validateExtra(extra map[string]any, reserved map[string]bool) error {
+	for key := range extra {
+		if reserved[key] {
+			return fmt.Errorf("extra property %s is a reserved CIP-25 property", key)
+		}
+		if len(key) > MAX_METADATA_STRING {
+			return fmt.Errorf("property %s is longer than %d bytes", key, MAX_METADATA_STRING)
+		}
+	}
+	return nil
+}
+
+// Validate checks the metadata holds what CIP-25 requires.
+func (m CIP25Metadata) Validate() error {
+	if m.Version < 0 || m.Version > 2 {
+		return fmt.Errorf("unknown CIP-25 version %d", m.Version)
+	}
+	if len(m.Policies) == 0 {
+		return errors.New("no policy in the CIP-25 metadata")
+	}
+	for policyId, assets := range m.Policies {
+		if decoded, err := hex.DecodeString(policyId); err != nil || len(decoded) != 28 {
+			return fmt.Errorf("invalid policy id %s", policyId)
+		}
+		for assetName, asset := range assets {
+			if len(assetName) > 32 {
+				return fmt.Errorf("asset name %s is longer than 32 bytes", assetName)
+			}
+			if asset.Name == "" || asset.Image == "" {
+				return fmt.Errorf("asset %s.%s requires a name and an image", policyId, assetName)
+			}
+			if asset.MediaType != "" && !strings.HasPrefix(asset.MediaType, "image/") {
+				return fmt.Errorf("media type %s of asset %s.%s is not an image", asset.MediaType, policyId, assetName)
+			}
+			if err := validateExtra(asset.Extra, cip25AssetKeys); err != nil {
+				return err
+			}
+			for _, file := range asset.Files {
+				if file.MediaType == "" || file.Src == "" {
+					return fmt.Errorf("file of asset %s.%s requires a media type and a source", policyId, assetName)
+				}
+				if len(file.Name) > MAX_METADATA_STRING || len(file.MediaType) > MAX_METADATA_STRING {
+					return fmt.Errorf("file of asset %s.%s has a name or media type longer than %d bytes", policyId, assetName, MAX_METADATA_STRING)
+				}
+				if err := validateExtra(file.Extra, cip25FileKeys); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+/*
+ToMetadatum validates the metadata and returns the metadatum to set
+under CIP25_LABEL, with the strings longer than 64 bytes split.
+*/
+func (m CIP25Metadata) ToMetadatum() (any, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	if m.Version == 2 {
+		res := map[any]any{"version": 2}
+		for policyId, assets := range m.Policies {
+			policy, _ := hex.DecodeString(policyId)
+			policyMeta := make(map[cbor.ByteString]any, len(assets))
+			for assetName, asset := range assets {
+				policyMeta[cbor.ByteString(assetName)] = asset.metadatum()
+			}
+			res[cbor.ByteString(policy)] = policyMeta
+		}
+		return res, nil
+	}
+	res := make(TagMetadata, len(m.Policies))
+	for policyId, assets := range m.Policies {
+		policyMeta := make(map[string]any, len(assets))
+		for assetName, asset := range assets {
+			policyMeta[assetName] = asset.metadatum()
+		}
+		res[policyId] = policyMeta
+	}
+	return res, nil
+}
+
+// AddTo validates the metadata and sets it under CIP25_LABEL in aux.
+func (m CIP25Metadata) AddTo(aux *AuxiliaryData) error {
+	metadatum, err := m.ToMetadatum()
+	if err != nil {
+		return err
+	}
+	aux.SetLabel(CIP25_LABEL, metadatum)
+	return nil
+}
+
+// metadataKey is a text or bytes key of a metadata map.
+type metadataKey struct {
+	bytes bool
+	value string
+}
+
+func (k *metadataKey) UnmarshalCBOR(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("empty metadata key")
+	}
+	switch data[0] >> 5 {
+	case 2:
+		var b []byte
+		if err := cbor.Unmarshal(data, &b); err != nil {
+			return err
+		}
+		k.bytes, k.value = true, string(b)
+		return nil
+	case 3:
+		k.bytes = false
+		return cbor.Unmarshal(data, &k.value)
+	default:
+		return errors.New("metadata key is neither text nor bytes")
+	}
+}
+
+// encoded returns the cbor of a metadatum, decoded from a transaction
+// or built with ToMetadatum.
+func encoded(value any) (cbor.RawMessage, error) {
+	if raw, ok := value.(cbor.RawMessage); ok {
+		return raw, nil
+	}
+	return cbor.Marshal(value)
+}
+
+// joinString reads a text metadatum, possibly split into chunks.
+func joinString(raw cbor.RawMessage) (string, bool) {
+	if raw == nil {
+		return "", true
+	}
+	var value any
+	if err := cbor.Unmarshal(raw, &value); err != nil {
+		return "", false
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case []any:
+		var sb strings.Builder
+		for _, item := range v {
+			chunk, ok := item.(string)
+			if !ok {
+				return "", false
+			}
+			sb.WriteString(chunk)
+		}
+		return sb.String(), true
+	default:
+		return "", false
+	}
+}
+
+func extraOf(entries map[string]cbor.RawMessage, reserved map[string]bool) (map[string]any, error) {
+	var extra map[string]any
+	for key, raw := range entries {
+		if reserved[key] {
+			continue
+		}
+		var value any
+		if err := cbor.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		if extra == nil {
+			extra = make(map[string]any)
+		}
+		extra[key] = value
+	}
+	return extra, nil
+}
+
+func parseCIP25File(raw cbor.RawMessage) (CIP25File, error) {
+	var entries map[string]cbor.RawMessage
+	if err := cbor.Unmarshal(raw, &entries); err != nil {
+		return CIP25File{}, errors.New("invalid CIP-25 file")
+	}
+	extra, err := extraOf(entries, cip25FileKeys)
+	if err != nil {
+		return CIP25File{}, err
+	}
+	file := CIP25File{Extra: extra}
+	var okName, okType, okSrc bool
+	file.Name, okName = joinString(entries["name"])
+	file.MediaType, okType = joinString(entries["mediaType"])
+	file.Src, okSrc = joinString(entries["src"])
+	if !okName || !okType || !okSrc {
+		return CIP25File{}, errors.New("invalid CIP-25 file")
+	}
+	return file, nil
+}
+
+func parseCIP25Asset(raw cbor.RawMessage) (CIP25Asset, error) {
+	var entries map[string]cbor.RawMessage
+	if err := cbor.Unmarshal(raw, &entries); err != nil {
+		return CIP25Asset{}, errors.New("invalid CIP-25 asset")
+	}
+	extra, err := extraOf(entries, cip25AssetKeys)
+	if err != nil {
+		return CIP25Asset{}, err
+	}
+	asset := CIP25Asset{Extra: extra}
+	fields := []*string{&asset.Name, &asset.Image, &asset.MediaType, &asset.Description}
+	for i, key := range []string{"name", "image", "mediaType", "description"} {
+		var ok bool
+		if *fields[i], ok = joinString(entries[key]); !ok {
+			return CIP25Asset{}, fmt.Errorf("invalid CIP-25 property %s", key)
+		}
+	}
+	if rawFiles, ok := entries["files"]; ok {
+		var files []cbor.RawMessage
+		if err := cbor.Unmarshal(rawFiles, &files); err != nil {
+			return CIP25Asset{}, errors.New("invalid CIP-25 files")
+		}
+		for _, rawFile := range files {
+			file, err := parseCIP25File(rawFile)
+			if err != nil {
+				return CIP25Asset{}, err
+			}
+			asset.Files = append(asset.Files, file)
+		}
+	}
+	return asset, nil
+}
+
+/*
+ParseCIP25 reads the CIP-25 metadata under CIP25_LABEL, joining the
+strings split into chunks. It accepts metadata decoded from a
+transaction as well as metadata built with ToMetadatum. Policies keyed
+by bytes, as in version 2, are returned by their hex policy id.
+*/
+func ParseCIP25(metadata GeneralMetadata) (*CIP25Metadata, error) {
+	value, ok := metadata[CIP25_LABEL]
+	if !ok {
+		return nil, errors.New("no CIP-25 metadata")
+	}
+	raw, err := encoded(value)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[metadataKey]cbor.RawMessage
+	if err := cbor.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("invalid CIP-25 metadata: %w", err)
+	}
+	res := CIP25Metadata{Version: 1, Policies: make(map[string]map[string]CIP25Asset)}
+	versionKey := metadataKey{value: "version"}
+	if version, ok := entries[versionKey]; ok {
+		if err := cbor.Unmarshal(version, &res.Version); err != nil {
+			return nil, fmt.Errorf("invalid CIP-25 version: %w", err)
+		}
+		delete(entries, versionKey)
+	}
+	for policyKey, policyValue := range entries {
+		policyId := policyKey.value
+		if policyKey.bytes {
+			policyId = hex.EncodeToString([]byte(policyKey.value))
+		}
+		var assets map[metadataKey]cbor.RawMessage
+		if err := cbor.Unmarshal(policyValue, &assets); err != nil {
+			return nil, fmt.Errorf("invalid CIP-25 policy %s", policyId)
+		}
+		res.Policies[policyId] = make(map[string]CIP25Asset, len(assets))
+		for assetName, assetValue := range assets {
+			asset, err := parseCIP25Asset(assetValue)
+			if err != nil {
+				return nil, err
+			}
+			res.Policies[policyId][assetName.value] = asset
+		}
+	}
+	return &res, nil
+}
@@ -16,6 +16,10 @@ type TagMetadata map[string]any
 
 type Metadata map[int]TagMetadata
 
+// GeneralMetadata holds metadatums of any shape under their labels, such
+// as the bytes keyed maps of CIP-25 version 2 a TagMetadata can't hold.
+type GeneralMetadata map[int]any
+
 type ShelleyMaryMetadata struct {
 	_             struct{}                    `cbor:",toarray,omitempty"`
 	Metadata      Metadata                    `cbor:",omitempty"`
@@ -41,6 +45,8 @@ type AuxiliaryData struct {
 	_basicMeta   Metadata
 	_ShelleyMeta ShelleyMaryMetadata
 	_AlonzoMeta  AlonzoMetadata
+	// metadatums set with SetLabel that aren't a TagMetadata
+	_labels GeneralMetadata
 }
 
 func (ad *AuxiliaryData) SetBasicMetadata(value Metadata) {
@@ -53,8 +59,60 @@ func (ad *AuxiliaryData) SetShelleyMetadata(value ShelleyMaryMetadata) {
 	ad._ShelleyMeta = value
 }
 
+func (ad *AuxiliaryData) isAlonzo() bool {
+	return len(ad._AlonzoMeta.Metadata) != 0 || len(ad._AlonzoMeta.NativeScripts) != 0 || len(ad._AlonzoMeta.PlutusScripts) != 0
+}
+
+// eraMetadata returns the metadata of the era of the auxiliary data.
+func (ad *AuxiliaryData) eraMetadata() *Metadata {
+	switch {
+	case len(ad._basicMeta) != 0:
+		return &ad._basicMeta
+	case ad.isAlonzo():
+		return &ad._AlonzoMeta.Metadata
+	default:
+		return &ad._ShelleyMeta.Metadata
+	}
+}
+
+// GetMetadata returns the labelled metadata, whatever the era of the
+// auxiliary data, along with the metadatums set with SetLabel.
+func (ad *AuxiliaryData) GetMetadata() GeneralMetadata {
+	metadata := *ad.eraMetadata()
+	res := make(GeneralMetadata, len(metadata)+len(ad._labels))
+	for label, value := range metadata {
+		res[label] = value
+	}
+	for label, value := range ad._labels {
+		res[label] = value
+	}
+	return res
+}
+
+/*
+SetLabel sets the metadatum under label, keeping the other labels. A
+TagMetadata goes to the metadata of the era of the auxiliary data, other
+metadatums are encoded next to it.
+*/
+func (ad *AuxiliaryData) SetLabel(label int, value any) {
+	metadata := ad.eraMetadata()
+	if tagMetadata, ok := value.(TagMetadata); ok {
+		if *metadata == nil {
+			*metadata = Metadata{}
+		}
+		(*metadata)[label] = tagMetadata
+		delete(ad._labels, label)
+		return
+	}
+	delete(*metadata, label)
+	if ad._labels == nil {
+		ad._labels = GeneralMetadata{}
+	}
+	ad._labels[label] = value
+}
+
 func (ad *AuxiliaryData) Hash() []byte {
-	if len(ad._basicMeta) != 0 || len(ad._ShelleyMeta.Metadata) != 0 || len(ad._AlonzoMeta.Metadata) != 0 {
+	if len(ad._basicMeta) != 0 || len(ad._ShelleyMeta.Metadata) != 0 || len(ad._AlonzoMeta.Metadata) != 0 || len(ad._labels) != 0 {
 		marshaled, _ := cbor.Marshal(ad)
 		return serialization.Blake2bHash(marshaled)
 	} else {
@@ -67,7 +125,16 @@ func (ad *AuxiliaryData) UnmarshalCBOR(value []byte) error {
 	if err_shelley != nil {
 		err_basic_meta := cbor.Unmarshal(value, &ad._basicMeta)
 		if err_basic_meta != nil {
-			return err_basic_meta
+			// metadatums that aren't a TagMetadata, as maps keyed by
+			// bytes, are kept encoded
+			ad._basicMeta = nil
+			var labels map[int]cbor.RawMessage
+			if cbor.Unmarshal(value, &labels) != nil {
+				return err_basic_meta
+			}
+			for label, metadatum := range labels {
+				ad.SetLabel(label, metadatum)
+			}
 		}
 	}
 	return nil
@@ -75,6 +142,9 @@ func (ad *AuxiliaryData) UnmarshalCBOR(value []byte) error {
 
 func (ad *AuxiliaryData) MarshalCBOR() ([]byte, error) {
 	enc, _ := cbor.EncOptions{Sort: cbor.SortLengthFirst}.EncMode()
+	if len(ad._labels) != 0 {
+		return ad.marshalWithLabels(enc)
+	}
 	if len(ad._basicMeta) != 0 {
 		return enc.Marshal(ad._basicMeta)
 	}
@@ -86,3 +156,27 @@ func (ad *AuxiliaryData) MarshalCBOR() ([]byte, error) {
 	}
 	return enc.Marshal(ad._ShelleyMeta)
 }
+
+// marshalWithLabels encodes the auxiliary data in its era, with the
+// metadatums set with SetLabel next to the era metadata.
+func (ad *AuxiliaryData) marshalWithLabels(enc cbor.EncMode) ([]byte, error) {
+	metadata := ad.GetMetadata()
+	switch {
+	case len(ad._basicMeta) != 0:
+		return enc.Marshal(metadata)
+	case ad.isAlonzo():
+		return enc.Marshal(struct {
+			Metadata      GeneralMetadata             `cbor:"0,keyasint,omitempty"`
+			NativeScripts []NativeScript.NativeScript `cbor:"1,keyasint,omitempty"`
+			PlutusScripts []uint8                     `cbor:"2,keyasint,omitempty"`
+		}{metadata, ad._AlonzoMeta.NativeScripts, ad._AlonzoMeta.PlutusScripts})
+	case len(ad._ShelleyMeta.NativeScripts) != 0:
+		return enc.Marshal(struct {
+			_             struct{} `cbor:",toarray"`
+			Metadata      GeneralMetadata
+			NativeScripts []NativeScript.NativeScript
+		}{Metadata: metadata, NativeScripts: ad._ShelleyMeta.NativeScripts})
+	default:
+		return enc.Marshal(metadata)
+	}
+}
@@ -0,0 +1,150 @@
+package NativeScript
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/Policy"
+)
+
+/*
+The JSON encoding is the one of cardano-cli policy files:
+
+	{"type": "all", "scripts": [
+		{"type": "sig", "keyHash": "..."},
+		{"type": "before", "slot": 1000}
+	]}
+
+"before" is an InvalidHereafter script and "after" an InvalidBefore one,
+both naming the slot bounding the validity interval.
+*/
+type sigJSON struct {
+	Type    string `json:"type"`
+	KeyHash string `json:"keyHash"`
+}
+
+type scriptsJSON struct {
+	Type    string         `json:"type"`
+	Scripts []NativeScript `json:"scripts"`
+}
+
+type atLeastJSON struct {
+	Type     string         `json:"type"`
+	Required int            `json:"required"`
+	Scripts  []NativeScript `json:"scripts"`
+}
+
+type slotJSON struct {
+	Type string `json:"type"`
+	Slot int64  `json:"slot"`
+}
+
+type anyJSON struct {
+	Type     string         `json:"type"`
+	KeyHash  string         `json:"keyHash"`
+	Required *int           `json:"required"`
+	Slot     *int64         `json:"slot"`
+	Scripts  []NativeScript `json:"scripts"`
+}
+
+func nonNil(scripts []NativeScript) []NativeScript {
+	if scripts == nil {
+		return []NativeScript{}
+	}
+	return scripts
+}
+
+func (ns NativeScript) MarshalJSON() ([]byte, error) {
+	switch ns.Tag {
+	case ScriptPubKey:
+		return json.Marshal(sigJSON{Type: "sig", KeyHash: hex.EncodeToString(ns.KeyHash)})
+	case ScriptAll:
+		return json.Marshal(scriptsJSON{Type: "all", Scripts: nonNil(ns.NativeScripts)})
+	case ScriptAny:
+		return json.Marshal(scriptsJSON{Type: "any", Scripts: nonNil(ns.NativeScripts)})
+	case ScriptNofK:
+		return json.Marshal(atLeastJSON{Type: "atLeast", Required: ns.NoK, Scripts: nonNil(ns.NativeScripts)})
+	case InvalidBefore:
+		return json.Marshal(slotJSON{Type: "after", Slot: ns.Before})
+	case InvalidHereafter:
+		return json.Marshal(slotJSON{Type: "before", Slot: ns.After})
+	default:
+		return nil, fmt.Errorf("unknown native script tag %d", ns.Tag)
+	}
+}
+
+func (ns *NativeScript) UnmarshalJSON(value []byte) error {
+	tmp := anyJSON{}
+	if err := json.Unmarshal(value, &tmp); err != nil {
+		return err
+	}
+	*ns = NativeScript{}
+	switch tmp.Type {
+	case "sig":
+		keyHash, err := hex.DecodeString(tmp.KeyHash)
+		if err != nil {
+			return err
+		}
+		if len(keyHash) != 28 {
+			return errors.New("invalid length of a key hash")
+		}
+		ns.Tag = ScriptPubKey
+		ns.KeyHash = keyHash
+	case "all", "any":
+		if tmp.Scripts == nil {
+			return fmt.Errorf("missing scripts in a native script of type %s", tmp.Type)
+		}
+		ns.Tag = ScriptAll
+		if tmp.Type == "any" {
+			ns.Tag = ScriptAny
+		}
+		ns.NativeScripts = tmp.Scripts
+	case "atLeast":
+		if tmp.Scripts == nil || tmp.Required == nil {
+			return errors.New("missing scripts or required in a native script of type atLeast")
+		}
+		if *tmp.Required < 0 || *tmp.Required > len(tmp.Scripts) {
+			return fmt.Errorf("required %d out of range for %d scripts", *tmp.Required, len(tmp.Scripts))
+		}
+		ns.Tag = ScriptNofK
+		ns.NoK = *tmp.Required
+		ns.NativeScripts = tmp.Scripts
+	case "after", "before":
+		if tmp.Slot == nil || *tmp.Slot < 0 {
+			return fmt.Errorf("missing or negative slot in a native script of type %s", tmp.Type)
+		}
+		if tmp.Type == "after" {
+			ns.Tag = InvalidBefore
+			ns.Before = *tmp.Slot
+		} else {
+			ns.Tag = InvalidHereafter
+			ns.After = *tmp.Slot
+		}
+	default:
+		return fmt.Errorf("unknown native script type %q", tmp.Type)
+	}
+	return nil
+}
+
+// PolicyId returns the policy id of the assets minted under the script.
+func (ns NativeScript) PolicyId() Policy.PolicyId {
+	hash := ns.Hash()
+	return Policy.PolicyId{Value: hex.EncodeToString(hash.Bytes())}
+}
+
+// ToAddress returns the mainnet address of the script, as
+// Address.ScriptAddress builds it.
+func (ns NativeScript) ToAddress(stakingCredential []byte) Address.Address {
+	return Address.ScriptAddress(ns.Hash(), stakingCredential)
+}
+
+// ToNetworkAddress returns the address of the script on network, with
+// stake as its delegation part: a base, pointer or enterprise address.
+func (ns NativeScript) ToNetworkAddress(stake Address.StakeReference, network constants.Network) Address.Address {
+	return Address.New(Credential.FromScriptHash(ns.Hash()), stake, network)
+}
@@ -2,6 +2,7 @@ package NativeScript
 
 import (
 	"log"
+	"sort"
 
 	"github.com/SundaeSwap-finance/apollo/serialization"
 
@@ -143,3 +144,142 @@ func (ns *NativeScript) MarshalCBOR() ([]uint8, error) {
 		return make([]uint8, 0), nil
 	}
 }
+
+/*
+Evaluate reports whether the script validates for a transaction signed
+by signers and valid from validityStart up to ttl, 0 leaving a bound
+of the validity interval open.
+*/
+func (ns NativeScript) Evaluate(signers []serialization.PubKeyHash, validityStart int64, ttl int64) bool {
+	signed := make(map[string]bool, len(signers))
+	for _, signer := range signers {
+		signed[string(signer[:])] = true
+	}
+	return ns.evaluate(func(keyHash []byte) bool { return signed[string(keyHash)] }, validityStart, ttl)
+}
+
+// ValidInInterval reports whether the script can validate within the
+// validity interval, provided every key it names signs.
+func (ns NativeScript) ValidInInterval(validityStart int64, ttl int64) bool {
+	return ns.evaluate(func([]byte) bool { return true }, validityStart, ttl)
+}
+
+func (ns NativeScript) evaluate(signed func([]byte) bool, validityStart int64, ttl int64) bool {
+	switch ns.Tag {
+	case ScriptPubKey:
+		return signed(ns.KeyHash)
+	case ScriptAll:
+		for _, script := range ns.NativeScripts {
+			if !script.evaluate(signed, validityStart, ttl) {
+				return false
+			}
+		}
+		return true
+	case ScriptAny:
+		for _, script := range ns.NativeScripts {
+			if script.evaluate(signed, validityStart, ttl) {
+				return true
+			}
+		}
+		return false
+	case ScriptNofK:
+		valid := 0
+		for _, script := range ns.NativeScripts {
+			if script.evaluate(signed, validityStart, ttl) {
+				valid++
+			}
+		}
+		return valid >= ns.NoK
+	case InvalidBefore:
+		return validityStart != 0 && validityStart >= ns.Before
+	case InvalidHereafter:
+		return ttl != 0 && ttl <= ns.After
+	default:
+		return false
+	}
+}
+
+/*
+KeyHashes returns the keys that sign the script in the worst case: all
+of them for ScriptAll, and the alternatives needing the most keys for
+ScriptAny and ScriptNofK. It is what fee estimation accounts witnesses
+for.
+*/
+func (ns NativeScript) KeyHashes() []serialization.PubKeyHash {
+	switch ns.Tag {
+	case ScriptPubKey:
+		var pkh serialization.PubKeyHash
+		copy(pkh[:], ns.KeyHash)
+		return []serialization.PubKeyHash{pkh}
+	case ScriptAll:
+		return uniqueKeyHashes(ns.NativeScripts, len(ns.NativeScripts))
+	case ScriptAny:
+		return uniqueKeyHashes(ns.NativeScripts, 1)
+	case ScriptNofK:
+		return uniqueKeyHashes(ns.NativeScripts, ns.NoK)
+	default:
+		return nil
+	}
+}
+
+/*
+UnsignedKeyHashes returns the keys not among signers whose signature
+could still make the script validate: the unsigned keys of the parts of
+the script that don't validate yet. It is empty once the script
+validates.
+*/
+func (ns NativeScript) UnsignedKeyHashes(signers []serialization.PubKeyHash, validityStart int64, ttl int64) []serialization.PubKeyHash {
+	signed := make(map[string]bool, len(signers))
+	for _, signer := range signers {
+		signed[string(signer[:])] = true
+	}
+	seen := make(map[serialization.PubKeyHash]bool)
+	res := make([]serialization.PubKeyHash, 0)
+	ns.unsignedKeyHashes(func(keyHash []byte) bool { return signed[string(keyHash)] }, validityStart, ttl, func(pkh serialization.PubKeyHash) {
+		if !seen[pkh] {
+			seen[pkh] = true
+			res = append(res, pkh)
+		}
+	})
+	return res
+}
+
+func (ns NativeScript) unsignedKeyHashes(signed func([]byte) bool, validityStart int64, ttl int64, add func(serialization.PubKeyHash)) {
+	if ns.evaluate(signed, validityStart, ttl) {
+		return
+	}
+	switch ns.Tag {
+	case ScriptPubKey:
+		var pkh serialization.PubKeyHash
+		copy(pkh[:], ns.KeyHash)
+		add(pkh)
+	case ScriptAll, ScriptAny, ScriptNofK:
+		for _, script := range ns.NativeScripts {
+			script.unsignedKeyHashes(signed, validityStart, ttl, add)
+		}
+	}
+}
+
+// uniqueKeyHashes merges the key hashes of the n scripts needing the
+// most keys.
+func uniqueKeyHashes(scripts []NativeScript, n int) []serialization.PubKeyHash {
+	children := make([][]serialization.PubKeyHash, len(scripts))
+	for i, script := range scripts {
+		children[i] = script.KeyHashes()
+	}
+	sort.SliceStable(children, func(i, j int) bool { return len(children[i]) > len(children[j]) })
+	if n > len(children) {
+		n = len(children)
+	}
+	seen := make(map[serialization.PubKeyHash]bool)
+	res := make([]serialization.PubKeyHash, 0)
+	for _, keys := range children[:max(n, 0)] {
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				res = append(res, key)
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,563 @@
+package PlutusData
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SundaeSwap-finance/apollo/serialization"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+// Constructor tags, see CIP-0005 and the plutus core spec.
+const (
+	CONSTR_TAG_BASE          = 121
+	CONSTR_TAG_EXTENDED_BASE = 1280
+	CONSTR_TAG_GENERAL       = 102
+)
+
+var (
+	plutusDataType = reflect.TypeOf(PlutusData{})
+	bigIntType     = reflect.TypeOf(big.Int{})
+)
+
+/*
+NewConstr returns the constructor with the given index and fields,
+using the most compact tag available for the index.
+*/
+func NewConstr(index uint64, fields ...PlutusData) PlutusData {
+	var list any = PlutusIndefArray(fields)
+	if len(fields) == 0 {
+		list = PlutusDefArray{}
+	}
+	switch {
+	case index < 7:
+		return PlutusData{PlutusDataType: PlutusArray, TagNr: CONSTR_TAG_BASE + index, Value: list}
+	case index < 128:
+		return PlutusData{PlutusDataType: PlutusArray, TagNr: CONSTR_TAG_EXTENDED_BASE + index - 7, Value: list}
+	}
+	return PlutusData{
+		PlutusDataType: PlutusArray,
+		TagNr:          CONSTR_TAG_GENERAL,
+		Value: PlutusDefArray{
+			{PlutusDataType: PlutusInt, Value: index},
+			{PlutusDataType: PlutusArray, Value: list},
+		},
+	}
+}
+
+// Constr returns the constructor index and fields of pd, if it is a
+// constructor.
+func (pd PlutusData) Constr() (uint64, []PlutusData, bool) {
+	switch {
+	case pd.TagNr >= CONSTR_TAG_BASE && pd.TagNr < CONSTR_TAG_BASE+7:
+		fields, ok := listOf(pd.Value)
+		return pd.TagNr - CONSTR_TAG_BASE, fields, ok
+	case pd.TagNr >= CONSTR_TAG_EXTENDED_BASE && pd.TagNr < CONSTR_TAG_EXTENDED_BASE+121:
+		fields, ok := listOf(pd.Value)
+		return pd.TagNr - CONSTR_TAG_EXTENDED_BASE + 7, fields, ok
+	case pd.TagNr == CONSTR_TAG_GENERAL:
+		content, ok := listOf(pd.Value)
+		if !ok || len(content) != 2 {
+			return 0, nil, false
+		}
+		index, ok := content[0].BigInt()
+		if !ok || !index.IsUint64() {
+			return 0, nil, false
+		}
+		fields, ok := listOf(content[1].Value)
+		return index.Uint64(), fields, ok
+	}
+	return 0, nil, false
+}
+
+// listOf returns the elements of a plutus list, however it was built.
+func listOf(value any) ([]PlutusData, bool) {
+	switch v := value.(type) {
+	case PlutusIndefArray:
+		return v, true
+	case PlutusDefArray:
+		return v, true
+	case []PlutusData:
+		return v, true
+	case PlutusData:
+		return listOf(v.Value)
+	case *PlutusData:
+		// constructors parsed from json keep their fields behind a pointer
+		return listOf(v.Value)
+	}
+	return nil, false
+}
+
+// MapEntry is a key and its value in a plutus map.
+type MapEntry struct {
+	Key   PlutusData
+	Value PlutusData
+}
+
+/*
+AssocMap is a plutus map that keeps its entries in order and takes any
+plutus data as keys, unlike the CborMap maps are decoded into.
+*/
+type AssocMap []MapEntry
+
+func (m AssocMap) MarshalCBOR() ([]byte, error) {
+	res := mapHeader(len(m))
+	for _, entry := range m {
+		key, err := cbor.Marshal(&entry.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := cbor.Marshal(&entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		res = append(append(res, key...), value...)
+	}
+	return res, nil
+}
+
+func mapHeader(n int) []byte {
+	switch {
+	case n < 24:
+		return []byte{0xa0 | byte(n)}
+	case n <= 0xff:
+		return []byte{0xb8, byte(n)}
+	case n <= 0xffff:
+		return []byte{0xb9, byte(n >> 8), byte(n)}
+	}
+	return []byte{0xba, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+}
+
+// NewMap returns the plutus map with the given entries, in order.
+func NewMap(entries ...MapEntry) PlutusData {
+	return PlutusData{PlutusDataType: PlutusMap, Value: AssocMap(entries)}
+}
+
+// NewList returns the plutus list of items.
+func NewList(items ...PlutusData) PlutusData {
+	if len(items) == 0 {
+		return PlutusData{PlutusDataType: PlutusArray, Value: PlutusDefArray{}}
+	}
+	return PlutusData{PlutusDataType: PlutusArray, Value: PlutusIndefArray(items)}
+}
+
+// NewBytes returns plutus data holding b.
+func NewBytes(b []byte) PlutusData {
+	return PlutusData{PlutusDataType: PlutusBytes, Value: b}
+}
+
+// Bytes returns the bytes held by pd, if it holds bytes.
+func (pd PlutusData) Bytes() ([]byte, bool) {
+	if pd.PlutusDataType != PlutusBytes || pd.TagNr != 0 {
+		return nil, false
+	}
+	b, ok := pd.Value.([]byte)
+	return b, ok
+}
+
+// List returns the elements of pd, if it is a list.
+func (pd PlutusData) List() ([]PlutusData, bool) {
+	if pd.PlutusDataType != PlutusArray || pd.TagNr != 0 {
+		return nil, false
+	}
+	return listOf(pd.Value)
+}
+
+/*
+MapEntries returns the entries of pd, if it is a map. Maps built with
+NewMap keep their order, decoded ones are sorted by the encoding of
+their keys.
+*/
+func (pd PlutusData) MapEntries() ([]MapEntry, error) {
+	if pd.PlutusDataType != PlutusMap || pd.TagNr != 0 {
+		return nil, fmt.Errorf("plutus: not a map")
+	}
+	var contents map[serialization.CustomBytes]PlutusData
+	switch v := pd.Value.(type) {
+	case AssocMap:
+		return append([]MapEntry{}, v...), nil
+	case CborMap:
+		contents = *v.Contents
+	case *CborMap:
+		contents = *v.Contents
+	case map[serialization.CustomBytes]PlutusData:
+		contents = v
+	default:
+		return nil, fmt.Errorf("plutus: not a map")
+	}
+	type encodedEntry struct {
+		encoded []byte
+		entry   MapEntry
+	}
+	entries := make([]encodedEntry, 0, len(contents))
+	for cb, value := range contents {
+		// keys are held as CustomBytes, turn them back into plutus data
+		encoded, err := cbor.Marshal(cb)
+		if err != nil {
+			return nil, err
+		}
+		var key PlutusData
+		if err := cbor.Unmarshal(encoded, &key); err != nil {
+			return nil, err
+		}
+		entries = append(entries, encodedEntry{encoded: encoded, entry: MapEntry{Key: key, Value: value}})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].encoded, entries[j].encoded) < 0
+	})
+	res := make([]MapEntry, len(entries))
+	for i, e := range entries {
+		res[i] = e.entry
+	}
+	return res, nil
+}
+
+type fieldInfo struct {
+	index int
+	name  string
+}
+
+// structInfo reads the constructor index and the encoded fields of a
+// struct type.
+func structInfo(t reflect.Type) (uint64, []fieldInfo, error) {
+	var constr uint64
+	fields := make([]fieldInfo, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("plutus")
+		if field.Name == "_" {
+			for _, opt := range strings.Split(tag, ",") {
+				value, ok := strings.CutPrefix(strings.TrimSpace(opt), "constr=")
+				if !ok {
+					continue
+				}
+				n, err := strconv.ParseUint(value, 10, 64)
+				if err != nil {
+					return 0, nil, fmt.Errorf("plutus: invalid constructor %q on %s", value, t)
+				}
+				constr = n
+			}
+			continue
+		}
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		fields = append(fields, fieldInfo{index: i, name: field.Name})
+	}
+	return constr, fields, nil
+}
+
+/*
+Marshal converts v to plutus data.
+
+Structs become constructors, with the index taken from a blank field
+tagged `plutus:"constr=N"` (0 when missing) and one field per exported
+struct field in declaration order; fields tagged `plutus:"-"` are
+skipped. Slices and arrays become lists, maps become plutus maps,
+[]byte, byte arrays and strings become bytes, integers and *big.Int
+become integers and bools become Bool constructors (False=0, True=1).
+Pointers inside v become Option constructors: Some (0) with the value
+or None (1) when nil. A pointer passed directly to Marshal is followed.
+PlutusData values are used as they are.
+*/
+func Marshal(v any) (PlutusData, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return PlutusData{}, fmt.Errorf("plutus: cannot marshal nil")
+	}
+	if rv.Kind() == reflect.Pointer && rv.Type().Elem() != bigIntType {
+		if rv.IsNil() {
+			return PlutusData{}, fmt.Errorf("plutus: cannot marshal nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	return marshalValue(rv)
+}
+
+func marshalValue(rv reflect.Value) (PlutusData, error) {
+	t := rv.Type()
+	switch t {
+	case plutusDataType:
+		return rv.Interface().(PlutusData), nil
+	case bigIntType:
+		n := rv.Interface().(big.Int)
+		return NewBigInt(&n), nil
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			return NewConstr(1), nil
+		}
+		return NewConstr(0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewBigInt(big.NewInt(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return NewBigInt(new(big.Int).SetUint64(rv.Uint())), nil
+	case reflect.String:
+		return PlutusData{PlutusDataType: PlutusBytes, Value: []byte(rv.String())}, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return PlutusData{PlutusDataType: PlutusBytes, Value: append([]byte{}, rv.Bytes()...)}, nil
+		}
+		return marshalList(rv)
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return PlutusData{PlutusDataType: PlutusBytes, Value: b}, nil
+		}
+		return marshalList(rv)
+	case reflect.Map:
+		return marshalMap(rv)
+	case reflect.Pointer:
+		if t.Elem() == bigIntType {
+			if rv.IsNil() {
+				return PlutusData{}, fmt.Errorf("plutus: cannot marshal nil *big.Int")
+			}
+			return NewBigInt(rv.Interface().(*big.Int)), nil
+		}
+		if rv.IsNil() {
+			return NewConstr(1), nil
+		}
+		inner, err := marshalValue(rv.Elem())
+		if err != nil {
+			return PlutusData{}, err
+		}
+		return NewConstr(0, inner), nil
+	case reflect.Interface:
+		if rv.IsNil() {
+			return PlutusData{}, fmt.Errorf("plutus: cannot marshal nil %s", t)
+		}
+		return marshalValue(rv.Elem())
+	case reflect.Struct:
+		constr, fields, err := structInfo(t)
+		if err != nil {
+			return PlutusData{}, err
+		}
+		contents := make([]PlutusData, 0, len(fields))
+		for _, field := range fields {
+			pd, err := marshalValue(rv.Field(field.index))
+			if err != nil {
+				return PlutusData{}, fmt.Errorf("plutus: field %s.%s: %w", t, field.name, err)
+			}
+			contents = append(contents, pd)
+		}
+		return NewConstr(constr, contents...), nil
+	}
+	return PlutusData{}, fmt.Errorf("plutus: unsupported type %s", t)
+}
+
+func marshalList(rv reflect.Value) (PlutusData, error) {
+	if rv.Len() == 0 {
+		return PlutusData{PlutusDataType: PlutusArray, Value: PlutusDefArray{}}, nil
+	}
+	list := make(PlutusIndefArray, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		pd, err := marshalValue(rv.Index(i))
+		if err != nil {
+			return PlutusData{}, err
+		}
+		list = append(list, pd)
+	}
+	return PlutusData{PlutusDataType: PlutusArray, Value: list}, nil
+}
+
+func marshalMap(rv reflect.Value) (PlutusData, error) {
+	contents := make(map[serialization.CustomBytes]PlutusData, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := marshalValue(iter.Key())
+		if err != nil {
+			return PlutusData{}, err
+		}
+		if key.PlutusDataType != PlutusInt && key.PlutusDataType != PlutusBytes {
+			return PlutusData{}, fmt.Errorf("plutus: unsupported map key type %s", iter.Key().Type())
+		}
+		// map keys are held as CustomBytes, which decode from the key's cbor
+		encoded, err := cbor.Marshal(&key)
+		if err != nil {
+			return PlutusData{}, err
+		}
+		var cb serialization.CustomBytes
+		if err := cbor.Unmarshal(encoded, &cb); err != nil {
+			return PlutusData{}, err
+		}
+		value, err := marshalValue(iter.Value())
+		if err != nil {
+			return PlutusData{}, err
+		}
+		contents[cb] = value
+	}
+	return PlutusData{PlutusDataType: PlutusMap, Value: CborMap{Contents: &contents}}, nil
+}
+
+/*
+Unmarshal stores the plutus data pd in the value pointed to by v,
+following the rules of Marshal in reverse. Constructors must match the
+index and the number of fields of the target struct.
+*/
+func Unmarshal(pd PlutusData, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("plutus: Unmarshal needs a non-nil pointer, got %T", v)
+	}
+	return unmarshalValue(pd, rv.Elem())
+}
+
+func typeError(pd PlutusData, t reflect.Type) error {
+	return fmt.Errorf("plutus: cannot unmarshal %s into %s", ToCbor(&pd), t)
+}
+
+func unmarshalValue(pd PlutusData, rv reflect.Value) error {
+	t := rv.Type()
+	switch t {
+	case plutusDataType:
+		rv.Set(reflect.ValueOf(pd))
+		return nil
+	case bigIntType:
+		n, ok := pd.BigInt()
+		if !ok {
+			return typeError(pd, t)
+		}
+		rv.Set(reflect.ValueOf(*n))
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		index, fields, ok := pd.Constr()
+		if !ok || index > 1 || len(fields) != 0 {
+			return typeError(pd, t)
+		}
+		rv.SetBool(index == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := pd.BigInt()
+		if !ok || !n.IsInt64() || rv.OverflowInt(n.Int64()) {
+			return typeError(pd, t)
+		}
+		rv.SetInt(n.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := pd.BigInt()
+		if !ok || !n.IsUint64() || rv.OverflowUint(n.Uint64()) {
+			return typeError(pd, t)
+		}
+		rv.SetUint(n.Uint64())
+	case reflect.String:
+		b, ok := pd.Bytes()
+		if !ok {
+			return typeError(pd, t)
+		}
+		rv.SetString(string(b))
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b, ok := pd.Bytes()
+			if !ok {
+				return typeError(pd, t)
+			}
+			rv.SetBytes(append([]byte{}, b...))
+			return nil
+		}
+		list, ok := pd.List()
+		if !ok {
+			return typeError(pd, t)
+		}
+		slice := reflect.MakeSlice(t, len(list), len(list))
+		for i, el := range list {
+			if err := unmarshalValue(el, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(slice)
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b, ok := pd.Bytes()
+			if !ok || len(b) != rv.Len() {
+				return typeError(pd, t)
+			}
+			reflect.Copy(rv, reflect.ValueOf(b))
+			return nil
+		}
+		list, ok := pd.List()
+		if !ok || len(list) != rv.Len() {
+			return typeError(pd, t)
+		}
+		for i, el := range list {
+			if err := unmarshalValue(el, rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return unmarshalMap(pd, rv)
+	case reflect.Pointer:
+		if t.Elem() == bigIntType {
+			n, ok := pd.BigInt()
+			if !ok {
+				return typeError(pd, t)
+			}
+			rv.Set(reflect.ValueOf(n))
+			return nil
+		}
+		index, fields, ok := pd.Constr()
+		switch {
+		case ok && index == 1 && len(fields) == 0:
+			rv.Set(reflect.Zero(t))
+		case ok && index == 0 && len(fields) == 1:
+			inner := reflect.New(t.Elem())
+			if err := unmarshalValue(fields[0], inner.Elem()); err != nil {
+				return err
+			}
+			rv.Set(inner)
+		default:
+			return typeError(pd, t)
+		}
+	case reflect.Interface:
+		if !plutusDataType.AssignableTo(t) {
+			return fmt.Errorf("plutus: cannot unmarshal into interface %s", t)
+		}
+		rv.Set(reflect.ValueOf(pd))
+	case reflect.Struct:
+		constr, fields, err := structInfo(t)
+		if err != nil {
+			return err
+		}
+		index, contents, ok := pd.Constr()
+		if !ok || index != constr || len(contents) != len(fields) {
+			return typeError(pd, t)
+		}
+		for i, field := range fields {
+			if err := unmarshalValue(contents[i], rv.Field(field.index)); err != nil {
+				return fmt.Errorf("plutus: field %s.%s: %w", t, field.name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("plutus: unsupported type %s", t)
+	}
+	return nil
+}
+
+func unmarshalMap(pd PlutusData, rv reflect.Value) error {
+	t := rv.Type()
+	entries, err := pd.MapEntries()
+	if err != nil {
+		return typeError(pd, t)
+	}
+	result := reflect.MakeMapWithSize(t, len(entries))
+	for _, entry := range entries {
+		key := reflect.New(t.Key()).Elem()
+		if err := unmarshalValue(entry.Key, key); err != nil {
+			return err
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if err := unmarshalValue(entry.Value, elem); err != nil {
+			return err
+		}
+		result.SetMapIndex(key, elem)
+	}
+	rv.Set(result)
+	return nil
+}
@@ -6,11 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/big"
 	"reflect"
 	"sort"
 
+	"github.com/SundaeSwap-finance/apollo/constants"
 	"github.com/SundaeSwap-finance/apollo/serialization"
 	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
 
 	"github.com/Salvionied/cbor/v2"
 
@@ -1213,6 +1216,9 @@ func (pd *Datum) Clone() Datum {
 }
 
 func (pd Datum) MarshalCBOR() ([]uint8, error) {
+	if n, ok := pd.Value.(*big.Int); ok && pd.TagNr == 0 {
+		return serialization.MarshalBigInt(n), nil
+	}
 	if pd.TagNr == 0 {
 		return cbor.Marshal(pd.Value)
 	} else {
@@ -1261,6 +1267,10 @@ func (pd *Datum) UnmarshalCBOR(value []uint8) error {
 			pd.PlutusDataType = PlutusInt
 			pd.Value = x
 			pd.TagNr = 0
+		case int64, big.Int:
+			pd.PlutusDataType = PlutusInt
+			pd.Value = normalizeInt(toBigInt(x))
+			pd.TagNr = 0
 
 		case []uint8:
 			pd.PlutusDataType = PlutusBytes
@@ -1285,12 +1295,59 @@ func (pd *Datum) UnmarshalCBOR(value []uint8) error {
 	return nil
 }
 
+/*
+PlutusData is a generic plutus data value. Integers are held as uint64
+when they are non-negative and fit in 64 bits, and as *big.Int
+otherwise.
+*/
 type PlutusData struct {
 	PlutusDataType PlutusType
 	TagNr          uint64
 	Value          any
 }
 
+// NewBigInt returns plutus data holding the integer n.
+func NewBigInt(n *big.Int) PlutusData {
+	return PlutusData{PlutusDataType: PlutusInt, Value: normalizeInt(n)}
+}
+
+// BigInt returns the integer held by pd, if it holds one.
+func (pd PlutusData) BigInt() (*big.Int, bool) {
+	if pd.PlutusDataType != PlutusInt || pd.TagNr != 0 {
+		return nil, false
+	}
+	switch v := pd.Value.(type) {
+	case uint64:
+		return new(big.Int).SetUint64(v), true
+	case int64:
+		return big.NewInt(v), true
+	case int:
+		return big.NewInt(int64(v)), true
+	case *big.Int:
+		return new(big.Int).Set(v), true
+	}
+	return nil, false
+}
+
+func toBigInt(x any) *big.Int {
+	switch v := x.(type) {
+	case int64:
+		return big.NewInt(v)
+	case big.Int:
+		return &v
+	}
+	return nil
+}
+
+// normalizeInt keeps integers that fit in a uint64 as one, so they
+// compare equal to the values decoded from plain CBOR integers.
+func normalizeInt(n *big.Int) any {
+	if n.Sign() >= 0 && n.IsUint64() {
+		return n.Uint64()
+	}
+	return new(big.Int).Set(n)
+}
+
 func (pd *PlutusData) Equal(other PlutusData) bool {
 	marshaledThis, _ := cbor.Marshal(pd)
 	marshaledOther, _ := cbor.Marshal(other)
@@ -1330,6 +1387,9 @@ func (cm *CborMap) UnmarshalCBOR(value []uint8) error {
 }
 
 func (pd *PlutusData) MarshalCBOR() ([]uint8, error) {
+	if n, ok := pd.Value.(*big.Int); ok && pd.TagNr == 0 {
+		return serialization.MarshalBigInt(n), nil
+	}
 	if pd.TagNr == 0 {
 		return cbor.Marshal(pd.Value)
 	} else {
@@ -1338,7 +1398,10 @@ func (pd *PlutusData) MarshalCBOR() ([]uint8, error) {
 }
 func (pd *PlutusData) UnmarshalJSON(value []byte) error {
 	var x any
-	err := json.Unmarshal(value, &x)
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	// keep integers as numbers so large ones don't lose precision
+	decoder.UseNumber()
+	err := decoder.Decode(&x)
 	if err != nil {
 		return err
 	}
@@ -1360,7 +1423,11 @@ func (pd *PlutusData) UnmarshalJSON(value []byte) error {
 			var tag int
 			constructor, ok := val["constructor"]
 			if ok {
-				tag = int(121 + constructor.(float64))
+				constr, err := constructor.(json.Number).Int64()
+				if err != nil {
+					return err
+				}
+				tag = int(121 + constr)
 			} else {
 				tag = 0
 			}
@@ -1376,8 +1443,12 @@ func (pd *PlutusData) UnmarshalJSON(value []byte) error {
 			pd.PlutusDataType = PlutusBytes
 			pd.Value, _ = hex.DecodeString(val["bytes"].(string))
 		} else if _, ok := val["int"]; ok {
+			n, ok := new(big.Int).SetString(val["int"].(json.Number).String(), 10)
+			if !ok {
+				return fmt.Errorf("invalid integer in plutus data: %v", val["int"])
+			}
 			pd.PlutusDataType = PlutusInt
-			pd.Value = uint64(val["int"].(float64))
+			pd.Value = normalizeInt(n)
 		} else {
 			fmt.Println("Invalid Nested Struct in plutus data")
 		}
@@ -1397,16 +1468,17 @@ func (pd *PlutusData) UnmarshalCBOR(value []uint8) error {
 		case []interface{}:
 			pd.TagNr = ok.Number
 			pd.PlutusDataType = PlutusArray
-			if value[2] == 0x9f {
+			content := value[tagHeaderLength(value[0]):]
+			if content[0] == 0x9f {
 				y := PlutusIndefArray{}
-				err = cbor.Unmarshal(value[2:], &y)
+				err = cbor.Unmarshal(content, &y)
 				if err != nil {
 					return err
 				}
 				pd.Value = y
 			} else {
 				y := PlutusDefArray{}
-				err = cbor.Unmarshal(value[2:], &y)
+				err = cbor.Unmarshal(content, &y)
 				if err != nil {
 					return err
 				}
@@ -1447,6 +1519,11 @@ func (pd *PlutusData) UnmarshalCBOR(value []uint8) error {
 			pd.PlutusDataType = PlutusInt
 			pd.Value = x
 			pd.TagNr = 0
+		case int64, big.Int:
+			// negative integers and tag 2/3 bignums
+			pd.PlutusDataType = PlutusInt
+			pd.Value = normalizeInt(toBigInt(x))
+			pd.TagNr = 0
 
 		case []uint8:
 			pd.PlutusDataType = PlutusBytes
@@ -1474,6 +1551,21 @@ func (pd *PlutusData) UnmarshalCBOR(value []uint8) error {
 	return nil
 }
 
+// tagHeaderLength returns the size of a cbor tag head from its first byte.
+func tagHeaderLength(initial byte) int {
+	switch info := initial & 0x1f; {
+	case info < 24:
+		return 1
+	case info == 24:
+		return 2
+	case info == 25:
+		return 3
+	case info == 26:
+		return 5
+	}
+	return 9
+}
+
 type RawPlutusData struct {
 	//TODO
 }
@@ -1534,55 +1626,31 @@ func PlutusScriptHash(script ScriptHashable) serialization.ScriptHash {
 type PlutusV1Script []byte
 
 func (ps *PlutusV1Script) ToAddress(stakingCredential []byte) Address.Address {
-	hash := PlutusScriptHash(ps)
-	if stakingCredential == nil {
-		return Address.Address{hash.Bytes(), nil, Address.MAINNET, Address.SCRIPT_NONE, 0b01110001, "addr"}
-	} else {
-		return Address.Address{
-			PaymentPart: hash.Bytes(),
-			StakingPart: stakingCredential,
-			Network:     Address.MAINNET,
-			AddressType: Address.SCRIPT_KEY,
-			HeaderByte:  0b00010001,
-			Hrp:         "addr",
-		}
-	}
+	return Address.ScriptAddress(PlutusScriptHash(ps), stakingCredential)
+}
+
+func (ps *PlutusV1Script) ToNetworkAddress(stake Address.StakeReference, network constants.Network) Address.Address {
+	return Address.New(Credential.FromScriptHash(PlutusScriptHash(ps)), stake, network)
 }
 
 type PlutusV2Script []byte
 
 func (ps *PlutusV2Script) ToAddress(stakingCredential []byte) Address.Address {
-	hash := PlutusScriptHash(ps)
-	if stakingCredential == nil {
-		return Address.Address{hash.Bytes(), nil, Address.MAINNET, Address.SCRIPT_NONE, 0b01110001, "addr"}
-	} else {
-		return Address.Address{
-			PaymentPart: hash.Bytes(),
-			StakingPart: stakingCredential,
-			Network:     Address.MAINNET,
-			AddressType: Address.SCRIPT_KEY,
-			HeaderByte:  0b00010001,
-			Hrp:         "addr",
-		}
-	}
+	return Address.ScriptAddress(PlutusScriptHash(ps), stakingCredential)
+}
+
+func (ps *PlutusV2Script) ToNetworkAddress(stake Address.StakeReference, network constants.Network) Address.Address {
+	return Address.New(Credential.FromScriptHash(PlutusScriptHash(ps)), stake, network)
 }
 
 type PlutusV3Script []byte
 
 func (ps *PlutusV3Script) ToAddress(stakingCredential []byte) Address.Address {
-	hash := PlutusScriptHash(ps)
-	if stakingCredential == nil {
-		return Address.Address{hash.Bytes(), nil, Address.MAINNET, Address.SCRIPT_NONE, 0b01110001, "addr"}
-	} else {
-		return Address.Address{
-			PaymentPart: hash.Bytes(),
-			StakingPart: stakingCredential,
-			Network:     Address.MAINNET,
-			AddressType: Address.SCRIPT_KEY,
-			HeaderByte:  0b00010001,
-			Hrp:         "addr",
-		}
-	}
+	return Address.ScriptAddress(PlutusScriptHash(ps), stakingCredential)
+}
+
+func (ps *PlutusV3Script) ToNetworkAddress(stake Address.StakeReference, network constants.Network) Address.Address {
+	return Address.New(Credential.FromScriptHash(PlutusScriptHash(ps)), stake, network)
 }
 
 func (ps PlutusV1Script) Hash() serialization.ScriptHash {
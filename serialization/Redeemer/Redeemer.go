@@ -13,6 +13,8 @@ const (
 	MINT
 	CERT
 	REWARD
+	VOTE
+	PROPOSE
 )
 
 // See https://ogmios.dev/mini-protocols/local-tx-submission/#evaluatetx
@@ -21,6 +23,8 @@ var RedeemerTagNames = map[RedeemerTag]string{
 	1: "mint",
 	2: "certificate",
 	3: "withdrawal",
+	4: "vote",
+	5: "propose",
 }
 
 type ExecutionUnits struct {
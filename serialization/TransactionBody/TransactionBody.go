@@ -5,6 +5,7 @@ import (
 
 	"github.com/SundaeSwap-finance/apollo/serialization"
 	"github.com/SundaeSwap-finance/apollo/serialization/Certificate"
+	"github.com/SundaeSwap-finance/apollo/serialization/Governance"
 	"github.com/SundaeSwap-finance/apollo/serialization/MultiAsset"
 	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
 	"github.com/SundaeSwap-finance/apollo/serialization/TransactionOutput"
@@ -15,23 +16,27 @@ import (
 )
 
 type TransactionBody struct {
-	Inputs            []TransactionInput.TransactionInput   `cbor:"0,keyasint"`
-	Outputs           []TransactionOutput.TransactionOutput `cbor:"1,keyasint"`
-	Fee               int64                                 `cbor:"2,keyasint"`
-	Ttl               int64                                 `cbor:"3,keyasint,omitempty"`
-	Certificates      *Certificate.Certificates             `cbor:"4,keyasint,omitempty"`
-	Withdrawals       *Withdrawal.Withdrawal                `cbor:"5,keyasint,omitempty"`
-	UpdateProposals   []any                                 `cbor:"6,keyasint,omitempty"`
-	AuxiliaryDataHash []byte                                `cbor:"7,keyasint,omitempty"`
-	ValidityStart     int64                                 `cbor:"8,keyasint,omitempty"`
-	Mint              MultiAsset.MultiAsset[int64]          `cbor:"9,keyasint,omitempty"`
-	ScriptDataHash    []byte                                `cbor:"11,keyasint,omitempty"`
-	Collateral        []TransactionInput.TransactionInput   `cbor:"13,keyasint,omitempty"`
-	RequiredSigners   []serialization.PubKeyHash            `cbor:"14,keyasint,omitempty"`
-	NetworkId         []byte                                `cbor:"15,keyasint,omitempty"`
-	CollateralReturn  *TransactionOutput.TransactionOutput  `cbor:"16,keyasint,omitempty"`
-	TotalCollateral   int                                   `cbor:"17,keyasint,omitempty"`
-	ReferenceInputs   []TransactionInput.TransactionInput   `cbor:"18,keyasint,omitempty"`
+	Inputs             []TransactionInput.TransactionInput   `cbor:"0,keyasint"`
+	Outputs            []TransactionOutput.TransactionOutput `cbor:"1,keyasint"`
+	Fee                int64                                 `cbor:"2,keyasint"`
+	Ttl                int64                                 `cbor:"3,keyasint,omitempty"`
+	Certificates       *Certificate.Certificates             `cbor:"4,keyasint,omitempty"`
+	Withdrawals        *Withdrawal.Withdrawal                `cbor:"5,keyasint,omitempty"`
+	UpdateProposals    []any                                 `cbor:"6,keyasint,omitempty"`
+	AuxiliaryDataHash  []byte                                `cbor:"7,keyasint,omitempty"`
+	ValidityStart      int64                                 `cbor:"8,keyasint,omitempty"`
+	Mint               MultiAsset.MultiAsset[int64]          `cbor:"9,keyasint,omitempty"`
+	ScriptDataHash     []byte                                `cbor:"11,keyasint,omitempty"`
+	Collateral         []TransactionInput.TransactionInput   `cbor:"13,keyasint,omitempty"`
+	RequiredSigners    []serialization.PubKeyHash            `cbor:"14,keyasint,omitempty"`
+	NetworkId          []byte                                `cbor:"15,keyasint,omitempty"`
+	CollateralReturn   *TransactionOutput.TransactionOutput  `cbor:"16,keyasint,omitempty"`
+	TotalCollateral    int                                   `cbor:"17,keyasint,omitempty"`
+	ReferenceInputs    []TransactionInput.TransactionInput   `cbor:"18,keyasint,omitempty"`
+	VotingProcedures   *Governance.VotingProcedures          `cbor:"19,keyasint,omitempty"`
+	ProposalProcedures Governance.ProposalProcedures         `cbor:"20,keyasint,omitempty"`
+	TreasuryValue      *int64                                `cbor:"21,keyasint,omitempty"`
+	Donation           int64                                 `cbor:"22,keyasint,omitempty"`
 }
 
 func (tx *TransactionBody) Hash() []byte {
@@ -1,7 +1,10 @@
 package TransactionWitnessSet
 
 import (
+	"fmt"
+
 	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo/serialization/BootstrapWitness"
 	"github.com/SundaeSwap-finance/apollo/serialization/NativeScript"
 	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
 	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
@@ -11,7 +14,7 @@ import (
 type normaltws struct {
 	VkeyWitnesses      []VerificationKeyWitness.VerificationKeyWitness `cbor:"0,keyasint,omitempty"`
 	NativeScripts      []NativeScript.NativeScript                     `cbor:"1,keyasint,omitempty"`
-	BootstrapWitnesses []any                                           `cbor:"2,keyasint,omitempty"`
+	BootstrapWitnesses []BootstrapWitness.BootstrapWitness             `cbor:"2,keyasint,omitempty"`
 	PlutusV1Script     []PlutusData.PlutusV1Script                     `cbor:"3,keyasint,omitempty"`
 	PlutusV2Script     []PlutusData.PlutusV2Script                     `cbor:"6,keyasint,omitempty"`
 	PlutusV3Script     []PlutusData.PlutusV3Script                     `cbor:"7,keyasint,omitempty"`
@@ -21,7 +24,7 @@ type normaltws struct {
 type TransactionWitnessSet struct {
 	VkeyWitnesses      []VerificationKeyWitness.VerificationKeyWitness `cbor:"0,keyasint,omitempty"`
 	NativeScripts      []NativeScript.NativeScript                     `cbor:"1,keyasint,omitempty"`
-	BootstrapWitnesses []any                                           `cbor:"2,keyasint,omitempty"`
+	BootstrapWitnesses []BootstrapWitness.BootstrapWitness             `cbor:"2,keyasint,omitempty"`
 	PlutusV1Script     []PlutusData.PlutusV1Script                     `cbor:"3,keyasint,omitempty"`
 	PlutusV2Script     []PlutusData.PlutusV2Script                     `cbor:"6,keyasint,omitempty"`
 	PlutusV3Script     []PlutusData.PlutusV3Script                     `cbor:"7,keyasint,omitempty"`
@@ -32,7 +35,7 @@ type TransactionWitnessSet struct {
 type WithRedeemerNoScripts struct {
 	VkeyWitnesses      []VerificationKeyWitness.VerificationKeyWitness `cbor:"0,keyasint,omitempty"`
 	NativeScripts      []NativeScript.NativeScript                     `cbor:"1,keyasint,omitempty"`
-	BootstrapWitnesses []any                                           `cbor:"2,keyasint,omitempty"`
+	BootstrapWitnesses []BootstrapWitness.BootstrapWitness             `cbor:"2,keyasint,omitempty"`
 	PlutusV1Script     []PlutusData.PlutusV1Script                     `cbor:"3,keyasint,omitempty"`
 	PlutusV2Script     []PlutusData.PlutusV2Script                     `cbor:"6,keyasint,omitempty"`
 	PlutusV3Script     []PlutusData.PlutusV3Script                     `cbor:"7,keyasint,omitempty"`
@@ -67,3 +70,78 @@ func (tws *TransactionWitnessSet) MarshalCBOR() ([]byte, error) {
 		Redeemer:           tws.Redeemer,
 	})
 }
+
+// appendUnique appends the items whose key is not in dst yet. An item
+// whose key is held already replaces it when replace says so.
+func appendUnique[T any](dst []T, key func(T) string, replace func(held T, item T) bool, items ...T) []T {
+	seen := make(map[string]int, len(dst))
+	for i, item := range dst {
+		seen[key(item)] = i
+	}
+	for _, item := range items {
+		k := key(item)
+		i, ok := seen[k]
+		if !ok {
+			seen[k] = len(dst)
+			dst = append(dst, item)
+		} else if replace != nil && replace(dst[i], item) {
+			dst[i] = item
+		}
+	}
+	return dst
+}
+
+func encodedKey[T any](item T) string {
+	encoded, _ := cbor.Marshal(item)
+	return string(encoded)
+}
+
+/*
+Merge adds the witnesses of others that tws doesn't hold yet, as when
+collecting the signatures of several parties. Vkey witnesses are the
+same when their keys are, bootstrap witnesses when their keys and
+attributes are, redeemers when their tag and index are, and
+other witnesses when their encodings are. A vkey or bootstrap witness
+for a key already held replaces it, so that signing again fixes a stale
+signature.
+*/
+func (tws *TransactionWitnessSet) Merge(others ...TransactionWitnessSet) {
+	tws.merge(nil, others...)
+}
+
+/*
+MergeVerified is Merge for the transaction body of hash bodyHash: of two
+vkey or bootstrap witnesses for the same key, the one signing bodyHash
+is kept, and the newer one when both or neither do.
+*/
+func (tws *TransactionWitnessSet) MergeVerified(bodyHash []byte, others ...TransactionWitnessSet) {
+	tws.merge(bodyHash, others...)
+}
+
+// merge keeps the newer of two vkey or bootstrap witnesses for the same
+// key, unless only the held one signs bodyHash.
+func (tws *TransactionWitnessSet) merge(bodyHash []byte, others ...TransactionWitnessSet) {
+	for _, other := range others {
+		tws.VkeyWitnesses = appendUnique(tws.VkeyWitnesses, func(vkw VerificationKeyWitness.VerificationKeyWitness) string {
+			return string(vkw.Vkey.Payload)
+		}, func(held VerificationKeyWitness.VerificationKeyWitness, item VerificationKeyWitness.VerificationKeyWitness) bool {
+			return bodyHash == nil || item.Verify(bodyHash) || !held.Verify(bodyHash)
+		}, other.VkeyWitnesses...)
+		tws.NativeScripts = appendUnique(tws.NativeScripts, func(ns NativeScript.NativeScript) string {
+			hash := ns.Hash()
+			return string(hash.Bytes())
+		}, nil, other.NativeScripts...)
+		tws.BootstrapWitnesses = appendUnique(tws.BootstrapWitnesses, func(bw BootstrapWitness.BootstrapWitness) string {
+			return string(bw.PublicKey) + string(bw.Attributes)
+		}, func(held BootstrapWitness.BootstrapWitness, item BootstrapWitness.BootstrapWitness) bool {
+			return bodyHash == nil || item.Verify(bodyHash) || !held.Verify(bodyHash)
+		}, other.BootstrapWitnesses...)
+		tws.PlutusV1Script = appendUnique(tws.PlutusV1Script, func(s PlutusData.PlutusV1Script) string { return string(s) }, nil, other.PlutusV1Script...)
+		tws.PlutusV2Script = appendUnique(tws.PlutusV2Script, func(s PlutusData.PlutusV2Script) string { return string(s) }, nil, other.PlutusV2Script...)
+		tws.PlutusV3Script = appendUnique(tws.PlutusV3Script, func(s PlutusData.PlutusV3Script) string { return string(s) }, nil, other.PlutusV3Script...)
+		tws.PlutusData = appendUnique(tws.PlutusData, func(pd PlutusData.PlutusData) string { return encodedKey(&pd) }, nil, other.PlutusData...)
+		tws.Redeemer = appendUnique(tws.Redeemer, func(r Redeemer.Redeemer) string {
+			return fmt.Sprintf("%d:%d", r.Tag, r.Index)
+		}, nil, other.Redeemer...)
+	}
+}
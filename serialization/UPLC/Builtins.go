@@ -0,0 +1,813 @@
+package UPLC
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"unicode/utf8"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ripemd160"
+	"golang.org/x/crypto/sha3"
+)
+
+// Largest byte string integerToByteString and replicateByte may build.
+const MAX_BUILTIN_BYTES = 8192
+
+type builtinSignature struct {
+	forces int
+	arity  int
+}
+
+var builtinSignatures = map[DefaultFunction]builtinSignature{
+	AddInteger: {0, 2}, SubtractInteger: {0, 2}, MultiplyInteger: {0, 2},
+	DivideInteger: {0, 2}, QuotientInteger: {0, 2}, RemainderInteger: {0, 2},
+	ModInteger: {0, 2}, EqualsInteger: {0, 2}, LessThanInteger: {0, 2},
+	LessThanEqualsInteger: {0, 2}, AppendByteString: {0, 2},
+	ConsByteString: {0, 2}, SliceByteString: {0, 3}, LengthOfByteString: {0, 1},
+	IndexByteString: {0, 2}, EqualsByteString: {0, 2}, LessThanByteString: {0, 2},
+	LessThanEqualsByteString: {0, 2}, Sha2_256: {0, 1}, Sha3_256: {0, 1},
+	Blake2b_256: {0, 1}, VerifyEd25519Signature: {0, 3}, AppendString: {0, 2},
+	EqualsString: {0, 2}, EncodeUtf8: {0, 1}, DecodeUtf8: {0, 1},
+	IfThenElse: {1, 3}, ChooseUnit: {1, 2}, Trace: {1, 2}, FstPair: {2, 1},
+	SndPair: {2, 1}, ChooseList: {2, 3}, MkCons: {1, 2}, HeadList: {1, 1},
+	TailList: {1, 1}, NullList: {1, 1}, ChooseData: {1, 6}, ConstrData: {0, 2},
+	MapData: {0, 1}, ListData: {0, 1}, IData: {0, 1}, BData: {0, 1},
+	UnConstrData: {0, 1}, UnMapData: {0, 1}, UnListData: {0, 1}, UnIData: {0, 1},
+	UnBData: {0, 1}, EqualsData: {0, 2}, MkPairData: {0, 2}, MkNilData: {0, 1},
+	MkNilPairData: {0, 1}, SerialiseData: {0, 1},
+	VerifyEcdsaSecp256k1Signature: {0, 3}, VerifySchnorrSecp256k1Signature: {0, 3},
+	Keccak_256: {0, 1}, Blake2b_224: {0, 1}, IntegerToByteString: {0, 3},
+	ByteStringToInteger: {0, 2}, AndByteString: {0, 3}, OrByteString: {0, 3},
+	XorByteString: {0, 3}, ComplementByteString: {0, 1}, ReadBit: {0, 2},
+	WriteBits: {0, 3}, ReplicateByte: {0, 2}, ShiftByteString: {0, 2},
+	RotateByteString: {0, 2}, CountSetBits: {0, 1}, FindFirstSetBit: {0, 1},
+	Ripemd_160: {0, 1},
+}
+
+var (
+	dataType     = Type{Tag: TypeData}
+	dataListType = ListOf(dataType)
+	dataPairType = PairOf(dataType, dataType)
+)
+
+func argument[T any](args []value, i int, tag TypeTag) (T, error) {
+	var zero T
+	c, ok := args[i].(Value)
+	if !ok || c.Type.Tag != tag {
+		return zero, fmt.Errorf("argument %d is not a %s", i+1, Type{Tag: tag})
+	}
+	contents, ok := c.Contents.(T)
+	if !ok {
+		return zero, fmt.Errorf("argument %d is a malformed %s", i+1, c.Type)
+	}
+	return contents, nil
+}
+
+func integers(args []value) (*big.Int, *big.Int, error) {
+	x, err := argument[*big.Int](args, 0, TypeInteger)
+	if err != nil {
+		return nil, nil, err
+	}
+	y, err := argument[*big.Int](args, 1, TypeInteger)
+	return x, y, err
+}
+
+func byteStrings(args []value, first int) ([]byte, []byte, error) {
+	x, err := argument[[]byte](args, first, TypeByteString)
+	if err != nil {
+		return nil, nil, err
+	}
+	y, err := argument[[]byte](args, first+1, TypeByteString)
+	return x, y, err
+}
+
+func list(args []value, i int) (Type, []any, error) {
+	c, ok := args[i].(Value)
+	if !ok || c.Type.Tag != TypeList {
+		return Type{}, nil, fmt.Errorf("argument %d is not a list", i+1)
+	}
+	items, _ := c.Contents.([]any)
+	return c.Type, items, nil
+}
+
+func data(args []value, i int) (PlutusData.PlutusData, error) {
+	return argument[PlutusData.PlutusData](args, i, TypeData)
+}
+
+func dataList(items []any) ([]PlutusData.PlutusData, error) {
+	res := make([]PlutusData.PlutusData, len(items))
+	for i, item := range items {
+		pd, ok := item.(PlutusData.PlutusData)
+		if !ok {
+			return nil, errors.New("malformed data list")
+		}
+		res[i] = pd
+	}
+	return res, nil
+}
+
+func dataValues(items []PlutusData.PlutusData) []any {
+	res := make([]any, len(items))
+	for i, item := range items {
+		res[i] = item
+	}
+	return res
+}
+
+// divMod returns the quotient and remainder of x by y rounded towards
+// zero, or towards negative infinity when floor is set.
+func divMod(x *big.Int, y *big.Int, floor bool) (*big.Int, *big.Int, error) {
+	if y.Sign() == 0 {
+		return nil, nil, errors.New("division by zero")
+	}
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if floor && r.Sign() != 0 && r.Sign() != y.Sign() {
+		q.Sub(q, big.NewInt(1))
+		r.Add(r, y)
+	}
+	return q, r, nil
+}
+
+func hash(h func([]byte) []byte) func([]value) (value, error) {
+	return func(args []value) (value, error) {
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		return ByteString(h(b)), nil
+	}
+}
+
+var hashes = map[DefaultFunction]func([]value) (value, error){
+	Sha2_256: hash(func(b []byte) []byte {
+		sum := sha256.Sum256(b)
+		return sum[:]
+	}),
+	Sha3_256: hash(func(b []byte) []byte {
+		sum := sha3.Sum256(b)
+		return sum[:]
+	}),
+	Blake2b_256: hash(func(b []byte) []byte {
+		sum := blake2b.Sum256(b)
+		return sum[:]
+	}),
+	Blake2b_224: hash(func(b []byte) []byte {
+		h, _ := blake2b.New(28, nil)
+		h.Write(b)
+		return h.Sum(nil)
+	}),
+	Keccak_256: hash(func(b []byte) []byte {
+		h := sha3.NewLegacyKeccak256()
+		h.Write(b)
+		return h.Sum(nil)
+	}),
+	Ripemd_160: hash(func(b []byte) []byte {
+		h := ripemd160.New()
+		h.Write(b)
+		return h.Sum(nil)
+	}),
+}
+
+// smallInt returns n as an int when it lies within [lo, hi].
+func smallInt(n *big.Int, lo int64, hi int64) (int, bool) {
+	if !n.IsInt64() || n.Int64() < lo || n.Int64() > hi {
+		return 0, false
+	}
+	return int(n.Int64()), true
+}
+
+// clamp returns n as an int64 within [lo, hi].
+func clamp(n *big.Int, lo int64, hi int64) int64 {
+	if n.Cmp(big.NewInt(lo)) < 0 {
+		return lo
+	}
+	if n.Cmp(big.NewInt(hi)) > 0 {
+		return hi
+	}
+	return n.Int64()
+}
+
+func (m *Machine) runBuiltin(fun DefaultFunction, args []value) (value, error) {
+	if h, ok := hashes[fun]; ok {
+		return h(args)
+	}
+	switch fun {
+	case AddInteger, SubtractInteger, MultiplyInteger:
+		x, y, err := integers(args)
+		if err != nil {
+			return nil, err
+		}
+		switch fun {
+		case AddInteger:
+			return Integer(new(big.Int).Add(x, y)), nil
+		case SubtractInteger:
+			return Integer(new(big.Int).Sub(x, y)), nil
+		}
+		return Integer(new(big.Int).Mul(x, y)), nil
+	case DivideInteger, QuotientInteger, RemainderInteger, ModInteger:
+		x, y, err := integers(args)
+		if err != nil {
+			return nil, err
+		}
+		q, r, err := divMod(x, y, fun == DivideInteger || fun == ModInteger)
+		if err != nil {
+			return nil, err
+		}
+		if fun == DivideInteger || fun == QuotientInteger {
+			return Integer(q), nil
+		}
+		return Integer(r), nil
+	case EqualsInteger, LessThanInteger, LessThanEqualsInteger:
+		x, y, err := integers(args)
+		if err != nil {
+			return nil, err
+		}
+		cmp := x.Cmp(y)
+		return Bool(cmp == 0 && fun != LessThanInteger || cmp < 0 && fun != EqualsInteger), nil
+	case AppendByteString:
+		x, y, err := byteStrings(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return ByteString(append(append([]byte{}, x...), y...)), nil
+	case ConsByteString:
+		n, err := argument[*big.Int](args, 0, TypeInteger)
+		if err != nil {
+			return nil, err
+		}
+		b, err := argument[[]byte](args, 1, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		head, ok := smallInt(n, 0, 255)
+		if !ok {
+			if m.costs.Language >= PlutusV3 {
+				return nil, fmt.Errorf("byte %s out of range", n)
+			}
+			// older languages wrap the integer around
+			head = int(new(big.Int).Mod(n, big.NewInt(256)).Int64())
+		}
+		return ByteString(append([]byte{byte(head)}, b...)), nil
+	case SliceByteString:
+		start, err := argument[*big.Int](args, 0, TypeInteger)
+		if err != nil {
+			return nil, err
+		}
+		n, err := argument[*big.Int](args, 1, TypeInteger)
+		if err != nil {
+			return nil, err
+		}
+		b, err := argument[[]byte](args, 2, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		from := clamp(start, 0, int64(len(b)))
+		to := from + clamp(n, 0, int64(len(b))-from)
+		return ByteString(append([]byte{}, b[from:to]...)), nil
+	case LengthOfByteString:
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		return Integer(big.NewInt(int64(len(b)))), nil
+	case IndexByteString:
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		n, err := argument[*big.Int](args, 1, TypeInteger)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := smallInt(n, 0, int64(len(b))-1)
+		if !ok {
+			return nil, fmt.Errorf("index %s out of bounds", n)
+		}
+		return Integer(big.NewInt(int64(b[i]))), nil
+	case EqualsByteString, LessThanByteString, LessThanEqualsByteString:
+		x, y, err := byteStrings(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		cmp := bytes.Compare(x, y)
+		return Bool(cmp == 0 && fun != LessThanByteString || cmp < 0 && fun != EqualsByteString), nil
+	case VerifyEd25519Signature:
+		key, msg, err := byteStrings(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := argument[[]byte](args, 2, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != ed25519.PublicKeySize || len(sig) != ed25519.SignatureSize {
+			return nil, errors.New("invalid key or signature length")
+		}
+		return Bool(ed25519.Verify(key, msg, sig)), nil
+	case VerifyEcdsaSecp256k1Signature, VerifySchnorrSecp256k1Signature:
+		key, msg, err := byteStrings(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := argument[[]byte](args, 2, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if fun == VerifyEcdsaSecp256k1Signature {
+			ok, err = verifyEcdsa(key, msg, sig)
+		} else {
+			ok, err = verifySchnorr(key, msg, sig)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return Bool(ok), nil
+	case AppendString, EqualsString:
+		x, err := argument[string](args, 0, TypeString)
+		if err != nil {
+			return nil, err
+		}
+		y, err := argument[string](args, 1, TypeString)
+		if err != nil {
+			return nil, err
+		}
+		if fun == EqualsString {
+			return Bool(x == y), nil
+		}
+		return String(x + y), nil
+	case EncodeUtf8:
+		s, err := argument[string](args, 0, TypeString)
+		if err != nil {
+			return nil, err
+		}
+		return ByteString([]byte(s)), nil
+	case DecodeUtf8:
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(b) {
+			return nil, errors.New("invalid utf-8")
+		}
+		return String(string(b)), nil
+	case IfThenElse:
+		cond, err := argument[bool](args, 0, TypeBool)
+		if err != nil {
+			return nil, err
+		}
+		if cond {
+			return args[1], nil
+		}
+		return args[2], nil
+	case ChooseUnit:
+		if !isUnit(args[0]) {
+			return nil, errors.New("argument 1 is not unit")
+		}
+		return args[1], nil
+	case Trace:
+		msg, err := argument[string](args, 0, TypeString)
+		if err != nil {
+			return nil, err
+		}
+		m.Logs = append(m.Logs, msg)
+		return args[1], nil
+	case FstPair, SndPair:
+		c, ok := args[0].(Value)
+		pair, isPair := c.Contents.([2]any)
+		if !ok || c.Type.Tag != TypePair || !isPair {
+			return nil, errors.New("argument 1 is not a pair")
+		}
+		if fun == FstPair {
+			return Value{Type: c.Type.Args[0], Contents: pair[0]}, nil
+		}
+		return Value{Type: c.Type.Args[1], Contents: pair[1]}, nil
+	case ChooseList:
+		_, items, err := list(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 0 {
+			return args[1], nil
+		}
+		return args[2], nil
+	case MkCons:
+		t, items, err := list(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		head, ok := args[0].(Value)
+		if !ok || !head.Type.Equal(t.Args[0]) {
+			return nil, fmt.Errorf("cannot cons onto a %s", t)
+		}
+		return Value{Type: t, Contents: append([]any{head.Contents}, items...)}, nil
+	case HeadList, TailList, NullList:
+		t, items, err := list(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if fun == NullList {
+			return Bool(len(items) == 0), nil
+		}
+		if len(items) == 0 {
+			return nil, errors.New("empty list")
+		}
+		if fun == HeadList {
+			return Value{Type: t.Args[0], Contents: items[0]}, nil
+		}
+		return Value{Type: t, Contents: items[1:]}, nil
+	case ChooseData:
+		pd, err := data(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if _, _, ok := pd.Constr(); ok {
+			return args[1], nil
+		}
+		if _, err := pd.MapEntries(); err == nil {
+			return args[2], nil
+		}
+		if _, ok := pd.List(); ok {
+			return args[3], nil
+		}
+		if _, ok := pd.BigInt(); ok {
+			return args[4], nil
+		}
+		return args[5], nil
+	case ConstrData:
+		n, err := argument[*big.Int](args, 0, TypeInteger)
+		if err != nil {
+			return nil, err
+		}
+		t, items, err := list(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		if !t.Equal(dataListType) {
+			return nil, errors.New("argument 2 is not a list of data")
+		}
+		if n.Sign() < 0 || !n.IsUint64() {
+			return nil, fmt.Errorf("invalid constructor index %s", n)
+		}
+		fields, err := dataList(items)
+		if err != nil {
+			return nil, err
+		}
+		return Data(PlutusData.NewConstr(n.Uint64(), fields...)), nil
+	case MapData:
+		t, items, err := list(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if !t.Equal(ListOf(dataPairType)) {
+			return nil, errors.New("argument 1 is not a list of data pairs")
+		}
+		entries := make([]PlutusData.MapEntry, len(items))
+		for i, item := range items {
+			pair, _ := item.([2]any)
+			key, ok := pair[0].(PlutusData.PlutusData)
+			val, isData := pair[1].(PlutusData.PlutusData)
+			if !ok || !isData {
+				return nil, errors.New("malformed data pair")
+			}
+			entries[i] = PlutusData.MapEntry{Key: key, Value: val}
+		}
+		return Data(PlutusData.NewMap(entries...)), nil
+	case ListData:
+		t, items, err := list(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if !t.Equal(dataListType) {
+			return nil, errors.New("argument 1 is not a list of data")
+		}
+		elems, err := dataList(items)
+		if err != nil {
+			return nil, err
+		}
+		return Data(PlutusData.NewList(elems...)), nil
+	case IData:
+		n, err := argument[*big.Int](args, 0, TypeInteger)
+		if err != nil {
+			return nil, err
+		}
+		return Data(PlutusData.NewBigInt(n)), nil
+	case BData:
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		return Data(PlutusData.NewBytes(b)), nil
+	case UnConstrData:
+		pd, err := data(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		index, fields, ok := pd.Constr()
+		if !ok {
+			return nil, errors.New("not a constructor")
+		}
+		pair := [2]any{new(big.Int).SetUint64(index), dataValues(fields)}
+		return Value{Type: PairOf(Type{Tag: TypeInteger}, dataListType), Contents: pair}, nil
+	case UnMapData:
+		pd, err := data(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := pd.MapEntries()
+		if err != nil {
+			return nil, errors.New("not a map")
+		}
+		pairs := make([]any, len(entries))
+		for i, entry := range entries {
+			pairs[i] = [2]any{entry.Key, entry.Value}
+		}
+		return Value{Type: ListOf(dataPairType), Contents: pairs}, nil
+	case UnListData:
+		pd, err := data(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		items, ok := pd.List()
+		if !ok {
+			return nil, errors.New("not a list")
+		}
+		return Value{Type: dataListType, Contents: dataValues(items)}, nil
+	case UnIData:
+		pd, err := data(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := pd.BigInt()
+		if !ok {
+			return nil, errors.New("not an integer")
+		}
+		return Integer(n), nil
+	case UnBData:
+		pd, err := data(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := pd.Bytes()
+		if !ok {
+			return nil, errors.New("not a byte string")
+		}
+		return ByteString(b), nil
+	case EqualsData:
+		x, err := data(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		y, err := data(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		ex, err := encodeData(x)
+		if err != nil {
+			return nil, err
+		}
+		ey, err := encodeData(y)
+		if err != nil {
+			return nil, err
+		}
+		return Bool(bytes.Equal(ex, ey)), nil
+	case MkPairData:
+		x, err := data(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		y, err := data(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return Value{Type: dataPairType, Contents: [2]any{x, y}}, nil
+	case MkNilData, MkNilPairData:
+		if !isUnit(args[0]) {
+			return nil, errors.New("argument 1 is not unit")
+		}
+		if fun == MkNilData {
+			return Value{Type: dataListType, Contents: []any{}}, nil
+		}
+		return Value{Type: ListOf(dataPairType), Contents: []any{}}, nil
+	case SerialiseData:
+		pd, err := data(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := encodeData(pd)
+		if err != nil {
+			return nil, err
+		}
+		return ByteString(encoded), nil
+	case IntegerToByteString:
+		bigEndian, err := argument[bool](args, 0, TypeBool)
+		if err != nil {
+			return nil, err
+		}
+		width, err := argument[*big.Int](args, 1, TypeInteger)
+		if err != nil {
+			return nil, err
+		}
+		n, err := argument[*big.Int](args, 2, TypeInteger)
+		if err != nil {
+			return nil, err
+		}
+		w, ok := smallInt(width, 0, MAX_BUILTIN_BYTES)
+		if !ok {
+			return nil, fmt.Errorf("invalid width %s", width)
+		}
+		if n.Sign() < 0 {
+			return nil, fmt.Errorf("negative integer %s", n)
+		}
+		b := n.Bytes()
+		if w == 0 && len(b) > MAX_BUILTIN_BYTES || w != 0 && len(b) > w {
+			return nil, fmt.Errorf("%s does not fit in %d bytes", n, w)
+		}
+		if w > len(b) {
+			b = append(make([]byte, w-len(b)), b...)
+		}
+		if !bigEndian {
+			reverse(b)
+		}
+		return ByteString(b), nil
+	case ByteStringToInteger:
+		bigEndian, err := argument[bool](args, 0, TypeBool)
+		if err != nil {
+			return nil, err
+		}
+		b, err := argument[[]byte](args, 1, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		b = append([]byte{}, b...)
+		if !bigEndian {
+			reverse(b)
+		}
+		return Integer(new(big.Int).SetBytes(b)), nil
+	case AndByteString, OrByteString, XorByteString:
+		pad, err := argument[bool](args, 0, TypeBool)
+		if err != nil {
+			return nil, err
+		}
+		x, y, err := byteStrings(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) > len(y) {
+			x, y = y, x
+		}
+		res := append([]byte{}, y...)
+		if !pad {
+			res = res[:len(x)]
+		}
+		for i := range x {
+			switch fun {
+			case AndByteString:
+				res[i] &= x[i]
+			case OrByteString:
+				res[i] |= x[i]
+			default:
+				res[i] ^= x[i]
+			}
+		}
+		return ByteString(res), nil
+	case ComplementByteString:
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		res := make([]byte, len(b))
+		for i := range b {
+			res[i] = ^b[i]
+		}
+		return ByteString(res), nil
+	case ReadBit:
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		n, err := argument[*big.Int](args, 1, TypeInteger)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := smallInt(n, 0, int64(len(b))*8-1)
+		if !ok {
+			return nil, fmt.Errorf("bit index %s out of bounds", n)
+		}
+		return Bool(b[len(b)-1-i/8]>>(i%8)&1 == 1), nil
+	case WriteBits:
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		_, indexes, err := list(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		set, err := argument[bool](args, 2, TypeBool)
+		if err != nil {
+			return nil, err
+		}
+		res := append([]byte{}, b...)
+		for _, index := range indexes {
+			n, isInt := index.(*big.Int)
+			if !isInt {
+				return nil, errors.New("argument 2 is not a list of integers")
+			}
+			i, ok := smallInt(n, 0, int64(len(b))*8-1)
+			if !ok {
+				return nil, fmt.Errorf("bit index %s out of bounds", n)
+			}
+			if set {
+				res[len(b)-1-i/8] |= 1 << (i % 8)
+			} else {
+				res[len(b)-1-i/8] &^= 1 << (i % 8)
+			}
+		}
+		return ByteString(res), nil
+	case ReplicateByte:
+		count, value, err := integers(args)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := smallInt(count, 0, MAX_BUILTIN_BYTES)
+		if !ok {
+			return nil, fmt.Errorf("invalid length %s", count)
+		}
+		v, ok := smallInt(value, 0, 255)
+		if !ok {
+			return nil, fmt.Errorf("byte %s out of range", value)
+		}
+		return ByteString(bytes.Repeat([]byte{byte(v)}, n)), nil
+	case ShiftByteString, RotateByteString:
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		n, err := argument[*big.Int](args, 1, TypeInteger)
+		if err != nil {
+			return nil, err
+		}
+		width := int64(len(b)) * 8
+		if width == 0 {
+			return ByteString([]byte{}), nil
+		}
+		x := new(big.Int).SetBytes(b)
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(width)), big.NewInt(1))
+		var res *big.Int
+		if fun == RotateByteString {
+			k := uint(new(big.Int).Mod(n, big.NewInt(width)).Int64())
+			res = new(big.Int).Lsh(x, k)
+			res.Or(res, new(big.Int).Rsh(x, uint(width)-k))
+		} else {
+			k := clamp(n, -width, width)
+			if k >= 0 {
+				res = new(big.Int).Lsh(x, uint(k))
+			} else {
+				res = new(big.Int).Rsh(x, uint(-k))
+			}
+		}
+		res.And(res, mask)
+		return ByteString(res.FillBytes(make([]byte, len(b)))), nil
+	case CountSetBits:
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		count := 0
+		for _, c := range b {
+			count += bits.OnesCount8(c)
+		}
+		return Integer(big.NewInt(int64(count))), nil
+	case FindFirstSetBit:
+		b, err := argument[[]byte](args, 0, TypeByteString)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(b) - 1; i >= 0; i-- {
+			if b[i] != 0 {
+				index := (len(b)-1-i)*8 + bits.TrailingZeros8(b[i])
+				return Integer(big.NewInt(int64(index))), nil
+			}
+		}
+		return Integer(big.NewInt(-1)), nil
+	}
+	return nil, errors.New("builtin is not supported")
+}
+
+func isUnit(v value) bool {
+	c, ok := v.(Value)
+	return ok && c.Type.Tag == TypeUnit
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
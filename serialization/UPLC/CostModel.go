@@ -0,0 +1,392 @@
+package UPLC
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+)
+
+// ExBudget is an amount of execution units.
+type ExBudget struct {
+	Mem   int64
+	Steps int64
+}
+
+func (b ExBudget) Add(other ExBudget) ExBudget {
+	return ExBudget{Mem: b.Mem + other.Mem, Steps: b.Steps + other.Steps}
+}
+
+func (b ExBudget) Sub(other ExBudget) ExBudget {
+	return ExBudget{Mem: b.Mem - other.Mem, Steps: b.Steps - other.Steps}
+}
+
+// Language is the plutus ledger language a script is run as.
+type Language int
+
+const (
+	PlutusV1 Language = iota + 1
+	PlutusV2
+	PlutusV3
+)
+
+func (l Language) String() string {
+	return fmt.Sprintf("PlutusV%d", int(l))
+}
+
+type stepKind int
+
+const (
+	stepConst stepKind = iota
+	stepVar
+	stepLambda
+	stepApply
+	stepDelay
+	stepForce
+	stepBuiltin
+	stepConstr
+	stepCase
+	stepKinds
+)
+
+var stepNames = [stepKinds]string{
+	"cekConstCost", "cekVarCost", "cekLamCost", "cekApplyCost", "cekDelayCost",
+	"cekForceCost", "cekBuiltinCost", "cekConstrCost", "cekCaseCost",
+}
+
+// costFunction prices a builtin call from the sizes of its arguments.
+type costFunction func(sizes []int64) int64
+
+type builtinCost struct {
+	cpu costFunction
+	mem costFunction
+}
+
+/*
+CostModel prices the steps of the CEK machine and the builtins of a
+language. Builtins without parameters in the model are not available
+to scripts run with it.
+*/
+type CostModel struct {
+	Language Language
+	startup  ExBudget
+	steps    [stepKinds]ExBudget
+	builtins map[DefaultFunction]builtinCost
+}
+
+/*
+CostModelFromParams builds the cost model of language from its
+parameters as found on chain and in ProtocolParameters.CostModels, in
+the order of PlutusData.V1COSTMODELKEYS, V2COSTMODELKEYS or
+V3COSTMODELKEYSUNSORTED.
+*/
+func CostModelFromParams(language Language, params []int) (CostModel, error) {
+	var keys []string
+	switch language {
+	case PlutusV1:
+		keys = PlutusData.V1COSTMODELKEYS
+	case PlutusV2:
+		keys = PlutusData.V2COSTMODELKEYS
+	case PlutusV3:
+		keys = PlutusData.V3COSTMODELKEYSUNSORTED
+	default:
+		return CostModel{}, fmt.Errorf("uplc: unknown language %d", language)
+	}
+	named := make(map[string]int64, len(keys))
+	for i, key := range keys {
+		if i >= len(params) {
+			break
+		}
+		named[key] = int64(params[i])
+	}
+	return NewCostModel(language, named)
+}
+
+// NewCostModel builds the cost model of language from named parameters.
+func NewCostModel(language Language, params map[string]int64) (CostModel, error) {
+	cm := CostModel{Language: language, builtins: make(map[DefaultFunction]builtinCost)}
+	p := costParams(params)
+	var err error
+	if cm.startup, err = p.step("cekStartupCost"); err != nil {
+		return CostModel{}, err
+	}
+	for kind := stepKind(0); kind < stepKinds; kind++ {
+		cm.steps[kind], err = p.step(stepNames[kind])
+		if err != nil && kind != stepConstr && kind != stepCase {
+			return CostModel{}, err
+		}
+	}
+	for fun, shapes := range builtinShapes {
+		name := fun.String()
+		cpu, err := p.function(name+"-cpu-arguments", shapes[0])
+		if err != nil {
+			continue
+		}
+		mem, err := p.function(name+"-memory-arguments", shapes[1])
+		if err != nil {
+			continue
+		}
+		cm.builtins[fun] = builtinCost{cpu: cpu, mem: mem}
+	}
+	return cm, nil
+}
+
+type costShape int
+
+const (
+	constantCost costShape = iota
+	linearInX
+	linearInY
+	linearInZ
+	addedSizes
+	multipliedSizes
+	minSize
+	maxSize
+	subtractedSizes
+	linearOnDiagonal
+	constAboveDiagonal
+	quadraticInY
+	quadraticInZ
+	linearInYAndZ
+	linearInMaxYZ
+	literalInYOrLinearInZ
+)
+
+// Shapes of the cpu and memory costs of each builtin.
+var builtinShapes = map[DefaultFunction][2]costShape{
+	AddInteger:                      {maxSize, maxSize},
+	SubtractInteger:                 {maxSize, maxSize},
+	MultiplyInteger:                 {multipliedSizes, addedSizes},
+	DivideInteger:                   {constAboveDiagonal, subtractedSizes},
+	QuotientInteger:                 {constAboveDiagonal, subtractedSizes},
+	RemainderInteger:                {constAboveDiagonal, subtractedSizes},
+	ModInteger:                      {constAboveDiagonal, subtractedSizes},
+	EqualsInteger:                   {minSize, constantCost},
+	LessThanInteger:                 {minSize, constantCost},
+	LessThanEqualsInteger:           {minSize, constantCost},
+	AppendByteString:                {addedSizes, addedSizes},
+	ConsByteString:                  {linearInY, addedSizes},
+	SliceByteString:                 {linearInZ, linearInZ},
+	LengthOfByteString:              {constantCost, constantCost},
+	IndexByteString:                 {constantCost, constantCost},
+	EqualsByteString:                {linearOnDiagonal, constantCost},
+	LessThanByteString:              {minSize, constantCost},
+	LessThanEqualsByteString:        {minSize, constantCost},
+	Sha2_256:                        {linearInX, constantCost},
+	Sha3_256:                        {linearInX, constantCost},
+	Blake2b_256:                     {linearInX, constantCost},
+	VerifyEd25519Signature:          {linearInY, constantCost},
+	AppendString:                    {addedSizes, addedSizes},
+	EqualsString:                    {linearOnDiagonal, constantCost},
+	EncodeUtf8:                      {linearInX, linearInX},
+	DecodeUtf8:                      {linearInX, linearInX},
+	IfThenElse:                      {constantCost, constantCost},
+	ChooseUnit:                      {constantCost, constantCost},
+	Trace:                           {constantCost, constantCost},
+	FstPair:                         {constantCost, constantCost},
+	SndPair:                         {constantCost, constantCost},
+	ChooseList:                      {constantCost, constantCost},
+	MkCons:                          {constantCost, constantCost},
+	HeadList:                        {constantCost, constantCost},
+	TailList:                        {constantCost, constantCost},
+	NullList:                        {constantCost, constantCost},
+	ChooseData:                      {constantCost, constantCost},
+	ConstrData:                      {constantCost, constantCost},
+	MapData:                         {constantCost, constantCost},
+	ListData:                        {constantCost, constantCost},
+	IData:                           {constantCost, constantCost},
+	BData:                           {constantCost, constantCost},
+	UnConstrData:                    {constantCost, constantCost},
+	UnMapData:                       {constantCost, constantCost},
+	UnListData:                      {constantCost, constantCost},
+	UnIData:                         {constantCost, constantCost},
+	UnBData:                         {constantCost, constantCost},
+	EqualsData:                      {minSize, constantCost},
+	MkPairData:                      {constantCost, constantCost},
+	MkNilData:                       {constantCost, constantCost},
+	MkNilPairData:                   {constantCost, constantCost},
+	SerialiseData:                   {linearInX, linearInX},
+	VerifyEcdsaSecp256k1Signature:   {constantCost, constantCost},
+	VerifySchnorrSecp256k1Signature: {linearInY, constantCost},
+	Keccak_256:                      {linearInX, constantCost},
+	Blake2b_224:                     {linearInX, constantCost},
+	IntegerToByteString:             {quadraticInZ, literalInYOrLinearInZ},
+	ByteStringToInteger:             {quadraticInY, linearInY},
+	AndByteString:                   {linearInYAndZ, linearInMaxYZ},
+	OrByteString:                    {linearInYAndZ, linearInMaxYZ},
+	XorByteString:                   {linearInYAndZ, linearInMaxYZ},
+	ComplementByteString:            {linearInX, linearInX},
+	ReadBit:                         {constantCost, constantCost},
+	WriteBits:                       {linearInY, linearInX},
+	ReplicateByte:                   {linearInX, linearInX},
+	ShiftByteString:                 {linearInX, linearInX},
+	RotateByteString:                {linearInX, linearInX},
+	CountSetBits:                    {linearInX, constantCost},
+	FindFirstSetBit:                 {linearInX, constantCost},
+	Ripemd_160:                      {linearInX, constantCost},
+}
+
+type costParams map[string]int64
+
+func (p costParams) get(key string) (int64, error) {
+	v, ok := p[key]
+	if !ok {
+		return 0, fmt.Errorf("uplc: missing cost model parameter %s", key)
+	}
+	return v, nil
+}
+
+func (p costParams) has(prefix string) bool {
+	for key := range p {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p costParams) step(name string) (ExBudget, error) {
+	cpu, err := p.get(name + "-exBudgetCPU")
+	if err != nil {
+		return ExBudget{}, err
+	}
+	mem, err := p.get(name + "-exBudgetMemory")
+	if err != nil {
+		return ExBudget{}, err
+	}
+	return ExBudget{Mem: mem, Steps: cpu}, nil
+}
+
+// linear reads prefix-intercept and prefix-slope.
+func (p costParams) linear(prefix string) (int64, int64, error) {
+	intercept, err := p.get(prefix + "-intercept")
+	if err != nil {
+		return 0, 0, err
+	}
+	slope, err := p.get(prefix + "-slope")
+	return intercept, slope, err
+}
+
+func (p costParams) function(prefix string, shape costShape) (costFunction, error) {
+	switch shape {
+	case constantCost:
+		c, err := p.get(prefix)
+		return func([]int64) int64 { return c }, err
+	case linearInX, linearInY, linearInZ:
+		arg := int(shape - linearInX)
+		intercept, slope, err := p.linear(prefix)
+		return func(s []int64) int64 { return intercept + slope*s[arg] }, err
+	case addedSizes, multipliedSizes, minSize, maxSize:
+		intercept, slope, err := p.linear(prefix)
+		return func(s []int64) int64 {
+			var size int64
+			switch shape {
+			case addedSizes:
+				size = s[0] + s[1]
+			case multipliedSizes:
+				size = s[0] * s[1]
+			case minSize:
+				size = min(s[0], s[1])
+			default:
+				size = max(s[0], s[1])
+			}
+			return intercept + slope*size
+		}, err
+	case subtractedSizes:
+		if !p.has(prefix + "-minimum") {
+			// remainder and mod are linear in the divisor since plutus v3
+			return p.function(prefix, linearInY)
+		}
+		intercept, slope, err := p.linear(prefix)
+		if err != nil {
+			return nil, err
+		}
+		minimum, err := p.get(prefix + "-minimum")
+		return func(s []int64) int64 { return intercept + slope*max(minimum, s[0]-s[1]) }, err
+	case linearOnDiagonal:
+		intercept, slope, err := p.linear(prefix)
+		if err != nil {
+			return nil, err
+		}
+		c, err := p.get(prefix + "-constant")
+		return func(s []int64) int64 {
+			if s[0] == s[1] {
+				return intercept + slope*s[0]
+			}
+			return c
+		}, err
+	case constAboveDiagonal:
+		c, err := p.get(prefix + "-constant")
+		if err != nil {
+			return nil, err
+		}
+		var model costFunction
+		if p.has(prefix + "-model-arguments-c00") {
+			model, err = p.quadraticInXAndY(prefix + "-model-arguments")
+		} else {
+			model, err = p.function(prefix+"-model-arguments", multipliedSizes)
+		}
+		return func(s []int64) int64 {
+			if s[0] < s[1] {
+				return c
+			}
+			return model(s)
+		}, err
+	case quadraticInY, quadraticInZ:
+		arg := 1
+		if shape == quadraticInZ {
+			arg = 2
+		}
+		var c [3]int64
+		for i := range c {
+			v, err := p.get(fmt.Sprintf("%s-c%d", prefix, i))
+			if err != nil {
+				return nil, err
+			}
+			c[i] = v
+		}
+		return func(s []int64) int64 { return c[0] + c[1]*s[arg] + c[2]*s[arg]*s[arg] }, nil
+	case linearInYAndZ:
+		intercept, err := p.get(prefix + "-intercept")
+		if err != nil {
+			return nil, err
+		}
+		slope1, err := p.get(prefix + "-slope1")
+		if err != nil {
+			return nil, err
+		}
+		slope2, err := p.get(prefix + "-slope2")
+		return func(s []int64) int64 { return intercept + slope1*s[1] + slope2*s[2] }, err
+	case linearInMaxYZ:
+		intercept, slope, err := p.linear(prefix)
+		return func(s []int64) int64 { return intercept + slope*max(s[1], s[2]) }, err
+	case literalInYOrLinearInZ:
+		intercept, slope, err := p.linear(prefix)
+		return func(s []int64) int64 {
+			if s[1] == 0 {
+				return intercept + slope*s[2]
+			}
+			return s[1]
+		}, err
+	}
+	return nil, fmt.Errorf("uplc: unknown cost shape %d", shape)
+}
+
+func (p costParams) quadraticInXAndY(prefix string) (costFunction, error) {
+	var c [6]int64
+	for i, suffix := range []string{"c00", "c10", "c01", "c20", "c11", "c02"} {
+		v, err := p.get(prefix + "-" + suffix)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = v
+	}
+	minimum, err := p.get(prefix + "-minimum")
+	if err != nil {
+		return nil, err
+	}
+	return func(s []int64) int64 {
+		x, y := s[0], s[1]
+		return max(minimum, c[0]+c[1]*x+c[2]*y+c[3]*x*x+c[4]*x*y+c[5]*y*y)
+	}, nil
+}
@@ -0,0 +1,231 @@
+package UPLC
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+)
+
+// Memory sizes of the bls12_381 elements, in words.
+const (
+	G1_ELEMENT_SIZE = 18
+	G2_ELEMENT_SIZE = 36
+	ML_RESULT_SIZE  = 72
+)
+
+func integerSize(n *big.Int) int64 {
+	if n.Sign() == 0 {
+		return 1
+	}
+	return int64((n.BitLen()-1)/64 + 1)
+}
+
+func bytesSize(b []byte) int64 {
+	if len(b) == 0 {
+		return 1
+	}
+	return int64((len(b)-1)/8 + 1)
+}
+
+// constantSize is the memory size of a constant, in 64 bit words.
+func constantSize(t Type, contents any) int64 {
+	switch t.Tag {
+	case TypeInteger:
+		if n, ok := contents.(*big.Int); ok {
+			return integerSize(n)
+		}
+	case TypeByteString:
+		if b, ok := contents.([]byte); ok {
+			return bytesSize(b)
+		}
+	case TypeString:
+		if s, ok := contents.(string); ok {
+			return int64(utf8.RuneCountInString(s))
+		}
+	case TypeList:
+		var size int64
+		items, _ := contents.([]any)
+		for _, item := range items {
+			size += constantSize(t.Args[0], item)
+		}
+		return size
+	case TypePair:
+		if pair, ok := contents.([2]any); ok {
+			return 1 + constantSize(t.Args[0], pair[0]) + constantSize(t.Args[1], pair[1])
+		}
+	case TypeData:
+		if pd, ok := contents.(PlutusData.PlutusData); ok {
+			return dataSize(pd)
+		}
+	case TypeBls12_381_G1:
+		return G1_ELEMENT_SIZE
+	case TypeBls12_381_G2:
+		return G2_ELEMENT_SIZE
+	case TypeBls12_381_MlResult:
+		return ML_RESULT_SIZE
+	}
+	return 1
+}
+
+func dataSize(pd PlutusData.PlutusData) int64 {
+	size := int64(4)
+	if _, fields, ok := pd.Constr(); ok {
+		for _, field := range fields {
+			size += dataSize(field)
+		}
+	} else if n, ok := pd.BigInt(); ok {
+		size += integerSize(n)
+	} else if b, ok := pd.Bytes(); ok {
+		size += bytesSize(b)
+	} else if items, ok := pd.List(); ok {
+		for _, item := range items {
+			size += dataSize(item)
+		}
+	} else if entries, err := pd.MapEntries(); err == nil {
+		for _, entry := range entries {
+			size += dataSize(entry.Key) + dataSize(entry.Value)
+		}
+	}
+	return size
+}
+
+func sizeOf(v value) int64 {
+	if c, ok := v.(Value); ok {
+		return constantSize(c.Type, c.Contents)
+	}
+	return 1
+}
+
+// wordsOfBytes sizes an integer counting bytes as the words they fill.
+func wordsOfBytes(v value) int64 {
+	n, ok := constantContents[*big.Int](v)
+	if !ok || n.Sign() <= 0 {
+		return 0
+	}
+	if !n.IsInt64() {
+		return math.MaxInt64
+	}
+	return (n.Int64()-1)/8 + 1
+}
+
+// builtinSizes are the sizes the cost of fun is computed from.
+func builtinSizes(fun DefaultFunction, args []value) []int64 {
+	sizes := make([]int64, len(args))
+	for i, arg := range args {
+		sizes[i] = sizeOf(arg)
+	}
+	switch fun {
+	case IntegerToByteString:
+		sizes[1] = wordsOfBytes(args[1])
+	case ReplicateByte:
+		sizes[0] = wordsOfBytes(args[0])
+	case ShiftByteString, RotateByteString:
+		if n, ok := constantContents[*big.Int](args[1]); ok {
+			abs := new(big.Int).Abs(n)
+			sizes[1] = math.MaxInt64
+			if abs.IsInt64() {
+				sizes[1] = abs.Int64()
+			}
+		}
+	case WriteBits:
+		if items, ok := constantContents[[]any](args[1]); ok {
+			sizes[1] = int64(len(items))
+		}
+	}
+	return sizes
+}
+
+func constantContents[T any](v value) (T, bool) {
+	var zero T
+	c, ok := v.(Value)
+	if !ok {
+		return zero, false
+	}
+	contents, ok := c.Contents.(T)
+	return contents, ok
+}
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= math.MaxUint8:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+	return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, n)
+}
+
+func encodeDataList(items []PlutusData.PlutusData) ([]byte, error) {
+	if len(items) == 0 {
+		return []byte{0x80}, nil
+	}
+	res := []byte{0x9f}
+	for _, item := range items {
+		encoded, err := encodeData(item)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, encoded...)
+	}
+	return append(res, 0xff), nil
+}
+
+/*
+encodeData encodes pd the way the ledger does, which serialiseData
+returns and equalsData compares: lists are indefinite unless empty and
+bytes longer than 64 are chunked.
+*/
+func encodeData(pd PlutusData.PlutusData) ([]byte, error) {
+	if index, fields, ok := pd.Constr(); ok {
+		var res []byte
+		switch {
+		case index < 7:
+			res = cborHead(6, PlutusData.CONSTR_TAG_BASE+index)
+		case index < 128:
+			res = cborHead(6, PlutusData.CONSTR_TAG_EXTENDED_BASE+index-7)
+		default:
+			res = append(cborHead(6, PlutusData.CONSTR_TAG_GENERAL), 0x82)
+			res = append(res, cborHead(0, index)...)
+		}
+		list, err := encodeDataList(fields)
+		if err != nil {
+			return nil, err
+		}
+		return append(res, list...), nil
+	}
+	if n, ok := pd.BigInt(); ok {
+		return serialization.MarshalBigInt(n), nil
+	}
+	if b, ok := pd.Bytes(); ok {
+		return serialization.MarshalBoundedBytes(b), nil
+	}
+	if items, ok := pd.List(); ok {
+		return encodeDataList(items)
+	}
+	entries, err := pd.MapEntries()
+	if err != nil {
+		return nil, fmt.Errorf("uplc: invalid plutus data %v", pd)
+	}
+	res := cborHead(5, uint64(len(entries)))
+	for _, entry := range entries {
+		key, err := encodeData(entry.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := encodeData(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		res = append(append(res, key...), value...)
+	}
+	return res, nil
+}
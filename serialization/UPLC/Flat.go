@@ -0,0 +1,639 @@
+package UPLC
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+const (
+	TERM_TAG_BITS    = 4
+	TYPE_TAG_BITS    = 4
+	BUILTIN_TAG_BITS = 7
+)
+
+const (
+	termVar byte = iota
+	termDelay
+	termLambda
+	termApply
+	termConstant
+	termForce
+	termError
+	termBuiltin
+	termConstr
+	termCase
+)
+
+var ErrUnexpectedEnd = errors.New("uplc: unexpected end of flat data")
+
+/*
+DecodeScript decodes the program of a plutus script. Scripts usually
+hold the flat encoded program wrapped in one cbor byte string, like the
+compiled code of a blueprint, some tools wrap it twice. The number of
+wrappings is returned so EncodeScript can keep it.
+*/
+func DecodeScript(script []byte) (Program, int, error) {
+	wrapping := 0
+	for {
+		var inner []byte
+		if err := cbor.Unmarshal(script, &inner); err != nil {
+			break
+		}
+		script = inner
+		wrapping++
+	}
+	if wrapping == 0 {
+		return Program{}, 0, errors.New("uplc: script is not a cbor byte string")
+	}
+	program, err := Decode(script)
+	return program, wrapping, err
+}
+
+// EncodeScript flat encodes program and wraps it in wrapping cbor byte
+// strings.
+func EncodeScript(program Program, wrapping int) ([]byte, error) {
+	res, err := Encode(program)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < wrapping; i++ {
+		res, err = cbor.Marshal(res)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+type flatReader struct {
+	data []byte
+	pos  int // in bits
+}
+
+func (r *flatReader) bit() (bool, error) {
+	if r.pos >= len(r.data)*8 {
+		return false, ErrUnexpectedEnd
+	}
+	b := r.data[r.pos/8]>>(7-r.pos%8)&1 == 1
+	r.pos++
+	return b, nil
+}
+
+func (r *flatReader) bits(n int) (byte, error) {
+	var res byte
+	for i := 0; i < n; i++ {
+		b, err := r.bit()
+		if err != nil {
+			return 0, err
+		}
+		res <<= 1
+		if b {
+			res |= 1
+		}
+	}
+	return res, nil
+}
+
+// filler skips the zero bits and the final one bit up to a byte
+// boundary.
+func (r *flatReader) filler() error {
+	for {
+		b, err := r.bit()
+		if err != nil {
+			return err
+		}
+		if b {
+			break
+		}
+	}
+	if r.pos%8 != 0 {
+		return errors.New("uplc: invalid filler")
+	}
+	return nil
+}
+
+func (r *flatReader) natural() (*big.Int, error) {
+	res := new(big.Int)
+	shift := uint(0)
+	for {
+		group, err := r.bits(8)
+		if err != nil {
+			return nil, err
+		}
+		chunk := new(big.Int).SetUint64(uint64(group & 0x7f))
+		res.Or(res, chunk.Lsh(chunk, shift))
+		shift += 7
+		if group&0x80 == 0 {
+			return res, nil
+		}
+	}
+}
+
+func (r *flatReader) word64() (uint64, error) {
+	n, err := r.natural()
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsUint64() {
+		return 0, fmt.Errorf("uplc: %s does not fit in 64 bits", n)
+	}
+	return n.Uint64(), nil
+}
+
+func (r *flatReader) integer() (*big.Int, error) {
+	n, err := r.natural()
+	if err != nil {
+		return nil, err
+	}
+	// zigzag encoding
+	if n.Bit(0) == 0 {
+		return n.Rsh(n, 1), nil
+	}
+	n.Rsh(n, 1)
+	return n.Neg(n).Sub(n, big.NewInt(1)), nil
+}
+
+func (r *flatReader) byteString() ([]byte, error) {
+	if err := r.filler(); err != nil {
+		return nil, err
+	}
+	res := make([]byte, 0)
+	for {
+		if r.pos/8 >= len(r.data) {
+			return nil, ErrUnexpectedEnd
+		}
+		size := int(r.data[r.pos/8])
+		r.pos += 8
+		if size == 0 {
+			return res, nil
+		}
+		if r.pos/8+size > len(r.data) {
+			return nil, ErrUnexpectedEnd
+		}
+		res = append(res, r.data[r.pos/8:r.pos/8+size]...)
+		r.pos += size * 8
+	}
+}
+
+// list reads the elements of a flat list, each one preceded by a one
+// bit and the list ended by a zero bit.
+func (r *flatReader) list(element func() error) error {
+	for {
+		more, err := r.bit()
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+		if err := element(); err != nil {
+			return err
+		}
+	}
+}
+
+// Decode decodes a flat encoded program.
+func Decode(data []byte) (Program, error) {
+	r := &flatReader{data: data}
+	var program Program
+	for i := range program.Version {
+		v, err := r.word64()
+		if err != nil {
+			return Program{}, err
+		}
+		program.Version[i] = v
+	}
+	term, err := r.term()
+	if err != nil {
+		return Program{}, err
+	}
+	program.Term = term
+	if err := r.filler(); err != nil {
+		return Program{}, err
+	}
+	if r.pos != len(data)*8 {
+		return Program{}, errors.New("uplc: trailing data after program")
+	}
+	return program, nil
+}
+
+func (r *flatReader) terms() ([]Term, error) {
+	res := make([]Term, 0)
+	err := r.list(func() error {
+		term, err := r.term()
+		if err != nil {
+			return err
+		}
+		res = append(res, term)
+		return nil
+	})
+	return res, err
+}
+
+func (r *flatReader) term() (Term, error) {
+	tag, err := r.bits(TERM_TAG_BITS)
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case termVar:
+		index, err := r.word64()
+		if err != nil {
+			return nil, err
+		}
+		return Var{Index: index}, nil
+	case termDelay:
+		term, err := r.term()
+		if err != nil {
+			return nil, err
+		}
+		return Delay{Term: term}, nil
+	case termLambda:
+		body, err := r.term()
+		if err != nil {
+			return nil, err
+		}
+		return Lambda{Body: body}, nil
+	case termApply:
+		function, err := r.term()
+		if err != nil {
+			return nil, err
+		}
+		argument, err := r.term()
+		if err != nil {
+			return nil, err
+		}
+		return Apply{Function: function, Argument: argument}, nil
+	case termConstant:
+		typ, err := r.constantType()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := r.constant(typ)
+		if err != nil {
+			return nil, err
+		}
+		return Constant{Value: Value{Type: typ, Contents: contents}}, nil
+	case termForce:
+		term, err := r.term()
+		if err != nil {
+			return nil, err
+		}
+		return Force{Term: term}, nil
+	case termError:
+		return Error{}, nil
+	case termBuiltin:
+		fun, err := r.bits(BUILTIN_TAG_BITS)
+		if err != nil {
+			return nil, err
+		}
+		if int(fun) >= len(builtinNames) {
+			return nil, fmt.Errorf("uplc: unknown builtin %d", fun)
+		}
+		return Builtin{Fun: DefaultFunction(fun)}, nil
+	case termConstr:
+		constrTag, err := r.word64()
+		if err != nil {
+			return nil, err
+		}
+		fields, err := r.terms()
+		if err != nil {
+			return nil, err
+		}
+		return Constr{Tag: constrTag, Fields: fields}, nil
+	case termCase:
+		scrutinee, err := r.term()
+		if err != nil {
+			return nil, err
+		}
+		branches, err := r.terms()
+		if err != nil {
+			return nil, err
+		}
+		return Case{Scrutinee: scrutinee, Branches: branches}, nil
+	}
+	return nil, fmt.Errorf("uplc: unknown term tag %d", tag)
+}
+
+// constantType reads the list of type tags of a constant and parses it.
+func (r *flatReader) constantType() (Type, error) {
+	tags := make([]TypeTag, 0)
+	err := r.list(func() error {
+		tag, err := r.bits(TYPE_TAG_BITS)
+		tags = append(tags, TypeTag(tag))
+		return err
+	})
+	if err != nil {
+		return Type{}, err
+	}
+	typ, rest, err := parseType(tags)
+	if err != nil {
+		return Type{}, err
+	}
+	if len(rest) != 0 {
+		return Type{}, errors.New("uplc: invalid constant type")
+	}
+	return typ, nil
+}
+
+func parseType(tags []TypeTag) (Type, []TypeTag, error) {
+	if len(tags) == 0 {
+		return Type{}, nil, errors.New("uplc: invalid constant type")
+	}
+	switch tags[0] {
+	case TypeInteger, TypeByteString, TypeString, TypeUnit, TypeBool, TypeData,
+		TypeBls12_381_G1, TypeBls12_381_G2, TypeBls12_381_MlResult:
+		return Type{Tag: tags[0]}, tags[1:], nil
+	case typeApplication:
+		if len(tags) > 1 && tags[1] == TypeList {
+			elem, rest, err := parseType(tags[2:])
+			return ListOf(elem), rest, err
+		}
+		if len(tags) > 2 && tags[1] == typeApplication && tags[2] == TypePair {
+			first, rest, err := parseType(tags[3:])
+			if err != nil {
+				return Type{}, nil, err
+			}
+			second, rest, err := parseType(rest)
+			return PairOf(first, second), rest, err
+		}
+	}
+	return Type{}, nil, fmt.Errorf("uplc: invalid constant type tag %d", tags[0])
+}
+
+func (r *flatReader) constant(typ Type) (any, error) {
+	switch typ.Tag {
+	case TypeInteger:
+		return r.integer()
+	case TypeByteString:
+		return r.byteString()
+	case TypeString:
+		b, err := r.byteString()
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(b) {
+			return nil, errors.New("uplc: invalid utf8 string constant")
+		}
+		return string(b), nil
+	case TypeUnit:
+		return nil, nil
+	case TypeBool:
+		return r.bit()
+	case TypeList:
+		res := make([]any, 0)
+		err := r.list(func() error {
+			el, err := r.constant(typ.Args[0])
+			res = append(res, el)
+			return err
+		})
+		return res, err
+	case TypePair:
+		first, err := r.constant(typ.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		second, err := r.constant(typ.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		return [2]any{first, second}, nil
+	case TypeData:
+		b, err := r.byteString()
+		if err != nil {
+			return nil, err
+		}
+		var pd PlutusData.PlutusData
+		if err := cbor.Unmarshal(b, &pd); err != nil {
+			return nil, err
+		}
+		return pd, nil
+	}
+	return nil, fmt.Errorf("uplc: %s constants have no flat encoding", typ)
+}
+
+type flatWriter struct {
+	data    []byte
+	current byte
+	used    int // bits used in current
+}
+
+func (w *flatWriter) bit(b bool) {
+	w.current <<= 1
+	if b {
+		w.current |= 1
+	}
+	w.used++
+	if w.used == 8 {
+		w.data = append(w.data, w.current)
+		w.current, w.used = 0, 0
+	}
+}
+
+func (w *flatWriter) bits(n int, value byte) {
+	for i := n - 1; i >= 0; i-- {
+		w.bit(value>>i&1 == 1)
+	}
+}
+
+func (w *flatWriter) filler() {
+	for w.used != 7 {
+		w.bit(false)
+	}
+	w.bit(true)
+}
+
+func (w *flatWriter) natural(n *big.Int) {
+	n = new(big.Int).Set(n)
+	mask := big.NewInt(0x7f)
+	for {
+		group := byte(new(big.Int).And(n, mask).Uint64())
+		n.Rsh(n, 7)
+		if n.Sign() != 0 {
+			group |= 0x80
+		}
+		w.bits(8, group)
+		if n.Sign() == 0 {
+			return
+		}
+	}
+}
+
+func (w *flatWriter) word64(n uint64) {
+	w.natural(new(big.Int).SetUint64(n))
+}
+
+func (w *flatWriter) integer(n *big.Int) {
+	zigzag := new(big.Int).Lsh(n, 1)
+	if n.Sign() < 0 {
+		zigzag.Neg(zigzag).Sub(zigzag, big.NewInt(1))
+	}
+	w.natural(zigzag)
+}
+
+func (w *flatWriter) byteString(b []byte) {
+	w.filler()
+	for len(b) > 0 {
+		size := min(len(b), 255)
+		w.data = append(w.data, byte(size))
+		w.data = append(w.data, b[:size]...)
+		b = b[size:]
+	}
+	w.data = append(w.data, 0)
+}
+
+// Encode flat encodes program.
+func Encode(program Program) ([]byte, error) {
+	w := &flatWriter{}
+	for _, v := range program.Version {
+		w.word64(v)
+	}
+	if err := w.term(program.Term); err != nil {
+		return nil, err
+	}
+	w.filler()
+	return w.data, nil
+}
+
+func (w *flatWriter) terms(terms []Term) error {
+	for _, term := range terms {
+		w.bit(true)
+		if err := w.term(term); err != nil {
+			return err
+		}
+	}
+	w.bit(false)
+	return nil
+}
+
+func (w *flatWriter) term(term Term) error {
+	switch t := term.(type) {
+	case Var:
+		w.bits(TERM_TAG_BITS, termVar)
+		w.word64(t.Index)
+	case Delay:
+		w.bits(TERM_TAG_BITS, termDelay)
+		return w.term(t.Term)
+	case Lambda:
+		w.bits(TERM_TAG_BITS, termLambda)
+		return w.term(t.Body)
+	case Apply:
+		w.bits(TERM_TAG_BITS, termApply)
+		if err := w.term(t.Function); err != nil {
+			return err
+		}
+		return w.term(t.Argument)
+	case Constant:
+		w.bits(TERM_TAG_BITS, termConstant)
+		for _, tag := range typeTags(t.Value.Type) {
+			w.bit(true)
+			w.bits(TYPE_TAG_BITS, byte(tag))
+		}
+		w.bit(false)
+		return w.constant(t.Value.Type, t.Value.Contents)
+	case Force:
+		w.bits(TERM_TAG_BITS, termForce)
+		return w.term(t.Term)
+	case Error:
+		w.bits(TERM_TAG_BITS, termError)
+	case Builtin:
+		w.bits(TERM_TAG_BITS, termBuiltin)
+		w.bits(BUILTIN_TAG_BITS, byte(t.Fun))
+	case Constr:
+		w.bits(TERM_TAG_BITS, termConstr)
+		w.word64(t.Tag)
+		return w.terms(t.Fields)
+	case Case:
+		w.bits(TERM_TAG_BITS, termCase)
+		if err := w.term(t.Scrutinee); err != nil {
+			return err
+		}
+		return w.terms(t.Branches)
+	default:
+		return fmt.Errorf("uplc: cannot encode term %T", term)
+	}
+	return nil
+}
+
+func typeTags(t Type) []TypeTag {
+	switch t.Tag {
+	case TypeList:
+		return append([]TypeTag{typeApplication, TypeList}, typeTags(t.Args[0])...)
+	case TypePair:
+		tags := []TypeTag{typeApplication, typeApplication, TypePair}
+		tags = append(tags, typeTags(t.Args[0])...)
+		return append(tags, typeTags(t.Args[1])...)
+	}
+	return []TypeTag{t.Tag}
+}
+
+func (w *flatWriter) constant(typ Type, contents any) error {
+	invalid := fmt.Errorf("uplc: invalid %s constant %v", typ, contents)
+	switch typ.Tag {
+	case TypeInteger:
+		n, ok := contents.(*big.Int)
+		if !ok {
+			return invalid
+		}
+		w.integer(n)
+	case TypeByteString:
+		b, ok := contents.([]byte)
+		if !ok {
+			return invalid
+		}
+		w.byteString(b)
+	case TypeString:
+		s, ok := contents.(string)
+		if !ok {
+			return invalid
+		}
+		w.byteString([]byte(s))
+	case TypeUnit:
+	case TypeBool:
+		b, ok := contents.(bool)
+		if !ok {
+			return invalid
+		}
+		w.bit(b)
+	case TypeList:
+		elements, ok := contents.([]any)
+		if !ok {
+			return invalid
+		}
+		for _, el := range elements {
+			w.bit(true)
+			if err := w.constant(typ.Args[0], el); err != nil {
+				return err
+			}
+		}
+		w.bit(false)
+	case TypePair:
+		pair, ok := contents.([2]any)
+		if !ok {
+			return invalid
+		}
+		if err := w.constant(typ.Args[0], pair[0]); err != nil {
+			return err
+		}
+		return w.constant(typ.Args[1], pair[1])
+	case TypeData:
+		pd, ok := contents.(PlutusData.PlutusData)
+		if !ok {
+			return invalid
+		}
+		encoded, err := cbor.Marshal(&pd)
+		if err != nil {
+			return err
+		}
+		w.byteString(encoded)
+	default:
+		return fmt.Errorf("uplc: %s constants have no flat encoding", typ)
+	}
+	return nil
+}
@@ -0,0 +1,359 @@
+package UPLC
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrOutOfBudget  = errors.New("uplc: out of budget")
+	ErrErrorTerm    = errors.New("uplc: error term evaluated")
+	ErrFreeVariable = errors.New("uplc: free variable")
+)
+
+// value is the result of evaluating a term: a constant Value, a
+// closure, a partially applied builtin or a constructor.
+type value interface {
+	isValue()
+}
+
+type vDelay struct {
+	body Term
+	env  *env
+}
+
+type vLambda struct {
+	body Term
+	env  *env
+}
+
+type vBuiltin struct {
+	fun    DefaultFunction
+	forces int
+	args   []value
+}
+
+type vConstr struct {
+	tag    uint64
+	fields []value
+}
+
+func (Value) isValue()    {}
+func (vDelay) isValue()   {}
+func (vLambda) isValue()  {}
+func (vBuiltin) isValue() {}
+func (vConstr) isValue()  {}
+
+// env binds the de Bruijn indices of a term, the innermost binder first.
+type env struct {
+	value value
+	next  *env
+}
+
+func (e *env) lookup(index uint64) (value, bool) {
+	for ; e != nil && index > 0; e = e.next {
+		if index == 1 {
+			return e.value, true
+		}
+		index--
+	}
+	return nil, false
+}
+
+// Continuation frames of the machine.
+type (
+	frameAwaitArg struct {
+		fun value
+	}
+	frameAwaitFunTerm struct {
+		env *env
+		arg Term
+	}
+	frameAwaitFunValue struct {
+		arg value
+	}
+	frameForce  struct{}
+	frameConstr struct {
+		env    *env
+		tag    uint64
+		fields []Term
+		done   []value
+	}
+	frameCases struct {
+		env      *env
+		branches []Term
+	}
+)
+
+/*
+Machine is a CEK machine evaluating programs under a cost model. It
+stops with ErrOutOfBudget as soon as evaluation costs more than the
+budget it was given.
+*/
+type Machine struct {
+	costs  CostModel
+	budget ExBudget
+	spent  ExBudget
+	// Logs holds the messages of the trace builtin, in order.
+	Logs []string
+}
+
+func NewMachine(costs CostModel, budget ExBudget) *Machine {
+	return &Machine{costs: costs, budget: budget}
+}
+
+// Consumed returns the execution units spent so far.
+func (m *Machine) Consumed() ExBudget {
+	return m.spent
+}
+
+func (m *Machine) spend(cost ExBudget) error {
+	m.spent = m.spent.Add(cost)
+	if m.spent.Mem > m.budget.Mem || m.spent.Steps > m.budget.Steps {
+		return ErrOutOfBudget
+	}
+	return nil
+}
+
+func (m *Machine) step(kind stepKind) error {
+	return m.spend(m.costs.steps[kind])
+}
+
+// Run evaluates the program and returns the term it reduces to.
+func (m *Machine) Run(program Program) (Term, error) {
+	// constr and case came with plutus core 1.1.0
+	sums := program.Version[0] > 1 || program.Version[0] == 1 && program.Version[1] >= 1
+	if err := m.spend(m.costs.startup); err != nil {
+		return nil, err
+	}
+	var (
+		stack     []any
+		current   *env
+		term      = program.Term
+		result    value
+		computing = true
+	)
+	push := func(f any) {
+		stack = append(stack, f)
+	}
+	for {
+		if computing {
+			var err error
+			switch t := term.(type) {
+			case Var:
+				if err = m.step(stepVar); err != nil {
+					return nil, err
+				}
+				v, ok := current.lookup(t.Index)
+				if !ok {
+					return nil, ErrFreeVariable
+				}
+				result, computing = v, false
+			case Delay:
+				err = m.step(stepDelay)
+				result, computing = vDelay{body: t.Term, env: current}, false
+			case Lambda:
+				err = m.step(stepLambda)
+				result, computing = vLambda{body: t.Body, env: current}, false
+			case Apply:
+				err = m.step(stepApply)
+				push(frameAwaitFunTerm{env: current, arg: t.Argument})
+				term = t.Function
+			case Constant:
+				err = m.step(stepConst)
+				result, computing = t.Value, false
+			case Force:
+				err = m.step(stepForce)
+				push(frameForce{})
+				term = t.Term
+			case Error:
+				return nil, ErrErrorTerm
+			case Builtin:
+				if _, ok := m.costs.builtins[t.Fun]; !ok {
+					return nil, fmt.Errorf("uplc: builtin %s is not available in %s", t.Fun, m.costs.Language)
+				}
+				err = m.step(stepBuiltin)
+				result, computing = vBuiltin{fun: t.Fun}, false
+			case Constr:
+				if !sums {
+					return nil, fmt.Errorf("uplc: constr needs plutus core 1.1.0")
+				}
+				err = m.step(stepConstr)
+				if len(t.Fields) == 0 {
+					result, computing = vConstr{tag: t.Tag}, false
+				} else {
+					push(frameConstr{env: current, tag: t.Tag, fields: t.Fields[1:]})
+					term = t.Fields[0]
+				}
+			case Case:
+				if !sums {
+					return nil, fmt.Errorf("uplc: case needs plutus core 1.1.0")
+				}
+				err = m.step(stepCase)
+				push(frameCases{env: current, branches: t.Branches})
+				term = t.Scrutinee
+			default:
+				return nil, fmt.Errorf("uplc: unknown term %T", term)
+			}
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if len(stack) == 0 {
+			return discharge(result), nil
+		}
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		var err error
+		switch f := top.(type) {
+		case frameAwaitFunTerm:
+			push(frameAwaitArg{fun: result})
+			current, term, computing = f.env, f.arg, true
+		case frameAwaitArg:
+			current, term, result, computing, err = m.apply(f.fun, result)
+		case frameAwaitFunValue:
+			current, term, result, computing, err = m.apply(result, f.arg)
+		case frameForce:
+			current, term, result, computing, err = m.force(result)
+		case frameConstr:
+			done := append(append([]value{}, f.done...), result)
+			if len(f.fields) == 0 {
+				result = vConstr{tag: f.tag, fields: done}
+			} else {
+				push(frameConstr{env: f.env, tag: f.tag, fields: f.fields[1:], done: done})
+				current, term, computing = f.env, f.fields[0], true
+			}
+		case frameCases:
+			c, ok := result.(vConstr)
+			if !ok {
+				return nil, fmt.Errorf("uplc: case on a non constructor value")
+			}
+			if c.tag >= uint64(len(f.branches)) {
+				return nil, fmt.Errorf("uplc: no branch for constructor %d", c.tag)
+			}
+			for i := len(c.fields) - 1; i >= 0; i-- {
+				push(frameAwaitFunValue{arg: c.fields[i]})
+			}
+			current, term, computing = f.env, f.branches[c.tag], true
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// apply returns the state of the machine after applying fun to arg,
+// either a term to compute or a value to return.
+func (m *Machine) apply(fun value, arg value) (*env, Term, value, bool, error) {
+	switch f := fun.(type) {
+	case vLambda:
+		return &env{value: arg, next: f.env}, f.body, nil, true, nil
+	case vBuiltin:
+		sig := builtinSignatures[f.fun]
+		if f.forces < sig.forces {
+			return nil, nil, nil, false, fmt.Errorf("uplc: %s applied before being forced", f.fun)
+		}
+		if len(f.args) >= sig.arity {
+			return nil, nil, nil, false, fmt.Errorf("uplc: %s applied to too many arguments", f.fun)
+		}
+		args := append(append([]value{}, f.args...), arg)
+		if len(args) < sig.arity {
+			return nil, nil, vBuiltin{fun: f.fun, forces: f.forces, args: args}, false, nil
+		}
+		res, err := m.callBuiltin(f.fun, args)
+		return nil, nil, res, false, err
+	}
+	return nil, nil, nil, false, fmt.Errorf("uplc: application of a non function value")
+}
+
+func (m *Machine) force(v value) (*env, Term, value, bool, error) {
+	switch f := v.(type) {
+	case vDelay:
+		return f.env, f.body, nil, true, nil
+	case vBuiltin:
+		if f.forces >= builtinSignatures[f.fun].forces {
+			return nil, nil, nil, false, fmt.Errorf("uplc: %s forced too many times", f.fun)
+		}
+		return nil, nil, vBuiltin{fun: f.fun, forces: f.forces + 1, args: f.args}, false, nil
+	}
+	return nil, nil, nil, false, fmt.Errorf("uplc: force of a non delayed value")
+}
+
+func (m *Machine) callBuiltin(fun DefaultFunction, args []value) (value, error) {
+	cost := m.costs.builtins[fun]
+	sizes := builtinSizes(fun, args)
+	if err := m.spend(ExBudget{Mem: cost.mem(sizes), Steps: cost.cpu(sizes)}); err != nil {
+		return nil, err
+	}
+	res, err := m.runBuiltin(fun, args)
+	if err != nil {
+		return nil, fmt.Errorf("uplc: %s: %w", fun, err)
+	}
+	return res, nil
+}
+
+// discharge turns a value back into a closed term.
+func discharge(v value) Term {
+	switch v := v.(type) {
+	case Value:
+		return Constant{Value: v}
+	case vDelay:
+		return Delay{Term: dischargeTerm(v.body, v.env, 0)}
+	case vLambda:
+		return Lambda{Body: dischargeTerm(v.body, v.env, 1)}
+	case vBuiltin:
+		var t Term = Builtin{Fun: v.fun}
+		for i := 0; i < v.forces; i++ {
+			t = Force{Term: t}
+		}
+		for _, arg := range v.args {
+			t = Apply{Function: t, Argument: discharge(arg)}
+		}
+		return t
+	case vConstr:
+		fields := make([]Term, len(v.fields))
+		for i, field := range v.fields {
+			fields[i] = discharge(field)
+		}
+		return Constr{Tag: v.tag, Fields: fields}
+	}
+	return Error{}
+}
+
+// dischargeTerm substitutes the variables of t bound by e, bound being
+// the number of binders between t and e.
+func dischargeTerm(t Term, e *env, bound uint64) Term {
+	switch t := t.(type) {
+	case Var:
+		if t.Index <= bound {
+			return t
+		}
+		if v, ok := e.lookup(t.Index - bound); ok {
+			return discharge(v)
+		}
+		return t
+	case Delay:
+		return Delay{Term: dischargeTerm(t.Term, e, bound)}
+	case Lambda:
+		return Lambda{Body: dischargeTerm(t.Body, e, bound+1)}
+	case Apply:
+		return Apply{Function: dischargeTerm(t.Function, e, bound), Argument: dischargeTerm(t.Argument, e, bound)}
+	case Force:
+		return Force{Term: dischargeTerm(t.Term, e, bound)}
+	case Constr:
+		fields := make([]Term, len(t.Fields))
+		for i, field := range t.Fields {
+			fields[i] = dischargeTerm(field, e, bound)
+		}
+		return Constr{Tag: t.Tag, Fields: fields}
+	case Case:
+		branches := make([]Term, len(t.Branches))
+		for i, branch := range t.Branches {
+			branches[i] = dischargeTerm(branch, e, bound)
+		}
+		return Case{Scrutinee: dischargeTerm(t.Scrutinee, e, bound), Branches: branches}
+	}
+	return t
+}
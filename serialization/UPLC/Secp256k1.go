@@ -0,0 +1,160 @@
+package UPLC
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// secp256k1 curve y^2 = x^3 + 7 over the field of order p, with a base
+// point G of order n.
+var (
+	secpP, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	secpN, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	secpGx, _ = new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	secpGy, _ = new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+	secpG     = secpPoint{x: secpGx, y: secpGy}
+	secpHalfN = new(big.Int).Rsh(secpN, 1)
+)
+
+// secpPoint is an affine point of the curve, x being nil at infinity.
+type secpPoint struct {
+	x, y *big.Int
+}
+
+func (a secpPoint) infinity() bool {
+	return a.x == nil
+}
+
+func secpAdd(a secpPoint, b secpPoint) secpPoint {
+	if a.infinity() {
+		return b
+	}
+	if b.infinity() {
+		return a
+	}
+	var slope *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if new(big.Int).Add(a.y, b.y).Mod(new(big.Int).Add(a.y, b.y), secpP).Sign() == 0 {
+			return secpPoint{}
+		}
+		// tangent: 3x^2 / 2y
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		slope = num.Mul(num, den.ModInverse(den.Mod(den, secpP), secpP))
+	} else {
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		slope = num.Mul(num, den.ModInverse(den.Mod(den, secpP), secpP))
+	}
+	slope.Mod(slope, secpP)
+	x := new(big.Int).Mul(slope, slope)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, secpP)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, slope).Sub(y, a.y).Mod(y, secpP)
+	return secpPoint{x: x, y: y}
+}
+
+func secpMul(k *big.Int, a secpPoint) secpPoint {
+	res := secpPoint{}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res = secpAdd(res, res)
+		if k.Bit(i) == 1 {
+			res = secpAdd(res, a)
+		}
+	}
+	return res
+}
+
+// secpLiftX returns the point with the given x and an even y, if any.
+func secpLiftX(x *big.Int) (secpPoint, bool) {
+	if x.Cmp(secpP) >= 0 {
+		return secpPoint{}, false
+	}
+	c := new(big.Int).Exp(x, big.NewInt(3), secpP)
+	c.Add(c, big.NewInt(7)).Mod(c, secpP)
+	exp := new(big.Int).Add(secpP, big.NewInt(1))
+	y := new(big.Int).Exp(c, exp.Rsh(exp, 2), secpP)
+	if new(big.Int).Exp(y, big.NewInt(2), secpP).Cmp(c) != 0 {
+		return secpPoint{}, false
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(secpP, y)
+	}
+	return secpPoint{x: new(big.Int).Set(x), y: y}, true
+}
+
+/*
+verifyEcdsa checks a 64 byte r || s signature of a 32 byte message hash
+by a compressed public key. Like libsecp256k1 it only accepts
+signatures with a low s, and fails on malformed keys and signatures.
+*/
+func verifyEcdsa(key []byte, msg []byte, sig []byte) (bool, error) {
+	if len(key) != 33 || len(msg) != 32 || len(sig) != 64 {
+		return false, errors.New("invalid key, message or signature length")
+	}
+	if key[0] != 0x02 && key[0] != 0x03 {
+		return false, errors.New("invalid public key")
+	}
+	q, ok := secpLiftX(new(big.Int).SetBytes(key[1:]))
+	if !ok {
+		return false, errors.New("invalid public key")
+	}
+	if key[0] == 0x03 {
+		q.y.Sub(secpP, q.y)
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(secpN) >= 0 || s.Cmp(secpN) >= 0 {
+		return false, errors.New("invalid signature")
+	}
+	if r.Sign() == 0 || s.Sign() == 0 || s.Cmp(secpHalfN) > 0 {
+		return false, nil
+	}
+	z := new(big.Int).SetBytes(msg)
+	w := new(big.Int).ModInverse(s, secpN)
+	u1 := new(big.Int).Mul(z, w)
+	u2 := new(big.Int).Mul(r, w)
+	point := secpAdd(secpMul(u1.Mod(u1, secpN), secpG), secpMul(u2.Mod(u2, secpN), q))
+	if point.infinity() {
+		return false, nil
+	}
+	return new(big.Int).Mod(point.x, secpN).Cmp(r) == 0, nil
+}
+
+func taggedHash(tag string, parts ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+// verifySchnorr checks a BIP-340 signature of msg by an x only key.
+func verifySchnorr(key []byte, msg []byte, sig []byte) (bool, error) {
+	if len(key) != 32 || len(sig) != 64 {
+		return false, errors.New("invalid key or signature length")
+	}
+	p, ok := secpLiftX(new(big.Int).SetBytes(key))
+	if !ok {
+		return false, errors.New("invalid public key")
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(secpP) >= 0 || s.Cmp(secpN) >= 0 {
+		return false, nil
+	}
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", sig[:32], key, msg))
+	e.Mod(e, secpN)
+	// R = sG - eP
+	negE := new(big.Int).Sub(secpN, e)
+	point := secpAdd(secpMul(s, secpG), secpMul(negE.Mod(negE, secpN), p))
+	if point.infinity() || point.y.Bit(0) == 1 {
+		return false, nil
+	}
+	return point.x.Cmp(r) == 0, nil
+}
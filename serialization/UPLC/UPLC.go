@@ -0,0 +1,355 @@
+package UPLC
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+)
+
+// Term is an untyped plutus core term, with variables as de Bruijn
+// indices.
+type Term interface {
+	isTerm()
+}
+
+// Var refers to the binder of the enclosing lambdas, 1 being the
+// innermost one.
+type Var struct {
+	Index uint64
+}
+
+type Delay struct {
+	Term Term
+}
+
+type Lambda struct {
+	Body Term
+}
+
+type Apply struct {
+	Function Term
+	Argument Term
+}
+
+type Constant struct {
+	Value Value
+}
+
+type Force struct {
+	Term Term
+}
+
+type Error struct{}
+
+type Builtin struct {
+	Fun DefaultFunction
+}
+
+// Constr builds a value of a sum of products type, plutus core 1.1.0
+// and up.
+type Constr struct {
+	Tag    uint64
+	Fields []Term
+}
+
+// Case picks the branch of the Constr its scrutinee evaluates to,
+// plutus core 1.1.0 and up.
+type Case struct {
+	Scrutinee Term
+	Branches  []Term
+}
+
+func (Var) isTerm()      {}
+func (Delay) isTerm()    {}
+func (Lambda) isTerm()   {}
+func (Apply) isTerm()    {}
+func (Constant) isTerm() {}
+func (Force) isTerm()    {}
+func (Error) isTerm()    {}
+func (Builtin) isTerm()  {}
+func (Constr) isTerm()   {}
+func (Case) isTerm()     {}
+
+// Program is a versioned plutus core term, the contents of a plutus
+// script.
+type Program struct {
+	Version [3]uint64
+	Term    Term
+}
+
+type TypeTag byte
+
+const (
+	TypeInteger TypeTag = iota
+	TypeByteString
+	TypeString
+	TypeUnit
+	TypeBool
+	TypeList
+	TypePair
+	typeApplication
+	TypeData
+	TypeBls12_381_G1
+	TypeBls12_381_G2
+	TypeBls12_381_MlResult
+)
+
+// Type is the type of a constant, Args holding the element type of a
+// list and the two types of a pair.
+type Type struct {
+	Tag  TypeTag
+	Args []Type
+}
+
+func ListOf(elem Type) Type {
+	return Type{Tag: TypeList, Args: []Type{elem}}
+}
+
+func PairOf(first Type, second Type) Type {
+	return Type{Tag: TypePair, Args: []Type{first, second}}
+}
+
+func (t Type) Equal(other Type) bool {
+	if t.Tag != other.Tag || len(t.Args) != len(other.Args) {
+		return false
+	}
+	for i := range t.Args {
+		if !t.Args[i].Equal(other.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t Type) String() string {
+	switch t.Tag {
+	case TypeInteger:
+		return "integer"
+	case TypeByteString:
+		return "bytestring"
+	case TypeString:
+		return "string"
+	case TypeUnit:
+		return "unit"
+	case TypeBool:
+		return "bool"
+	case TypeList:
+		return fmt.Sprintf("(list %s)", t.Args[0])
+	case TypePair:
+		return fmt.Sprintf("(pair %s %s)", t.Args[0], t.Args[1])
+	case TypeData:
+		return "data"
+	case TypeBls12_381_G1:
+		return "bls12_381_G1_element"
+	case TypeBls12_381_G2:
+		return "bls12_381_G2_element"
+	case TypeBls12_381_MlResult:
+		return "bls12_381_mlresult"
+	}
+	return fmt.Sprintf("type(%d)", t.Tag)
+}
+
+/*
+Value is a constant of the given type. Contents hold
+
+	integer     *big.Int
+	bytestring  []byte
+	string      string
+	unit        nil
+	bool        bool
+	list        []any, with the contents of each element
+	pair        [2]any
+	data        PlutusData.PlutusData
+
+and the serialized point for bls12_381 elements.
+*/
+type Value struct {
+	Type     Type
+	Contents any
+}
+
+func Integer(n *big.Int) Value {
+	return Value{Type: Type{Tag: TypeInteger}, Contents: n}
+}
+
+func ByteString(b []byte) Value {
+	return Value{Type: Type{Tag: TypeByteString}, Contents: b}
+}
+
+func String(s string) Value {
+	return Value{Type: Type{Tag: TypeString}, Contents: s}
+}
+
+func Unit() Value {
+	return Value{Type: Type{Tag: TypeUnit}}
+}
+
+func Bool(b bool) Value {
+	return Value{Type: Type{Tag: TypeBool}, Contents: b}
+}
+
+func Data(pd PlutusData.PlutusData) Value {
+	return Value{Type: Type{Tag: TypeData}, Contents: pd}
+}
+
+type DefaultFunction byte
+
+// Builtin functions, in the order of their flat encoding.
+const (
+	AddInteger DefaultFunction = iota
+	SubtractInteger
+	MultiplyInteger
+	DivideInteger
+	QuotientInteger
+	RemainderInteger
+	ModInteger
+	EqualsInteger
+	LessThanInteger
+	LessThanEqualsInteger
+	AppendByteString
+	ConsByteString
+	SliceByteString
+	LengthOfByteString
+	IndexByteString
+	EqualsByteString
+	LessThanByteString
+	LessThanEqualsByteString
+	Sha2_256
+	Sha3_256
+	Blake2b_256
+	VerifyEd25519Signature
+	AppendString
+	EqualsString
+	EncodeUtf8
+	DecodeUtf8
+	IfThenElse
+	ChooseUnit
+	Trace
+	FstPair
+	SndPair
+	ChooseList
+	MkCons
+	HeadList
+	TailList
+	NullList
+	ChooseData
+	ConstrData
+	MapData
+	ListData
+	IData
+	BData
+	UnConstrData
+	UnMapData
+	UnListData
+	UnIData
+	UnBData
+	EqualsData
+	MkPairData
+	MkNilData
+	MkNilPairData
+	SerialiseData
+	VerifyEcdsaSecp256k1Signature
+	VerifySchnorrSecp256k1Signature
+	Bls12_381_G1_Add
+	Bls12_381_G1_Neg
+	Bls12_381_G1_ScalarMul
+	Bls12_381_G1_Equal
+	Bls12_381_G1_Compress
+	Bls12_381_G1_Uncompress
+	Bls12_381_G1_HashToGroup
+	Bls12_381_G2_Add
+	Bls12_381_G2_Neg
+	Bls12_381_G2_ScalarMul
+	Bls12_381_G2_Equal
+	Bls12_381_G2_Compress
+	Bls12_381_G2_Uncompress
+	Bls12_381_G2_HashToGroup
+	Bls12_381_MillerLoop
+	Bls12_381_MulMlResult
+	Bls12_381_FinalVerify
+	Keccak_256
+	Blake2b_224
+	IntegerToByteString
+	ByteStringToInteger
+	AndByteString
+	OrByteString
+	XorByteString
+	ComplementByteString
+	ReadBit
+	WriteBits
+	ReplicateByte
+	ShiftByteString
+	RotateByteString
+	CountSetBits
+	FindFirstSetBit
+	Ripemd_160
+)
+
+var builtinNames = []string{
+	"addInteger", "subtractInteger", "multiplyInteger", "divideInteger",
+	"quotientInteger", "remainderInteger", "modInteger", "equalsInteger",
+	"lessThanInteger", "lessThanEqualsInteger", "appendByteString",
+	"consByteString", "sliceByteString", "lengthOfByteString",
+	"indexByteString", "equalsByteString", "lessThanByteString",
+	"lessThanEqualsByteString", "sha2_256", "sha3_256", "blake2b_256",
+	"verifyEd25519Signature", "appendString", "equalsString", "encodeUtf8",
+	"decodeUtf8", "ifThenElse", "chooseUnit", "trace", "fstPair", "sndPair",
+	"chooseList", "mkCons", "headList", "tailList", "nullList", "chooseData",
+	"constrData", "mapData", "listData", "iData", "bData", "unConstrData",
+	"unMapData", "unListData", "unIData", "unBData", "equalsData",
+	"mkPairData", "mkNilData", "mkNilPairData", "serialiseData",
+	"verifyEcdsaSecp256k1Signature", "verifySchnorrSecp256k1Signature",
+	"bls12_381_G1_add", "bls12_381_G1_neg", "bls12_381_G1_scalarMul",
+	"bls12_381_G1_equal", "bls12_381_G1_compress", "bls12_381_G1_uncompress",
+	"bls12_381_G1_hashToGroup", "bls12_381_G2_add", "bls12_381_G2_neg",
+	"bls12_381_G2_scalarMul", "bls12_381_G2_equal", "bls12_381_G2_compress",
+	"bls12_381_G2_uncompress", "bls12_381_G2_hashToGroup",
+	"bls12_381_millerLoop", "bls12_381_mulMlResult", "bls12_381_finalVerify",
+	"keccak_256", "blake2b_224", "integerToByteString", "byteStringToInteger",
+	"andByteString", "orByteString", "xorByteString", "complementByteString",
+	"readBit", "writeBits", "replicateByte", "shiftByteString",
+	"rotateByteString", "countSetBits", "findFirstSetBit", "ripemd_160",
+}
+
+func (f DefaultFunction) String() string {
+	if int(f) < len(builtinNames) {
+		return builtinNames[f]
+	}
+	return fmt.Sprintf("builtin(%d)", f)
+}
+
+// Apply returns the program with its term applied to args, in order.
+func (p Program) Apply(args ...Term) Program {
+	term := p.Term
+	for _, arg := range args {
+		term = Apply{Function: term, Argument: arg}
+	}
+	return Program{Version: p.Version, Term: term}
+}
+
+// ApplyData returns the program applied to params, as data constants.
+func (p Program) ApplyData(params ...PlutusData.PlutusData) Program {
+	args := make([]Term, len(params))
+	for i, param := range params {
+		args[i] = Constant{Value: Data(param)}
+	}
+	return p.Apply(args...)
+}
+
+/*
+ApplyParams applies params, in order, to a parameterized
+PlutusV1Script, PlutusV2Script or PlutusV3Script and returns the script
+of the same type, whose Hash and ToAddress derive from the parameters.
+*/
+func ApplyParams[S ~[]byte](script S, params ...PlutusData.PlutusData) (S, error) {
+	program, wrapping, err := DecodeScript(script)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := EncodeScript(program.ApplyData(params...), wrapping)
+	if err != nil {
+		return nil, err
+	}
+	return S(encoded), nil
+}
@@ -7,3 +7,14 @@ type VerificationKeyWitness struct {
 	Vkey      Key.VerificationKey
 	Signature []uint8
 }
+
+// SignBodyHash signs the hash of a transaction body, detached from the
+// transaction itself.
+func SignBodyHash(bodyHash []byte, vkey Key.VerificationKey, skey Key.SigningKey) VerificationKeyWitness {
+	return VerificationKeyWitness{Vkey: vkey, Signature: skey.Sign(bodyHash)}
+}
+
+// Verify checks the witness signs the transaction body of hash bodyHash.
+func (vkw VerificationKeyWitness) Verify(bodyHash []byte) bool {
+	return vkw.Vkey.Verify(bodyHash, vkw.Signature)
+}
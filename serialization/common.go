@@ -1,9 +1,11 @@
 package serialization
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"log"
+	"math/big"
 	"reflect"
 	"strconv"
 
@@ -54,6 +56,70 @@ func (dh *DatumHash) Equal(other DatumHash) bool {
 type ScriptDataHash ConstrainedBytes
 
 type PubKeyHash [28]byte
+
+// StripSetTag removes the optional tag 258 that Conway era
+// encoders may wrap around sets, leaving the inner array.
+func StripSetTag(data []byte) []byte {
+	if len(data) > 3 && data[0] == 0xd9 && data[1] == 0x01 && data[2] == 0x02 {
+		return data[3:]
+	}
+	return data
+}
+
+// Maximum length of a single chunk of plutus bounded bytes.
+const BOUNDED_BYTES_CHUNK_SIZE = 64
+
+func encodeHead(major byte, value uint64) []byte {
+	switch {
+	case value < 24:
+		return []byte{major<<5 | byte(value)}
+	case value <= 0xff:
+		return []byte{major<<5 | 24, byte(value)}
+	case value <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(value))
+	case value <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(value))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, value)
+	}
+}
+
+// MarshalBoundedBytes encodes a byte string the way plutus data requires,
+// splitting it into an indefinite length string of 64 byte chunks when
+// it is longer than that.
+func MarshalBoundedBytes(data []byte) []byte {
+	if len(data) <= BOUNDED_BYTES_CHUNK_SIZE {
+		return append(encodeHead(2, uint64(len(data))), data...)
+	}
+	res := []byte{0x5f}
+	for start := 0; start < len(data); start += BOUNDED_BYTES_CHUNK_SIZE {
+		end := min(start+BOUNDED_BYTES_CHUNK_SIZE, len(data))
+		res = append(res, encodeHead(2, uint64(end-start))...)
+		res = append(res, data[start:end]...)
+	}
+	return append(res, 0xff)
+}
+
+/*
+MarshalBigInt encodes an integer as a plain CBOR integer when it fits in
+64 bits, and as a tag 2 or tag 3 bignum with bounded bytes otherwise.
+*/
+func MarshalBigInt(n *big.Int) []byte {
+	if n.Sign() >= 0 {
+		if n.IsUint64() {
+			return encodeHead(0, n.Uint64())
+		}
+		return append([]byte{0xc2}, MarshalBoundedBytes(n.Bytes())...)
+	}
+	// negative integers are encoded as -1 - n
+	abs := new(big.Int).Neg(n)
+	abs.Sub(abs, big.NewInt(1))
+	if abs.IsUint64() {
+		return encodeHead(1, abs.Uint64())
+	}
+	return append([]byte{0xc3}, MarshalBoundedBytes(abs.Bytes())...)
+}
+
 type CustomBytes struct {
 	Value string
 	tp    string
@@ -81,12 +147,19 @@ func (cb CustomBytes) MarshalCBOR() ([]byte, error) {
 		return cbor.Marshal(cb.Value)
 	}
 	if cb.tp == "uint64" {
-		n, err := strconv.ParseInt(cb.Value, 10, 64)
+		n, err := strconv.ParseUint(cb.Value, 10, 64)
 		if err != nil {
 			return nil, err
 		}
 		return cbor.Marshal(n)
 	}
+	if cb.tp == "bigint" {
+		n, ok := new(big.Int).SetString(cb.Value, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer key: %s", cb.Value)
+		}
+		return MarshalBigInt(n), nil
+	}
 	res, err := hex.DecodeString(cb.Value)
 	if err != nil {
 		return nil, err
@@ -114,6 +187,13 @@ func (cb *CustomBytes) UnmarshalCBOR(value []byte) error {
 	case uint64:
 		cb.tp = "uint64"
 		cb.Value = strconv.FormatUint(res.(uint64), 10)
+	case int64:
+		cb.tp = "bigint"
+		cb.Value = strconv.FormatInt(res.(int64), 10)
+	case big.Int:
+		n := res.(big.Int)
+		cb.tp = "bigint"
+		cb.Value = n.String()
 	}
 	return nil
 }
@@ -0,0 +1,208 @@
+package apollotypes_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/SundaeSwap-finance/apollo/apollotypes"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+)
+
+const blueprintJson = `{
+  "preamble": {
+    "title": "sundae/orders",
+    "version": "0.0.0",
+    "plutusVersion": "v3",
+    "compiler": {"name": "Aiken", "version": "v1.1.0"}
+  },
+  "validators": [
+    {
+      "title": "order.order.spend",
+      "datum": {"title": "datum", "schema": {"$ref": "#/definitions/types~1OrderDatum"}},
+      "redeemer": {"title": "redeemer", "schema": {"$ref": "#/definitions/types~1OrderAction"}},
+      "parameters": [{"title": "owner", "schema": {"$ref": "#/definitions/ByteArray"}}],
+      "compiledCode": "4e4d01000033222220051200120011",
+      "hash": "00"
+    },
+    {
+      "title": "pool.mint",
+      "redeemer": {"title": "redeemer", "purpose": {"oneOf": ["mint", "spend"]}, "schema": {"$ref": "#/definitions/Data"}},
+      "compiledCode": "4e4d01000033222220051200120011",
+      "hash": "00"
+    }
+  ],
+  "definitions": {
+    "ByteArray": {"dataType": "bytes"},
+    "Int": {"dataType": "integer"},
+    "Data": {"title": "Data", "description": "Any Plutus data."},
+    "Option$Int": {
+      "title": "Option",
+      "anyOf": [
+        {"title": "Some", "dataType": "constructor", "index": 0, "fields": [{"$ref": "#/definitions/Int"}]},
+        {"title": "None", "dataType": "constructor", "index": 1, "fields": []}
+      ]
+    },
+    "List$Int": {"dataType": "list", "items": {"$ref": "#/definitions/Int"}},
+    "Dict": {"dataType": "map", "keys": {"$ref": "#/definitions/ByteArray"}, "values": {"$ref": "#/definitions/Int"}},
+    "types/OrderDatum": {
+      "title": "OrderDatum",
+      "anyOf": [
+        {
+          "title": "OrderDatum",
+          "dataType": "constructor",
+          "index": 0,
+          "fields": [
+            {"title": "owner", "dataType": "bytes", "minLength": 28, "maxLength": 28},
+            {"title": "amount", "$ref": "#/definitions/Int"},
+            {"title": "expiry", "$ref": "#/definitions/Option$Int"},
+            {"title": "fees", "$ref": "#/definitions/List$Int"},
+            {"title": "routes", "$ref": "#/definitions/Dict"},
+            {"title": "extension", "$ref": "#/definitions/Data"}
+          ]
+        }
+      ]
+    },
+    "types/OrderAction": {
+      "title": "OrderAction",
+      "anyOf": [
+        {"title": "Scoop", "dataType": "constructor", "index": 0, "fields": []},
+        {"title": "Cancel", "dataType": "constructor", "index": 1, "fields": []}
+      ]
+    }
+  }
+}`
+
+type orderDatum struct {
+	Owner     []byte
+	Amount    *big.Int
+	Expiry    *int64
+	Fees      []int64
+	Routes    map[string]int64
+	Extension PlutusData.PlutusData
+}
+
+func validOrder() orderDatum {
+	expiry := int64(100)
+	return orderDatum{
+		Owner:     make([]byte, 28),
+		Amount:    big.NewInt(-10),
+		Expiry:    &expiry,
+		Fees:      []int64{1, 2},
+		Routes:    map[string]int64{"ada": 5},
+		Extension: PlutusData.NewConstr(4),
+	}
+}
+
+func TestBlueprintScriptsAndPurposes(t *testing.T) {
+	bp, err := apollotypes.ParseBlueprint([]byte(blueprintJson))
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := bp.GetScript("order.order.spend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := script.(PlutusData.PlutusV3Script); !ok {
+		t.Errorf("Expected a PlutusV3Script, got %T", script)
+	}
+	order, _ := bp.Validator("order.order.spend")
+	if purposes := order.Purposes(); len(purposes) != 1 || purposes[0] != "spend" {
+		t.Errorf("Expected the spend purpose, got %v", purposes)
+	}
+	if len(order.Parameters) != 1 || order.Parameters[0].Title != "owner" {
+		t.Errorf("Expected the owner parameter, got %v", order.Parameters)
+	}
+	pool, _ := bp.Validator("pool.mint")
+	if purposes := pool.Purposes(); len(purposes) != 2 || purposes[0] != "mint" {
+		t.Errorf("Expected the purposes of the redeemer, got %v", purposes)
+	}
+	resolved, err := bp.Resolve(order.Datum.Schema)
+	if err != nil || resolved.Title != "OrderDatum" {
+		t.Errorf("Expected the datum to resolve to OrderDatum, got %v %v", resolved.Title, err)
+	}
+	if _, err := bp.GetScript("missing"); err == nil {
+		t.Error("Expected an error for a missing validator")
+	}
+}
+
+func TestBlueprintValidateDatum(t *testing.T) {
+	bp, _ := apollotypes.ParseBlueprint([]byte(blueprintJson))
+	datum, err := PlutusData.Marshal(validOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bp.ValidateDatum("order.order.spend", datum); err != nil {
+		t.Error(err)
+	}
+	redeemer := PlutusData.NewConstr(1)
+	if err := bp.ValidateRedeemer("order.order.spend", redeemer); err != nil {
+		t.Error(err)
+	}
+
+	anyExtension := validOrder()
+	anyExtension.Extension = PlutusData.NewBigInt(big.NewInt(1))
+	datum, _ = PlutusData.Marshal(anyExtension)
+	if err := bp.ValidateDatum("order.order.spend", datum); err != nil {
+		t.Errorf("Expected any data to be a valid extension, got %v", err)
+	}
+
+	shortOwner := validOrder()
+	shortOwner.Owner = []byte{1}
+	datum, _ = PlutusData.Marshal(shortOwner)
+	err = bp.ValidateDatum("order.order.spend", datum)
+	var schemaErr *apollotypes.SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Path != "datum.owner" {
+		t.Errorf("Expected an error at datum.owner, got %v", err)
+	}
+
+	type wrongFees struct {
+		Owner     []byte
+		Amount    int64
+		Expiry    *int64
+		Fees      [][]byte
+		Routes    map[string]int64
+		Extension PlutusData.PlutusData
+	}
+	datum, _ = PlutusData.Marshal(wrongFees{Owner: make([]byte, 28), Fees: [][]byte{{}, {1}}, Extension: PlutusData.NewConstr(0)})
+	err = bp.ValidateDatum("order.order.spend", datum)
+	if err == nil || err.Error() != "datum.fees[0]: expected an integer, got bytes" {
+		t.Errorf("Expected an error on the first fee, got %v", err)
+	}
+
+	badExpiry := validOrder()
+	datum, _ = PlutusData.Marshal(badExpiry)
+	fields := datum.Value.(PlutusData.PlutusIndefArray)
+	fields[2] = PlutusData.NewConstr(0, PlutusData.PlutusData{PlutusDataType: PlutusData.PlutusBytes, Value: []byte{}})
+	err = bp.ValidateDatum("order.order.spend", datum)
+	if err == nil || err.Error() != "datum.expiry[0]: expected an integer, got bytes" {
+		t.Errorf("Expected an error inside the Some constructor, got %v", err)
+	}
+
+	err = bp.ValidateRedeemer("order.order.spend", PlutusData.NewConstr(5))
+	if err == nil || err.Error() != "redeemer: constructor 5 does not match OrderAction" {
+		t.Errorf("Expected an unknown constructor error, got %v", err)
+	}
+}
+
+func TestBlueprintApplyParams(t *testing.T) {
+	bp, _ := apollotypes.ParseBlueprint([]byte(blueprintJson))
+	owner := PlutusData.PlutusData{PlutusDataType: PlutusData.PlutusBytes, Value: make([]byte, 28)}
+	applied, err := bp.ApplyParams("order.order.spend", owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, _ := bp.GetScript("order.order.spend")
+	if _, ok := applied.(PlutusData.PlutusV3Script); !ok || applied.Hash() == script.Hash() {
+		t.Errorf("Expected a PlutusV3Script with a new hash, got %T", applied)
+	}
+
+	_, err = bp.ApplyParams("order.order.spend", PlutusData.NewBigInt(big.NewInt(1)))
+	var schemaErr *apollotypes.SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Path != "owner" {
+		t.Errorf("Expected an error on the owner parameter, got %v", err)
+	}
+	if _, err := bp.ApplyParams("order.order.spend"); err == nil {
+		t.Error("Expected an error for a missing parameter")
+	}
+}
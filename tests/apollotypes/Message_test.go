@@ -0,0 +1,178 @@
+package apollotypes_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo/apollotypes"
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/COSE"
+	"github.com/SundaeSwap-finance/apollo/serialization/Key"
+	"golang.org/x/crypto/blake2b"
+)
+
+func messageWallet() apollotypes.GenericWallet {
+	paymentKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, 32))
+	stakeKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, 32))
+	wallet := apollotypes.GenericWallet{
+		SigningKey:           Key.SigningKey{Payload: paymentKey},
+		VerificationKey:      Key.VerificationKey{Payload: paymentKey.Public().(ed25519.PublicKey)},
+		StakeSigningKey:      Key.StakeSigningKey{Payload: stakeKey},
+		StakeVerificationKey: Key.StakeVerificationKey{Payload: stakeKey.Public().(ed25519.PublicKey)},
+	}
+	paymentHash, _ := wallet.VerificationKey.Hash()
+	stakeHash, _ := Key.VerificationKey(wallet.StakeVerificationKey).Hash()
+	wallet.Address = *Address.AddressFromBytes(paymentHash[:], false, stakeHash[:], false, constants.MAINNET)
+	return wallet
+}
+
+func TestSignMessage(t *testing.T) {
+	wallet := messageWallet()
+	payload := []byte("challenge 42")
+	signed, err := wallet.SignMessage(wallet.Address, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// [<<{1: -8, "address": ...}>>, {"hashed": false}, ...] as CIP-30 wallets encode it
+	if !strings.HasPrefix(signed.Signature, "845846a201276761646472657373583901") ||
+		!strings.Contains(signed.Signature, "a166686173686564f4") {
+		t.Errorf("Unexpected protected header in %s", signed.Signature)
+	}
+	if !strings.HasPrefix(signed.Key, "a4010103272006215820") {
+		t.Errorf("Unexpected COSE_Key %s", signed.Key)
+	}
+	verified, err := apollotypes.VerifySignedMessage(signed.Signature, signed.Key, wallet.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(verified, payload) {
+		t.Errorf("Expected payload %s, got %s", payload, verified)
+	}
+
+	stakeAddress := *Address.AddressFromBytes(nil, false, wallet.Address.StakingPart, false, constants.MAINNET)
+	stakeSigned, err := wallet.SignMessage(stakeAddress, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := apollotypes.VerifySignedMessage(stakeSigned.Signature, stakeSigned.Key, stakeAddress); err != nil {
+		t.Error(err)
+	}
+	if _, err := apollotypes.VerifySignedMessage(stakeSigned.Signature, stakeSigned.Key, wallet.Address); err == nil {
+		t.Error("Expected a message signed for another address to be rejected")
+	}
+
+	other := *Address.AddressFromBytes(bytes.Repeat([]byte{3}, 28), false, nil, false, constants.MAINNET)
+	if _, err := wallet.SignMessage(other, payload); err == nil {
+		t.Error("Expected signing for an address of another wallet to fail")
+	}
+	if _, err := apollotypes.VerifySignedMessage(signed.Signature, stakeSigned.Key, wallet.Address); err == nil {
+		t.Error("Expected a message verified with another key of the address to be rejected")
+	}
+}
+
+func TestVerifyTamperedMessage(t *testing.T) {
+	wallet := messageWallet()
+	signed, _ := wallet.SignMessage(wallet.Address, []byte("challenge 42"))
+	decoded, _ := hex.DecodeString(signed.Signature)
+	message := COSE.Sign1{}
+	if err := cbor.Unmarshal(decoded, &message); err != nil {
+		t.Fatal(err)
+	}
+	message.Payload = []byte("challenge 43")
+	tampered, _ := cbor.Marshal(message)
+	if _, err := apollotypes.VerifySignedMessage(hex.EncodeToString(tampered), signed.Key, wallet.Address); err == nil {
+		t.Error("Expected a tampered payload to be rejected")
+	}
+	// tagged COSE_Sign1 messages are accepted as well
+	tagged, _ := cbor.Marshal(cbor.Tag{Number: COSE.SIGN1_TAG, Content: cbor.RawMessage(decoded)})
+	if _, err := apollotypes.VerifySignedMessage(hex.EncodeToString(tagged), signed.Key, wallet.Address); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestVerifyHashedMessage(t *testing.T) {
+	wallet := messageWallet()
+	payload := []byte("challenge 42")
+	hash, _ := blake2b.New(28, nil)
+	hash.Write(payload)
+	message, err := COSE.NewSign1(wallet.Address.Bytes(), hash.Sum(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message.Hashed = true
+	sigStructure, err := message.SigStructure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	message.Signature = ed25519.Sign(ed25519.PrivateKey(wallet.SigningKey.Payload), sigStructure)
+	signature, _ := cbor.Marshal(message)
+	key, _ := cbor.Marshal(COSE.Key{PublicKey: wallet.VerificationKey.Payload})
+
+	if _, err := apollotypes.VerifySignedMessage(hex.EncodeToString(signature), hex.EncodeToString(key), wallet.Address); err == nil {
+		t.Error("Expected a hashed message to be rejected without its payload")
+	}
+	if err := apollotypes.VerifySignedMessagePayload(hex.EncodeToString(signature), hex.EncodeToString(key), wallet.Address, payload); err != nil {
+		t.Error(err)
+	}
+	if err := apollotypes.VerifySignedMessagePayload(hex.EncodeToString(signature), hex.EncodeToString(key), wallet.Address, []byte("challenge 43")); err == nil {
+		t.Error("Expected another payload to be rejected")
+	}
+
+	signed, _ := wallet.SignMessage(wallet.Address, payload)
+	if err := apollotypes.VerifySignedMessagePayload(signed.Signature, signed.Key, wallet.Address, payload); err != nil {
+		t.Error(err)
+	}
+}
+
+// signData output for "Hello Cardano" from the enterprise mainnet address
+// of the RFC 8032 test 1 key, assembled byte by byte following CIP-30/CIP-8.
+const (
+	vectorSecret    = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+	vectorAddress   = "6135dedd2982a03cf39e7dce03c839994ffdec2ec6b04f1cf2d40e61a3"
+	vectorSignature = "84582aa201276761646472657373581d6135dedd2982a03cf39e7dce03c839994ffdec2ec6b04f1cf2d40e61a3a166686173686564f44d48656c6c6f2043617264616e6f584036bac9b644411d5fdf24acb5b840929ee79dbdb90b3cc9e720f80546e8f1cc95f762bed048dec19e5766d0102212d04b65caa039f844d00040d792afadd6a604"
+	vectorKey       = "a4010103272006215820d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+)
+
+func TestSignDataVector(t *testing.T) {
+	addressBytes, _ := hex.DecodeString(vectorAddress)
+	address := *Address.AddressFromBytes(addressBytes[1:], false, nil, false, constants.MAINNET)
+	if !bytes.Equal(address.Bytes(), addressBytes) {
+		t.Fatalf("Expected address %s, got %x", vectorAddress, address.Bytes())
+	}
+
+	payload, err := apollotypes.VerifySignedMessage(vectorSignature, vectorKey, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != "Hello Cardano" {
+		t.Errorf("Expected payload Hello Cardano, got %s", payload)
+	}
+	decoded, _ := hex.DecodeString(vectorSignature)
+	message := COSE.Sign1{}
+	if err := cbor.Unmarshal(decoded, &message); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(message.Address, addressBytes) || message.Hashed {
+		t.Errorf("Unexpected headers, address %x, hashed %v", message.Address, message.Hashed)
+	}
+
+	seed, _ := hex.DecodeString(vectorSecret)
+	signingKey := ed25519.NewKeyFromSeed(seed)
+	wallet := apollotypes.GenericWallet{
+		SigningKey:      Key.SigningKey{Payload: signingKey},
+		VerificationKey: Key.VerificationKey{Payload: signingKey.Public().(ed25519.PublicKey)},
+		Address:         address,
+	}
+	signed, err := wallet.SignMessage(address, []byte("Hello Cardano"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Signature != vectorSignature || signed.Key != vectorKey {
+		t.Errorf("Expected signData output\n%s\n%s\ngot\n%s\n%s", vectorSignature, vectorKey, signed.Signature, signed.Key)
+	}
+}
@@ -1,11 +1,16 @@
 package address_test
 
 import (
+	"encoding/hex"
 	"fmt"
 	"reflect"
 	"testing"
 
+	"github.com/SundaeSwap-finance/apollo/constants"
 	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/HDWallet"
+	"github.com/btcsuite/btcutil/base58"
 )
 
 func TestDecodeAddress(t *testing.T) {
@@ -26,7 +31,7 @@ func TestDecodeAddress(t *testing.T) {
 	  script1cda3khwqv60360rp5m7akt50m6ttapacs8rqhn5w342z7r35m37
 	  (2498243, 27, 3)
 
-	  There is no pointer implementation in the decoding function therefore there are no test cases for it
+	  Pointer addresses are covered by TestCIP19Addresses
 	  **/
 	cases := map[string]testDecodeCase{
 		"Valid KEY_KEY mainnet address": {
@@ -258,9 +263,9 @@ func TestDecodeAddress(t *testing.T) {
 			input:    "TEST",
 			expected: expectedResult{Error: "invalid index of 1", IsError: true},
 		},
-		"Old Address Format": {
-			input:    "DdzFFzCqrhsqohJ5SJXSmtmXWb19MosWJpgbJSK17GnTto1E13YrYqYfTMpzYV4ft2xt5WFqAkbxPZv63pjL3mGW1e299kcqhewLNSvB",
-			expected: expectedResult{Error: "string not all lowercase or all uppercase", IsError: true},
+		"Invalid Old Address Format": {
+			input:    "DdzFFzCqrhsqohJ5SJXSmtmXWb19MosWJpgbJSK17GnTto1E13YrYqYfTMpzYV4ft2xt5WFqAkbxPZv63pjL3mGW1e299kcqhewLNSvC",
+			expected: expectedResult{Error: "invalid byron address crc", IsError: true},
 		},
 		"Invalid Network": {
 			input:    "addr1llhwamhwamhq5hyc50",
@@ -520,3 +525,219 @@ func TestString(t *testing.T) {
 		})
 	}
 }
+
+func TestByronAddress(t *testing.T) {
+	cases := map[string]struct {
+		address        string
+		derivationPath bool
+	}{
+		"Icarus":     {"Ae2tdPwUPEZ4YjgvykNpoFeYUxoyhNj2kg8KfKWN2FizsSpLUPv68MpTVDo", false},
+		"Daedalus":   {"DdzFFzCqrhsw3prhfMFDNFowbzUku3QmrMwarfjUbWXRisodn97R436SHc1rimp4MhPNmbdYb1aTdqtGSJixMVMi5MkArDQJ6Sc1n3Ez", true},
+		"Daedalus 2": {"DdzFFzCqrhsqohJ5SJXSmtmXWb19MosWJpgbJSK17GnTto1E13YrYqYfTMpzYV4ft2xt5WFqAkbxPZv63pjL3mGW1e299kcqhewLNSvB", true},
+	}
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			addr, err := Address.DecodeAddress(testCase.address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if addr.AddressType != Address.BYRON || addr.Network != Address.MAINNET {
+				t.Errorf("Expected a mainnet byron address, got type %d network %d", addr.AddressType, addr.Network)
+			}
+			if addr.String() != testCase.address {
+				t.Errorf("Expected %s, got %s", testCase.address, addr.String())
+			}
+			decoded := Address.Address{}
+			if err := decoded.UnmarshalCBOR(mustHex(addr.ToCbor())); err != nil {
+				t.Fatal(err)
+			}
+			if decoded.String() != testCase.address {
+				t.Errorf("Expected the cbor round trip to give %s, got %s", testCase.address, decoded.String())
+			}
+			byron, err := addr.Byron()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (byron.DerivationPath != nil) != testCase.derivationPath || byron.ProtocolMagic != 0 {
+				t.Errorf("Unexpected attributes %x %d", byron.DerivationPath, byron.ProtocolMagic)
+			}
+			if byron.String() != testCase.address {
+				t.Errorf("Expected re-encoding to give %s, got %s", testCase.address, byron.String())
+			}
+		})
+	}
+
+	raw := base58.Decode(cases["Icarus"].address)
+	raw[len(raw)-1] ^= 1
+	if _, err := Address.DecodeByronAddressBase58(base58.Encode(raw)); err == nil {
+		t.Error("Expected a wrong crc to be rejected")
+	}
+	if _, err := Address.DecodeAddress("Ae2tdPwUPEZ4YjgvykNpoFeYUxoyhNj2kg8KfKWN2FizsSpLUPv68MpTVD0"); err == nil {
+		t.Error("Expected an invalid base58 address to be rejected")
+	}
+}
+
+func TestIcarusAddress(t *testing.T) {
+	hd := HDWallet.NewHDWalletFromMnemonic("art forum devote street sure rather head chuckle guard poverty release quote oak craft enemy", "")
+	xpub := hd.DeriveIcarusKey(0, HDWallet.EXTERNAL_CHAIN, 0).XPrivKey.XPub().Bytes()
+	mainnet := Address.NewIcarusAddress(xpub, constants.MAINNET_PROTOCOL_MAGIC)
+	if mainnet.String() != "Ae2tdPwUPEZHtBmjZBF4YpMkK9tMSPTE2ADEZTPN97saNkhG78TvXdp3GDk" {
+		t.Errorf("Unexpected mainnet address %s", mainnet.String())
+	}
+	testnet := Address.NewIcarusAddress(xpub, constants.TESTNET_PROTOCOL_MAGIC)
+	if testnet.String() != "2cWKMJemoBakHmnFC1MK2B748yBEvXdyi1degYht6y6xv4gLoc1NV9MKauqVqFA77zmTK" {
+		t.Errorf("Unexpected testnet address %s", testnet.String())
+	}
+	decoded, err := Address.DecodeAddress(testnet.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Network != Address.TESTNET {
+		t.Error("Expected the protocol magic to mark a testnet address")
+	}
+	byron, _ := decoded.Byron()
+	if byron.ProtocolMagic != constants.TESTNET_PROTOCOL_MAGIC || !reflect.DeepEqual(byron.Root, testnet.Root) {
+		t.Errorf("Unexpected decoded address %v", byron)
+	}
+}
+
+func mustHex(value string) []byte {
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		panic(err)
+	}
+	return decoded
+}
+
+func TestCIP19Addresses(t *testing.T) {
+	paymentKey, _ := Credential.FromBytes(mustHex("9493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e"), false)
+	stakeKey, _ := Credential.FromBytes(mustHex("337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251"), false)
+	script, _ := Credential.FromBytes(mustHex("c37b1b5dc0669f1d3c61a6fddb2e8fde96be87b881c60bce8e8d542f"), true)
+	pointer := Address.Pointer{Slot: 2498243, TxIndex: 27, CertIndex: 3}
+
+	type cip19Case struct {
+		payment  *Credential.Credential
+		stake    Address.StakeReference
+		mainnet  string
+		testnet  string
+		addrType byte
+	}
+	cases := map[string]cip19Case{
+		"type 0": {&paymentKey, Address.StakeCredential(stakeKey),
+			"addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x",
+			"addr_test1qz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs68faae",
+			Address.KEY_KEY},
+		"type 1": {&script, Address.StakeCredential(stakeKey),
+			"addr1z8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gten0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs9yc0hh",
+			"addr_test1zrphkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gten0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgsxj90mg",
+			Address.SCRIPT_KEY},
+		"type 2": {&paymentKey, Address.StakeCredential(script),
+			"addr1yx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerkr0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shs2z78ve",
+			"addr_test1yz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerkr0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shsf5r8qx",
+			Address.KEY_SCRIPT},
+		"type 3": {&script, Address.StakeCredential(script),
+			"addr1x8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gt7r0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shskhj42g",
+			"addr_test1xrphkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gt7r0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shs4p04xh",
+			Address.SCRIPT_SCRIPT},
+		"type 4": {&paymentKey, Address.StakePointer(pointer),
+			"addr1gx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer5pnz75xxcrzqf96k",
+			"addr_test1gz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer5pnz75xxcrdw5vky",
+			Address.KEY_POINTER},
+		"type 5": {&script, Address.StakePointer(pointer),
+			"addr128phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtupnz75xxcrtw79hu",
+			"addr_test12rphkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtupnz75xxcryqrvmw",
+			Address.SCRIPT_POINTER},
+		"type 6": {&paymentKey, Address.NoStake,
+			"addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8",
+			"addr_test1vz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerspjrlsz",
+			Address.KEY_NONE},
+		"type 7": {&script, Address.NoStake,
+			"addr1w8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcyjy7wx",
+			"addr_test1wrphkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcl6szpr",
+			Address.SCRIPT_NONE},
+		"type 14": {nil, Address.StakeCredential(stakeKey),
+			"stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw",
+			"stake_test1uqehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gssrtvn",
+			Address.NONE_KEY},
+		"type 15": {nil, Address.StakeCredential(script),
+			"stake178phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcccycj5",
+			"stake_test17rphkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcljw6kf",
+			Address.NONE_SCRIPT},
+	}
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			for network, expected := range map[constants.Network]string{constants.MAINNET: testCase.mainnet, constants.PREPROD: testCase.testnet} {
+				var built Address.Address
+				if testCase.payment == nil {
+					built = Address.NewRewardAddress(*testCase.stake.Credential, network)
+				} else {
+					built = Address.New(*testCase.payment, testCase.stake, network)
+				}
+				if built.String() != expected || built.AddressType != testCase.addrType {
+					t.Errorf("Expected %s, got %s of type %d", expected, built.String(), built.AddressType)
+				}
+				decoded, err := Address.DecodeAddress(expected)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(decoded, built) {
+					t.Errorf("Expected decoding to give %s, got %s", built.Debug(), decoded.Debug())
+				}
+				fromHex, err := Address.DecodeAddressHex(built.Hex())
+				if err != nil || fromHex.String() != expected {
+					t.Errorf("Expected the hex round trip to give %s, got %s (%v)", expected, fromHex.String(), err)
+				}
+				fromCbor := Address.Address{}
+				if err := fromCbor.UnmarshalCBOR(mustHex(built.ToCbor())); err != nil || fromCbor.String() != expected {
+					t.Errorf("Expected the cbor round trip to give %s, got %s (%v)", expected, fromCbor.String(), err)
+				}
+				stake, err := decoded.StakeReference()
+				if err != nil || !reflect.DeepEqual(stake, testCase.stake) {
+					t.Errorf("Unexpected stake reference %v (%v)", stake, err)
+				}
+				if testCase.payment != nil {
+					if payment, err := decoded.PaymentCredential(); err != nil || payment != *testCase.payment {
+						t.Errorf("Unexpected payment credential %v (%v)", payment, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidShelleyAddress(t *testing.T) {
+	valid := mustHex("019493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251")
+	if _, err := Address.DecodeAddressBytes(valid[:40]); err == nil {
+		t.Error("Expected a truncated base address to be rejected")
+	}
+	// a pointer whose certificate index is cut in the middle
+	pointer := append(append([]byte{0x41}, valid[1:29]...), 0x81, 0x98, 0xe7, 0x43, 0x1b, 0x83)
+	if _, err := Address.DecodeAddressBytes(pointer); err == nil {
+		t.Error("Expected a truncated pointer to be rejected")
+	}
+}
+
+func TestStakeKeyHash(t *testing.T) {
+	hash := mustHex("337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251")
+	stake, err := Address.StakeKeyHash(hash)
+	if err != nil || stake.Credential == nil || !reflect.DeepEqual(stake.Credential.Bytes(), hash) {
+		t.Errorf("Unexpected stake reference %v (%v)", stake, err)
+	}
+	if stake, err := Address.StakeKeyHash(nil); err != nil || !reflect.DeepEqual(stake, Address.NoStake) {
+		t.Errorf("Expected no stake, got %v (%v)", stake, err)
+	}
+	for _, invalid := range [][]byte{hash[:27], append(hash, 0)} {
+		if _, err := Address.StakeKeyHash(invalid); err == nil {
+			t.Errorf("Expected a stake key hash of %d bytes to be rejected", len(invalid))
+		}
+	}
+
+	script, _ := Credential.FromBytes(mustHex("c37b1b5dc0669f1d3c61a6fddb2e8fde96be87b881c60bce8e8d542f"), true)
+	addr := Address.ScriptAddress(script.Hash, hash)
+	if addr.String() != "addr1z8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gten0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs9yc0hh" {
+		t.Errorf("Unexpected script address %s", addr.String())
+	}
+	if _, err := Address.DecodeAddressBytes(Address.ScriptAddress(script.Hash, hash[:27]).Bytes()); err == nil {
+		t.Error("Expected a script address with a truncated stake key hash not to decode")
+	}
+}
@@ -0,0 +1,90 @@
+package serialization_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo/serialization/AssetName"
+	"github.com/SundaeSwap-finance/apollo/serialization/CIP68"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+)
+
+func TestLabelPrefix(t *testing.T) {
+	for label, expected := range map[int]string{
+		CIP68.REFERENCE_LABEL: "000643b0",
+		CIP68.NFT_LABEL:       "000de140",
+		CIP68.FT_LABEL:        "0014df10",
+		CIP68.RFT_LABEL:       "001bc280",
+	} {
+		prefix, err := CIP68.LabelPrefix(label)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(prefix) != expected {
+			t.Errorf("Expected %s for label %d, got %x", expected, label, prefix)
+		}
+	}
+	if _, err := CIP68.LabelPrefix(1 << 16); err == nil {
+		t.Error("Expected a label out of range to fail")
+	}
+}
+
+func TestParseAssetName(t *testing.T) {
+	assetName, err := CIP68.NewAssetName(CIP68.NFT_LABEL, "Moon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assetName.HexString() != "000de1404d6f6f6e" {
+		t.Errorf("Invalid asset name %s", assetName.HexString())
+	}
+	label, name, err := CIP68.ParseAssetName(assetName)
+	if err != nil || label != CIP68.NFT_LABEL || name != "Moon" {
+		t.Errorf("Expected 222 Moon, got %d %s %v", label, name, err)
+	}
+	for _, invalid := range []string{"4d6f6f6e", "000de1504d6f6f6e", "000de1"} {
+		if _, _, err := CIP68.ParseAssetName(*AssetName.NewAssetNameFromHexString(invalid)); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+	if _, err := CIP68.NewAssetName(CIP68.NFT_LABEL, "a name that is far too long to fit"); err == nil {
+		t.Error("Expected a name longer than 32 bytes to be rejected")
+	}
+}
+
+func TestCIP68Datum(t *testing.T) {
+	datum, err := CIP68.NewDatum(map[string]any{"name": "Moon", "image": "ipfs://moon", "rarity": 3}, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd, err := datum.ToPlutusData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := cbor.Marshal(&pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := PlutusData.PlutusData{}
+	if err := cbor.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := CIP68.ParseDatum(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := parsed.Text("name"); !ok || name != "Moon" {
+		t.Errorf("Expected name Moon, got %s", name)
+	}
+	rarity, ok := parsed.Field("rarity")
+	if n, isInt := rarity.BigInt(); !ok || !isInt || n.Int64() != 3 {
+		t.Errorf("Expected rarity 3, got %v", rarity)
+	}
+	if parsed.Version != 1 {
+		t.Errorf("Expected version 1, got %d", parsed.Version)
+	}
+	if _, err := CIP68.ParseDatum(PlutusData.NewConstr(0, PlutusData.NewBytes([]byte("Moon")), PlutusData.NewBigInt(big.NewInt(1)), PlutusData.NewConstr(0))); err == nil {
+		t.Error("Expected a datum without a metadata map to be rejected")
+	}
+}
@@ -0,0 +1,153 @@
+package certificate_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Certificate"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/Governance"
+)
+
+func hash28(b byte) [28]byte {
+	var h [28]byte
+	for i := range h {
+		h[i] = b
+	}
+	return h
+}
+
+func TestCertificatesRoundTrip(t *testing.T) {
+	keyCred := Credential.FromKeyHash(hash28(1))
+	scriptCred := Credential.FromScriptHash(hash28(2))
+	pool := serialization.PubKeyHash(hash28(3))
+	drep := Certificate.DRepFromCredential(keyCred)
+	abstain := Certificate.DRep{Type: Certificate.AlwaysAbstain}
+	anchor := &Governance.Anchor{Url: "https://example.com/drep.json", DataHash: make([]byte, 32)}
+	port := uint16(3001)
+	var rewardAccount [29]byte
+	rewardAccount[0] = 0xe1
+	certs := map[string]Certificate.Certificate{
+		"stake registration":   Certificate.NewStakeRegistration(keyCred),
+		"stake deregistration": Certificate.NewStakeDeregistration(scriptCred),
+		"stake delegation":     Certificate.NewStakeDelegation(keyCred, pool),
+		"pool registration": Certificate.NewPoolRegistration(Certificate.PoolParams{
+			Operator:      pool,
+			VrfKeyHash:    make([]byte, 32),
+			Pledge:        100_000_000,
+			Cost:          340_000_000,
+			Margin:        Governance.UnitInterval{Numerator: 1, Denominator: 100},
+			RewardAccount: rewardAccount,
+			PoolOwners:    []serialization.PubKeyHash{hash28(4)},
+			Relays: []Certificate.Relay{
+				{Type: Certificate.SingleHostAddr, Port: &port, Ipv4: []byte{127, 0, 0, 1}},
+				{Type: Certificate.SingleHostName, DnsName: "relay.example.com"},
+				{Type: Certificate.MultiHostName, DnsName: "example.com"},
+			},
+			PoolMetadata: &Certificate.PoolMetadata{Url: "https://example.com/pool.json", Hash: make([]byte, 32)},
+		}),
+		"pool retirement": Certificate.NewPoolRetirement(pool, 500),
+		"genesis delegation": {
+			Type:                Certificate.GenesisKeyDelegation,
+			GenesisHash:         make([]byte, 28),
+			GenesisDelegateHash: make([]byte, 28),
+			VrfKeyHash:          make([]byte, 32),
+		},
+		"mir to accounts": {
+			Type:              Certificate.MoveInstantaneousRewards,
+			MoveInstantaneous: Certificate.MoveInstantaneousReward{Pot: 0, Rewards: map[Credential.Credential]int64{keyCred: 10, scriptCred: -5}},
+		},
+		"mir to pot": {
+			Type:              Certificate.MoveInstantaneousRewards,
+			MoveInstantaneous: Certificate.MoveInstantaneousReward{Pot: 1, OtherPot: 1000},
+		},
+		"registration":                       Certificate.NewRegistration(keyCred, 2_000_000),
+		"unregistration":                     Certificate.NewUnregistration(keyCred, 2_000_000),
+		"vote delegation":                    Certificate.NewVoteDelegation(keyCred, abstain),
+		"stake vote delegation":              Certificate.NewStakeVoteDelegation(keyCred, pool, drep),
+		"stake registration delegation":      Certificate.NewStakeRegistrationDelegation(keyCred, pool, 2_000_000),
+		"vote registration delegation":       Certificate.NewVoteRegistrationDelegation(scriptCred, Certificate.DRep{Type: Certificate.AlwaysNoConfidence}, 2_000_000),
+		"stake vote registration delegation": Certificate.NewStakeVoteRegistrationDelegation(keyCred, pool, drep, 2_000_000),
+		"auth committee hot":                 Certificate.NewAuthCommitteeHot(keyCred, scriptCred),
+		"resign committee cold":              Certificate.NewResignCommitteeCold(keyCred, nil),
+		"register drep":                      Certificate.NewRegisterDRep(keyCred, 500_000_000, anchor),
+		"unregister drep":                    Certificate.NewUnregisterDRep(keyCred, 500_000_000),
+		"update drep":                        Certificate.NewUpdateDRep(scriptCred, anchor),
+	}
+	for name, cert := range certs {
+		encoded, err := cbor.Marshal(cert)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		decoded := Certificate.Certificate{}
+		err = cbor.Unmarshal(encoded, &decoded)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if decoded.Type != cert.Type {
+			t.Errorf("%s: wrong type %d", name, decoded.Type)
+		}
+		reencoded, _ := cbor.Marshal(decoded)
+		if !bytes.Equal(encoded, reencoded) {
+			t.Errorf("%s: did not round trip: %x != %x", name, encoded, reencoded)
+		}
+	}
+}
+
+func TestStakeDelegationCbor(t *testing.T) {
+	cert := Certificate.NewStakeDelegation(Credential.FromKeyHash(hash28(1)), hash28(3))
+	encoded, _ := cbor.Marshal(cert)
+	expected := "83028200581c" + "01010101010101010101010101010101010101010101010101010101" + "581c" + "03030303030303030303030303030303030303030303030303030303"
+	if got := hex.EncodeToString(encoded); got != expected {
+		t.Errorf("unexpected encoding %s", got)
+	}
+}
+
+func TestCertificatesWithSetTag(t *testing.T) {
+	certs := Certificate.Certificates{}
+	reg := Certificate.NewStakeRegistration(Credential.FromKeyHash(hash28(1)))
+	certs = append(certs, &reg)
+	encoded, _ := cbor.Marshal(certs)
+	decoded := Certificate.Certificates{}
+	err := cbor.Unmarshal(append([]byte{0xd9, 0x01, 0x02}, encoded...), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0].Type != Certificate.StakeRegistration {
+		t.Errorf("unexpected certificates %v", decoded)
+	}
+}
+
+func TestCertificateWitnesses(t *testing.T) {
+	pool := hash28(3)
+	registration := Certificate.NewPoolRegistration(Certificate.PoolParams{
+		Operator:   pool,
+		PoolOwners: []serialization.PubKeyHash{hash28(4), hash28(5)},
+	})
+	expected := []Credential.Credential{
+		Credential.FromKeyHash(pool),
+		Credential.FromKeyHash(hash28(4)),
+		Credential.FromKeyHash(hash28(5)),
+	}
+	witnesses := registration.Witnesses()
+	if len(witnesses) != len(expected) {
+		t.Fatalf("Expected the operator and both owners, got %v", witnesses)
+	}
+	for i, witness := range witnesses {
+		if witness != expected[i] {
+			t.Errorf("Expected witness %d to be %v, got %v", i, expected[i], witness)
+		}
+	}
+	delegation := Certificate.NewStakeDelegation(Credential.FromKeyHash(hash28(1)), pool)
+	if witnesses := delegation.Witnesses(); len(witnesses) != 1 || witnesses[0] != Credential.FromKeyHash(hash28(1)) {
+		t.Errorf("Expected the stake credential to witness a delegation, got %v", witnesses)
+	}
+	if witnesses := Certificate.NewStakeRegistration(Credential.FromKeyHash(hash28(1))).Witnesses(); len(witnesses) != 0 {
+		t.Errorf("Expected no witness for a stake registration, got %v", witnesses)
+	}
+}
@@ -0,0 +1,155 @@
+package governance_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/Governance"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionBody"
+	"github.com/SundaeSwap-finance/apollo/serialization/Withdrawal"
+)
+
+func hash28(b byte) [28]byte {
+	var h [28]byte
+	for i := range h {
+		h[i] = b
+	}
+	return h
+}
+
+func TestVotingProceduresRoundTrip(t *testing.T) {
+	actionId, err := Governance.NewGovActionId("bb2ff620c0dd8b0adc19e6ffadea1a150c85d1b22d05e2db10c55c613b8c8a10", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vp := Governance.VotingProcedures{}
+	vp.Add(Governance.StakePoolVoter(hash28(1)), actionId, Governance.VotingProcedure{Vote: Governance.VoteYes})
+	vp.Add(Governance.DRepVoter(Credential.FromKeyHash(hash28(2))), actionId, Governance.VotingProcedure{
+		Vote:   Governance.VoteAbstain,
+		Anchor: &Governance.Anchor{Url: "https://example.com", DataHash: make([]byte, 32)},
+	})
+	vp.Add(Governance.DRepVoter(Credential.FromScriptHash(hash28(3))), actionId, Governance.VotingProcedure{Vote: Governance.VoteNo})
+	encoded, err := cbor.Marshal(vp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := Governance.VotingProcedures{}
+	err = cbor.Unmarshal(encoded, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reencoded, _ := cbor.Marshal(decoded)
+	if !bytes.Equal(encoded, reencoded) {
+		t.Errorf("voting procedures did not round trip: %x != %x", encoded, reencoded)
+	}
+	voters := decoded.Voters()
+	if len(voters) != 3 ||
+		voters[0].Type != Governance.DRepScriptHash ||
+		voters[1].Type != Governance.DRepKeyHash ||
+		voters[2].Type != Governance.StakingPoolKeyHash {
+		t.Errorf("voters not in ledger order: %v", voters)
+	}
+	if decoded[voters[1]][actionId].Anchor.Url != "https://example.com" {
+		t.Error("anchor lost in round trip")
+	}
+}
+
+func TestGovActionsRoundTrip(t *testing.T) {
+	prev, _ := Governance.NewGovActionId("bb2ff620c0dd8b0adc19e6ffadea1a150c85d1b22d05e2db10c55c613b8c8a10", 0)
+	var account [29]byte
+	account[0] = 0xe1
+	withdrawals := Withdrawal.New()
+	_ = withdrawals.Add(account, 1_000_000)
+	anchor := Governance.Anchor{Url: "https://example.com/constitution", DataHash: make([]byte, 32)}
+	actions := map[string]Governance.GovAction{
+		"parameter change": Governance.NewParameterChangeAction(&prev, map[uint64]cbor.RawMessage{0: {0x18, 0x2c}}, nil),
+		"hard fork":        Governance.NewHardForkInitiationAction(nil, Governance.ProtocolVersion{Major: 10, Minor: 0}),
+		"treasury":         Governance.NewTreasuryWithdrawalsAction(withdrawals, make([]byte, 28)),
+		"no confidence":    Governance.NewNoConfidenceAction(&prev),
+		"update committee": Governance.NewUpdateCommitteeAction(nil,
+			[]Credential.Credential{Credential.FromKeyHash(hash28(4))},
+			map[Credential.Credential]uint64{Credential.FromScriptHash(hash28(5)): 500},
+			Governance.UnitInterval{Numerator: 2, Denominator: 3}),
+		"constitution": Governance.NewConstitutionUpdateAction(&prev, Governance.Constitution{Anchor: anchor}),
+		"info":         Governance.NewInfoAction(),
+	}
+	for name, action := range actions {
+		proposals := Governance.ProposalProcedures{{
+			Deposit:       100_000_000_000,
+			RewardAccount: account,
+			GovAction:     action,
+			Anchor:        anchor,
+		}}
+		encoded, err := cbor.Marshal(proposals)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		decoded := Governance.ProposalProcedures{}
+		err = cbor.Unmarshal(encoded, &decoded)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if decoded[0].GovAction.Type != action.Type {
+			t.Errorf("%s: wrong action type %d", name, decoded[0].GovAction.Type)
+		}
+		reencoded, _ := cbor.Marshal(decoded)
+		if !bytes.Equal(encoded, reencoded) {
+			t.Errorf("%s: did not round trip: %x != %x", name, encoded, reencoded)
+		}
+	}
+}
+
+func TestProposalProceduresWithSetTag(t *testing.T) {
+	proposals := Governance.ProposalProcedures{{
+		Deposit:   1,
+		GovAction: Governance.NewInfoAction(),
+		Anchor:    Governance.Anchor{Url: "a", DataHash: make([]byte, 32)},
+	}}
+	encoded, _ := cbor.Marshal(proposals)
+	tagged := append([]byte{0xd9, 0x01, 0x02}, encoded...)
+	decoded := Governance.ProposalProcedures{}
+	err := cbor.Unmarshal(tagged, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded.TotalDeposit() != 1 {
+		t.Errorf("unexpected proposals %v", decoded)
+	}
+}
+
+func TestTxBodyWithGovernance(t *testing.T) {
+	actionId, _ := Governance.NewGovActionId("bb2ff620c0dd8b0adc19e6ffadea1a150c85d1b22d05e2db10c55c613b8c8a10", 0)
+	votes := Governance.VotingProcedures{}
+	votes.Add(Governance.CommitteeVoter(Credential.FromKeyHash(serialization.PubKeyHash(hash28(6)))), actionId, Governance.VotingProcedure{Vote: Governance.VoteYes})
+	txb := TransactionBody.TransactionBody{Fee: 200_000, VotingProcedures: &votes}
+	txb.ProposalProcedures = Governance.ProposalProcedures{{
+		Deposit:   1,
+		GovAction: Governance.NewInfoAction(),
+		Anchor:    Governance.Anchor{Url: "a", DataHash: make([]byte, 32)},
+	}}
+	encoded, err := cbor.Marshal(txb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := TransactionBody.TransactionBody{}
+	err = cbor.Unmarshal(encoded, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.VotingProcedures == nil || len(*decoded.VotingProcedures) != 1 || len(decoded.ProposalProcedures) != 1 {
+		t.Errorf("governance fields lost: %v", decoded)
+	}
+	if hex.EncodeToString(decoded.Hash()) != hex.EncodeToString(txb.Hash()) {
+		t.Error("body hash changed after round trip")
+	}
+	empty, _ := cbor.Marshal(TransactionBody.TransactionBody{Fee: 200_000})
+	if bytes.Contains(empty, []byte{0x13}) || bytes.Contains(empty, []byte{0x14}) {
+		t.Errorf("empty governance fields should be omitted: %x", empty)
+	}
+}
@@ -2,11 +2,15 @@ package serialization_test
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
 	"testing"
 
 	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
 	"github.com/SundaeSwap-finance/apollo/serialization/NativeScript"
 )
 
@@ -30,3 +34,106 @@ func TestNativeScriptsSerializationAndHash(t *testing.T) {
 		t.Errorf("Invalid Hashing Of NativeScript")
 	}
 }
+
+func keyScript(b byte) (NativeScript.NativeScript, serialization.PubKeyHash) {
+	var pkh serialization.PubKeyHash
+	pkh[27] = b
+	return NativeScript.NativeScript{Tag: NativeScript.ScriptPubKey, KeyHash: pkh[:]}, pkh
+}
+
+func TestNativeScriptEvaluate(t *testing.T) {
+	a, pkhA := keyScript(1)
+	b, pkhB := keyScript(2)
+	c, pkhC := keyScript(3)
+	twoOfThree := NativeScript.NativeScript{Tag: NativeScript.ScriptNofK, NoK: 2, NativeScripts: []NativeScript.NativeScript{a, b, c}}
+	timelocked := NativeScript.NativeScript{Tag: NativeScript.ScriptAll, NativeScripts: []NativeScript.NativeScript{
+		twoOfThree,
+		{Tag: NativeScript.InvalidBefore, Before: 100},
+		{Tag: NativeScript.InvalidHereafter, After: 200},
+	}}
+	cases := []struct {
+		signers       []serialization.PubKeyHash
+		validityStart int64
+		ttl           int64
+		expected      bool
+	}{
+		{[]serialization.PubKeyHash{pkhA, pkhC}, 100, 200, true},
+		{[]serialization.PubKeyHash{pkhB}, 100, 200, false},
+		{[]serialization.PubKeyHash{pkhA, pkhB}, 0, 200, false},
+		{[]serialization.PubKeyHash{pkhA, pkhB}, 150, 0, false},
+		{[]serialization.PubKeyHash{pkhA, pkhB}, 99, 150, false},
+		{[]serialization.PubKeyHash{pkhA, pkhB}, 150, 201, false},
+	}
+	for i, c := range cases {
+		if timelocked.Evaluate(c.signers, c.validityStart, c.ttl) != c.expected {
+			t.Errorf("Case %d: expected %v", i, c.expected)
+		}
+	}
+	if !timelocked.ValidInInterval(120, 180) || timelocked.ValidInInterval(0, 180) {
+		t.Error("Invalid validity interval check")
+	}
+	if len(twoOfThree.KeyHashes()) != 2 || len(timelocked.KeyHashes()) != 2 {
+		t.Errorf("Expected 2 signers, got %d and %d", len(twoOfThree.KeyHashes()), len(timelocked.KeyHashes()))
+	}
+	all := NativeScript.NativeScript{Tag: NativeScript.ScriptAll, NativeScripts: []NativeScript.NativeScript{a, b, a}}
+	if len(all.KeyHashes()) != 2 {
+		t.Errorf("Expected duplicate keys to be merged, got %d", len(all.KeyHashes()))
+	}
+	unsigned := twoOfThree.UnsignedKeyHashes([]serialization.PubKeyHash{pkhB}, 0, 0)
+	if len(unsigned) != 2 || unsigned[0] != pkhA || unsigned[1] != pkhC {
+		t.Errorf("Expected A and C to be missing, got %v", unsigned)
+	}
+	if len(twoOfThree.UnsignedKeyHashes([]serialization.PubKeyHash{pkhA, pkhB}, 0, 0)) != 0 {
+		t.Error("Expected no missing key once the script validates")
+	}
+}
+
+func TestNativeScriptJson(t *testing.T) {
+	policyJson := `{"type":"any","scripts":[
+		{"type":"all","scripts":[{"type":"sig","keyHash":"bdb17f2e0cc15ba1fc39b149d46a80211ada8c6a839c2e006ed8ef39"}]},
+		{"type":"all","scripts":[{"type":"sig","keyHash":"0966fdfb5f72bfc22f4e6b5195b7efb80e023f5204bbf37067049b4a"}]},
+		{"type":"all","scripts":[{"type":"sig","keyHash":"6c70c3fc4f73e5bbb54cc87bdf943fad2213da692b260e12749bcc10"}]}
+	]}`
+	nativeScript := NativeScript.NativeScript{}
+	if err := json.Unmarshal([]byte(policyJson), &nativeScript); err != nil {
+		t.Fatal(err)
+	}
+	if nativeScript.PolicyId().Value != "1d8b26107c604d36e24963be3ba26f264245cae0e10c7fa15846efd2" {
+		t.Errorf("Invalid policy id %s", nativeScript.PolicyId())
+	}
+	address := nativeScript.ToAddress(nil)
+	if hex.EncodeToString(address.PaymentPart) != nativeScript.PolicyId().Value || address.AddressType != Address.SCRIPT_NONE {
+		t.Errorf("Invalid script address %s", address.String())
+	}
+	testnet := nativeScript.ToNetworkAddress(Address.NoStake, constants.PREPROD)
+	if testnet.HeaderByte != 0b01110000 || testnet.Hrp != "addr_test" {
+		t.Errorf("Invalid testnet script address %s", testnet.String())
+	}
+
+	timelock := `{"type":"all","scripts":[{"type":"atLeast","required":1,"scripts":[{"type":"sig","keyHash":"bdb17f2e0cc15ba1fc39b149d46a80211ada8c6a839c2e006ed8ef39"}]},{"type":"after","slot":100},{"type":"before","slot":2000}]}`
+	if err := json.Unmarshal([]byte(timelock), &nativeScript); err != nil {
+		t.Fatal(err)
+	}
+	if nativeScript.NativeScripts[1].Tag != NativeScript.InvalidBefore || nativeScript.NativeScripts[1].Before != 100 ||
+		nativeScript.NativeScripts[2].Tag != NativeScript.InvalidHereafter || nativeScript.NativeScripts[2].After != 2000 {
+		t.Errorf("Invalid timelocks %v", nativeScript.NativeScripts)
+	}
+	encoded, err := json.Marshal(nativeScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != timelock {
+		t.Errorf("Invalid reserialization %s", encoded)
+	}
+
+	for _, invalid := range []string{
+		`{"type":"sig","keyHash":"bdb1"}`,
+		`{"type":"atLeast","required":2,"scripts":[]}`,
+		`{"type":"before"}`,
+		`{"type":"timelock","slot":1}`,
+	} {
+		if err := json.Unmarshal([]byte(invalid), &nativeScript); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo/serialization"
 	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
 )
 
@@ -325,3 +329,194 @@ func TestRoundTripDefiniteDatum(t *testing.T) {
 		t.Error("failed roundtrip")
 	}
 }
+
+func TestRoundTripSignedIntegers(t *testing.T) {
+	bigChunked := "c25f5840" + "01" + strings.Repeat("00", 63) + "46" + strings.Repeat("00", 6) + "ff"
+	cases := map[string]string{
+		"-1":                    "20",
+		"-1000":                 "3903e7",
+		"-18446744073709551616": "3bffffffffffffffff",
+		"18446744073709551616":  "c249010000000000000000",
+		"-18446744073709551617": "c349010000000000000000",
+		new(big.Int).Lsh(big.NewInt(1), 8*69).String(): bigChunked,
+	}
+	for expected, cborHex := range cases {
+		decoded, _ := hex.DecodeString(cborHex)
+		var pd PlutusData.PlutusData
+		if err := cbor.Unmarshal(decoded, &pd); err != nil {
+			t.Fatal(err)
+		}
+		n, ok := pd.BigInt()
+		if !ok || n.String() != expected {
+			t.Errorf("Expected %s, got %v", expected, pd.Value)
+		}
+		marshaled, _ := cbor.Marshal(pd)
+		if hex.EncodeToString(marshaled) != cborHex {
+			t.Error("Invalid marshaling", hex.EncodeToString(marshaled), "Expected", cborHex)
+		}
+		fromBigInt := PlutusData.NewBigInt(n)
+		marshaled, _ = cbor.Marshal(&fromBigInt)
+		if hex.EncodeToString(marshaled) != cborHex {
+			t.Error("Invalid marshaling of NewBigInt", hex.EncodeToString(marshaled), "Expected", cborHex)
+		}
+	}
+}
+
+func TestRoundTripSignedIntegersNested(t *testing.T) {
+	cborHex := "d8799f203bffffffffffffffffc349010000000000000000a1200105ff"
+	decoded, _ := hex.DecodeString(cborHex)
+	var pd PlutusData.PlutusData
+	if err := cbor.Unmarshal(decoded, &pd); err != nil {
+		t.Fatal(err)
+	}
+	marshaled, _ := cbor.Marshal(pd)
+	if hex.EncodeToString(marshaled) != cborHex {
+		t.Error("Invalid marshaling", hex.EncodeToString(marshaled), "Expected", cborHex)
+	}
+}
+
+func TestPlutusDataSignedIntegersFromJson(t *testing.T) {
+	var pd PlutusData.PlutusData
+	err := json.Unmarshal([]byte(`{"constructor": 0, "fields": [{"int": -5}, {"int": 340282366920938463463374607431768211455}]}`), &pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marshaled, _ := cbor.Marshal(pd)
+	expected := "d8799f24c250ffffffffffffffffffffffffffffffffff"
+	if hex.EncodeToString(marshaled) != expected {
+		t.Error("Invalid marshaling", hex.EncodeToString(marshaled), "Expected", expected)
+	}
+}
+
+type testAsset struct {
+	PolicyId  []byte
+	AssetName string
+}
+
+type testOrder struct {
+	_         struct{} `plutus:"constr=0"`
+	Owner     serialization.PubKeyHash
+	Offer     testAsset
+	Amount    *big.Int
+	Scoop     int64
+	Expiry    *int64
+	Extension PlutusData.PlutusData
+	Fees      []uint64
+	Routes    map[string]int
+	Partial   bool
+	Internal  string `plutus:"-"`
+}
+
+type testCancel struct {
+	_      struct{} `plutus:"constr=9"`
+	Reason string
+}
+
+type testLarge struct {
+	_ struct{} `plutus:"constr=200"`
+}
+
+func TestMarshalStructToPlutusData(t *testing.T) {
+	expiry := int64(-5)
+	order := testOrder{
+		Owner:     serialization.PubKeyHash{0x01},
+		Offer:     testAsset{PolicyId: []byte{0xab}, AssetName: "SUN"},
+		Amount:    new(big.Int).Lsh(big.NewInt(1), 70),
+		Scoop:     7,
+		Expiry:    &expiry,
+		Extension: PlutusData.NewConstr(3),
+		Fees:      []uint64{},
+		Routes:    map[string]int{"a": 1},
+		Partial:   true,
+		Internal:  "ignored",
+	}
+	pd, err := PlutusData.Marshal(&order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "d8799f581c01000000000000000000000000000000000000000000000000000000" +
+		"d8799f41ab4353554eff" +
+		"c249400000000000000000" +
+		"07" +
+		"d8799f24ff" +
+		"d87c80" +
+		"80" +
+		"a1416101" +
+		"d87a80" +
+		"ff"
+	marshaled, _ := cbor.Marshal(&pd)
+	if hex.EncodeToString(marshaled) != expected {
+		t.Error("Invalid marshaling", hex.EncodeToString(marshaled), "Expected", expected)
+	}
+
+	decodedBytes, _ := hex.DecodeString(expected)
+	var decodedPd PlutusData.PlutusData
+	if err := cbor.Unmarshal(decodedBytes, &decodedPd); err != nil {
+		t.Fatal(err)
+	}
+	var decoded testOrder
+	if err := PlutusData.Unmarshal(decodedPd, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	order.Internal = ""
+	if decoded.Amount.Cmp(order.Amount) != 0 || *decoded.Expiry != expiry {
+		t.Error("Invalid integers", decoded.Amount, *decoded.Expiry)
+	}
+	decoded.Amount, order.Amount, decoded.Expiry, order.Expiry = nil, nil, nil, nil
+	if !decoded.Extension.Equal(order.Extension) {
+		t.Error("Invalid extension")
+	}
+	decoded.Extension, order.Extension = PlutusData.PlutusData{}, PlutusData.PlutusData{}
+	if fmt.Sprint(decoded) != fmt.Sprint(order) {
+		t.Error("Invalid unmarshaling", decoded, "Expected", order)
+	}
+}
+
+func TestMarshalConstructorTags(t *testing.T) {
+	cases := []struct {
+		value    any
+		expected string
+	}{
+		{testCancel{Reason: "x"}, "d905029f4178ff"},
+		{testLarge{}, "d8668218c880"},
+		{struct{ Expiry *int64 }{}, "d8799fd87a80ff"},
+		{[]testAsset{{AssetName: "a"}}, "9fd8799f404161ffff"},
+	}
+	for _, c := range cases {
+		pd, err := PlutusData.Marshal(c.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		marshaled, _ := cbor.Marshal(&pd)
+		if hex.EncodeToString(marshaled) != c.expected {
+			t.Error("Invalid marshaling", hex.EncodeToString(marshaled), "Expected", c.expected)
+		}
+		decodedBytes, _ := hex.DecodeString(c.expected)
+		var decodedPd PlutusData.PlutusData
+		if err := cbor.Unmarshal(decodedBytes, &decodedPd); err != nil {
+			t.Fatal(err)
+		}
+		target := reflect.New(reflect.TypeOf(c.value))
+		if err := PlutusData.Unmarshal(decodedPd, target.Interface()); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestUnmarshalPlutusDataErrors(t *testing.T) {
+	pd, _ := PlutusData.Marshal(testCancel{Reason: "x"})
+	var order testOrder
+	if err := PlutusData.Unmarshal(pd, &order); err == nil {
+		t.Error("Expected a constructor mismatch error")
+	}
+	var small int8
+	if err := PlutusData.Unmarshal(PlutusData.NewBigInt(big.NewInt(300)), &small); err == nil {
+		t.Error("Expected an overflow error")
+	}
+	if err := PlutusData.Unmarshal(pd, order); err == nil {
+		t.Error("Expected an error for a non pointer target")
+	}
+	if _, err := PlutusData.Marshal(map[testCancel]int{{}: 1}); err == nil {
+		t.Error("Expected an error for a constructor map key")
+	}
+}
@@ -39,6 +39,7 @@ func TestTxBodyMarshalCBOR(t *testing.T) {
 		input    TransactionBody.TransactionBody
 		expected expectedResult
 	}
+	treasury, emptyTreasury := int64(1000000000), int64(0)
 	vectors := map[string]test{
 		"Simple": {
 			input: TransactionBody.TransactionBody{
@@ -66,6 +67,57 @@ func TestTxBodyMarshalCBOR(t *testing.T) {
 				IsError: false,
 			},
 		},
+		"TreasuryDonation": {
+			input: TransactionBody.TransactionBody{
+				Inputs: []TransactionInput.TransactionInput{
+					{
+						TransactionId: mustDecodeHexString("e8a7a0b0e5b883e5bda9e5b1b1e5b88be8aeba"),
+						Index:         0,
+					},
+				},
+				Outputs: []TransactionOutput.TransactionOutput{
+					TransactionOutput.SimpleTransactionOutput(
+						mustDecodeAddress("addr_test1vrj2asywelxue68wlz84g6xpjfv69vn9arknsgxvtlg2uusqey860"),
+						Value.Value{
+							Coin: 1000000,
+						},
+					),
+				},
+				Fee:           1000000,
+				Ttl:           1000,
+				TreasuryValue: &treasury,
+				Donation:      5000000,
+			},
+			expected: expectedResult{
+				Result:  "a600818253e8a7a0b0e5b883e5bda9e5b1b1e5b88be8aeba00018182581d60e4aec08ecfcdcce8eef88f5468c19259a2b265e8ed3820cc5fd0ae721a000f4240021a000f4240031903e8151a3b9aca00161a004c4b40",
+				IsError: false,
+			},
+		},
+		"EmptyTreasury": {
+			input: TransactionBody.TransactionBody{
+				Inputs: []TransactionInput.TransactionInput{
+					{
+						TransactionId: mustDecodeHexString("e8a7a0b0e5b883e5bda9e5b1b1e5b88be8aeba"),
+						Index:         0,
+					},
+				},
+				Outputs: []TransactionOutput.TransactionOutput{
+					TransactionOutput.SimpleTransactionOutput(
+						mustDecodeAddress("addr_test1vrj2asywelxue68wlz84g6xpjfv69vn9arknsgxvtlg2uusqey860"),
+						Value.Value{
+							Coin: 1000000,
+						},
+					),
+				},
+				Fee:           1000000,
+				Ttl:           1000,
+				TreasuryValue: &emptyTreasury,
+			},
+			expected: expectedResult{
+				Result:  "a500818253e8a7a0b0e5b883e5bda9e5b1b1e5b88be8aeba00018182581d60e4aec08ecfcdcce8eef88f5468c19259a2b265e8ed3820cc5fd0ae721a000f4240021a000f4240031903e81500",
+				IsError: false,
+			},
+		},
 	}
 
 	for name, testCase := range vectors {
@@ -87,3 +139,13 @@ func TestTxBodyMarshalCBOR(t *testing.T) {
 		})
 	}
 }
+
+func TestTxBodyEmptyTreasuryRoundTrip(t *testing.T) {
+	body := TransactionBody.TransactionBody{}
+	if err := cbor.Unmarshal(mustDecodeHexString("a500818253e8a7a0b0e5b883e5bda9e5b1b1e5b88be8aeba00018182581d60e4aec08ecfcdcce8eef88f5468c19259a2b265e8ed3820cc5fd0ae721a000f4240021a000f4240031903e81500"), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.TreasuryValue == nil || *body.TreasuryValue != 0 {
+		t.Errorf("Expected an empty treasury value to be kept, got %v", body.TreasuryValue)
+	}
+}
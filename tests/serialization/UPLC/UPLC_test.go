@@ -0,0 +1,274 @@
+package uplc_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/UPLC"
+)
+
+func TestFlatEncodeTerms(t *testing.T) {
+	cases := []struct {
+		program  UPLC.Program
+		expected string
+	}{
+		{UPLC.Program{Version: [3]uint64{1, 0, 0}, Term: UPLC.Lambda{Body: UPLC.Var{Index: 1}}}, "010000200101"},
+		{UPLC.Program{Version: [3]uint64{1, 0, 0}, Term: UPLC.Constant{Value: UPLC.Integer(big.NewInt(11))}}, "010000480581"},
+		{UPLC.Program{Version: [3]uint64{1, 1, 0}, Term: UPLC.Constr{Tag: 1, Fields: []UPLC.Term{UPLC.Error{}}}}, "010100801b01"},
+		{UPLC.Program{Version: [3]uint64{1, 0, 0}, Term: UPLC.Builtin{Fun: UPLC.AddInteger}}, "0100007001"},
+	}
+	for _, c := range cases {
+		encoded, err := UPLC.Encode(c.program)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(encoded) != c.expected {
+			t.Error("Invalid encoding", hex.EncodeToString(encoded), "Expected", c.expected)
+		}
+		decoded, err := UPLC.Decode(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, c.program) {
+			t.Errorf("Invalid decoding %v, expected %v", decoded, c.program)
+		}
+	}
+}
+
+func TestFlatRoundTripConstants(t *testing.T) {
+	values := []UPLC.Value{
+		UPLC.Integer(new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 100))),
+		UPLC.ByteString(make([]byte, 300)),
+		UPLC.String("apollo"),
+		UPLC.Unit(),
+		UPLC.Bool(true),
+		{Type: UPLC.ListOf(UPLC.Type{Tag: UPLC.TypeInteger}), Contents: []any{big.NewInt(1), big.NewInt(-2)}},
+		{Type: UPLC.PairOf(UPLC.Type{Tag: UPLC.TypeBool}, UPLC.ListOf(UPLC.Type{Tag: UPLC.TypeByteString})), Contents: [2]any{false, []any{[]byte{1}}}},
+		UPLC.Data(PlutusData.NewConstr(2, PlutusData.NewBigInt(big.NewInt(-7)))),
+	}
+	for _, value := range values {
+		program := UPLC.Program{Version: [3]uint64{1, 0, 0}, Term: UPLC.Constant{Value: value}}
+		encoded, err := UPLC.Encode(program)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := UPLC.Decode(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reencoded, _ := UPLC.Encode(decoded)
+		if hex.EncodeToString(reencoded) != hex.EncodeToString(encoded) {
+			t.Errorf("Invalid round trip of %s", value.Type)
+		}
+		if !decoded.Term.(UPLC.Constant).Value.Type.Equal(value.Type) {
+			t.Errorf("Invalid type %s, expected %s", decoded.Term.(UPLC.Constant).Value.Type, value.Type)
+		}
+	}
+}
+
+func TestApplyParams(t *testing.T) {
+	// double wrapped always succeeds script
+	scriptBytes, _ := hex.DecodeString("4e4d01000033222220051200120011")
+	program, wrapping, err := UPLC.DecodeScript(scriptBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrapping != 2 {
+		t.Errorf("Expected 2 cbor wrappings, got %d", wrapping)
+	}
+	reencoded, _ := UPLC.EncodeScript(program, wrapping)
+	if hex.EncodeToString(reencoded) != hex.EncodeToString(scriptBytes) {
+		t.Error("Invalid round trip", hex.EncodeToString(reencoded))
+	}
+
+	script := PlutusData.PlutusV3Script(scriptBytes[1:])
+	param := PlutusData.NewBigInt(big.NewInt(42))
+	applied, err := UPLC.ApplyParams(script, param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.Hash() == script.Hash() {
+		t.Error("Expected the hash to change with the parameters")
+	}
+	appliedProgram, wrapping, err := UPLC.DecodeScript(applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrapping != 1 {
+		t.Errorf("Expected the wrapping to be kept, got %d", wrapping)
+	}
+	apply, ok := appliedProgram.Term.(UPLC.Apply)
+	if !ok || !reflect.DeepEqual(apply.Function, program.Term) {
+		t.Fatalf("Expected the script applied to its parameter, got %v", appliedProgram.Term)
+	}
+	arg := apply.Argument.(UPLC.Constant).Value.Contents.(PlutusData.PlutusData)
+	if !arg.Equal(param) {
+		t.Error("Invalid parameter", arg)
+	}
+	expected := "54010000333222220051200120014c0102182a0001"
+	if hex.EncodeToString(applied) != expected {
+		t.Error("Invalid applied script", hex.EncodeToString(applied), "Expected", expected)
+	}
+}
+
+// unitCostModel prices every machine step and builtin parameter at 1.
+func unitCostModel(t *testing.T, language UPLC.Language) UPLC.CostModel {
+	keys := map[UPLC.Language][]string{
+		UPLC.PlutusV1: PlutusData.V1COSTMODELKEYS,
+		UPLC.PlutusV2: PlutusData.V2COSTMODELKEYS,
+		UPLC.PlutusV3: PlutusData.V3COSTMODELKEYSUNSORTED,
+	}[language]
+	params := make([]int, len(keys))
+	for i := range params {
+		params[i] = 1
+	}
+	cm, err := UPLC.CostModelFromParams(language, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cm
+}
+
+func integer(n int64) UPLC.Value {
+	return UPLC.Integer(big.NewInt(n))
+}
+
+func bytestring(s string) UPLC.Value {
+	b, _ := hex.DecodeString(s)
+	return UPLC.ByteString(b)
+}
+
+func call(fun UPLC.DefaultFunction, args ...UPLC.Value) UPLC.Term {
+	var term UPLC.Term = UPLC.Builtin{Fun: fun}
+	for _, arg := range args {
+		term = UPLC.Apply{Function: term, Argument: UPLC.Constant{Value: arg}}
+	}
+	return term
+}
+
+func run(t *testing.T, term UPLC.Term, budget UPLC.ExBudget) (UPLC.Term, *UPLC.Machine, error) {
+	machine := UPLC.NewMachine(unitCostModel(t, UPLC.PlutusV3), budget)
+	res, err := machine.Run(UPLC.Program{Version: [3]uint64{1, 1, 0}, Term: term})
+	return res, machine, err
+}
+
+func TestMachineCosts(t *testing.T) {
+	res, machine, err := run(t, call(UPLC.AddInteger, integer(1), integer(2)), UPLC.ExBudget{Mem: 100, Steps: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, UPLC.Constant{Value: integer(3)}) {
+		t.Error("Invalid result", res)
+	}
+	// startup, two applications, the builtin, two constants and the call
+	expected := UPLC.ExBudget{Mem: 8, Steps: 8}
+	if machine.Consumed() != expected {
+		t.Error("Invalid budget", machine.Consumed(), "Expected", expected)
+	}
+
+	_, machine, err = run(t, call(UPLC.AddInteger, integer(1), integer(2)), UPLC.ExBudget{Mem: 100, Steps: 7})
+	if !errors.Is(err, UPLC.ErrOutOfBudget) {
+		t.Error("Expected out of budget, got", err)
+	}
+
+	v1 := UPLC.NewMachine(unitCostModel(t, UPLC.PlutusV1), UPLC.ExBudget{Mem: 100, Steps: 100})
+	_, err = v1.Run(UPLC.Program{Version: [3]uint64{1, 0, 0}, Term: call(UPLC.SerialiseData)})
+	if err == nil {
+		t.Error("Expected serialiseData to be unavailable in plutus v1")
+	}
+}
+
+func TestMachineSumsAndTraces(t *testing.T) {
+	identity := UPLC.Lambda{Body: UPLC.Var{Index: 1}}
+	term := UPLC.Case{
+		Scrutinee: UPLC.Constr{Tag: 1, Fields: []UPLC.Term{UPLC.Constant{Value: integer(5)}}},
+		Branches:  []UPLC.Term{UPLC.Error{}, identity},
+	}
+	res, _, err := run(t, term, UPLC.ExBudget{Mem: 100, Steps: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, UPLC.Constant{Value: integer(5)}) {
+		t.Error("Invalid result", res)
+	}
+
+	trace := UPLC.Apply{
+		Function: UPLC.Apply{Function: UPLC.Force{Term: UPLC.Builtin{Fun: UPLC.Trace}}, Argument: UPLC.Constant{Value: UPLC.String("failing")}},
+		Argument: UPLC.Delay{Term: UPLC.Error{}},
+	}
+	_, machine, err := run(t, UPLC.Force{Term: trace}, UPLC.ExBudget{Mem: 100, Steps: 100})
+	if !errors.Is(err, UPLC.ErrErrorTerm) {
+		t.Error("Expected the error term, got", err)
+	}
+	if !reflect.DeepEqual(machine.Logs, []string{"failing"}) {
+		t.Error("Invalid logs", machine.Logs)
+	}
+
+	machine = UPLC.NewMachine(unitCostModel(t, UPLC.PlutusV3), UPLC.ExBudget{Mem: 100, Steps: 100})
+	_, err = machine.Run(UPLC.Program{Version: [3]uint64{1, 0, 0}, Term: term})
+	if err == nil {
+		t.Error("Expected case to need plutus core 1.1.0")
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	ecdsaKey := "03f973a0b87062c389d125d8199e803b832b6ac6bf7867a4f6cd87506060fc4c58"
+	ecdsaMsg := "8d3aa1a6f227d714692a9d5a7fbbda496fb09f17f7207a11ffd0a4cca6cf35b7"
+	ecdsaSig := "d90cd625ee87dd38656dd95cf79f65f60f7273b67d3096e68bd81e4f5342691f1573343e7eb8072ea83850cc9fa59335059a4affe2ffe692067b24ed055e1e55"
+	// first BIP-340 test vector
+	schnorrKey := "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9"
+	schnorrMsg := "0000000000000000000000000000000000000000000000000000000000000000"
+	schnorrSig := "e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0"
+	indef := PlutusData.PlutusData{PlutusDataType: PlutusData.PlutusArray, Value: PlutusData.PlutusIndefArray{PlutusData.NewBytes([]byte{1})}}
+
+	cases := []struct {
+		term     UPLC.Term
+		expected UPLC.Value
+	}{
+		{call(UPLC.DivideInteger, integer(-7), integer(2)), integer(-4)},
+		{call(UPLC.ModInteger, integer(-7), integer(2)), integer(1)},
+		{call(UPLC.QuotientInteger, integer(-7), integer(2)), integer(-3)},
+		{call(UPLC.RemainderInteger, integer(-7), integer(2)), integer(-1)},
+		{call(UPLC.SliceByteString, integer(1), integer(2), bytestring("01020304")), bytestring("0203")},
+		{call(UPLC.IntegerToByteString, UPLC.Bool(false), integer(4), integer(258)), bytestring("02010000")},
+		{call(UPLC.ByteStringToInteger, UPLC.Bool(true), bytestring("0102")), integer(258)},
+		{call(UPLC.ShiftByteString, bytestring("80ff"), integer(-4)), bytestring("080f")},
+		{call(UPLC.RotateByteString, bytestring("80ff"), integer(4)), bytestring("0ff8")},
+		{call(UPLC.AndByteString, UPLC.Bool(true), bytestring("0f"), bytestring("ff00")), bytestring("0f00")},
+		{call(UPLC.AndByteString, UPLC.Bool(false), bytestring("0f"), bytestring("ff00")), bytestring("0f")},
+		{call(UPLC.ReadBit, bytestring("0001"), integer(0)), UPLC.Bool(true)},
+		{call(UPLC.FindFirstSetBit, bytestring("0100")), integer(8)},
+		{call(UPLC.SerialiseData, UPLC.Data(PlutusData.NewConstr(0))), bytestring("d87980")},
+		{call(UPLC.EqualsData, UPLC.Data(indef), UPLC.Data(PlutusData.NewList(PlutusData.NewBytes([]byte{1})))), UPLC.Bool(true)},
+		{call(UPLC.VerifyEcdsaSecp256k1Signature, bytestring(ecdsaKey), bytestring(ecdsaMsg), bytestring(ecdsaSig)), UPLC.Bool(true)},
+		{call(UPLC.VerifyEcdsaSecp256k1Signature, bytestring(ecdsaKey), bytestring(schnorrMsg), bytestring(ecdsaSig)), UPLC.Bool(false)},
+		{call(UPLC.VerifySchnorrSecp256k1Signature, bytestring(schnorrKey), bytestring(schnorrMsg), bytestring(schnorrSig)), UPLC.Bool(true)},
+		{call(UPLC.VerifySchnorrSecp256k1Signature, bytestring(schnorrKey), bytestring(ecdsaMsg), bytestring(schnorrSig)), UPLC.Bool(false)},
+	}
+	for _, c := range cases {
+		res, _, err := run(t, c.term, UPLC.ExBudget{Mem: 1000, Steps: 1000})
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(res, UPLC.Constant{Value: c.expected}) {
+			t.Error("Invalid result", res, "Expected", c.expected)
+		}
+	}
+
+	failing := []UPLC.Term{
+		call(UPLC.DivideInteger, integer(1), integer(0)),
+		call(UPLC.ConsByteString, integer(256), bytestring("")),
+		call(UPLC.IntegerToByteString, UPLC.Bool(true), integer(1), integer(256)),
+		call(UPLC.IndexByteString, bytestring("00"), integer(1)),
+	}
+	for _, term := range failing {
+		if _, _, err := run(t, term, UPLC.ExBudget{Mem: 1000, Steps: 1000}); err == nil {
+			t.Error("Expected failure of", term)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package metadata_test
 
 import (
 	"encoding/hex"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/Salvionied/cbor/v2"
 	"github.com/SundaeSwap-finance/apollo/serialization/Metadata"
@@ -38,3 +40,112 @@ func TestAuxiliaryData(t *testing.T) {
 		t.Errorf("Invalid Hashing Of AuxiliaryData expected %s got %s", "9ef720ec820d751e0b7d18534b37a19c2fea055ed49d496b5865d27e8ed34def", hex.EncodeToString(aux.Hash()))
 	}
 }
+
+const cip25PolicyId = "f0ff48bbb7bbe9d59a40f1ce90e9e9d0ff5002ec48f232b49ca0fb9a"
+
+func roundTripCIP25(t *testing.T, nft Metadata.CIP25Metadata) *Metadata.CIP25Metadata {
+	aux := Metadata.AuxiliaryData{}
+	aux.SetShelleyMetadata(Metadata.ShelleyMaryMetadata{Metadata: Metadata.Metadata{674: Metadata.TagMetadata{"msg": []string{"mint"}}}})
+	if err := nft.AddTo(&aux); err != nil {
+		t.Fatal(err)
+	}
+	marshaled, err := cbor.Marshal(&aux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := Metadata.AuxiliaryData{}
+	if err := cbor.Unmarshal(marshaled, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded.GetMetadata()[674]; !ok {
+		t.Error("Expected the other labels to be kept")
+	}
+	parsed, err := Metadata.ParseCIP25(decoded.GetMetadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestCIP25Metadata(t *testing.T) {
+	image := "ipfs://" + strings.Repeat("Qm", 50)
+	nft := Metadata.CIP25Metadata{Policies: map[string]map[string]Metadata.CIP25Asset{
+		cip25PolicyId: {"bluedesert": {
+			Name:      "Blue Desert",
+			Image:     image,
+			MediaType: "image/png",
+			Files:     []Metadata.CIP25File{{Name: "hd", MediaType: "image/png", Src: image}},
+			Extra:     map[string]any{"artist": "apollo"},
+		}},
+	}}
+	metadatum, err := nft.ToMetadatum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	asset := metadatum.(Metadata.TagMetadata)[cip25PolicyId].(map[string]any)["bluedesert"].(map[string]any)
+	if chunks, ok := asset["image"].([]any); !ok || len(chunks) != 2 {
+		t.Errorf("Expected the image to be split in 2 chunks, got %v", asset["image"])
+	}
+
+	for _, version := range []int{1, 2} {
+		nft.Version = version
+		parsed := roundTripCIP25(t, nft)
+		if parsed.Version != version {
+			t.Errorf("Expected version %d, got %d", version, parsed.Version)
+		}
+		parsedAsset := parsed.Policies[cip25PolicyId]["bluedesert"]
+		if parsedAsset.Name != "Blue Desert" || parsedAsset.Image != image || parsedAsset.MediaType != "image/png" {
+			t.Errorf("Invalid asset %v", parsedAsset)
+		}
+		if len(parsedAsset.Files) != 1 || parsedAsset.Files[0].Src != image || parsedAsset.Extra["artist"] != "apollo" {
+			t.Errorf("Invalid files or extra properties %v", parsedAsset)
+		}
+	}
+
+	for _, invalid := range []Metadata.CIP25Metadata{
+		{Policies: map[string]map[string]Metadata.CIP25Asset{"f0ff": {"a": {Name: "a", Image: "b"}}}},
+		{Policies: map[string]map[string]Metadata.CIP25Asset{cip25PolicyId: {"a": {Name: "a"}}}},
+		{Policies: map[string]map[string]Metadata.CIP25Asset{cip25PolicyId: {"a": {Name: "a", Image: "b", Extra: map[string]any{"files": 1}}}}},
+		{Version: 3, Policies: map[string]map[string]Metadata.CIP25Asset{cip25PolicyId: {"a": {Name: "a", Image: "b"}}}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Expected %v to be invalid", invalid)
+		}
+	}
+}
+
+func TestCIP25BytesAssetNames(t *testing.T) {
+	names := []string{"\x00\x0d\xe1\x40moon", "[97 98]"}
+	nft := Metadata.CIP25Metadata{Version: 2, Policies: map[string]map[string]Metadata.CIP25Asset{cip25PolicyId: {}}}
+	for _, name := range names {
+		nft.Policies[cip25PolicyId][name] = Metadata.CIP25Asset{Name: "Moon", Image: "ipfs://moon"}
+	}
+	parsed := roundTripCIP25(t, nft)
+	for _, name := range names {
+		if _, ok := parsed.Policies[cip25PolicyId][name]; !ok {
+			t.Errorf("Expected asset %x to be parsed, got %v", name, parsed.Policies)
+		}
+	}
+}
+
+func TestSplitString(t *testing.T) {
+	if Metadata.SplitString("short") != "short" {
+		t.Error("Expected a short string to be kept")
+	}
+	long := strings.Repeat("é", 40)
+	chunks, ok := Metadata.SplitString(long).([]any)
+	if !ok {
+		t.Fatal("Expected a long string to be split")
+	}
+	joined := ""
+	for _, chunk := range chunks {
+		s := chunk.(string)
+		if len(s) > 64 || !utf8.ValidString(s) {
+			t.Errorf("Invalid chunk %q", s)
+		}
+		joined += s
+	}
+	if joined != long {
+		t.Errorf("Expected %s, got %s", long, joined)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -12,14 +13,23 @@ import (
 	"github.com/SundaeSwap-finance/apollo/serialization"
 	"github.com/SundaeSwap-finance/apollo/serialization/Address"
 	"github.com/SundaeSwap-finance/apollo/serialization/Amount"
+	"github.com/SundaeSwap-finance/apollo/serialization/CIP68"
+	"github.com/SundaeSwap-finance/apollo/serialization/Certificate"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/Governance"
+	"github.com/SundaeSwap-finance/apollo/serialization/Metadata"
 	"github.com/SundaeSwap-finance/apollo/serialization/MultiAsset"
+	"github.com/SundaeSwap-finance/apollo/serialization/NativeScript"
 	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Policy"
 	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
 	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
 	"github.com/SundaeSwap-finance/apollo/serialization/TransactionOutput"
 	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
 	"github.com/SundaeSwap-finance/apollo/serialization/Value"
 	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/FixedChainContext"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/CoinSelection"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Errors"
 )
 
 type Network int
@@ -337,6 +347,221 @@ func TestUseInputAsCollateral(t *testing.T) {
 	}
 }
 
+func TestGovernanceProposalAndVote(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	userAddress := "addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w"
+	myAddress, _ := Address.DecodeAddress(userAddress)
+	utxos := []UTxO.UTxO{makeFakeUtxo(myAddress, 0, 150_000_000_000)}
+	actionId, _ := Governance.NewGovActionId("bb2ff620c0dd8b0adc19e6ffadea1a150c85d1b22d05e2db10c55c613b8c8a10", 0)
+	var drep [28]byte
+	copy(drep[:], myAddress.PaymentPart)
+	deposit := 100_000_000_000
+	anchor := Governance.Anchor{Url: "https://example.com/proposal.json", DataHash: make([]byte, 32)}
+	apollob := apollo.New(&cc)
+	apollob, err := apollob.AddInputAddressFromBech32(userAddress).AddLoadedUTxOs(utxos...).
+		PayToAddressBech32(userAddress, int(2_000_000)).
+		ProposeGovernanceAction(Governance.NewInfoAction(), deposit, myAddress, anchor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob = apollob.
+		AddVote(Governance.DRepVoter(Credential.FromKeyHash(drep)), actionId, Governance.VoteYes, nil).
+		SetTtl(0 + 300)
+	apollob, _, err = apollob.Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := apollob.GetTx().TransactionBody
+	if len(body.ProposalProcedures) != 1 || body.VotingProcedures == nil || len(*body.VotingProcedures) != 1 {
+		t.Error("Tx is missing its governance procedures")
+	}
+	if body.ProposalProcedures[0].RewardAccount[0] != 0xe1 {
+		t.Errorf("Unexpected reward account header %x", body.ProposalProcedures[0].RewardAccount[0])
+	}
+	outputVal := Value.SimpleValue(0, MultiAsset.MultiAsset[int64]{})
+	for _, output := range body.Outputs {
+		outputVal = outputVal.Add(output.GetAmount())
+	}
+	outputVal.AddLovelace(apollob.Fee + int64(deposit))
+	inputVal := Value.SimpleValue(0, MultiAsset.MultiAsset[int64]{}).Add(utxos[0].Output.GetAmount())
+	if !inputVal.Equal(outputVal) {
+		t.Error("Tx is not balanced")
+	}
+
+	enterprise, err := Address.DecodeAddress("addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := apollo.New(&cc).ProposeGovernanceAction(Governance.NewInfoAction(), deposit, enterprise, anchor); err == nil {
+		t.Error("Expected a return address without staking part to be rejected")
+	}
+}
+
+func TestStakeCertificatesBalancing(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	userAddress := "addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w"
+	myAddress, _ := Address.DecodeAddress(userAddress)
+	stakeCred, _ := Credential.FromBytes(myAddress.StakingPart, false)
+	var pool serialization.PubKeyHash
+	copy(pool[:], myAddress.PaymentPart)
+	tests := map[string]struct {
+		build   func(*apollo.Apollo) *apollo.Apollo
+		deposit int64
+		certs   int
+	}{
+		"register and delegate": {
+			build: func(b *apollo.Apollo) *apollo.Apollo {
+				return b.RegisterStake(stakeCred).
+					DelegateStake(stakeCred, pool).
+					DelegateVote(stakeCred, Certificate.DRep{Type: Certificate.AlwaysAbstain})
+			},
+			deposit: 2_000_000,
+			certs:   3,
+		},
+		"deregister": {
+			build: func(b *apollo.Apollo) *apollo.Apollo {
+				return b.DeregisterStake(stakeCred)
+			},
+			deposit: -2_000_000,
+			certs:   1,
+		},
+	}
+	for name, test := range tests {
+		utxos := []UTxO.UTxO{makeFakeUtxo(myAddress, 0, 10_000_000)}
+		apollob := apollo.New(&cc)
+		apollob = apollob.AddInputAddressFromBech32(userAddress).AddLoadedUTxOs(utxos...).
+			PayToAddressBech32(userAddress, int(2_000_000)).
+			SetTtl(0 + 300)
+		apollob, _, err := test.build(apollob).Complete()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		body := apollob.GetTx().TransactionBody
+		if body.Certificates == nil || len(*body.Certificates) != test.certs {
+			t.Errorf("%s: unexpected certificates", name)
+		}
+		outputVal := Value.SimpleValue(0, MultiAsset.MultiAsset[int64]{})
+		for _, output := range body.Outputs {
+			outputVal = outputVal.Add(output.GetAmount())
+		}
+		outputVal.AddLovelace(apollob.Fee + test.deposit)
+		inputVal := Value.SimpleValue(0, MultiAsset.MultiAsset[int64]{}).Add(utxos[0].Output.GetAmount())
+		if !inputVal.Equal(outputVal) {
+			t.Errorf("%s: Tx is not balanced", name)
+		}
+	}
+}
+
+func TestDonationBalancing(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	userAddress := "addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w"
+	myAddress, _ := Address.DecodeAddress(userAddress)
+	utxos := []UTxO.UTxO{makeFakeUtxo(myAddress, 0, 10_000_000)}
+	apollob := apollo.New(&cc)
+	apollob = apollob.AddInputAddressFromBech32(userAddress).AddLoadedUTxOs(utxos...).
+		PayToAddressBech32(userAddress, int(2_000_000)).
+		Donate(3_000_000).
+		SetCurrentTreasuryValue(1_000_000_000).
+		SetTtl(0 + 300)
+	apollob, _, err := apollob.Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := apollob.GetTx().TransactionBody
+	if body.Donation != 3_000_000 || body.TreasuryValue == nil || *body.TreasuryValue != 1_000_000_000 {
+		t.Error("Tx is missing donation or treasury value")
+	}
+	outputVal := Value.SimpleValue(0, MultiAsset.MultiAsset[int64]{})
+	for _, output := range body.Outputs {
+		outputVal = outputVal.Add(output.GetAmount())
+	}
+	outputVal.AddLovelace(apollob.Fee + body.Donation)
+	inputVal := Value.SimpleValue(0, MultiAsset.MultiAsset[int64]{}).Add(utxos[0].Output.GetAmount())
+	if !inputVal.Equal(outputVal) {
+		t.Error("Tx is not balanced")
+	}
+}
+
+func TestCoinSelectorInComplete(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	userAddress := "addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w"
+	myAddress, _ := Address.DecodeAddress(userAddress)
+	utxos := make([]UTxO.UTxO, 0)
+	for i := 0; i < 5; i++ {
+		utxos = append(utxos, makeFakeUtxo(myAddress, i, int64(i+1)*3_000_000))
+	}
+	apollob := apollo.New(&cc)
+	apollob = apollob.AddInputAddressFromBech32(userAddress).AddLoadedUTxOs(utxos...).
+		PayToAddressBech32(userAddress, int(10_000_000)).
+		SetCoinSelector(CoinSelection.LargestFirstSelector{}).
+		SetTtl(0 + 300)
+	apollob, _, err := apollob.Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputVal := Value.SimpleValue(0, MultiAsset.MultiAsset[int64]{})
+	seen := make(map[string]bool)
+	for _, input := range apollob.GetTx().TransactionBody.Inputs {
+		key := fmt.Sprintf("%s:%d", hex.EncodeToString(input.TransactionId), input.Index)
+		if seen[key] {
+			t.Error("Input selected twice", key)
+		}
+		seen[key] = true
+		for _, utxo := range utxos {
+			if utxo.GetKey() == key {
+				inputVal = inputVal.Add(utxo.Output.GetAmount())
+			}
+		}
+	}
+	outputVal := Value.SimpleValue(0, MultiAsset.MultiAsset[int64]{})
+	for _, output := range apollob.GetTx().TransactionBody.Outputs {
+		outputVal = outputVal.Add(output.GetAmount())
+	}
+	outputVal.AddLovelace(apollob.Fee)
+	if !inputVal.Equal(outputVal) {
+		t.Error("Tx is not balanced")
+	}
+}
+
+func TestCoinSelectionErrors(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	userAddress := "addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w"
+	myAddress, _ := Address.DecodeAddress(userAddress)
+	utxos := make([]UTxO.UTxO, 0)
+	for i := 0; i < 5; i++ {
+		utxos = append(utxos, makeFakeUtxo(myAddress, i, 3_000_000))
+	}
+	selectors := map[string]CoinSelection.UTxOSelector{
+		"builtin":       nil,
+		"largest first": CoinSelection.LargestFirstSelector{},
+	}
+	for name, selector := range selectors {
+		apollob := apollo.New(&cc).AddInputAddressFromBech32(userAddress).AddLoadedUTxOs(utxos...).
+			PayToAddressBech32(userAddress, int(10_000_000)).
+			SetMaxInputCount(2)
+		if selector != nil {
+			apollob = apollob.SetCoinSelector(selector)
+		}
+		_, _, err := apollob.Complete()
+		var maxErr *CoinSelection.MaxInputCountExceededError
+		if !errors.As(err, &maxErr) {
+			t.Errorf("%s: expected MaxInputCountExceededError, got %v", name, err)
+		}
+
+		apollob = apollo.New(&cc).AddInputAddressFromBech32(userAddress).AddLoadedUTxOs(utxos...).
+			PayToAddressBech32(userAddress, int(100_000_000))
+		if selector != nil {
+			apollob = apollob.SetCoinSelector(selector)
+		}
+		_, _, err = apollob.Complete()
+		var balanceErr *CoinSelection.InsufficientUtxoBalanceError
+		if !errors.As(err, &balanceErr) {
+			t.Errorf("%s: expected InsufficientUtxoBalanceError, got %v", name, err)
+		}
+	}
+}
+
 // func TestScriptAddress(t *testing.T) {
 // 	SC_CBOR := "5901ec01000032323232323232323232322223232533300a3232533300c002100114a066646002002444a66602400429404c8c94ccc040cdc78010018a5113330050050010033015003375c60260046eb0cc01cc024cc01cc024011200048040dd71980398048012400066e3cdd7198031804001240009110d48656c6c6f2c20576f726c642100149858c8014c94ccc028cdc3a400000226464a66602060240042930a99806a49334c6973742f5475706c652f436f6e73747220636f6e7461696e73206d6f7265206974656d73207468616e2065787065637465640016375c6020002601000a2a660169212b436f6e73747220696e64657820646964206e6f74206d6174636820616e7920747970652076617269616e7400163008004320033253330093370e900000089919299980798088010a4c2a66018921334c6973742f5475706c652f436f6e73747220636f6e7461696e73206d6f7265206974656d73207468616e2065787065637465640016375c601e002600e0062a660149212b436f6e73747220696e64657820646964206e6f74206d6174636820616e7920747970652076617269616e740016300700233001001480008888cccc01ccdc38008018061199980280299b8000448008c0380040080088c018dd5000918021baa0015734ae7155ceaab9e5573eae855d11"
 // 	//resultingAddr := "addr1w8elsgw3y2cyzfzdup6tj42v0k7vvte57cjzvdzvp595epsljnl47"
@@ -528,3 +753,216 @@ func TestUseInputAsCollateral(t *testing.T) {
 // 	fmt.Println(apollob.GetTx().TransactionBody.CollateralReturn, apollob.GetTx().TransactionBody.Withdrawals)
 // 	fmt.Println(hex.EncodeToString(cborred))
 // }
+
+func nativeKeyScript(b byte) NativeScript.NativeScript {
+	keyHash := make([]byte, 28)
+	keyHash[27] = b
+	return NativeScript.NativeScript{Tag: NativeScript.ScriptPubKey, KeyHash: keyHash}
+}
+
+func mintWithNativeScript(script NativeScript.NativeScript, ttl int64) (*apollo.Apollo, error) {
+	cc := FixedChainContext.InitFixedChainContext()
+	userAddress, _ := Address.DecodeAddress("addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w")
+	hash := script.Hash()
+	apollob := apollo.New(&cc).
+		SetChangeAddress(userAddress).
+		AddLoadedUTxOs(makeFakeUtxo(userAddress, 0, 100_000_000)).
+		MintAssetsWithNativeScript(apollo.NewUnit("", "TOKEN", 1), script).
+		PayToAddress(userAddress, 2_000_000, apollo.NewUnit(hex.EncodeToString(hash.Bytes()), "TOKEN", 1))
+	if ttl != 0 {
+		apollob = apollob.SetTtl(ttl)
+	}
+	apollob, _, err := apollob.Complete()
+	return apollob, err
+}
+
+func TestMintAssetsWithNativeScript(t *testing.T) {
+	single := nativeKeyScript(1)
+	apollob, err := mintWithNativeScript(single, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := apollob.GetTx()
+	hash := single.Hash()
+	policy := hex.EncodeToString(hash.Bytes())
+	for policyId := range tx.TransactionBody.Mint {
+		if policyId.Value != policy {
+			t.Errorf("Expected policy %s, got %s", policy, policyId.Value)
+		}
+	}
+	if len(tx.TransactionBody.Mint) != 1 {
+		t.Errorf("Expected one minted policy, got %v", tx.TransactionBody.Mint)
+	}
+	if len(tx.TransactionWitnessSet.NativeScripts) != 1 {
+		t.Errorf("Expected the native script to be attached, got %v", tx.TransactionWitnessSet.NativeScripts)
+	}
+	multisig := NativeScript.NativeScript{
+		Tag:           NativeScript.ScriptNofK,
+		NoK:           2,
+		NativeScripts: []NativeScript.NativeScript{nativeKeyScript(1), nativeKeyScript(2), nativeKeyScript(3)},
+	}
+	multisigTx, err := mintWithNativeScript(multisig, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if multisigTx.Fee <= apollob.Fee {
+		t.Errorf("Expected a 2 of 3 script to cost more than a single key, got %d and %d", multisigTx.Fee, apollob.Fee)
+	}
+}
+
+func TestNativeScriptValidityInterval(t *testing.T) {
+	script := NativeScript.NativeScript{
+		Tag: NativeScript.ScriptAll,
+		NativeScripts: []NativeScript.NativeScript{
+			nativeKeyScript(1),
+			{Tag: NativeScript.InvalidHereafter, After: 1000},
+		},
+	}
+	_, err := mintWithNativeScript(script, 0)
+	var intervalErr *Errors.NativeScriptIntervalError
+	if !errors.As(err, &intervalErr) {
+		t.Fatalf("Expected a validity interval error, got %v", err)
+	}
+	if _, err := mintWithNativeScript(script, 900); err != nil {
+		t.Error(err)
+	}
+	if _, err := mintWithNativeScript(script, 1001); !errors.As(err, &intervalErr) {
+		t.Errorf("Expected a validity interval error, got %v", err)
+	}
+}
+
+func TestCollectFromNativeScript(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	userAddress, _ := Address.DecodeAddress("addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w")
+	script := nativeKeyScript(1)
+	scriptUtxo := makeFakeUtxo(script.ToAddress(nil), 3, 10_000_000)
+	apollob, _, err := apollo.New(&cc).
+		SetChangeAddress(userAddress).
+		AddLoadedUTxOs(makeFakeUtxo(userAddress, 0, 100_000_000)).
+		CollectFromNativeScript(scriptUtxo, script).
+		PayToAddress(userAddress, 2_000_000).
+		Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := apollob.GetTx()
+	found := false
+	for _, input := range tx.TransactionBody.Inputs {
+		if input.Index == 3 {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected the script UTxO to be spent")
+	}
+	if len(tx.TransactionWitnessSet.NativeScripts) != 1 {
+		t.Errorf("Expected the native script to be attached, got %v", tx.TransactionWitnessSet.NativeScripts)
+	}
+}
+
+func TestAddCIP25Metadata(t *testing.T) {
+	script := nativeKeyScript(1)
+	policyId := script.PolicyId().Value
+	cc := FixedChainContext.InitFixedChainContext()
+	userAddress, _ := Address.DecodeAddress("addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w")
+	apollob := apollo.New(&cc).
+		SetChangeAddress(userAddress).
+		AddLoadedUTxOs(makeFakeUtxo(userAddress, 0, 100_000_000)).
+		MintAssetsWithNativeScript(apollo.NewUnit("", "Moon", 1), script).
+		MintAssetsWithNativeScript(apollo.NewUnit("", "Sun", 1), script)
+	apollob, err := apollob.AddCIP25Metadata(Metadata.CIP25Metadata{Policies: map[string]map[string]Metadata.CIP25Asset{
+		policyId: {"Moon": {Name: "Moon", Image: "ipfs://moon"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob, err = apollob.AddCIP25Metadata(Metadata.CIP25Metadata{Policies: map[string]map[string]Metadata.CIP25Asset{
+		policyId: {"Sun": {Name: "Sun", Image: "ipfs://sun"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := apollob.AddCIP25Metadata(Metadata.CIP25Metadata{Version: 2, Policies: map[string]map[string]Metadata.CIP25Asset{
+		policyId: {"Star": {Name: "Star", Image: "ipfs://star"}},
+	}}); err == nil {
+		t.Error("Expected mixing CIP-25 versions to fail")
+	}
+	apollob, _, err = apollob.Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := apollob.GetTx()
+	if len(tx.TransactionBody.AuxiliaryDataHash) != 32 {
+		t.Error("Expected the auxiliary data hash to be set")
+	}
+	nft, err := Metadata.ParseCIP25(tx.AuxiliaryData.GetMetadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nft.Policies[policyId]) != 2 || nft.Policies[policyId]["Sun"].Image != "ipfs://sun" {
+		t.Errorf("Invalid CIP-25 metadata %v", nft)
+	}
+
+	apollob = apollo.New(&cc).SetShelleyMetadata(Metadata.ShelleyMaryMetadata{
+		Metadata: Metadata.Metadata{Metadata.CIP25_LABEL: Metadata.TagMetadata{"version": "two"}},
+	})
+	if _, err := apollob.AddCIP25Metadata(Metadata.CIP25Metadata{Policies: map[string]map[string]Metadata.CIP25Asset{
+		policyId: {"Moon": {Name: "Moon", Image: "ipfs://moon"}},
+	}}); err == nil {
+		t.Error("Expected malformed metadata under label 721 not to be overwritten")
+	}
+}
+
+func TestMintCIP68(t *testing.T) {
+	script := nativeKeyScript(1)
+	policyId := script.PolicyId().Value
+	cc := FixedChainContext.InitFixedChainContext()
+	userAddress, _ := Address.DecodeAddress("addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w")
+	referenceAddress := script.ToAddress(nil)
+	datum, err := CIP68.NewDatum(map[string]any{"name": "Moon", "image": "ipfs://moon"}, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob, err := apollo.New(&cc).
+		SetChangeAddress(userAddress).
+		AddLoadedUTxOs(makeFakeUtxo(userAddress, 0, 100_000_000)).
+		AttachNativeScript(script).
+		MintCIP68(policyId, CIP68.NFT_LABEL, "Moon", 1, datum, referenceAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob, _, err = apollob.Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := apollob.GetTx()
+	referenceName, _ := CIP68.NewAssetName(CIP68.REFERENCE_LABEL, "Moon")
+	userName, _ := CIP68.NewAssetName(CIP68.NFT_LABEL, "Moon")
+	minted := tx.TransactionBody.Mint[Policy.PolicyId{Value: policyId}]
+	if minted[referenceName] != 1 || minted[userName] != 1 {
+		t.Errorf("Expected the reference and user tokens to be minted, got %v", minted)
+	}
+	found := false
+	for _, output := range tx.TransactionBody.Outputs {
+		if !bytes.Equal(output.GetAddress().PaymentPart, referenceAddress.PaymentPart) {
+			continue
+		}
+		found = true
+		if output.GetAmount().GetAssets()[Policy.PolicyId{Value: policyId}][referenceName] != 1 {
+			t.Error("Expected the reference token to be paid to the reference address")
+		}
+		parsed, err := CIP68.ParseDatum(*output.GetDatum())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name, _ := parsed.Text("name"); name != "Moon" {
+			t.Errorf("Expected name Moon, got %s", name)
+		}
+	}
+	if !found {
+		t.Error("Expected an output to the reference address")
+	}
+	if _, err := apollo.New(&cc).MintCIP68(policyId, CIP68.NFT_LABEL, "a name that is far too long to fit", 1, datum, referenceAddress); err == nil {
+		t.Error("Expected a name longer than 32 bytes to be rejected")
+	}
+}
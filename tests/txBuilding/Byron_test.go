@@ -0,0 +1,74 @@
+package txBuilding_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo"
+	"github.com/SundaeSwap-finance/apollo/apollotypes"
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/FixedChainContext"
+)
+
+func TestSpendFromByronAddress(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	apollob, err := apollo.New(&cc).SetIcarusWalletFromMnemonic(HD_MNEMONIC, constants.MAINNET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := apollob.GetWallet().(*apollotypes.IcarusWallet)
+	byron := *wallet.GetAddress()
+	if byron.AddressType != Address.BYRON || !bytes.Equal(byron.Bytes(), wallet.ByronAddress.Bytes()) {
+		t.Fatalf("Expected a bootstrap wallet address, got %s", byron.String())
+	}
+	receiver, _ := Address.DecodeAddress("addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w")
+	apollob, _, err = apollob.
+		SetWalletAsChangeAddress().
+		AddInput(makeFakeUtxo(byron, 0, 20_000_000)).
+		PayToAddress(receiver, 5_000_000).
+		Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing, err := apollob.MissingSigners(); err != nil || len(missing) != 1 || !bytes.Equal(missing[0][:], wallet.ByronAddress.Root) {
+		t.Errorf("Expected the address root to be missing, got %v", missing)
+	}
+	estimated := apollob.GetTx().TransactionBody.Fee
+	apollob = apollob.Sign()
+	tx := apollob.GetTx()
+	if len(tx.TransactionWitnessSet.BootstrapWitnesses) != 1 || len(tx.TransactionWitnessSet.VkeyWitnesses) != 0 {
+		t.Fatalf("Expected a single bootstrap witness, got %v", tx.TransactionWitnessSet)
+	}
+	if !bytes.Equal(tx.TransactionWitnessSet.BootstrapWitnesses[0].Root(), wallet.ByronAddress.Root) {
+		t.Error("Expected the witness to hash to the address root")
+	}
+	if err := apollob.VerifyWitnesses(); err != nil {
+		t.Error(err)
+	}
+	if missing, err := apollob.MissingSigners(); err != nil || len(missing) != 0 {
+		t.Errorf("Expected no missing signer, got %v", missing)
+	}
+	pp := cc.GetProtocolParams()
+	if minFee := int64(pp.MinFeeConstant + pp.MinFeeCoefficient*len(tx.Bytes())); estimated < minFee {
+		t.Errorf("Expected the fee %d to cover the signed size, needing %d", estimated, minFee)
+	}
+
+	encoded, err := cbor.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := Transaction.Transaction{}
+	if err := cbor.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.TransactionWitnessSet.BootstrapWitnesses) != 1 ||
+		!bytes.Equal(decoded.TransactionWitnessSet.BootstrapWitnesses[0].Signature, tx.TransactionWitnessSet.BootstrapWitnesses[0].Signature) {
+		t.Error("Expected the bootstrap witness to survive a cbor round trip")
+	}
+	if decoded.TransactionBody.Outputs[1].GetAddress().String() != byron.String() {
+		t.Error("Expected the change to go back to the bootstrap address")
+	}
+}
@@ -2,8 +2,10 @@ package txBuilding_test
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
+	"github.com/SundaeSwap-finance/apollo"
 	"github.com/SundaeSwap-finance/apollo/serialization/Address"
 	"github.com/SundaeSwap-finance/apollo/serialization/Asset"
 	"github.com/SundaeSwap-finance/apollo/serialization/AssetName"
@@ -198,6 +200,203 @@ func TestMultiAsset(t *testing.T) {
 
 }
 
+func TestBranchAndBoundExactMatch(t *testing.T) {
+	chain_context := FixedChainContext.InitFixedChainContext()
+	decoded_address, _ := Address.DecodeAddress(TESTADDRESS)
+	selector := CoinSelection.BranchAndBoundSelector{}
+	utxos := make([]UTxO.UTxO, 0)
+	for i, lovelace := range []int64{10_000_000, 7_000_000, 5_000_000, 3_000_000, 2_000_000} {
+		tx_in := TransactionInput.TransactionInput{TransactionId: make([]byte, 32), Index: i}
+		utxos = append(utxos, UTxO.UTxO{Input: tx_in, Output: TransactionOutput.SimpleTransactionOutput(decoded_address, Value.PureLovelaceValue(lovelace))})
+	}
+	request := []TransactionOutput.TransactionOutput{TransactionOutput.SimpleTransactionOutput(decoded_address, Value.PureLovelaceValue(8_000_000))}
+	selected, change, err := selector.Select(utxos, request, chain_context, -1, false, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(selected) != 2 {
+		t.Errorf("Expected 2 utxos to be selected, got %d", len(selected))
+	}
+	if change.GetCoin() != 0 {
+		t.Errorf("Expected no change, got %d", change.GetCoin())
+	}
+}
+
+func TestBranchAndBoundMultiAsset(t *testing.T) {
+	chain_context := FixedChainContext.InitFixedChainContext()
+	decoded_address, _ := Address.DecodeAddress(TESTADDRESS)
+	selector := CoinSelection.BranchAndBoundSelector{Seed: 42}
+	utxos := initUtxosDifferentiated()
+	request := []TransactionOutput.TransactionOutput{TransactionOutput.SimpleTransactionOutput(decoded_address,
+		Value.SimpleValue(5_000_000, MultiAsset.MultiAsset[int64]{
+			Policy.PolicyId{Value: "00000000000000000000000000000000000000000000000000000000"}: Asset.Asset[int64]{
+				AssetName.NewAssetNameFromString("token0"): int64(50),
+				AssetName.NewAssetNameFromString("token3"): int64(50),
+			},
+		}))}
+	selected, change, err := selector.Select(utxos, request, chain_context, -1, true, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	selectedAmount := Value.Value{}
+	for _, utxo := range selected {
+		selectedAmount = selectedAmount.Add(utxo.Output.GetValue())
+	}
+	if !request[0].GetValue().Less(selectedAmount) {
+		t.Errorf("Expected request to be fulfilled, selected %v", selectedAmount)
+	}
+	if change.GetCoin() < 0 {
+		t.Errorf("Expected positive change, got %d", change.GetCoin())
+	}
+	for i := 0; i < 5; i++ {
+		again, _, _ := selector.Select(utxos, request, chain_context, -1, true, true)
+		if len(again) != len(selected) {
+			t.Fatalf("Expected the same selection for the same seed")
+		}
+		for j := range again {
+			if again[j].GetKey() != selected[j].GetKey() {
+				t.Fatalf("Expected the same selection for the same seed")
+			}
+		}
+	}
+}
+
+func TestRandomImproveMultiAsset(t *testing.T) {
+	chain_context := FixedChainContext.InitFixedChainContext()
+	decoded_address, _ := Address.DecodeAddress(TESTADDRESS)
+	selector := CoinSelection.RandomImproveMultiAsset{}
+	utxos := initLargeWallet(1000)
+	request := []TransactionOutput.TransactionOutput{TransactionOutput.SimpleTransactionOutput(decoded_address,
+		Value.SimpleValue(150_000_000, MultiAsset.MultiAsset[int64]{
+			Policy.PolicyId{Value: "00000000000000000000000000000000000000000000000000000000"}: Asset.Asset[int64]{
+				AssetName.NewAssetNameFromString("token4"): int64(100),
+			},
+		}))}
+	// the selection is random, so check a few of them
+	for i := 0; i < 20; i++ {
+		selected, change, err := selector.Select(utxos, request, chain_context, -1, true, true)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		seen := make(map[string]bool)
+		selectedAmount := Value.Value{}
+		for _, utxo := range selected {
+			if seen[utxo.GetKey()] {
+				t.Fatalf("Expected %s to be selected once", utxo.GetKey())
+			}
+			seen[utxo.GetKey()] = true
+			selectedAmount = selectedAmount.Add(utxo.Output.GetValue())
+		}
+		if !request[0].GetValue().Less(selectedAmount) {
+			t.Fatalf("Expected request to be fulfilled, selected %v", selectedAmount)
+		}
+		if change.GetCoin() < int64(chain_context.MaxTxFee()) {
+			t.Fatalf("Expected the change to cover the max fee, got %d", change.GetCoin())
+		}
+	}
+}
+
+func TestBranchAndBoundErrors(t *testing.T) {
+	chain_context := FixedChainContext.InitFixedChainContext()
+	decoded_address, _ := Address.DecodeAddress(TESTADDRESS)
+	selector := CoinSelection.BranchAndBoundSelector{}
+	utxos := initUtxos()
+	request := []TransactionOutput.TransactionOutput{TransactionOutput.SimpleTransactionOutput(decoded_address, Value.PureLovelaceValue(1_000_000_000))}
+	_, _, err := selector.Select(utxos, request, chain_context, -1, false, false)
+	if _, ok := err.(*CoinSelection.InsufficientUtxoBalanceError); !ok {
+		t.Errorf("Expected InsufficientUtxoBalanceError, got %v", err)
+	}
+	request = []TransactionOutput.TransactionOutput{TransactionOutput.SimpleTransactionOutput(decoded_address, Value.PureLovelaceValue(25_000_000))}
+	_, _, err = selector.Select(utxos, request, chain_context, 2, false, false)
+	if _, ok := err.(*CoinSelection.MaxInputCountExceededError); !ok {
+		t.Errorf("Expected MaxInputCountExceededError, got %v", err)
+	}
+}
+
+func initLargeWallet(size int) []UTxO.UTxO {
+	rng := rand.New(rand.NewSource(1))
+	Addr, _ := Address.DecodeAddress(TESTADDRESS)
+	policy := Policy.PolicyId{Value: "00000000000000000000000000000000000000000000000000000000"}
+	utxos := make([]UTxO.UTxO, 0, size)
+	for i := 0; i < size; i++ {
+		tx_in := TransactionInput.TransactionInput{
+			TransactionId: make([]byte, 32),
+			Index:         i,
+		}
+		value := Value.PureLovelaceValue(int64(1_000_000 + rng.Intn(50_000_000)))
+		if i%4 == 0 {
+			value = Value.SimpleValue(value.Coin, MultiAsset.MultiAsset[int64]{
+				policy: Asset.Asset[int64]{AssetName.NewAssetNameFromString(fmt.Sprintf("token%d", i%20)): int64(rng.Intn(1000) + 1)},
+			})
+		}
+		utxos = append(utxos, UTxO.UTxO{Input: tx_in, Output: TransactionOutput.SimpleTransactionOutput(Addr, value)})
+	}
+	return utxos
+}
+
+/*
+benchmarkSelector times selector on a wallet of size UTxOs and reports
+the inputs, change outputs and fee of the transaction Complete builds
+with it, so that selectors compare on more than speed.
+*/
+func benchmarkSelector(b *testing.B, selector CoinSelection.UTxOSelector, size int) {
+	chain_context := FixedChainContext.InitFixedChainContext()
+	decoded_address, _ := Address.DecodeAddress(TESTADDRESS)
+	utxos := initLargeWallet(size)
+	request := []TransactionOutput.TransactionOutput{TransactionOutput.SimpleTransactionOutput(decoded_address,
+		Value.SimpleValue(150_000_000, MultiAsset.MultiAsset[int64]{
+			Policy.PolicyId{Value: "00000000000000000000000000000000000000000000000000000000"}: Asset.Asset[int64]{
+				AssetName.NewAssetNameFromString("token4"): int64(100),
+			},
+		}))}
+
+	apollob, _, err := apollo.New(&chain_context).
+		AddInputAddress(decoded_address).
+		AddLoadedUTxOs(utxos...).
+		PayToAddress(decoded_address, 150_000_000, apollo.NewUnit("00000000000000000000000000000000000000000000000000000000", "token4", 100)).
+		SetCoinSelector(selector).
+		Complete()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := selector.Select(utxos, request, chain_context, -1, true, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+	// reported after the loop, as ResetTimer drops reported metrics
+	body := apollob.GetTx().TransactionBody
+	b.ReportMetric(float64(len(body.Inputs)), "inputs")
+	b.ReportMetric(float64(len(body.Outputs)-1), "change-outputs")
+	b.ReportMetric(float64(body.Fee), "fee-lovelace")
+}
+
+func BenchmarkLargestFirst1000(b *testing.B) {
+	benchmarkSelector(b, CoinSelection.LargestFirstSelector{}, 1000)
+}
+
+func BenchmarkLargestFirst5000(b *testing.B) {
+	benchmarkSelector(b, CoinSelection.LargestFirstSelector{}, 5000)
+}
+
+func BenchmarkRandomImprove1000(b *testing.B) {
+	benchmarkSelector(b, CoinSelection.RandomImproveMultiAsset{}, 1000)
+}
+
+func BenchmarkRandomImprove5000(b *testing.B) {
+	benchmarkSelector(b, CoinSelection.RandomImproveMultiAsset{}, 5000)
+}
+
+func BenchmarkBranchAndBound1000(b *testing.B) {
+	benchmarkSelector(b, CoinSelection.BranchAndBoundSelector{Seed: 1}, 1000)
+}
+
+func BenchmarkBranchAndBound5000(b *testing.B) {
+	benchmarkSelector(b, CoinSelection.BranchAndBoundSelector{Seed: 1}, 5000)
+}
+
 // func TestRandomImproveAdaOnly(t *testing.T) {
 // 	chain_context := backend.InitFixedChainContext()
 // 	decoded_address, _ := serialization.DecodeAddress(Address)
@@ -0,0 +1,301 @@
+package txBuilding_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/SundaeSwap-finance/apollo"
+	"github.com/SundaeSwap-finance/apollo/apollotypes"
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/Key"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/Value"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/EmulatorChainContext"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Errors"
+)
+
+const EMULATOR_RECEIVER = "addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w"
+
+func newEmulator(t *testing.T) (*EmulatorChainContext.EmulatorChainContext, *apollotypes.GenericWallet) {
+	t.Helper()
+	cc, err := EmulatorChainContext.NewEmulatorChainContext(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob, err := apollo.New(cc).SetWalletFromMnemonicWithNetwork(HD_MNEMONIC, constants.MAINNET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := apollob.GetWallet().(*apollotypes.GenericWallet)
+	cc.Fund(wallet.Address, Value.PureLovelaceValue(100_000_000))
+	return cc, wallet
+}
+
+func emulatorPayment(t *testing.T, cc *EmulatorChainContext.EmulatorChainContext, lovelace int) *apollo.Apollo {
+	t.Helper()
+	return emulatorPaymentWithTtl(t, cc, lovelace, 0)
+}
+
+func emulatorPaymentWithTtl(t *testing.T, cc *EmulatorChainContext.EmulatorChainContext, lovelace int, ttl int64) *apollo.Apollo {
+	t.Helper()
+	apollob, err := apollo.New(cc).SetWalletFromMnemonicWithNetwork(HD_MNEMONIC, constants.MAINNET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := *apollob.GetWallet().GetAddress()
+	receiver, _ := Address.DecodeAddress(EMULATOR_RECEIVER)
+	apollob, _, err = apollob.
+		SetTtl(ttl).
+		SetWalletAsChangeAddress().
+		AddLoadedUTxOs(cc.Utxos(wallet)...).
+		PayToAddress(receiver, lovelace).
+		Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return apollob
+}
+
+func TestEmulatorGenesis(t *testing.T) {
+	cc, err := EmulatorChainContext.NewEmulatorChainContext(map[string]Value.Value{
+		EMULATOR_RECEIVER: Value.PureLovelaceValue(10_000_000),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, _ := Address.DecodeAddress(EMULATOR_RECEIVER)
+	utxos := cc.Utxos(receiver)
+	if len(utxos) != 1 || utxos[0].Output.Lovelace() != 10_000_000 {
+		t.Fatalf("Expected the genesis UTxO, got %v", utxos)
+	}
+	if _, err := cc.GetUtxoFromRef(hex.EncodeToString(utxos[0].Input.TransactionId), 0); err != nil {
+		t.Error(err)
+	}
+	if _, err := EmulatorChainContext.NewEmulatorChainContext(map[string]Value.Value{"addr1invalid": Value.PureLovelaceValue(1)}); err == nil {
+		t.Error("Expected an invalid genesis address to fail")
+	}
+}
+
+func TestEmulatorChainedPayments(t *testing.T) {
+	cc, wallet := newEmulator(t)
+	receiver, _ := Address.DecodeAddress(EMULATOR_RECEIVER)
+
+	unsigned := emulatorPayment(t, cc, 10_000_000)
+	if _, err := unsigned.Submit(); !errors.As(err, new(*Errors.MissingSignatureError)) {
+		t.Fatalf("Expected an unsigned transaction to be rejected, got %v", err)
+	}
+	snapshot := cc.Snapshot()
+
+	first := emulatorPayment(t, cc, 10_000_000).Sign()
+	id, err := first.Submit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cc.GetTx(hex.EncodeToString(id.Payload)); !ok {
+		t.Error("Expected the transaction to be recorded")
+	}
+	if _, err := first.Submit(); !errors.As(err, new(*Errors.UnresolvedInputError)) {
+		t.Errorf("Expected a resubmission to spend unknown inputs, got %v", err)
+	}
+	second := emulatorPayment(t, cc, 20_000_000).Sign()
+	if _, err := second.Submit(); err != nil {
+		t.Fatal(err)
+	}
+	if received := cc.Utxos(receiver); len(received) != 2 {
+		t.Errorf("Expected 2 payments to the receiver, got %d", len(received))
+	}
+	change := cc.Utxos(wallet.Address)
+	fees := first.GetTx().TransactionBody.Fee + second.GetTx().TransactionBody.Fee
+	if len(change) != 1 || change[0].Output.Lovelace() != 70_000_000-fees {
+		t.Errorf("Expected the change of the second payment only, got %v", change)
+	}
+
+	cc.Restore(snapshot)
+	if len(cc.Utxos(receiver)) != 0 || len(cc.Utxos(wallet.Address)) != 1 {
+		t.Error("Expected the snapshot to be restored")
+	}
+}
+
+func TestEmulatorValidityInterval(t *testing.T) {
+	cc, _ := newEmulator(t)
+	apollob := emulatorPaymentWithTtl(t, cc, 10_000_000, 100).Sign()
+	snapshot := cc.Snapshot()
+
+	cc.AwaitSlot(100)
+	if _, err := apollob.Submit(); !errors.As(err, new(*Errors.OutsideValidityIntervalError)) {
+		t.Errorf("Expected an expired transaction to be rejected, got %v", err)
+	}
+	cc.AwaitSlot(50)
+	if cc.LastBlockSlot() != 100 {
+		t.Error("Expected the chain not to go back in time")
+	}
+	cc.AwaitEpoch(2)
+	if cc.Epoch() != 2 || cc.LastBlockSlot() != 2*cc.GetGenesisParams().EpochLength {
+		t.Errorf("Expected the first slot of epoch 2, got %d", cc.LastBlockSlot())
+	}
+
+	cc.Restore(snapshot)
+	if cc.LastBlockSlot() != 0 {
+		t.Fatal("Expected the slot to be restored")
+	}
+	if _, err := apollob.Submit(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEmulatorRewardAccounts(t *testing.T) {
+	cc, wallet := newEmulator(t)
+	stakeHash, _ := Key.VerificationKey(wallet.StakeVerificationKey).Hash()
+	stake := Credential.FromKeyHash(stakeHash)
+	pool := serialization.PubKeyHash{0x01}
+
+	apollob, err := apollo.New(cc).SetWalletFromMnemonicWithNetwork(HD_MNEMONIC, constants.MAINNET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob, _, err = apollob.
+		SetWalletAsChangeAddress().
+		AddLoadedUTxOs(cc.Utxos(wallet.Address)...).
+		RegisterStake(stake).
+		DelegateStake(stake, pool).
+		AddRequiredSigner(stakeHash).
+		Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob = apollob.Sign().SignWithSkey(Key.VerificationKey(wallet.StakeVerificationKey), Key.SigningKey(wallet.StakeSigningKey))
+	if _, err := apollob.Submit(); err != nil {
+		t.Fatal(err)
+	}
+	account, ok := cc.RewardAccount(stake)
+	if !ok || account.Deposit != 2_000_000 || account.Pool == nil || *account.Pool != pool {
+		t.Fatalf("Expected a registered account delegated to the pool, got %v", account)
+	}
+	if err := cc.AddRewards(stake, 5_000_000); err != nil {
+		t.Fatal(err)
+	}
+	if account, _ := cc.RewardAccount(stake); account.Balance != 5_000_000 {
+		t.Errorf("Expected 5 ada of rewards, got %d", account.Balance)
+	}
+
+	apollob, err = apollo.New(cc).SetWalletFromMnemonicWithNetwork(HD_MNEMONIC, constants.MAINNET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob, _, err = apollob.
+		SetWalletAsChangeAddress().
+		AddLoadedUTxOs(cc.Utxos(wallet.Address)...).
+		DeregisterStake(stake).
+		AddRequiredSigner(stakeHash).
+		Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob = apollob.Sign().SignWithSkey(Key.VerificationKey(wallet.StakeVerificationKey), Key.SigningKey(wallet.StakeSigningKey))
+	if _, err := apollob.Submit(); err == nil || !strings.Contains(err.Error(), "rewards") {
+		t.Errorf("Expected deregistering an account holding rewards to fail, got %v", err)
+	}
+	if err := cc.AddRewards(Credential.FromKeyHash(pool), 1); err == nil {
+		t.Error("Expected rewards to an unregistered credential to fail")
+	}
+}
+
+// emulatorScriptSpend spends scriptUtxo with redeemerIs42 and the given
+// redeemer, without estimating the execution units so that failing
+// scripts can be built.
+func emulatorScriptSpend(t *testing.T, cc *EmulatorChainContext.EmulatorChainContext, scriptUtxo UTxO.UTxO, redeemer int64) Transaction.Transaction {
+	t.Helper()
+	apollob, err := apollo.New(cc).SetWalletFromMnemonicWithNetwork(HD_MNEMONIC, constants.MAINNET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob, _, err = apollob.
+		SetWalletAsChangeAddress().
+		AddLoadedUTxOs(cc.Utxos(*apollob.GetWallet().GetAddress())...).
+		CollectFrom(scriptUtxo, PlutusData.NewBigInt(big.NewInt(redeemer))).
+		AttachV3Script(redeemerIs42(t)).
+		DisableExecutionUnitsEstimation().
+		Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *apollob.Sign().GetTx()
+}
+
+func TestEmulatorPlutusSpend(t *testing.T) {
+	cc, wallet := newEmulator(t)
+	script := redeemerIs42(t)
+	scriptUtxo := cc.Fund(script.ToAddress(nil), Value.PureLovelaceValue(10_000_000))
+
+	apollob, err := apollo.New(cc).SetWalletFromMnemonicWithNetwork(HD_MNEMONIC, constants.MAINNET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob, _, err = apollob.
+		SetWalletAsChangeAddress().
+		AddLoadedUTxOs(cc.Utxos(wallet.Address)...).
+		CollectFrom(scriptUtxo, PlutusData.NewBigInt(big.NewInt(42))).
+		AttachV3Script(script).
+		Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := apollob.Sign().GetTx()
+	if tx.TransactionWitnessSet.Redeemer[0].ExUnits.Steps == 0 {
+		t.Error("Expected the emulator to estimate the execution units")
+	}
+	if _, err := cc.SubmitTx(*tx); err != nil {
+		t.Fatal(err)
+	}
+	if len(cc.Utxos(script.ToAddress(nil))) != 0 {
+		t.Error("Expected the script UTxO to be spent")
+	}
+}
+
+func TestEmulatorInvalidTransactions(t *testing.T) {
+	cc, wallet := newEmulator(t)
+	script := redeemerIs42(t)
+	scriptUtxo := cc.Fund(script.ToAddress(nil), Value.PureLovelaceValue(10_000_000))
+
+	passing := emulatorScriptSpend(t, cc, scriptUtxo, 42)
+	passing.Valid = false
+	if _, err := cc.SubmitTx(passing); err == nil || !strings.Contains(err.Error(), "none of its scripts fail") {
+		t.Errorf("Expected a transaction marked invalid with passing scripts to be rejected, got %v", err)
+	}
+	payment := *emulatorPayment(t, cc, 5_000_000).Sign().GetTx()
+	payment.Valid = false
+	if _, err := cc.SubmitTx(payment); err == nil || !strings.Contains(err.Error(), "none of its scripts fail") {
+		t.Errorf("Expected a transaction marked invalid without scripts to be rejected, got %v", err)
+	}
+	if len(cc.Utxos(wallet.Address)) != 1 || len(cc.Utxos(script.ToAddress(nil))) != 1 {
+		t.Fatal("Expected rejected transactions to leave the ledger untouched")
+	}
+
+	failing := emulatorScriptSpend(t, cc, scriptUtxo, 41)
+	var failure *Errors.ScriptFailureError
+	if _, err := cc.SubmitTx(failing); !errors.As(err, &failure) {
+		t.Errorf("Expected a transaction marked valid with a failing script to be rejected, got %v", err)
+	}
+	failing.Valid = false
+	if len(failing.TransactionBody.Collateral) == 0 {
+		t.Fatal("Expected the script spend to have collateral")
+	}
+	if _, err := cc.SubmitTx(failing); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cc.GetUtxoFromRef(hex.EncodeToString(scriptUtxo.Input.TransactionId), scriptUtxo.Input.Index); err != nil {
+		t.Error("Expected the script UTxO to stay unspent")
+	}
+	collateral := failing.TransactionBody.Collateral[0]
+	if _, err := cc.GetUtxoFromRef(hex.EncodeToString(collateral.TransactionId), collateral.Index); err == nil {
+		t.Error("Expected the collateral to be taken")
+	}
+}
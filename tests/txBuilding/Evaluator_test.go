@@ -0,0 +1,262 @@
+package txBuilding_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo"
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionBody"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionOutput"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionWitnessSet"
+	"github.com/SundaeSwap-finance/apollo/serialization/UPLC"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/Value"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/FixedChainContext"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Errors"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Evaluator"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/SlotConfig"
+)
+
+func onesCostModel(keys []string) PlutusData.CostModel {
+	cm := make(PlutusData.CostModel, len(keys))
+	for i := range cm {
+		cm[i] = 1
+	}
+	return cm
+}
+
+func evaluatorChainContext() FixedChainContext.FixedChainContext {
+	cc := FixedChainContext.InitFixedChainContext()
+	cc.ProtocolParams.CostModels = map[Base.CostModelsPlutusVersion]PlutusData.CostModel{
+		Base.CostModelsPlutusV2: onesCostModel(PlutusData.V2COSTMODELKEYS),
+		Base.CostModelsPlutusV3: onesCostModel(PlutusData.V3COSTMODELKEYSUNSORTED),
+	}
+	return cc
+}
+
+func builtinCall(fun UPLC.DefaultFunction, forces int, args ...UPLC.Term) UPLC.Term {
+	var term UPLC.Term = UPLC.Builtin{Fun: fun}
+	for i := 0; i < forces; i++ {
+		term = UPLC.Force{Term: term}
+	}
+	for _, arg := range args {
+		term = UPLC.Apply{Function: term, Argument: arg}
+	}
+	return term
+}
+
+// checkScript succeeds when check holds and fails tracing "wrong
+// redeemer" otherwise.
+func checkScript(t *testing.T, version [3]uint64, arity int, check UPLC.Term) []byte {
+	var body UPLC.Term = UPLC.Force{Term: builtinCall(UPLC.IfThenElse, 1, check,
+		UPLC.Delay{Term: UPLC.Constant{Value: UPLC.Unit()}},
+		UPLC.Delay{Term: UPLC.Force{Term: builtinCall(UPLC.Trace, 1,
+			UPLC.Constant{Value: UPLC.String("wrong redeemer")}, UPLC.Delay{Term: UPLC.Error{}})}},
+	)}
+	for i := 0; i < arity; i++ {
+		body = UPLC.Lambda{Body: body}
+	}
+	script, err := UPLC.EncodeScript(UPLC.Program{Version: version, Term: body}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+// redeemerIs42 is a PlutusV3 script reading its redeemer from the
+// script context.
+func redeemerIs42(t *testing.T) PlutusData.PlutusV3Script {
+	fields := builtinCall(UPLC.SndPair, 2, builtinCall(UPLC.UnConstrData, 0, UPLC.Var{Index: 1}))
+	redeemer := builtinCall(UPLC.UnIData, 0, builtinCall(UPLC.HeadList, 1, builtinCall(UPLC.TailList, 1, fields)))
+	return checkScript(t, [3]uint64{1, 1, 0}, 1, builtinCall(UPLC.EqualsInteger, 0, redeemer, UPLC.Constant{Value: UPLC.Integer(big.NewInt(42))}))
+}
+
+func buildScriptSpend(t *testing.T, redeemer int64) (*apollo.Apollo, error) {
+	cc := evaluatorChainContext()
+	script := redeemerIs42(t)
+	userAddress, _ := Address.DecodeAddress("addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w")
+	scriptUtxo := makeFakeUtxo(script.ToAddress(nil), 7, 10_000_000)
+	apollob := apollo.New(Evaluator.NewChainContext(cc)).
+		SetChangeAddress(userAddress).
+		AddLoadedUTxOs(makeFakeUtxo(userAddress, 0, 100_000_000)).
+		SetAdditionalUTxOs([]UTxO.UTxO{scriptUtxo}).
+		CollectFrom(scriptUtxo, PlutusData.NewBigInt(big.NewInt(redeemer))).
+		AttachV3Script(script).
+		PayToAddress(userAddress, 2_000_000)
+	apollob, _, err := apollob.Complete()
+	return apollob, err
+}
+
+func TestEvaluatorEstimatesBuilderRedeemers(t *testing.T) {
+	apollob, err := buildScriptSpend(t, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redeemers := apollob.GetTx().TransactionWitnessSet.Redeemer
+	if len(redeemers) != 1 {
+		t.Fatalf("Expected 1 redeemer, got %d", len(redeemers))
+	}
+	if redeemers[0].ExUnits.Mem <= 0 || redeemers[0].ExUnits.Steps <= 0 {
+		t.Errorf("Expected the execution units to be estimated, got %v", redeemers[0].ExUnits)
+	}
+	script := redeemerIs42(t)
+	txBytes, _ := cbor.Marshal(apollob.GetTx())
+	units, err := Evaluator.NewChainContext(evaluatorChainContext()).
+		EvaluateTxWithAdditionalUtxos(txBytes, []UTxO.UTxO{makeFakeUtxo(script.ToAddress(nil), 7, 10_000_000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if units["spend:0"] != redeemers[0].ExUnits {
+		t.Errorf("Expected %v, got %v", units["spend:0"], redeemers[0].ExUnits)
+	}
+}
+
+func TestEvaluatorReportsScriptFailure(t *testing.T) {
+	_, err := buildScriptSpend(t, 41)
+	var failure *Errors.ScriptFailureError
+	if !errors.As(err, &failure) {
+		t.Fatalf("Expected a script failure, got %v", err)
+	}
+	if failure.Redeemer != "spend:0" || !reflect.DeepEqual(failure.Logs, []string{"wrong redeemer"}) {
+		t.Errorf("Unexpected failure %v", failure)
+	}
+	if !errors.Is(err, UPLC.ErrErrorTerm) {
+		t.Errorf("Expected the error term to be the cause, got %v", failure.Err)
+	}
+}
+
+func TestEvaluateV2SpendWithDatum(t *testing.T) {
+	// succeeds when the datum equals the redeemer
+	script := PlutusData.PlutusV2Script(checkScript(t, [3]uint64{1, 0, 0}, 3,
+		builtinCall(UPLC.EqualsData, 0, UPLC.Var{Index: 3}, UPLC.Var{Index: 2})))
+	datum := PlutusData.NewConstr(0, PlutusData.NewBytes([]byte("apollo")))
+	scriptOutput := TransactionOutput.TransactionOutput{IsPostAlonzo: true, PostAlonzo: TransactionOutput.TransactionOutputAlonzo{
+		Address: script.ToAddress(nil),
+		Amount:  Value.PureLovelaceValue(5_000_000).ToAlonzoValue(),
+	}}
+	scriptOutput.SetDatum(&datum)
+	input := TransactionInput.TransactionInput{TransactionId: make([]byte, 32), Index: 1}
+	userAddress, _ := Address.DecodeAddress(FixedChainContext.TEST_ADDR)
+	tx := Transaction.Transaction{
+		TransactionBody: TransactionBody.TransactionBody{
+			Inputs:  []TransactionInput.TransactionInput{input},
+			Outputs: []TransactionOutput.TransactionOutput{TransactionOutput.SimpleTransactionOutput(userAddress, Value.PureLovelaceValue(4_800_000))},
+			Fee:     200_000,
+		},
+		TransactionWitnessSet: TransactionWitnessSet.TransactionWitnessSet{
+			PlutusV2Script: []PlutusData.PlutusV2Script{script},
+			Redeemer:       []Redeemer.Redeemer{{Tag: Redeemer.SPEND, Index: 0, Data: datum}},
+		},
+	}
+	cc := evaluatorChainContext()
+	resolved := []UTxO.UTxO{{Input: input, Output: scriptOutput}}
+	slots := SlotConfig.FromGenesis(cc.GetGenesisParams())
+	units, err := Evaluator.EvaluateTx(tx, resolved, cc.GetProtocolParams(), slots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if units["spend:0"].Steps <= 0 {
+		t.Errorf("Expected spend:0 to be evaluated, got %v", units)
+	}
+
+	tx.TransactionWitnessSet.Redeemer[0].Data = PlutusData.NewBytes([]byte("apollo"))
+	_, err = Evaluator.EvaluateTx(tx, resolved, cc.GetProtocolParams(), slots)
+	var failure *Errors.ScriptFailureError
+	if !errors.As(err, &failure) {
+		t.Errorf("Expected a script failure, got %v", err)
+	}
+
+	_, err = Evaluator.EvaluateTx(tx, nil, cc.GetProtocolParams(), slots)
+	var unresolved *Errors.UnresolvedInputError
+	if !errors.As(err, &unresolved) {
+		t.Errorf("Expected an unresolved input error, got %v", err)
+	}
+}
+
+// Mainnet cost models since the Plomin hard fork, in the on chain order.
+var (
+	mainnetCostModelV2 = PlutusData.CostModel{100788, 420, 1, 1, 1000, 173, 0, 1, 1000, 59957, 4, 1, 11183, 32, 201305, 8356, 4, 16000, 100, 16000, 100, 16000, 100, 16000, 100, 16000, 100, 16000, 100, 100, 100, 16000, 100, 94375, 32, 132994, 32, 61462, 4, 72010, 178, 0, 1, 22151, 32, 91189, 769, 4, 2, 85848, 228465, 122, 0, 1, 1, 1000, 42921, 4, 2, 24548, 29498, 38, 1, 898148, 27279, 1, 51775, 558, 1, 39184, 1000, 60594, 1, 141895, 32, 83150, 32, 15299, 32, 76049, 1, 13169, 4, 22100, 10, 28999, 74, 1, 28999, 74, 1, 43285, 552, 1, 44749, 541, 1, 33852, 32, 68246, 32, 72362, 32, 7243, 32, 7391, 32, 11546, 32, 85848, 228465, 122, 0, 1, 1, 90434, 519, 0, 1, 74433, 32, 85848, 228465, 122, 0, 1, 1, 85848, 228465, 122, 0, 1, 1, 955506, 213312, 0, 2, 270652, 22588, 4, 1457325, 64566, 4, 20467, 1, 4, 0, 141992, 32, 100788, 420, 1, 1, 81663, 32, 59498, 32, 20142, 32, 24588, 32, 20744, 32, 25933, 32, 24623, 32, 43053543, 10, 53384111, 14333, 10, 43574283, 26308, 10}
+	mainnetCostModelV3 = PlutusData.CostModel{100788, 420, 1, 1, 1000, 173, 0, 1, 1000, 59957, 4, 1, 11183, 32, 201305, 8356, 4, 16000, 100, 16000, 100, 16000, 100, 16000, 100, 16000, 100, 16000, 100, 100, 100, 16000, 100, 94375, 32, 132994, 32, 61462, 4, 72010, 178, 0, 1, 22151, 32, 91189, 769, 4, 2, 85848, 123203, 7305, -900, 1716, 549, 57, 85848, 0, 1, 1, 1000, 42921, 4, 2, 24548, 29498, 38, 1, 898148, 27279, 1, 51775, 558, 1, 39184, 1000, 60594, 1, 141895, 32, 83150, 32, 15299, 32, 76049, 1, 13169, 4, 22100, 10, 28999, 74, 1, 28999, 74, 1, 43285, 552, 1, 44749, 541, 1, 33852, 32, 68246, 32, 72362, 32, 7243, 32, 7391, 32, 11546, 32, 85848, 123203, 7305, -900, 1716, 549, 57, 85848, 0, 1, 90434, 519, 0, 1, 74433, 32, 85848, 123203, 7305, -900, 1716, 549, 57, 85848, 0, 1, 1, 85848, 123203, 7305, -900, 1716, 549, 57, 85848, 0, 1, 955506, 213312, 0, 2, 270652, 22588, 4, 1457325, 64566, 4, 20467, 1, 4, 0, 141992, 32, 100788, 420, 1, 1, 81663, 32, 59498, 32, 20142, 32, 24588, 32, 20744, 32, 25933, 32, 24623, 32, 43053543, 10, 53384111, 14333, 10, 43574283, 26308, 10, 16000, 100, 16000, 100, 962335, 18, 2780678, 6, 442008, 1, 52538055, 3756, 18, 267929, 18, 76433006, 8868, 18, 52948122, 18, 1995836, 36, 3227919, 12, 901022, 1, 166917843, 4307, 36, 284546, 36, 158221314, 26549, 36, 74698472, 36, 333849714, 1, 254006273, 72, 2174038, 72, 2261318, 64571, 4, 207616, 8310, 4, 1293828, 28716, 63, 0, 1, 1006041, 43623, 251, 0, 1, 100181, 726, 719, 0, 1, 100181, 726, 719, 0, 1, 100181, 726, 719, 0, 1, 107878, 680, 0, 1, 95336, 1, 281145, 18848, 0, 1, 180194, 159, 1, 1, 158519, 8942, 0, 1, 159378, 8813, 0, 1, 107490, 3298, 1, 106057, 655, 1, 1964219, 24520, 3}
+)
+
+// The script context of spending the output 1 of transaction 1111... with
+// redeemerIs42, laid out following the PlutusV3 ledger api.
+const redeemerIs42Context = "d8799fd8799f9fd8799fd8799f5820111111111111111111111111111111111111111111111111111111111111111101ffd8799fd8799fd87a9f581c7388769cbe1cb10d25753efa33bb7482c4b4d2d521ddb685de996a90ffd87a80ffa140a1401a00989680d87980d87a80ffffff809fd8799fd8799fd8799f581c22222222222222222222222222222222222222222222222222222222ffd87a80ffa140a1401a00958940d87980d87a80ffff1a00030d40a080a0d8799fd8799fd87980d87a80ffd8799fd87b80d87a80ffff80a1d87a9fd8799f5820111111111111111111111111111111111111111111111111111111111111111101ffff182aa05820295e6d70a58ba7313b2e0e8d75e981837d746c07083c8546c7c267bb3cc0c6dda080d87a80d87a80ff182ad87a9fd8799f5820111111111111111111111111111111111111111111111111111111111111111101ffd87a80ffff"
+
+func TestEvaluateWithMainnetCostModels(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	cc.ProtocolParams.CostModels = map[Base.CostModelsPlutusVersion]PlutusData.CostModel{
+		Base.CostModelsPlutusV2: mainnetCostModelV2,
+		Base.CostModelsPlutusV3: mainnetCostModelV3,
+	}
+	slots := SlotConfig.FromGenesis(cc.GetGenesisParams())
+	user := *Address.AddressFromBytes(bytes.Repeat([]byte{0x22}, 28), false, nil, false, constants.MAINNET)
+	input := TransactionInput.TransactionInput{TransactionId: bytes.Repeat([]byte{0x11}, 32), Index: 1}
+
+	script := redeemerIs42(t)
+	tx := Transaction.Transaction{
+		TransactionBody: TransactionBody.TransactionBody{
+			Inputs:  []TransactionInput.TransactionInput{input},
+			Outputs: []TransactionOutput.TransactionOutput{TransactionOutput.SimpleTransactionOutput(user, Value.PureLovelaceValue(9_800_000))},
+			Fee:     200_000,
+		},
+		TransactionWitnessSet: TransactionWitnessSet.TransactionWitnessSet{
+			PlutusV3Script: []PlutusData.PlutusV3Script{script},
+			Redeemer:       []Redeemer.Redeemer{{Tag: Redeemer.SPEND, Index: 0, Data: PlutusData.NewBigInt(big.NewInt(42))}},
+		},
+	}
+	resolved := []UTxO.UTxO{{Input: input, Output: TransactionOutput.SimpleTransactionOutput(script.ToAddress(nil), Value.PureLovelaceValue(10_000_000))}}
+	args, err := Evaluator.ScriptArgs(tx, resolved, slots, Redeemer.SPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	context, _ := cbor.Marshal(args[len(args)-1])
+	if hex.EncodeToString(context) != redeemerIs42Context {
+		t.Errorf("Expected script context\n%s\ngot\n%x", redeemerIs42Context, context)
+	}
+	units, err := Evaluator.EvaluateTx(tx, resolved, cc.GetProtocolParams(), slots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 31 machine steps of 16000/100, the startup 100/100, and the
+	// builtins unConstrData 24588/32, sndPair 141992/32, tailList
+	// 81663/32, headList 83150/32, unIData 20744/32, equalsInteger
+	// 51775+558*1/1 and ifThenElse 76049/1.
+	if expected := (Redeemer.ExecutionUnits{Mem: 3362, Steps: 976619}); units["spend:0"] != expected {
+		t.Errorf("Expected %v, got %v", expected, units["spend:0"])
+	}
+
+	v2 := PlutusData.PlutusV2Script(checkScript(t, [3]uint64{1, 0, 0}, 3,
+		builtinCall(UPLC.EqualsData, 0, UPLC.Var{Index: 3}, UPLC.Var{Index: 2})))
+	datum := PlutusData.NewConstr(0, PlutusData.NewBytes([]byte("apollo")))
+	scriptOutput := TransactionOutput.TransactionOutput{IsPostAlonzo: true, PostAlonzo: TransactionOutput.TransactionOutputAlonzo{
+		Address: v2.ToAddress(nil),
+		Amount:  Value.PureLovelaceValue(10_000_000).ToAlonzoValue(),
+	}}
+	scriptOutput.SetDatum(&datum)
+	tx.TransactionWitnessSet = TransactionWitnessSet.TransactionWitnessSet{
+		PlutusV2Script: []PlutusData.PlutusV2Script{v2},
+		Redeemer:       []Redeemer.Redeemer{{Tag: Redeemer.SPEND, Index: 0, Data: datum}},
+	}
+	units, err = Evaluator.EvaluateTx(tx, []UTxO.UTxO{{Input: input, Output: scriptOutput}}, cc.GetProtocolParams(), slots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 23 machine steps, the startup, ifThenElse 76049/1 and equalsData
+	// 898148+27279*9/1, Constr 0 [B "apollo"] taking 9 words.
+	if expected := (Redeemer.ExecutionUnits{Mem: 2402, Steps: 1587808}); units["spend:0"] != expected {
+		t.Errorf("Expected %v, got %v", expected, units["spend:0"])
+	}
+}
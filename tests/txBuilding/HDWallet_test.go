@@ -0,0 +1,186 @@
+package txBuilding_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/SundaeSwap-finance/apollo"
+	"github.com/SundaeSwap-finance/apollo/apollotypes"
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/HDWallet"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/FixedChainContext"
+)
+
+const HD_MNEMONIC = "test walk nut penalty hip pave soap entry language right filter choice"
+
+// addressChainContext serves the UTxOs funded per address.
+type addressChainContext struct {
+	FixedChainContext.FixedChainContext
+	funded map[string][]UTxO.UTxO
+}
+
+func (a *addressChainContext) Utxos(address Address.Address) []UTxO.UTxO {
+	return a.funded[address.String()]
+}
+
+func (a *addressChainContext) fund(address Address.Address, lovelace int64) {
+	index := 0
+	for _, utxos := range a.funded {
+		index += len(utxos)
+	}
+	a.funded[address.String()] = append(a.funded[address.String()], makeFakeUtxo(address, index, lovelace))
+}
+
+func TestHDWalletDerivation(t *testing.T) {
+	hd := HDWallet.NewHDWalletFromMnemonic(HD_MNEMONIC, "")
+	wallet := apollotypes.NewHDAccountWallet(hd, 0, constants.MAINNET)
+	if wallet.GetAddress().String() != "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3jcu5d8ps7zex2k2xt3uqxgjqnnj83ws8lhrn648jjxtwqfjkjv7" {
+		t.Errorf("Unexpected first address %s", wallet.GetAddress().String())
+	}
+	cc := FixedChainContext.InitFixedChainContext()
+	fromMnemonic := apollo.New(&cc).SetWalletFromMnemonic(HD_MNEMONIC).GetWallet().GetAddress()
+	if fromMnemonic.String() != wallet.GetAddress().String() {
+		t.Errorf("Expected the address of SetWalletFromMnemonic, got %s", fromMnemonic.String())
+	}
+	derived := wallet.DeriveAddress(HDWallet.INTERNAL_CHAIN, 7)
+	private := hd.DeriveKey(0, HDWallet.INTERNAL_CHAIN, 7).XPrivKey.PublicKey()
+	if !bytes.Equal(derived.VerificationKey.Payload, private) {
+		t.Error("Expected the public derivation to match the private one")
+	}
+	other := apollotypes.NewHDAccountWallet(hd, 1, constants.TESTNET)
+	if other.GetAddress().Hrp != "addr_test" || other.GetAddress().String() == wallet.GetAddress().String() {
+		t.Errorf("Unexpected address of account 1 %s", other.GetAddress().String())
+	}
+}
+
+func TestHDWalletDiscovery(t *testing.T) {
+	hd := HDWallet.NewHDWalletFromMnemonic(HD_MNEMONIC, "")
+	wallet := apollotypes.NewHDAccountWallet(hd, 0, constants.MAINNET)
+	wallet.GapLimit = 5
+	cc := &addressChainContext{FixedChainContext.InitFixedChainContext(), map[string][]UTxO.UTxO{}}
+	cc.fund(wallet.DeriveAddress(HDWallet.EXTERNAL_CHAIN, 3).Address, 30_000_000)
+	cc.fund(wallet.DeriveAddress(HDWallet.INTERNAL_CHAIN, 1).Address, 40_000_000)
+	// beyond the gap limit of the external chain
+	cc.fund(wallet.DeriveAddress(HDWallet.EXTERNAL_CHAIN, 9).Address, 1_000_000)
+
+	utxos := wallet.Discover(cc)
+	if len(utxos) != 2 {
+		t.Fatalf("Expected 2 UTxOs to be discovered, got %d", len(utxos))
+	}
+	if len(wallet.Addresses) != 3 || wallet.Addresses[0].Index != 0 || wallet.Addresses[0].Used() {
+		t.Errorf("Expected the first external address and the 2 used ones, got %v", wallet.Addresses)
+	}
+	if next := wallet.UnusedAddress(HDWallet.EXTERNAL_CHAIN); next.Index != 4 {
+		t.Errorf("Expected external index 4 to be the next unused, got %d", next.Index)
+	}
+	if wallet.ChangeAddress().String() != wallet.DeriveAddress(HDWallet.INTERNAL_CHAIN, 2).Address.String() {
+		t.Error("Expected the change address to be internal index 2")
+	}
+
+	receiver, _ := Address.DecodeAddress("addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w")
+	apollob, _, err := apollo.New(cc).
+		SetHDWallet(wallet).
+		PayToAddress(receiver, 60_000_000).
+		Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apollob = apollob.Sign()
+	if len(apollob.GetTx().TransactionWitnessSet.VkeyWitnesses) != 2 {
+		t.Errorf("Expected one witness per spent address, got %d", len(apollob.GetTx().TransactionWitnessSet.VkeyWitnesses))
+	}
+	if missing, err := apollob.MissingSigners(); err != nil || len(missing) != 0 {
+		t.Errorf("Expected every input to be signed, missing %v", missing)
+	}
+	if err := apollob.VerifyWitnesses(); err != nil {
+		t.Error(err)
+	}
+	change := apollob.GetTx().TransactionBody.Outputs[1].GetAddress()
+	if change.String() != wallet.ChangeAddress().String() {
+		t.Errorf("Expected the change to go to %s, got %s", wallet.ChangeAddress().String(), change.String())
+	}
+}
+
+func TestHDWalletDiscoveryWithHistory(t *testing.T) {
+	hd := HDWallet.NewHDWalletFromMnemonic(HD_MNEMONIC, "")
+	wallet := apollotypes.NewHDAccountWallet(hd, 0, constants.MAINNET)
+	wallet.GapLimit = 3
+	cc := &addressChainContext{FixedChainContext.InitFixedChainContext(), map[string][]UTxO.UTxO{}}
+	emptied := wallet.DeriveAddress(HDWallet.EXTERNAL_CHAIN, 2).Address
+	cc.fund(wallet.DeriveAddress(HDWallet.EXTERNAL_CHAIN, 5).Address, 10_000_000)
+
+	if utxos := wallet.Discover(cc); len(utxos) != 0 {
+		t.Errorf("Expected the funds past the emptied address to be missed, got %d UTxOs", len(utxos))
+	}
+	wallet.HasHistory = func(address Address.Address) bool {
+		return address.String() == emptied.String()
+	}
+	if utxos := wallet.Discover(cc); len(utxos) != 1 {
+		t.Fatalf("Expected 1 UTxO past the emptied address, got %d", len(utxos))
+	}
+	if len(wallet.Addresses) != 3 || !wallet.Addresses[1].InHistory || wallet.Addresses[2].Index != 5 {
+		t.Errorf("Expected the first, emptied and funded addresses, got %v", wallet.Addresses)
+	}
+	if next := wallet.UnusedAddress(HDWallet.EXTERNAL_CHAIN); next.Index != 6 {
+		t.Errorf("Expected external index 6 to be the next unused, got %d", next.Index)
+	}
+}
+
+func TestDiscoverAccounts(t *testing.T) {
+	hd := HDWallet.NewHDWalletFromMnemonic(HD_MNEMONIC, "")
+	cc := &addressChainContext{FixedChainContext.InitFixedChainContext(), map[string][]UTxO.UTxO{}}
+	if accounts := apollotypes.DiscoverAccounts(hd, constants.MAINNET, cc, 3, nil); len(accounts) != 1 {
+		t.Errorf("Expected account 0 for an empty wallet, got %d accounts", len(accounts))
+	}
+	cc.fund(apollotypes.NewHDAccountWallet(hd, 0, constants.MAINNET).DeriveAddress(HDWallet.EXTERNAL_CHAIN, 1).Address, 5_000_000)
+	cc.fund(apollotypes.NewHDAccountWallet(hd, 1, constants.MAINNET).DeriveAddress(HDWallet.EXTERNAL_CHAIN, 0).Address, 5_000_000)
+	cc.fund(apollotypes.NewHDAccountWallet(hd, 3, constants.MAINNET).DeriveAddress(HDWallet.EXTERNAL_CHAIN, 0).Address, 5_000_000)
+	accounts := apollotypes.DiscoverAccounts(hd, constants.MAINNET, cc, 3, nil)
+	if len(accounts) != 2 || accounts[1].Account != 1 {
+		t.Errorf("Expected discovery to stop at the unused account 2, got %d accounts", len(accounts))
+	}
+}
+
+func TestSetWalletFromMnemonicNetwork(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	apollob, err := apollo.New(&cc).SetWalletFromMnemonicWithNetwork(HD_MNEMONIC, constants.PREPROD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := apollob.GetWallet().(*apollotypes.GenericWallet)
+	if wallet.Address.String() != "addr_test1qz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3jcu5d8ps7zex2k2xt3uqxgjqnnj83ws8lhrn648jjxtwq2ytjqp" {
+		t.Errorf("Unexpected testnet base address %s", wallet.Address.String())
+	}
+	if wallet.Address.HeaderByte != 0b00000000 {
+		t.Errorf("Expected a testnet base address header, got %08b", wallet.Address.HeaderByte)
+	}
+	if wallet.EnterpriseAddress().String() != "addr_test1vz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerspjrlsz" {
+		t.Errorf("Unexpected testnet enterprise address %s", wallet.EnterpriseAddress().String())
+	}
+	if wallet.RewardAddress().String() != "stake_test1uqevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqp8n5xl" {
+		t.Errorf("Unexpected testnet reward address %s", wallet.RewardAddress().String())
+	}
+
+	withPassphrase, err := apollo.New(&cc).SetWalletFromMnemonicWithPassphrase(HD_MNEMONIC, "secret", constants.MAINNET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withPassphrase.GetWallet().GetAddress().String() == wallet.Address.String() {
+		t.Error("Expected the passphrase to derive another wallet")
+	}
+	expected := apollotypes.NewHDAccountWallet(HDWallet.NewHDWalletFromMnemonic(HD_MNEMONIC, "secret"), 0, constants.MAINNET)
+	if withPassphrase.GetWallet().GetAddress().String() != expected.GetAddress().String() {
+		t.Error("Expected the passphrase wallet to match the HD account derived with it")
+	}
+
+	if _, err := apollo.New(&cc).SetWalletFromMnemonicWithNetwork("not a mnemonic", constants.MAINNET); err == nil {
+		t.Error("Expected an invalid mnemonic to be rejected")
+	}
+	// without a network, the one of the chain context is used
+	cc.GenesisParams.NetworkMagic = constants.PREPROD_PROTOCOL_MAGIC
+	if address := apollo.New(&cc).SetWalletFromMnemonic(HD_MNEMONIC).GetWallet().GetAddress(); address.String() != wallet.Address.String() {
+		t.Errorf("Expected the testnet address of the chain context, got %s", address.String())
+	}
+}
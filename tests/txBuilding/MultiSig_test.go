@@ -0,0 +1,165 @@
+package txBuilding_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/Salvionied/cbor/v2"
+	"github.com/SundaeSwap-finance/apollo"
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Key"
+	"github.com/SundaeSwap-finance/apollo/serialization/NativeScript"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionWitnessSet"
+	"github.com/SundaeSwap-finance/apollo/serialization/VerificationKeyWitness"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/FixedChainContext"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Errors"
+)
+
+type treasuryKey struct {
+	vkey Key.VerificationKey
+	skey Key.SigningKey
+	hash serialization.PubKeyHash
+}
+
+func treasuryKeys() []treasuryKey {
+	keys := make([]treasuryKey, 3)
+	for i := range keys {
+		sk := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{byte(i + 1)}, 32))
+		keys[i].vkey = Key.VerificationKey{Payload: sk.Public().(ed25519.PublicKey)}
+		keys[i].skey = Key.SigningKey{Payload: sk}
+		keys[i].hash, _ = keys[i].vkey.Hash()
+	}
+	return keys
+}
+
+func signWitnessSet(bodyHash string, key treasuryKey) TransactionWitnessSet.TransactionWitnessSet {
+	hash, _ := hex.DecodeString(bodyHash)
+	return TransactionWitnessSet.TransactionWitnessSet{
+		VkeyWitnesses: []VerificationKeyWitness.VerificationKeyWitness{VerificationKeyWitness.SignBodyHash(hash, key.vkey, key.skey)},
+	}
+}
+
+func containsSigner(signers []string, pkh serialization.PubKeyHash) bool {
+	for _, signer := range signers {
+		if signer == hex.EncodeToString(pkh[:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMultiPartySigning(t *testing.T) {
+	keys := treasuryKeys()
+	treasury := NativeScript.NativeScript{Tag: NativeScript.ScriptNofK, NoK: 2}
+	for _, key := range keys {
+		treasury.NativeScripts = append(treasury.NativeScripts, NativeScript.NativeScript{Tag: NativeScript.ScriptPubKey, KeyHash: key.hash[:]})
+	}
+	cc := FixedChainContext.InitFixedChainContext()
+	treasuryAddress := treasury.ToAddress(nil)
+	receiver, _ := Address.DecodeAddress("addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w")
+	coordinator, _, err := apollo.New(&cc).
+		SetChangeAddress(treasuryAddress).
+		AddLoadedUTxOs(makeFakeUtxo(treasuryAddress, 0, 100_000_000)).
+		CollectFromNativeScript(makeFakeUtxo(treasuryAddress, 1, 50_000_000), treasury).
+		PayToAddress(receiver, 10_000_000).
+		Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := coordinator.ExportSigningRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if !containsSigner(request.RequiredSigners, key.hash) {
+			t.Errorf("Expected %x to be asked to sign", key.hash)
+		}
+	}
+
+	// every party reviews the transaction and signs its body hash detached
+	witnessSets := make([]TransactionWitnessSet.TransactionWitnessSet, 0)
+	for _, key := range keys[:2] {
+		party, err := apollo.New(&cc).LoadTxCbor(request.TxCbor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(party.GetTx().TransactionBody.Hash()) != request.BodyHash {
+			t.Fatal("Expected the loaded transaction to have the exported body hash")
+		}
+		witnessSet := signWitnessSet(request.BodyHash, key)
+		encoded, _ := cbor.Marshal(&witnessSet)
+		decoded := TransactionWitnessSet.TransactionWitnessSet{}
+		if err := cbor.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		witnessSets = append(witnessSets, decoded)
+	}
+	for _, witnessSet := range [][]TransactionWitnessSet.TransactionWitnessSet{witnessSets, witnessSets[:1]} {
+		if coordinator, err = coordinator.AddWitnessSets(witnessSet...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(coordinator.GetTx().TransactionWitnessSet.VkeyWitnesses) != 2 {
+		t.Errorf("Expected 2 vkey witnesses, got %d", len(coordinator.GetTx().TransactionWitnessSet.VkeyWitnesses))
+	}
+	if len(coordinator.GetTx().TransactionWitnessSet.NativeScripts) != 1 {
+		t.Errorf("Expected the treasury script to be kept once, got %d", len(coordinator.GetTx().TransactionWitnessSet.NativeScripts))
+	}
+	if err := coordinator.VerifyWitnesses(); err != nil {
+		t.Error(err)
+	}
+	if missing, err := coordinator.MissingSigners(); err != nil || len(missing) != 0 {
+		t.Errorf("Expected no missing signer once 2 of 3 signed, got %v", missing)
+	}
+
+	forged := signWitnessSet(hex.EncodeToString(make([]byte, 32)), keys[2])
+	if coordinator, err = coordinator.AddVerificationKeyWitness(forged.VkeyWitnesses[0]); err != nil {
+		t.Fatal(err)
+	}
+	var invalid *Errors.InvalidSignatureError
+	if err := coordinator.VerifyWitnesses(); !errors.As(err, &invalid) || !bytes.Equal(invalid.Vkey, keys[2].vkey.Payload) {
+		t.Errorf("Expected the forged witness to be reported, got %v", err)
+	}
+
+	// signing again replaces the forged witness, a forged one doesn't
+	// replace a valid one
+	if coordinator, err = coordinator.AddWitnessSets(signWitnessSet(request.BodyHash, keys[2])); err != nil {
+		t.Fatal(err)
+	}
+	if coordinator, err = coordinator.AddVerificationKeyWitness(signWitnessSet(hex.EncodeToString(make([]byte, 32)), keys[0]).VkeyWitnesses[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(coordinator.GetTx().TransactionWitnessSet.VkeyWitnesses) != 3 {
+		t.Errorf("Expected 3 vkey witnesses, got %d", len(coordinator.GetTx().TransactionWitnessSet.VkeyWitnesses))
+	}
+	if err := coordinator.VerifyWitnesses(); err != nil {
+		t.Errorf("Expected the forged witness to be replaced, got %v", err)
+	}
+
+	merged := TransactionWitnessSet.TransactionWitnessSet{}
+	merged.Merge(forged, signWitnessSet(request.BodyHash, keys[2]))
+	if len(merged.VkeyWitnesses) != 1 || !merged.VkeyWitnesses[0].Verify(coordinator.GetTx().TransactionBody.Hash()) {
+		t.Errorf("Expected the newer witness to win, got %v", merged.VkeyWitnesses)
+	}
+}
+
+func TestMultiPartySigningBeforeComplete(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	apollob := apollo.New(&cc)
+	if _, err := apollob.AddWitnessSets(TransactionWitnessSet.TransactionWitnessSet{}); err == nil {
+		t.Error("Expected adding witness sets without a transaction to fail")
+	}
+	if _, err := apollob.AddVerificationKeyWitness(VerificationKeyWitness.VerificationKeyWitness{}); err == nil {
+		t.Error("Expected adding a witness without a transaction to fail")
+	}
+	if err := apollob.VerifyWitnesses(); err == nil {
+		t.Error("Expected verifying witnesses without a transaction to fail")
+	}
+	if _, err := apollob.MissingSigners(); err == nil {
+		t.Error("Expected listing signers without a transaction to fail")
+	}
+}
@@ -0,0 +1,107 @@
+package txBuilding_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/SundaeSwap-finance/apollo"
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/FixedChainContext"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/PendingChainContext"
+)
+
+// evaluationChainContext records the additional UTxOs it evaluates with.
+type evaluationChainContext struct {
+	addressChainContext
+	additional []UTxO.UTxO
+}
+
+func (e *evaluationChainContext) EvaluateTxWithAdditionalUtxos(tx []uint8, utxos []UTxO.UTxO) (map[string]Redeemer.ExecutionUnits, error) {
+	e.additional = utxos
+	return e.addressChainContext.EvaluateTxWithAdditionalUtxos(tx, utxos)
+}
+
+func TestChainPendingTransactions(t *testing.T) {
+	backend := &evaluationChainContext{addressChainContext: addressChainContext{FixedChainContext.InitFixedChainContext(), map[string][]UTxO.UTxO{}}}
+	cc := PendingChainContext.NewPendingChainContext(backend)
+	now := time.Unix(1_700_000_000, 0)
+	cc.Now = func() time.Time { return now }
+
+	apollob, err := apollo.New(cc).SetWalletFromMnemonicWithNetwork(HD_MNEMONIC, constants.MAINNET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := *apollob.GetWallet().GetAddress()
+	backend.fund(wallet, 20_000_000)
+	funded := backend.funded[wallet.String()][0]
+	receiver, _ := Address.DecodeAddress("addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w")
+
+	apollob, _, err = apollob.SetWalletAsChangeAddress().AddLoadedUTxOs(cc.Utxos(wallet)...).PayToAddress(receiver, 5_000_000).Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := *apollob.Sign().GetTx()
+	if _, err := apollob.Submit(); err != nil {
+		t.Fatal(err)
+	}
+	firstId := first.Id()
+
+	utxos := cc.Utxos(wallet)
+	if len(utxos) != 1 || !bytes.Equal(utxos[0].Input.TransactionId, firstId.Payload) {
+		t.Fatalf("Expected the change of the pending transaction only, got %v", utxos)
+	}
+	if len(cc.Utxos(receiver)) != 1 {
+		t.Error("Expected the payment to show up at the receiver")
+	}
+	if _, err := cc.GetUtxoFromRef(hex.EncodeToString(funded.Input.TransactionId), funded.Input.Index); err == nil {
+		t.Error("Expected the spent input not to resolve")
+	}
+	change, err := cc.GetUtxoFromRef(hex.EncodeToString(firstId.Payload), utxos[0].Input.Index)
+	if err != nil || change.Output.Lovelace() != utxos[0].Output.Lovelace() {
+		t.Errorf("Expected the pending change to resolve, got %v", err)
+	}
+
+	second, err := apollo.New(cc).SetWalletFromMnemonicWithNetwork(HD_MNEMONIC, constants.MAINNET)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err = second.SetWalletAsChangeAddress().AddLoadedUTxOs(cc.Utxos(wallet)...).PayToAddress(receiver, 5_000_000).Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := second.GetTx().TransactionBody.Inputs
+	if len(inputs) != 1 || !bytes.Equal(inputs[0].TransactionId, firstId.Payload) {
+		t.Fatalf("Expected the second transaction to spend the pending change, got %v", inputs)
+	}
+	if _, err := cc.EvaluateTx(second.GetTx().Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.additional) != 1 || backend.additional[0].GetKey() != utxos[0].GetKey() {
+		t.Errorf("Expected the pending change to be passed to the evaluation, got %v", backend.additional)
+	}
+	if _, err := second.Sign().Submit(); err != nil {
+		t.Fatal(err)
+	}
+	if len(cc.Pending()) != 2 {
+		t.Fatalf("Expected 2 pending transactions, got %d", len(cc.Pending()))
+	}
+
+	// the backend now shows the first transaction confirmed
+	backend.funded[wallet.String()] = []UTxO.UTxO{utxos[0]}
+	if utxos := cc.Utxos(wallet); len(utxos) != 1 || bytes.Equal(utxos[0].Input.TransactionId, firstId.Payload) {
+		t.Errorf("Expected the change of the second transaction only, got %v", utxos)
+	}
+	if pending := cc.Pending(); len(pending) != 1 || bytes.Equal(pending[0].Id.Payload, firstId.Payload) {
+		t.Errorf("Expected the first transaction to be pruned, got %v", pending)
+	}
+
+	now = now.Add(PendingChainContext.DEFAULT_TTL)
+	if len(cc.Pending()) != 0 {
+		t.Error("Expected the second transaction to expire")
+	}
+}
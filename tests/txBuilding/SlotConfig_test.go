@@ -0,0 +1,97 @@
+package txBuilding_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SundaeSwap-finance/apollo"
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/FixedChainContext"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/SlotConfig"
+)
+
+func TestSlotConfigKnownPoints(t *testing.T) {
+	mainnet := SlotConfig.ForNetwork(constants.MAINNET)
+	if mainnet.SlotToTime(0).Unix() != 1506203091 {
+		t.Errorf("Expected byron start 1506203091, got %d", mainnet.SlotToTime(0).Unix())
+	}
+	if mainnet.SlotToTime(4492800).Unix() != 1596059091 {
+		t.Errorf("Expected shelley start 1596059091, got %d", mainnet.SlotToTime(4492800).Unix())
+	}
+	if mainnet.SlotToPosix(4492900) != 1596059191000 {
+		t.Errorf("Expected 1596059191000, got %d", mainnet.SlotToPosix(4492900))
+	}
+	if mainnet.SlotToTime(4492799).Unix() != 1596059071 {
+		t.Errorf("Expected the last byron slot to be 20 seconds long, got %d", mainnet.SlotToTime(4492799).Unix())
+	}
+	if mainnet.PosixToSlot(1596059191000) != 4492900 {
+		t.Errorf("Expected slot 4492900, got %d", mainnet.PosixToSlot(1596059191000))
+	}
+
+	preprod := SlotConfig.ForNetwork(constants.PREPROD)
+	if preprod.SlotToTime(86400).Unix() != 1655769600 {
+		t.Errorf("Expected preprod shelley start 1655769600, got %d", preprod.SlotToTime(86400).Unix())
+	}
+	preview := SlotConfig.ForNetwork(constants.PREVIEW)
+	if preview.SlotToTime(1000).Unix() != 1666657000 {
+		t.Errorf("Expected 1666657000, got %d", preview.SlotToTime(1000).Unix())
+	}
+}
+
+func TestSlotConfigRounding(t *testing.T) {
+	mainnet := SlotConfig.ForNetwork(constants.MAINNET)
+	exact := time.Unix(1596059191, 0)
+	between := exact.Add(500 * time.Millisecond)
+	if mainnet.TimeToSlot(exact) != 4492900 || mainnet.TimeToSlotCeil(exact) != 4492900 {
+		t.Errorf("Expected a slot boundary to round to itself")
+	}
+	if mainnet.TimeToSlot(between) != 4492900 {
+		t.Errorf("Expected rounding down to 4492900, got %d", mainnet.TimeToSlot(between))
+	}
+	if mainnet.TimeToSlotCeil(between) != 4492901 {
+		t.Errorf("Expected rounding up to 4492901, got %d", mainnet.TimeToSlotCeil(between))
+	}
+}
+
+func TestSetValidFromAndUntil(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	from := time.UnixMilli(1700000000500)
+	until := time.UnixMilli(1700000600500)
+	apollob := apollo.New(&cc).SetValidFrom(from).SetValidUntil(until)
+	config := apollob.GetSlotConfig()
+	if config.SlotToTime(apollob.ValidityStart).Before(from) {
+		t.Errorf("Expected the validity start to be at or after %v", from)
+	}
+	if config.SlotToTime(apollob.ValidityStart-1).After(from) {
+		t.Errorf("Expected the validity start to be the first slot after %v", from)
+	}
+	if config.SlotToTime(apollob.Ttl).After(until) {
+		t.Errorf("Expected the ttl to be at or before %v", until)
+	}
+	if apollob.Ttl-apollob.ValidityStart != 599 {
+		t.Errorf("Expected 599 slots of validity, got %d", apollob.Ttl-apollob.ValidityStart)
+	}
+
+	custom := SlotConfig.SlotConfig{Eras: []SlotConfig.Era{{StartSlot: 0, StartTime: time.Unix(0, 0), SlotLength: time.Second}}}
+	apollob = apollo.New(&cc).SetSlotConfig(custom).SetValidUntil(time.Unix(1000, 0))
+	if apollob.Ttl != 1000 {
+		t.Errorf("Expected the custom slot config to be used, got ttl %d", apollob.Ttl)
+	}
+}
+
+func TestSlotConfigWithoutEras(t *testing.T) {
+	mainnet := SlotConfig.ForNetwork(constants.MAINNET)
+	empty := SlotConfig.SlotConfig{}
+	if empty.SlotToPosix(4492900) != mainnet.SlotToPosix(4492900) || empty.PosixToSlot(1596059191000) != 4492900 {
+		t.Error("Expected a slot config without eras to convert like mainnet")
+	}
+	noLength := SlotConfig.SlotConfig{Eras: []SlotConfig.Era{{StartTime: time.Unix(1666656000, 0)}}}
+	if noLength.TimeToSlot(time.Unix(1666657000, 0)) != 1000 {
+		t.Errorf("Expected 1 second slots, got slot %d", noLength.TimeToSlot(time.Unix(1666657000, 0)))
+	}
+	fromEmptyGenesis := SlotConfig.FromGenesis(Base.GenesisParameters{})
+	if fromEmptyGenesis.TimeToSlot(time.Unix(1000, 0)) != 1000 {
+		t.Errorf("Expected 1 second slots from a genesis without slot length, got slot %d", fromEmptyGenesis.TimeToSlot(time.Unix(1000, 0)))
+	}
+}
@@ -0,0 +1,183 @@
+package txBuilding_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/SundaeSwap-finance/apollo"
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Certificate"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/Key"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionOutput"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/Value"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/FixedChainContext"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Errors"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Validation"
+)
+
+func buildValidationTx(t *testing.T, ttl int64, payTo string) (*apollo.Apollo, Key.PaymentKeyPair) {
+	cc := FixedChainContext.InitFixedChainContext()
+	keys := Key.PaymentKeyPairGenerate()
+	pkh, _ := keys.VerificationKey.Hash()
+	address := Address.AddressFromBytes(pkh[:], false, nil, false, constants.MAINNET)
+	utxos := []UTxO.UTxO{
+		makeFakeUtxo(*address, 0, 20_000_000),
+		makeFakeUtxo(*address, 1, 5_000_000),
+	}
+	apollob := apollo.New(&cc).
+		SetChangeAddress(*address).
+		AddLoadedUTxOs(utxos...).
+		PayToAddressBech32(payTo, 3_000_000).
+		SetTtl(ttl)
+	apollob, _, err := apollob.Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return apollob, keys
+}
+
+func TestValidateSignedTx(t *testing.T) {
+	userAddress := "addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w"
+	apollob, keys := buildValidationTx(t, 3000, userAddress)
+	apollob = apollob.SignWithSkey(keys.VerificationKey, keys.SigningKey)
+	if err := apollob.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateReportsBrokenRules(t *testing.T) {
+	userAddress := "addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w"
+	apollob, _ := buildValidationTx(t, 1000, userAddress)
+	apollob.GetTx().TransactionBody.Fee = 1
+	err := apollob.Validate()
+	var validationErrs *Errors.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	var missing *Errors.MissingSignatureError
+	if !errors.As(err, &missing) {
+		t.Error("Expected MissingSignatureError")
+	}
+	var feeErr *Errors.FeeTooSmallError
+	if !errors.As(err, &feeErr) {
+		t.Error("Expected FeeTooSmallError")
+	}
+	var valueErr *Errors.ValueNotConservedError
+	if !errors.As(err, &valueErr) {
+		t.Error("Expected ValueNotConservedError")
+	}
+	var intervalErr *Errors.OutsideValidityIntervalError
+	if !errors.As(err, &intervalErr) {
+		t.Error("Expected OutsideValidityIntervalError")
+	}
+}
+
+func TestValidateNetworkAndResolution(t *testing.T) {
+	testnetAddress := "addr_test1vrm9x2zsux7va6w892g38tvchnzahvcd9tykqf3ygnmwtaqyfg52x"
+	apollob, keys := buildValidationTx(t, 3000, testnetAddress)
+	apollob = apollob.SignWithSkey(keys.VerificationKey, keys.SigningKey)
+	err := apollob.Validate()
+	var networkErr *Errors.WrongNetworkError
+	if !errors.As(err, &networkErr) {
+		t.Fatalf("Expected WrongNetworkError, got %v", err)
+	}
+	if networkErr.Field != "output 0" {
+		t.Errorf("Expected the payment output to be on the wrong network, got %s", networkErr.Field)
+	}
+
+	cc := FixedChainContext.InitFixedChainContext()
+	err = Validation.Validate(*apollob.GetTx(), []UTxO.UTxO{}, cc.GetProtocolParams())
+	var unresolved *Errors.UnresolvedInputError
+	if !errors.As(err, &unresolved) {
+		t.Errorf("Expected UnresolvedInputError, got %v", err)
+	}
+}
+
+func TestMinFeeReferenceScripts(t *testing.T) {
+	userAddress := "addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w"
+	apollob, _ := buildValidationTx(t, 3000, userAddress)
+	tx := *apollob.GetTx()
+	reference := TransactionInput.TransactionInput{TransactionId: make([]byte, 32), Index: 9}
+	tx.TransactionBody.ReferenceInputs = []TransactionInput.TransactionInput{reference}
+	address, _ := Address.DecodeAddress(userAddress)
+	referenceUtxo := UTxO.UTxO{Input: reference, Output: TransactionOutput.TransactionOutput{IsPostAlonzo: true, PostAlonzo: TransactionOutput.TransactionOutputAlonzo{
+		Address:   address,
+		Amount:    Value.PureLovelaceValue(50_000_000).ToAlonzoValue(),
+		ScriptRef: &PlutusData.ScriptRef{Script: PlutusData.InnerScript{Script: make([]byte, 60_000)}},
+	}}}
+	cc := FixedChainContext.InitFixedChainContext()
+	pp := cc.GetProtocolParams()
+	pp.MinFeeReferenceScripts = 15
+
+	withoutScript := Validation.MinFee(tx, nil, pp)
+	withScript := Validation.MinFee(tx, []UTxO.UTxO{referenceUtxo}, pp)
+	// 25600 bytes at 15, 25600 at 18 and 8800 at 21.6 lovelace
+	if withScript-withoutScript != 1_034_880 {
+		t.Errorf("Expected a reference script fee of 1034880, got %d", withScript-withoutScript)
+	}
+	// the 51201st byte costs 21.6, the sum being rounded down
+	if fee := pp.ReferenceScriptsFee(51_201); fee != 844_821 {
+		t.Errorf("Expected 844821, got %d", fee)
+	}
+	if fee := pp.ReferenceScriptsFee(0); fee != 0 {
+		t.Errorf("Expected no fee without reference scripts, got %d", fee)
+	}
+}
+
+func TestMinFeeRoundsScriptsFeeUp(t *testing.T) {
+	userAddress := "addr1qymaeeefs9ff08cdplm3lvkscavm9x9vd7nmc44e9rlur08k3pj2xw9w3mvp7cg3fkzhed4zzhywdpd2t3pmc8u8nn8qm5ur5w"
+	apollob, _ := buildValidationTx(t, 3000, userAddress)
+	tx := *apollob.GetTx()
+	cc := FixedChainContext.InitFixedChainContext()
+	pp := cc.GetProtocolParams()
+	redeemer := Redeemer.Redeemer{Tag: Redeemer.SPEND, Index: 0, Data: PlutusData.NewBigInt(big.NewInt(42))}
+	tx.TransactionWitnessSet.Redeemer = []Redeemer.Redeemer{redeemer}
+	withoutUnits := Validation.MinFee(tx, nil, pp)
+	// one unit of each costs 0.0577721 lovelace, which is charged as 1
+	redeemer.ExUnits = Redeemer.ExecutionUnits{Mem: 1, Steps: 1}
+	tx.TransactionWitnessSet.Redeemer = []Redeemer.Redeemer{redeemer}
+	if fee := Validation.MinFee(tx, nil, pp); fee != withoutUnits+1 {
+		t.Errorf("Expected the scripts fee to be rounded up to 1, got %d", fee-withoutUnits)
+	}
+
+	tests := []struct {
+		mem, steps, fee int64
+	}{
+		{0, 0, 0},
+		{1, 1, 1},
+		// 264.4016299 lovelace, which float32 prices truncated to 263
+		{3362, 976619, 265},
+		{10_000_000, 10_000_000_000, 1_298_000},
+	}
+	for _, test := range tests {
+		if fee := pp.ScriptsFee(test.mem, test.steps); fee != test.fee {
+			t.Errorf("Expected %d for %d mem and %d steps, got %d", test.fee, test.mem, test.steps, fee)
+		}
+	}
+}
+
+func TestDepositsRejectMalformedParameters(t *testing.T) {
+	cc := FixedChainContext.InitFixedChainContext()
+	pp := cc.GetProtocolParams()
+	pp.KeyDeposits = "two ada"
+	stake := Credential.FromKeyHash(serialization.PubKeyHash{0x01})
+	certificates := Certificate.Certificates{}
+	registration := Certificate.NewStakeRegistration(stake)
+	certificates = append(certificates, &registration)
+	if _, err := Validation.Deposits(&certificates, nil, pp); err == nil {
+		t.Error("Expected a malformed key deposit to be reported")
+	}
+	deregistration := Certificate.NewStakeDeregistration(stake)
+	certificates = Certificate.Certificates{&deregistration}
+	if _, err := Validation.Refunds(&certificates, pp); err == nil {
+		t.Error("Expected a malformed key deposit to be reported")
+	}
+}
@@ -3,6 +3,7 @@ package Base
 import (
 	"encoding/hex"
 	"log"
+	"math/big"
 	"strconv"
 
 	"github.com/SundaeSwap-finance/apollo/serialization"
@@ -80,6 +81,47 @@ func (p ProtocolParameters) GetCoinsPerUtxoByte() int {
 	return 4310
 }
 
+// REFERENCE_SCRIPTS_FEE_TIER is the size of the tiers reference scripts
+// are priced by since Conway, each tier costing 1.2 times the previous.
+const REFERENCE_SCRIPTS_FEE_TIER = 25600
+
+/*
+ReferenceScriptsFee returns the fee for size bytes of reference
+scripts: MinFeeReferenceScripts per byte over the first tier, the
+price growing 1.2 times every tier after, rounded down once summed.
+*/
+func (p ProtocolParameters) ReferenceScriptsFee(size int) int64 {
+	price := big.NewRat(int64(p.MinFeeReferenceScripts), 1)
+	fee := new(big.Rat)
+	for remaining := int64(size); remaining > 0; remaining -= REFERENCE_SCRIPTS_FEE_TIER {
+		bytes := min(remaining, REFERENCE_SCRIPTS_FEE_TIER)
+		fee.Add(fee, new(big.Rat).Mul(price, big.NewRat(bytes, 1)))
+		price.Mul(price, big.NewRat(6, 5))
+	}
+	return new(big.Int).Quo(fee.Num(), fee.Denom()).Int64()
+}
+
+/*
+ScriptsFee returns the fee for running scripts of mem and steps
+execution units, rounded up as the ledger does. The prices are read as
+the decimals they are published as rather than as their float32 values.
+*/
+func (p ProtocolParameters) ScriptsFee(mem int64, steps int64) int64 {
+	fee := new(big.Rat).Mul(decimalRat(p.PriceMem), big.NewRat(mem, 1))
+	fee.Add(fee, new(big.Rat).Mul(decimalRat(p.PriceStep), big.NewRat(steps, 1)))
+	ceiling, remainder := new(big.Int).QuoRem(fee.Num(), fee.Denom(), new(big.Int))
+	if remainder.Sign() > 0 {
+		ceiling.Add(ceiling, big.NewInt(1))
+	}
+	return ceiling.Int64()
+}
+
+// decimalRat returns the shortest decimal reading as value.
+func decimalRat(value float32) *big.Rat {
+	rat, _ := new(big.Rat).SetString(strconv.FormatFloat(float64(value), 'f', -1, 32))
+	return rat
+}
+
 type Input struct {
 	Address             string          `json:"address"`
 	Amount              []AddressAmount `json:"amount"`
@@ -0,0 +1,494 @@
+package EmulatorChainContext
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Certificate"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionOutput"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/Value"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/FixedChainContext"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Errors"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Evaluator"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/SlotConfig"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Validation"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+// NETWORK_MAGIC is the network magic of the emulated chain, which starts
+// directly in Shelley.
+const NETWORK_MAGIC = 42
+
+// RewardAccount is the ledger state of a registered stake credential.
+type RewardAccount struct {
+	Deposit int64
+	Balance int64
+	Pool    *serialization.PubKeyHash
+	DRep    *Certificate.DRep
+}
+
+// Snapshot is a copy of the ledger state of an emulator, see Restore.
+type Snapshot struct {
+	slot     int64
+	utxos    map[string]UTxO.UTxO
+	rewards  map[string]RewardAccount
+	txs      map[string]Transaction.Transaction
+	fundings int
+}
+
+func (s Snapshot) clone() Snapshot {
+	res := Snapshot{
+		slot:     s.slot,
+		utxos:    make(map[string]UTxO.UTxO, len(s.utxos)),
+		rewards:  make(map[string]RewardAccount, len(s.rewards)),
+		txs:      make(map[string]Transaction.Transaction, len(s.txs)),
+		fundings: s.fundings,
+	}
+	for key, utxo := range s.utxos {
+		res.utxos[key] = utxo
+	}
+	for key, account := range s.rewards {
+		res.rewards[key] = account
+	}
+	for key, tx := range s.txs {
+		res.txs[key] = tx
+	}
+	return res
+}
+
+/*
+EmulatorChainContext is an in memory ledger to run multi transaction
+flows offline. Submitted transactions go through the phase-1 checks and
+their scripts are evaluated before they are applied, at the current slot
+which only moves on AwaitSlot and AwaitEpoch:
+
+	cc, _ := EmulatorChainContext.NewEmulatorChainContext(map[string]Value.Value{
+		"addr_test1...": Value.PureLovelaceValue(100_000_000),
+	})
+	apollob := apollo.New(cc)
+*/
+type EmulatorChainContext struct {
+	ProtocolParams Base.ProtocolParameters
+	GenesisParams  Base.GenesisParameters
+
+	mu     sync.Mutex
+	ledger Snapshot
+}
+
+/*
+NewEmulatorChainContext returns an emulator at slot 0 whose genesis
+transaction pays each bech32 address of genesis its value, in address
+order. The protocol parameters default to the ones of FixedChainContext,
+with the cost models of mainnet.
+*/
+func NewEmulatorChainContext(genesis map[string]Value.Value) (*EmulatorChainContext, error) {
+	fixed := FixedChainContext.InitFixedChainContext()
+	e := &EmulatorChainContext{
+		ProtocolParams: fixed.ProtocolParams,
+		GenesisParams:  fixed.GenesisParams,
+		ledger: Snapshot{
+			utxos:   make(map[string]UTxO.UTxO),
+			rewards: make(map[string]RewardAccount),
+			txs:     make(map[string]Transaction.Transaction),
+		},
+	}
+	e.ProtocolParams.CostModels = mainnetCostModels()
+	e.GenesisParams.NetworkMagic = NETWORK_MAGIC
+	addresses := make([]string, 0, len(genesis))
+	for address := range genesis {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	genesisId := serialization.Blake2bHash([]byte("genesis"))
+	for index, bech32 := range addresses {
+		address, err := Address.DecodeAddress(bech32)
+		if err != nil {
+			return nil, fmt.Errorf("genesis address %s: %w", bech32, err)
+		}
+		e.addUtxo(genesisId, index, TransactionOutput.SimpleTransactionOutput(address, genesis[bech32]))
+	}
+	return e, nil
+}
+
+func inputKey(input TransactionInput.TransactionInput) string {
+	return fmt.Sprintf("%s:%d", hex.EncodeToString(input.TransactionId), input.Index)
+}
+
+func credentialKey(cred Credential.Credential) string {
+	return cred.String()
+}
+
+// addUtxo adds an output to the ledger. The caller holds the lock.
+func (e *EmulatorChainContext) addUtxo(txId []byte, index int, output TransactionOutput.TransactionOutput) UTxO.UTxO {
+	utxo := UTxO.UTxO{
+		Input:  TransactionInput.TransactionInput{TransactionId: txId, Index: index},
+		Output: output,
+	}
+	e.ledger.utxos[inputKey(utxo.Input)] = utxo
+	return utxo
+}
+
+// Fund adds a UTxO paying value to address, out of thin air.
+func (e *EmulatorChainContext) Fund(address Address.Address, value Value.Value) UTxO.UTxO {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ledger.fundings++
+	txId := serialization.Blake2bHash([]byte(fmt.Sprintf("funding:%d", e.ledger.fundings)))
+	return e.addUtxo(txId, 0, TransactionOutput.SimpleTransactionOutput(address, value)).Clone()
+}
+
+// Snapshot returns a copy of the ledger state.
+func (e *EmulatorChainContext) Snapshot() Snapshot {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.ledger.clone()
+}
+
+// Restore brings the ledger back to a snapshot, which can be restored
+// again later.
+func (e *EmulatorChainContext) Restore(snapshot Snapshot) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ledger = snapshot.clone()
+}
+
+// AwaitSlot moves the chain to slot, doing nothing when it is already
+// past it.
+func (e *EmulatorChainContext) AwaitSlot(slot int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if slot > e.ledger.slot {
+		e.ledger.slot = slot
+	}
+}
+
+// AwaitEpoch moves the chain to the first slot of epoch.
+func (e *EmulatorChainContext) AwaitEpoch(epoch int) {
+	e.AwaitSlot(int64(epoch) * int64(e.GenesisParams.EpochLength))
+}
+
+// RewardAccount returns the reward account of a stake credential, if
+// registered.
+func (e *EmulatorChainContext) RewardAccount(stake Credential.Credential) (RewardAccount, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	account, ok := e.ledger.rewards[credentialKey(stake)]
+	return account, ok
+}
+
+// AddRewards credits the reward account of a registered stake
+// credential, standing in for the rewards of an epoch.
+func (e *EmulatorChainContext) AddRewards(stake Credential.Credential, lovelace int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	account, ok := e.ledger.rewards[credentialKey(stake)]
+	if !ok {
+		return fmt.Errorf("stake credential %s is not registered", stake.String())
+	}
+	account.Balance += lovelace
+	e.ledger.rewards[credentialKey(stake)] = account
+	return nil
+}
+
+// GetTx returns a transaction applied to the ledger by its hex encoded id.
+func (e *EmulatorChainContext) GetTx(txHash string) (Transaction.Transaction, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	tx, ok := e.ledger.txs[txHash]
+	return tx, ok
+}
+
+func (e *EmulatorChainContext) GetProtocolParams() Base.ProtocolParameters {
+	return e.ProtocolParams
+}
+
+func (e *EmulatorChainContext) GetGenesisParams() Base.GenesisParameters {
+	return e.GenesisParams
+}
+
+func (e *EmulatorChainContext) Network() int {
+	return e.GenesisParams.NetworkMagic
+}
+
+func (e *EmulatorChainContext) Epoch() int {
+	return e.LastBlockSlot() / e.GenesisParams.EpochLength
+}
+
+func (e *EmulatorChainContext) LastBlockSlot() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return int(e.ledger.slot)
+}
+
+// MaxTxFee returns the fee of a transaction of the maximum size.
+func (e *EmulatorChainContext) MaxTxFee() int {
+	return e.ProtocolParams.MinFeeConstant + e.ProtocolParams.MinFeeCoefficient*e.ProtocolParams.MaxTxSize
+}
+
+func (e *EmulatorChainContext) SlotConfig() (SlotConfig.SlotConfig, error) {
+	return SlotConfig.FromGenesis(e.GenesisParams), nil
+}
+
+// Utxos returns the UTxOs of address, sorted by reference.
+func (e *EmulatorChainContext) Utxos(address Address.Address) []UTxO.UTxO {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	target := address.String()
+	keys := make([]string, 0)
+	for key, utxo := range e.ledger.utxos {
+		if utxo.Output.GetAddress().String() == target {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	utxos := make([]UTxO.UTxO, 0, len(keys))
+	for _, key := range keys {
+		utxos = append(utxos, e.ledger.utxos[key].Clone())
+	}
+	return utxos
+}
+
+func (e *EmulatorChainContext) GetUtxoFromRef(txHash string, txIndex int) (UTxO.UTxO, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	utxo, ok := e.ledger.utxos[fmt.Sprintf("%s:%d", txHash, txIndex)]
+	if !ok {
+		return UTxO.UTxO{}, fmt.Errorf("Could not fetch utxo: %v#%v", txHash, txIndex)
+	}
+	return utxo.Clone(), nil
+}
+
+/*
+SubmitTx checks tx against the ledger at the current slot and applies
+it. Transactions marked invalid must have a failing script, and then
+have their collateral taken and their collateral return added instead.
+Nothing is applied when a check fails.
+*/
+func (e *EmulatorChainContext) SubmitTx(tx Transaction.Transaction) (serialization.TransactionId, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	body := tx.TransactionBody
+	resolved := make([]UTxO.UTxO, 0)
+	for _, inputs := range [][]TransactionInput.TransactionInput{body.Inputs, body.Collateral, body.ReferenceInputs} {
+		for _, input := range inputs {
+			if utxo, ok := e.ledger.utxos[inputKey(input)]; ok {
+				resolved = append(resolved, utxo)
+			}
+		}
+	}
+	pp := e.ProtocolParams
+	if err := Validation.ValidateAtSlot(tx, resolved, pp, e.ledger.slot); err != nil {
+		return serialization.TransactionId{}, err
+	}
+	txId := tx.Id()
+	_, err := Evaluator.EvaluateTx(tx, resolved, pp, SlotConfig.FromGenesis(e.GenesisParams))
+	if !tx.Valid {
+		var failure *Errors.ScriptFailureError
+		if err == nil {
+			return serialization.TransactionId{}, errors.New("transaction is marked invalid but none of its scripts fail")
+		}
+		if !errors.As(err, &failure) {
+			return serialization.TransactionId{}, err
+		}
+		for _, input := range body.Collateral {
+			delete(e.ledger.utxos, inputKey(input))
+		}
+		if body.CollateralReturn != nil {
+			e.addUtxo(txId.Payload, len(body.Outputs), *body.CollateralReturn)
+		}
+		e.ledger.txs[hex.EncodeToString(txId.Payload)] = tx
+		return txId, nil
+	}
+	if err != nil {
+		return serialization.TransactionId{}, err
+	}
+	rewards, err := e.applyStake(tx)
+	if err != nil {
+		return serialization.TransactionId{}, err
+	}
+	e.ledger.rewards = rewards
+	for _, input := range body.Inputs {
+		delete(e.ledger.utxos, inputKey(input))
+	}
+	for index, output := range body.Outputs {
+		e.addUtxo(txId.Payload, index, output)
+	}
+	e.ledger.txs[hex.EncodeToString(txId.Payload)] = tx
+	return txId, nil
+}
+
+/*
+applyStake returns the reward accounts once the withdrawals and then the
+certificates of tx are applied, leaving the ledger untouched. Rewards
+have to be withdrawn in full and accounts emptied before they are
+deregistered. The caller holds the lock.
+*/
+func (e *EmulatorChainContext) applyStake(tx Transaction.Transaction) (map[string]RewardAccount, error) {
+	rewards := make(map[string]RewardAccount, len(e.ledger.rewards))
+	for key, account := range e.ledger.rewards {
+		rewards[key] = account
+	}
+	body := tx.TransactionBody
+	if body.Withdrawals != nil {
+		for rewardAddress, amount := range *body.Withdrawals {
+			address, err := Address.DecodeAddressBytes(rewardAddress[:])
+			if err != nil {
+				return nil, err
+			}
+			stake, err := address.StakeReference()
+			if err != nil || stake.Credential == nil {
+				return nil, fmt.Errorf("invalid reward address %s", hex.EncodeToString(rewardAddress[:]))
+			}
+			key := credentialKey(*stake.Credential)
+			account, ok := rewards[key]
+			if !ok {
+				return nil, fmt.Errorf("withdrawal from unregistered stake credential %s", key)
+			}
+			if int64(amount) != account.Balance {
+				return nil, fmt.Errorf("withdrawal of %d from %s must be its whole balance of %d", amount, key, account.Balance)
+			}
+			account.Balance = 0
+			rewards[key] = account
+		}
+	}
+	if body.Certificates == nil {
+		return rewards, nil
+	}
+	keyDeposit, _ := strconv.ParseInt(e.ProtocolParams.KeyDeposits, 10, 64)
+	for _, cert := range *body.Certificates {
+		key := credentialKey(cert.StakeCredential)
+		account, registered := rewards[key]
+		switch cert.Type {
+		case Certificate.StakeRegistration, Certificate.Registration,
+			Certificate.StakeRegistrationDelegation,
+			Certificate.VoteRegistrationDelegation,
+			Certificate.StakeVoteRegistrationDelegation:
+			if registered {
+				return nil, fmt.Errorf("stake credential %s is already registered", key)
+			}
+			account = RewardAccount{Deposit: cert.Deposit}
+			if cert.Type == Certificate.StakeRegistration {
+				account.Deposit = keyDeposit
+			}
+		case Certificate.StakeDeregistration, Certificate.Unregistration:
+			if !registered {
+				return nil, fmt.Errorf("stake credential %s is not registered", key)
+			}
+			if account.Balance != 0 {
+				return nil, fmt.Errorf("stake credential %s still holds %d lovelace of rewards", key, account.Balance)
+			}
+			if cert.Type == Certificate.Unregistration && cert.Deposit != account.Deposit {
+				return nil, fmt.Errorf("refund of %d for %s doesn't match its deposit of %d", cert.Deposit, key, account.Deposit)
+			}
+			delete(rewards, key)
+			continue
+		case Certificate.StakeDelegation, Certificate.VoteDelegation, Certificate.StakeVoteDelegation:
+			if !registered {
+				return nil, fmt.Errorf("stake credential %s is not registered", key)
+			}
+		default:
+			continue
+		}
+		switch cert.Type {
+		case Certificate.StakeDelegation, Certificate.StakeVoteDelegation,
+			Certificate.StakeRegistrationDelegation, Certificate.StakeVoteRegistrationDelegation:
+			pool := cert.PoolKeyHash
+			account.Pool = &pool
+		}
+		switch cert.Type {
+		case Certificate.VoteDelegation, Certificate.StakeVoteDelegation,
+			Certificate.VoteRegistrationDelegation, Certificate.StakeVoteRegistrationDelegation:
+			drep := cert.DRep
+			account.DRep = &drep
+		}
+		rewards[key] = account
+	}
+	return rewards, nil
+}
+
+func (e *EmulatorChainContext) EvaluateTx(tx []uint8) (map[string]Redeemer.ExecutionUnits, error) {
+	return e.EvaluateTxWithAdditionalUtxos(tx, nil)
+}
+
+// EvaluateTxWithAdditionalUtxos evaluates tx at the current slot,
+// resolving its inputs from additionalUtxos first and from the ledger
+// otherwise.
+func (e *EmulatorChainContext) EvaluateTxWithAdditionalUtxos(tx []uint8, additionalUtxos []UTxO.UTxO) (map[string]Redeemer.ExecutionUnits, error) {
+	transaction := Transaction.Transaction{}
+	if err := cbor.Unmarshal(tx, &transaction); err != nil {
+		return nil, err
+	}
+	known := make(map[string]UTxO.UTxO)
+	for _, utxo := range additionalUtxos {
+		known[inputKey(utxo.Input)] = utxo
+	}
+	e.mu.Lock()
+	body := transaction.TransactionBody
+	resolved := make([]UTxO.UTxO, 0)
+	for _, inputs := range [][]TransactionInput.TransactionInput{body.Inputs, body.ReferenceInputs} {
+		for _, input := range inputs {
+			utxo, ok := known[inputKey(input)]
+			if !ok {
+				utxo, ok = e.ledger.utxos[inputKey(input)]
+			}
+			if ok {
+				resolved = append(resolved, utxo)
+			}
+		}
+	}
+	e.mu.Unlock()
+	if len(resolved) == 0 && len(body.Inputs) > 0 {
+		return nil, errors.New("none of the transaction inputs are known")
+	}
+	return Evaluator.EvaluateTx(transaction, resolved, e.ProtocolParams, SlotConfig.FromGenesis(e.GenesisParams))
+}
+
+// GetContractCbor returns the hex encoded cbor of a reference script
+// held in the ledger.
+func (e *EmulatorChainContext) GetContractCbor(scriptHash string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, utxo := range e.ledger.utxos {
+		ref := utxo.Output.GetScriptRef()
+		if ref == nil {
+			continue
+		}
+		script := ref.Script.Script
+		for _, hash := range []serialization.ScriptHash{
+			PlutusData.PlutusV1Script(script).Hash(),
+			PlutusData.PlutusV2Script(script).Hash(),
+			PlutusData.PlutusV3Script(script).Hash(),
+		} {
+			if hex.EncodeToString(hash[:]) == scriptHash {
+				return hex.EncodeToString(script)
+			}
+		}
+	}
+	return ""
+}
+
+func (e *EmulatorChainContext) CostModelsV1() PlutusData.CostModel {
+	return e.ProtocolParams.CostModels[Base.CostModelsPlutusV1]
+}
+
+func (e *EmulatorChainContext) CostModelsV2() PlutusData.CostModel {
+	return e.ProtocolParams.CostModels[Base.CostModelsPlutusV2]
+}
+
+func (e *EmulatorChainContext) CostModelsV3() PlutusData.CostModel {
+	return e.ProtocolParams.CostModels[Base.CostModelsPlutusV3]
+}
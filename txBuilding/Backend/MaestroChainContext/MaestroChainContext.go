@@ -1,79 +1,597 @@
 package MaestroChainContext
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/SundaeSwap-finance/apollo/serialization"
 	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Amount"
+	"github.com/SundaeSwap-finance/apollo/serialization/Asset"
+	"github.com/SundaeSwap-finance/apollo/serialization/AssetName"
+	"github.com/SundaeSwap-finance/apollo/serialization/MultiAsset"
 	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Policy"
 	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
 	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionOutput"
 	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/Value"
 	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+
+	"github.com/Salvionied/cbor/v2"
 )
 
+// UTXO_PAGE_SIZE is the number of UTxOs requested per page.
+const UTXO_PAGE_SIZE = 100
+
 type MaestroChainContext struct {
+	client          *http.Client
 	_epoch_info     Base.Epoch
 	_epoch          int
 	_Network        int
 	_genesis_param  Base.GenesisParameters
 	_protocol_param Base.ProtocolParameters
+	_baseUrl        string
+	_apiKey         string
+}
+
+/*
+NewMaestroChainContext returns a chain context querying the Maestro API
+at baseUrl, one of the constants.MAESTRO_BASE_URL_* urls, with apiKey.
+It fetches the current epoch and parameters right away:
+
+	mcc, err := MaestroChainContext.NewMaestroChainContext(constants.MAESTRO_BASE_URL_PREPROD, int(constants.PREPROD), "api_key")
+	apollob := apollo.New(&mcc)
+*/
+func NewMaestroChainContext(baseUrl string, network int, apiKey string) (MaestroChainContext, error) {
+	mcc := MaestroChainContext{
+		client:   &http.Client{},
+		_Network: network,
+		_baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		_apiKey:  apiKey,
+	}
+	if err := mcc.Init(); err != nil {
+		return mcc, err
+	}
+	return mcc, nil
+}
+
+func (mcc *MaestroChainContext) Init() error {
+	genesis, err := mcc.genesisParams()
+	if err != nil {
+		return err
+	}
+	mcc._genesis_param = genesis
+	epoch, err := mcc.latestEpoch()
+	if err != nil {
+		return err
+	}
+	mcc._epoch_info = epoch
+	mcc._epoch = epoch.Epoch
+	params, err := mcc.latestEpochParams()
+	if err != nil {
+		return err
+	}
+	mcc._protocol_param = params
+	return nil
+}
+
+// MaestroError is a non 2xx response of the Maestro API.
+type MaestroError struct {
+	StatusCode int
+	Message    string
+}
+
+func (m MaestroError) Error() string {
+	return fmt.Sprintf("maestro: %d %s", m.StatusCode, m.Message)
+}
+
+// envelope is the body of the Maestro responses.
+type envelope[T any] struct {
+	Data       T       `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+func (mcc *MaestroChainContext) do(method string, path string, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, mcc._baseUrl+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("api-key", mcc._apiKey)
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	res, err := mcc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(resBody, &failure) != nil || failure.Message == "" {
+			failure.Message = strings.TrimSpace(string(resBody))
+		}
+		return nil, MaestroError{StatusCode: res.StatusCode, Message: failure.Message}
+	}
+	return resBody, nil
+}
+
+// get decodes the response to a GET request of path into target.
+func (mcc *MaestroChainContext) get(path string, target any) error {
+	body, err := mcc.do("GET", path, "", nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, target)
+}
+
+type MaestroAsset struct {
+	Unit   string `json:"unit"`
+	Amount int64  `json:"amount"`
+}
+
+type MaestroDatum struct {
+	// "hash" or "inline"
+	Type  string `json:"type"`
+	Hash  string `json:"hash"`
+	Bytes string `json:"bytes"`
+}
+
+type MaestroScript struct {
+	Hash string `json:"hash"`
+	// "native", "plutusv1", "plutusv2" or "plutusv3"
+	Type  string `json:"type"`
+	Bytes string `json:"bytes"`
+}
+
+type MaestroUtxo struct {
+	TxHash          string         `json:"tx_hash"`
+	Index           int            `json:"index"`
+	Slot            int            `json:"slot"`
+	Address         string         `json:"address"`
+	Assets          []MaestroAsset `json:"assets"`
+	Datum           *MaestroDatum  `json:"datum"`
+	ReferenceScript *MaestroScript `json:"reference_script"`
+	TxoutCbor       string         `json:"txout_cbor"`
 }
 
-func NewBlockfrostChainContext(baseUrl string, network int, projectId string) MaestroChainContext {
-	mcc := MaestroChainContext{}
-	return mcc
+func (mu MaestroUtxo) amount() []Base.AddressAmount {
+	res := make([]Base.AddressAmount, 0, len(mu.Assets))
+	for _, asset := range mu.Assets {
+		res = append(res, Base.AddressAmount{Unit: asset.Unit, Quantity: strconv.FormatInt(asset.Amount, 10)})
+	}
+	return res
+}
+
+func (mu MaestroUtxo) value() (Value.Value, error) {
+	lovelace := int64(0)
+	multiAssets := MultiAsset.MultiAsset[int64]{}
+	for _, asset := range mu.Assets {
+		if asset.Unit == "lovelace" {
+			lovelace += asset.Amount
+			continue
+		}
+		if len(asset.Unit) < 56 {
+			return Value.Value{}, fmt.Errorf("invalid asset unit %s", asset.Unit)
+		}
+		policyId := Policy.PolicyId{Value: asset.Unit[:56]}
+		assetName := AssetName.NewAssetNameFromHexString(asset.Unit[56:])
+		if assetName == nil {
+			return Value.Value{}, fmt.Errorf("invalid asset name in unit %s", asset.Unit)
+		}
+		if _, ok := multiAssets[policyId]; !ok {
+			multiAssets[policyId] = Asset.Asset[int64]{}
+		}
+		multiAssets[policyId][*assetName] += asset.Amount
+	}
+	if len(multiAssets) > 0 {
+		return Value.Value{Am: Amount.Amount{Coin: lovelace, Value: multiAssets}, HasAssets: true}, nil
+	}
+	return Value.Value{Coin: lovelace, HasAssets: false}, nil
+}
+
+// ToUTxO converts the UTxO, keeping its inline datum or datum hash and
+// its reference script.
+func (mu MaestroUtxo) ToUTxO() (UTxO.UTxO, error) {
+	txId, err := hex.DecodeString(mu.TxHash)
+	if err != nil {
+		return UTxO.UTxO{}, err
+	}
+	address, err := Address.DecodeAddress(mu.Address)
+	if err != nil {
+		return UTxO.UTxO{}, err
+	}
+	amount, err := mu.value()
+	if err != nil {
+		return UTxO.UTxO{}, err
+	}
+	var datum *PlutusData.DatumOption
+	if mu.Datum != nil {
+		switch mu.Datum.Type {
+		case "inline":
+			decoded, err := hex.DecodeString(mu.Datum.Bytes)
+			if err != nil {
+				return UTxO.UTxO{}, err
+			}
+			var pd PlutusData.PlutusData
+			if err := cbor.Unmarshal(decoded, &pd); err != nil {
+				return UTxO.UTxO{}, err
+			}
+			option := PlutusData.DatumOptionInline(&pd)
+			datum = &option
+		case "hash":
+			decoded, err := hex.DecodeString(mu.Datum.Hash)
+			if err != nil {
+				return UTxO.UTxO{}, err
+			}
+			option := PlutusData.DatumOptionHash(decoded)
+			datum = &option
+		default:
+			return UTxO.UTxO{}, fmt.Errorf("unknown datum type %s", mu.Datum.Type)
+		}
+	}
+	var scriptRef *PlutusData.ScriptRef
+	if mu.ReferenceScript != nil {
+		script, err := hex.DecodeString(mu.ReferenceScript.Bytes)
+		if err != nil {
+			return UTxO.UTxO{}, err
+		}
+		scriptRef = &PlutusData.ScriptRef{Script: PlutusData.InnerScript{Script: script}}
+	}
+	input := TransactionInput.TransactionInput{TransactionId: txId, Index: mu.Index}
+	if scriptRef == nil && (datum == nil || datum.DatumType == PlutusData.DatumTypeHash) {
+		output := TransactionOutput.TransactionOutput{PreAlonzo: TransactionOutput.TransactionOutputShelley{
+			Address: address,
+			Amount:  amount,
+		}}
+		if datum != nil {
+			output.PreAlonzo.DatumHash = serialization.DatumHash{Payload: datum.Hash}
+			output.PreAlonzo.HasDatum = true
+		}
+		return UTxO.UTxO{Input: input, Output: output}, nil
+	}
+	return UTxO.UTxO{Input: input, Output: TransactionOutput.TransactionOutput{
+		IsPostAlonzo: true,
+		PostAlonzo: TransactionOutput.TransactionOutputAlonzo{
+			Address:   address,
+			Amount:    amount.ToAlonzoValue(),
+			Datum:     datum,
+			ScriptRef: scriptRef,
+		},
+	}}, nil
 }
-func (mcc *MaestroChainContext) Init() {
-	//TODO
+
+func (mu MaestroUtxo) toOutput() Base.Output {
+	output := Base.Output{Address: mu.Address, Amount: mu.amount(), OutputIndex: mu.Index}
+	if mu.Datum != nil {
+		output.DataHash = mu.Datum.Hash
+		if mu.Datum.Type == "inline" {
+			output.InlineDatum = mu.Datum.Bytes
+		}
+	}
+	if mu.ReferenceScript != nil {
+		output.ReferenceScriptHash = mu.ReferenceScript.Hash
+	}
+	return output
 }
 
 func (mcc *MaestroChainContext) GetUtxoFromRef(txHash string, index int) (UTxO.UTxO, error) {
-	var utxo UTxO.UTxO
-	return utxo, nil
+	var response envelope[MaestroUtxo]
+	if err := mcc.get(fmt.Sprintf("/transactions/%s/outputs/%d/txo?resolve_datums=true", txHash, index), &response); err != nil {
+		return UTxO.UTxO{}, fmt.Errorf("Could not fetch utxo: %v#%v: %w", txHash, index, err)
+	}
+	return response.Data.ToUTxO()
 }
 
 func (mcc *MaestroChainContext) TxOuts(txHash string) []Base.Output {
-	//TODO
-	return nil
-
+	var response envelope[struct {
+		Outputs []MaestroUtxo `json:"outputs"`
+	}]
+	if err := mcc.get(fmt.Sprintf("/transactions/%s", txHash), &response); err != nil {
+		log.Fatal(err, "REQUEST TRANSACTION")
+	}
+	outputs := make([]Base.Output, 0, len(response.Data.Outputs))
+	for _, output := range response.Data.Outputs {
+		outputs = append(outputs, output.toOutput())
+	}
+	return outputs
 }
 
 func (mcc *MaestroChainContext) LatestBlock() Base.Block {
-	latestBlock := Base.Block{}
-	//TODO
-	return latestBlock
+	var response envelope[struct {
+		Hash          string `json:"hash"`
+		Height        int    `json:"height"`
+		AbsoluteSlot  int    `json:"absolute_slot"`
+		Epoch         int    `json:"epoch"`
+		EpochSlot     int    `json:"epoch_slot"`
+		Size          int    `json:"size"`
+		TxHashes      []any  `json:"tx_hashes"`
+		PreviousBlock string `json:"previous_block"`
+		Confirmations int    `json:"confirmations"`
+	}]
+	if err := mcc.get("/blocks/latest", &response); err != nil {
+		log.Fatal(err, "REQUEST LATEST BLOCK")
+	}
+	block := response.Data
+	return Base.Block{
+		Hash:          block.Hash,
+		Height:        block.Height,
+		Slot:          block.AbsoluteSlot,
+		Epoch:         block.Epoch,
+		EpochSlot:     block.EpochSlot,
+		Size:          block.Size,
+		TxCount:       len(block.TxHashes),
+		PreviousBlock: block.PreviousBlock,
+		Confirmations: block.Confirmations,
+	}
+}
+
+func (mcc *MaestroChainContext) latestEpoch() (Base.Epoch, error) {
+	var response envelope[struct {
+		EpochNo   int    `json:"epoch_no"`
+		Fees      string `json:"fees"`
+		TxCount   int    `json:"tx_count"`
+		BlkCount  int    `json:"blk_count"`
+		StartTime int    `json:"start_time"`
+	}]
+	if err := mcc.get("/epochs/current", &response); err != nil {
+		return Base.Epoch{}, err
+	}
+	epoch := response.Data
+	genesis := mcc._genesis_param
+	return Base.Epoch{
+		Epoch:      epoch.EpochNo,
+		Fees:       epoch.Fees,
+		TxCount:    epoch.TxCount,
+		BlockCount: epoch.BlkCount,
+		StartTime:  epoch.StartTime,
+		EndTime:    epoch.StartTime + genesis.EpochLength*genesis.SlotLength,
+	}, nil
 }
 
 func (mcc *MaestroChainContext) LatestEpoch() Base.Epoch {
-	epoch := Base.Epoch{}
-	//TODO
+	epoch, err := mcc.latestEpoch()
+	if err != nil {
+		log.Fatal(err, "REQUEST LATEST EPOCH")
+	}
 	return epoch
-
 }
+
+/*
+AddressUtxos returns the UTxOs of a bech32 address, following the
+cursor of the paginated responses when gather is set and returning the
+first page only otherwise.
+*/
 func (mcc *MaestroChainContext) AddressUtxos(address string, gather bool) []Base.AddressUTXO {
-	addressUtxos := make([]Base.AddressUTXO, 0)
-	//TODO
+	utxos, err := mcc.addressUtxos(address, gather)
+	if err != nil {
+		log.Fatal(err, "REQUEST ADDRESS UTXOS")
+	}
+	addressUtxos := make([]Base.AddressUTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		output := utxo.toOutput()
+		addressUtxos = append(addressUtxos, Base.AddressUTXO{
+			TxHash:              utxo.TxHash,
+			OutputIndex:         utxo.Index,
+			Amount:              output.Amount,
+			DataHash:            output.DataHash,
+			InlineDatum:         output.InlineDatum,
+			ReferenceScriptHash: output.ReferenceScriptHash,
+		})
+	}
 	return addressUtxos
+}
+
+func (mcc *MaestroChainContext) addressUtxos(address string, gather bool) ([]MaestroUtxo, error) {
+	utxos := make([]MaestroUtxo, 0)
+	cursor := ""
+	for {
+		query := url.Values{}
+		query.Set("resolve_datums", "true")
+		query.Set("count", strconv.Itoa(UTXO_PAGE_SIZE))
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		var response envelope[[]MaestroUtxo]
+		if err := mcc.get(fmt.Sprintf("/addresses/%s/utxos?%s", address, query.Encode()), &response); err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, response.Data...)
+		if !gather || response.NextCursor == nil || *response.NextCursor == "" {
+			return utxos, nil
+		}
+		cursor = *response.NextCursor
+	}
+}
+
+type lovelace struct {
+	Ada struct {
+		Lovelace uint64 `json:"lovelace"`
+	} `json:"ada"`
+}
+
+type byteSize struct {
+	Bytes uint64 `json:"bytes"`
+}
+
+type exUnits struct {
+	Memory uint64 `json:"memory"`
+	Cpu    uint64 `json:"cpu"`
+}
+
+type MaestroProtocolParameters struct {
+	MinFeeCoefficient      uint64   `json:"min_fee_coefficient"`
+	MinFeeConstant         lovelace `json:"min_fee_constant"`
+	MinFeeReferenceScripts struct {
+		Base float64 `json:"base"`
+	} `json:"min_fee_reference_scripts"`
+	MaxBlockBodySize          byteSize         `json:"max_block_body_size"`
+	MaxBlockHeaderSize        byteSize         `json:"max_block_header_size"`
+	MaxTransactionSize        byteSize         `json:"max_transaction_size"`
+	MaxValueSize              byteSize         `json:"max_value_size"`
+	StakeCredentialDeposit    lovelace         `json:"stake_credential_deposit"`
+	StakePoolDeposit          lovelace         `json:"stake_pool_deposit"`
+	StakePoolPledgeInfluence  string           `json:"stake_pool_pledge_influence"`
+	MonetaryExpansion         string           `json:"monetary_expansion"`
+	TreasuryExpansion         string           `json:"treasury_expansion"`
+	MinStakePoolCost          lovelace         `json:"min_stake_pool_cost"`
+	MinUtxoDepositConstant    lovelace         `json:"min_utxo_deposit_constant"`
+	MinUtxoDepositCoefficient uint64           `json:"min_utxo_deposit_coefficient"`
+	PlutusCostModels          map[string][]int `json:"plutus_cost_models"`
+	ScriptExecutionPrices     struct {
+		Memory string `json:"memory"`
+		Cpu    string `json:"cpu"`
+	} `json:"script_execution_prices"`
+	MaxExecutionUnitsPerTransaction exUnits `json:"max_execution_units_per_transaction"`
+	MaxExecutionUnitsPerBlock       exUnits `json:"max_execution_units_per_block"`
+	CollateralPercentage            int     `json:"collateral_percentage"`
+	MaxCollateralInputs             int     `json:"max_collateral_inputs"`
+	Version                         struct {
+		Major int `json:"major"`
+		Minor int `json:"minor"`
+	} `json:"version"`
+}
 
+func ratio(s string) float32 {
+	n, d, ok := strings.Cut(s, "/")
+	if !ok {
+		f, _ := strconv.ParseFloat(s, 32)
+		return float32(f)
+	}
+	num, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return 0
+	}
+	den, err := strconv.ParseFloat(d, 64)
+	if err != nil || den == 0 {
+		return 0
+	}
+	return float32(num / den)
+}
+
+func (mcc *MaestroChainContext) latestEpochParams() (Base.ProtocolParameters, error) {
+	var response envelope[MaestroProtocolParameters]
+	if err := mcc.get("/protocol-parameters", &response); err != nil {
+		return Base.ProtocolParameters{}, err
+	}
+	pp := response.Data
+	costModels := make(map[Base.CostModelsPlutusVersion]PlutusData.CostModel)
+	for version, key := range map[Base.CostModelsPlutusVersion]string{
+		Base.CostModelsPlutusV1: "plutus_v1",
+		Base.CostModelsPlutusV2: "plutus_v2",
+		Base.CostModelsPlutusV3: "plutus_v3",
+	} {
+		if costModel, ok := pp.PlutusCostModels[key]; ok {
+			costModels[version] = costModel
+		}
+	}
+	format := func(n uint64) string {
+		return strconv.FormatUint(n, 10)
+	}
+	return Base.ProtocolParameters{
+		MinFeeConstant:         int(pp.MinFeeConstant.Ada.Lovelace),
+		MinFeeCoefficient:      int(pp.MinFeeCoefficient),
+		MaxBlockSize:           int(pp.MaxBlockBodySize.Bytes),
+		MaxTxSize:              int(pp.MaxTransactionSize.Bytes),
+		MaxBlockHeaderSize:     int(pp.MaxBlockHeaderSize.Bytes),
+		KeyDeposits:            format(pp.StakeCredentialDeposit.Ada.Lovelace),
+		PoolDeposits:           format(pp.StakePoolDeposit.Ada.Lovelace),
+		PooolInfluence:         ratio(pp.StakePoolPledgeInfluence),
+		MonetaryExpansion:      ratio(pp.MonetaryExpansion),
+		TreasuryExpansion:      ratio(pp.TreasuryExpansion),
+		ProtocolMajorVersion:   pp.Version.Major,
+		ProtocolMinorVersion:   pp.Version.Minor,
+		MinUtxo:                format(pp.MinUtxoDepositConstant.Ada.Lovelace),
+		MinPoolCost:            format(pp.MinStakePoolCost.Ada.Lovelace),
+		PriceMem:               ratio(pp.ScriptExecutionPrices.Memory),
+		PriceStep:              ratio(pp.ScriptExecutionPrices.Cpu),
+		MaxTxExMem:             format(pp.MaxExecutionUnitsPerTransaction.Memory),
+		MaxTxExSteps:           format(pp.MaxExecutionUnitsPerTransaction.Cpu),
+		MaxBlockExMem:          format(pp.MaxExecutionUnitsPerBlock.Memory),
+		MaxBlockExSteps:        format(pp.MaxExecutionUnitsPerBlock.Cpu),
+		MaxValSize:             format(pp.MaxValueSize.Bytes),
+		CollateralPercent:      pp.CollateralPercentage,
+		MaxCollateralInuts:     pp.MaxCollateralInputs,
+		CoinsPerUtxoByte:       format(pp.MinUtxoDepositCoefficient),
+		MinFeeReferenceScripts: int(pp.MinFeeReferenceScripts.Base),
+		CostModels:             costModels,
+	}, nil
 }
 
 func (mcc *MaestroChainContext) LatestEpochParams() Base.ProtocolParameters {
-	protocolParams := Base.ProtocolParameters{}
-	//TODO
-	return protocolParams
+	params, err := mcc.latestEpochParams()
+	if err != nil {
+		log.Fatal(err, "REQUEST PROTOCOL PARAMETERS")
+	}
+	return params
+}
+
+func (mcc *MaestroChainContext) genesisParams() (Base.GenesisParameters, error) {
+	var response envelope[struct {
+		ActiveSlotsCoefficient float32 `json:"active_slots_coefficient"`
+		EpochLength            int     `json:"epoch_length"`
+		MaxKesEvolutions       int     `json:"max_kes_evolutions"`
+		MaxLovelaceSupply      uint64  `json:"max_lovelace_supply"`
+		NetworkMagic           int     `json:"network_magic"`
+		SecurityParam          int     `json:"security_param"`
+		SlotLength             int     `json:"slot_length"`
+		SlotsPerKesPeriod      int     `json:"slots_per_kes_period"`
+		SystemStart            string  `json:"system_start"`
+		UpdateQuorum           int     `json:"update_quorum"`
+	}]
+	if err := mcc.get("/genesis", &response); err != nil {
+		return Base.GenesisParameters{}, err
+	}
+	genesis := response.Data
+	systemStart, err := time.Parse(time.RFC3339, genesis.SystemStart)
+	if err != nil {
+		return Base.GenesisParameters{}, err
+	}
+	return Base.GenesisParameters{
+		ActiveSlotsCoefficient: genesis.ActiveSlotsCoefficient,
+		UpdateQuorum:           genesis.UpdateQuorum,
+		MaxLovelaceSupply:      strconv.FormatUint(genesis.MaxLovelaceSupply, 10),
+		NetworkMagic:           genesis.NetworkMagic,
+		EpochLength:            genesis.EpochLength,
+		SystemStart:            int(systemStart.Unix()),
+		SlotsPerKesPeriod:      genesis.SlotsPerKesPeriod,
+		SlotLength:             genesis.SlotLength,
+		MaxKesEvolutions:       genesis.MaxKesEvolutions,
+		SecurityParam:          genesis.SecurityParam,
+	}, nil
 }
 
 func (mcc *MaestroChainContext) GenesisParams() Base.GenesisParameters {
-	genesisParams := Base.GenesisParameters{}
-	//TODO
-	return genesisParams
+	params, err := mcc.genesisParams()
+	if err != nil {
+		log.Fatal(err, "REQUEST GENESIS")
+	}
+	return params
 }
+
 func (mcc *MaestroChainContext) _CheckEpochAndUpdate() bool {
 	if mcc._epoch_info.EndTime <= int(time.Now().Unix()) {
 		latest_epochs := mcc.LatestEpoch()
 		mcc._epoch_info = latest_epochs
+		mcc._epoch = latest_epochs.Epoch
 		return true
 	}
 	return false
@@ -84,10 +602,7 @@ func (mcc *MaestroChainContext) Network() int {
 }
 
 func (mcc *MaestroChainContext) Epoch() int {
-	if mcc._CheckEpochAndUpdate() {
-		new_epoch := mcc.LatestEpoch()
-		mcc._epoch = new_epoch.Epoch
-	}
+	mcc._CheckEpochAndUpdate()
 	return mcc._epoch
 }
 
@@ -97,10 +612,6 @@ func (mcc *MaestroChainContext) LastBlockSlot() int {
 }
 
 func (mcc *MaestroChainContext) GetGenesisParams() Base.GenesisParameters {
-	if mcc._CheckEpochAndUpdate() {
-		params := mcc.GenesisParams()
-		mcc._genesis_param = params
-	}
 	return mcc._genesis_param
 }
 
@@ -120,55 +631,135 @@ func (mcc *MaestroChainContext) MaxTxFee() int {
 }
 
 func (mcc *MaestroChainContext) Utxos(address Address.Address) []UTxO.UTxO {
-	utxos := make([]UTxO.UTxO, 0)
-	//TODO
+	results, err := mcc.addressUtxos(address.String(), true)
+	if err != nil {
+		log.Fatal(err, "REQUEST ADDRESS UTXOS")
+	}
+	utxos := make([]UTxO.UTxO, 0, len(results))
+	for _, result := range results {
+		utxo, err := result.ToUTxO()
+		if err != nil {
+			log.Fatal(err, "CONVERT UTXO")
+		}
+		utxos = append(utxos, utxo)
+	}
 	return utxos
 }
 
+// SubmitTx submits tx through the Maestro transaction manager.
 func (mcc *MaestroChainContext) SubmitTx(tx Transaction.Transaction) (serialization.TransactionId, error) {
-	//TODO
-	return serialization.TransactionId{Payload: tx.TransactionBody.Hash()}, nil
+	txBytes, err := cbor.Marshal(tx)
+	if err != nil {
+		return serialization.TransactionId{}, err
+	}
+	if _, err := mcc.do("POST", "/txmanager", "application/cbor", txBytes); err != nil {
+		return serialization.TransactionId{}, err
+	}
+	return tx.TransactionBody.Id(), nil
+}
+
+type evaluationUtxo struct {
+	TxHash    string `json:"tx_hash"`
+	Index     int    `json:"index"`
+	TxoutCbor string `json:"txout_cbor"`
 }
 
-type EvalResult struct {
-	Result map[string]map[string]int `json:"EvaluationResult"`
+type evaluationRequest struct {
+	Cbor            string           `json:"cbor"`
+	AdditionalUtxos []evaluationUtxo `json:"additional_utxos,omitempty"`
 }
 
-type ExecutionResult struct {
-	Result EvalResult `json:"result"`
+type EvaluationResult struct {
+	RedeemerTag   string `json:"redeemer_tag"`
+	RedeemerIndex int    `json:"redeemer_index"`
+	ExUnits       struct {
+		Mem   int64 `json:"mem"`
+		Steps int64 `json:"steps"`
+	} `json:"ex_units"`
+}
+
+func convertRedeemerTag(tag string) (string, error) {
+	switch tag {
+	case "spend":
+		return Redeemer.RedeemerTagNames[Redeemer.SPEND], nil
+	case "mint":
+		return Redeemer.RedeemerTagNames[Redeemer.MINT], nil
+	case "cert", "certificate", "publish":
+		return Redeemer.RedeemerTagNames[Redeemer.CERT], nil
+	case "reward", "withdrawal", "withdraw":
+		return Redeemer.RedeemerTagNames[Redeemer.REWARD], nil
+	case "vote":
+		return Redeemer.RedeemerTagNames[Redeemer.VOTE], nil
+	case "propose":
+		return Redeemer.RedeemerTagNames[Redeemer.PROPOSE], nil
+	default:
+		return "", fmt.Errorf("unexpected maestro redeemer tag: %s", tag)
+	}
 }
 
 func (mcc *MaestroChainContext) EvaluateTx(tx []byte) (map[string]Redeemer.ExecutionUnits, error) {
-	final_result := make(map[string]Redeemer.ExecutionUnits)
-	//TODO
-	return final_result, nil
+	return mcc.EvaluateTxWithAdditionalUtxos(tx, nil)
 }
 
-type BlockfrostContractCbor struct {
-	Cbor string `json:"cbor"`
+// EvaluateTxWithAdditionalUtxos evaluates tx, the UTxOs it spends being
+// resolved from additionalUtxos when they aren't on chain yet.
+func (mcc *MaestroChainContext) EvaluateTxWithAdditionalUtxos(tx []byte, additionalUtxos []UTxO.UTxO) (map[string]Redeemer.ExecutionUnits, error) {
+	request := evaluationRequest{Cbor: hex.EncodeToString(tx)}
+	for _, utxo := range additionalUtxos {
+		output, err := cbor.Marshal(&utxo.Output)
+		if err != nil {
+			return nil, err
+		}
+		request.AdditionalUtxos = append(request.AdditionalUtxos, evaluationUtxo{
+			TxHash:    hex.EncodeToString(utxo.Input.TransactionId),
+			Index:     utxo.Input.Index,
+			TxoutCbor: hex.EncodeToString(output),
+		})
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := mcc.do("POST", "/transactions/evaluate", "application/json", body)
+	if err != nil {
+		return nil, err
+	}
+	var results []EvaluationResult
+	if err := json.Unmarshal(resBody, &results); err != nil {
+		return nil, err
+	}
+	final_result := make(map[string]Redeemer.ExecutionUnits)
+	for _, result := range results {
+		tag, err := convertRedeemerTag(result.RedeemerTag)
+		if err != nil {
+			return nil, err
+		}
+		final_result[fmt.Sprintf("%s:%d", tag, result.RedeemerIndex)] = Redeemer.ExecutionUnits{
+			Mem:   result.ExUnits.Mem,
+			Steps: result.ExUnits.Steps,
+		}
+	}
+	return final_result, nil
 }
 
+// GetContractCbor returns the hex encoded cbor of the script of hash
+// scriptHash, or "" when Maestro doesn't know it.
 func (mcc *MaestroChainContext) GetContractCbor(scriptHash string) string {
-	//TODO
-	return ""
+	var response envelope[MaestroScript]
+	if err := mcc.get(fmt.Sprintf("/scripts/%s", scriptHash), &response); err != nil {
+		return ""
+	}
+	return response.Data.Bytes
 }
 
 func (mcc *MaestroChainContext) CostModelsV1() PlutusData.CostModel {
-	// TODO
-	return PlutusData.CostModel{}
+	return mcc.GetProtocolParams().CostModels[Base.CostModelsPlutusV1]
 }
 
 func (mcc *MaestroChainContext) CostModelsV2() PlutusData.CostModel {
-	// TODO
-	return PlutusData.CostModel{}
+	return mcc.GetProtocolParams().CostModels[Base.CostModelsPlutusV2]
 }
 
 func (mcc *MaestroChainContext) CostModelsV3() PlutusData.CostModel {
-	// TODO
-	return PlutusData.CostModel{}
-}
-
-func (mcc *MaestroChainContext) EvaluateTxWithAdditionalUtxos([]uint8, []UTxO.UTxO) (map[string]Redeemer.ExecutionUnits, error) {
-	//TODO
-	return nil, nil
+	return mcc.GetProtocolParams().CostModels[Base.CostModelsPlutusV3]
 }
@@ -0,0 +1,264 @@
+package MaestroChainContext
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionOutput"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/Value"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+const (
+	testAddress = "addr_test1vqp4mmnx647vyutfwugav0yvxhl6pdkyg69x4xqzfl4vwwck92a9t"
+	testApiKey  = "maestro-api-key"
+	testTxHash  = "8a2e1c6b7d5f4e3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a"
+	testScript  = "67f33146617a5e61936081db3b2117cbf59bd2123748f58ac9678656"
+)
+
+// maestroServer replays the responses recorded in testdata.
+type maestroServer struct {
+	t          *testing.T
+	evaluation evaluationRequest
+	submitted  []byte
+	rejectTx   bool
+}
+
+func (m *maestroServer) replay(w http.ResponseWriter, name string) {
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func (m *maestroServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("api-key") != testApiKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"Unauthorized","message":"Invalid API key"}`))
+		return
+	}
+	switch r.URL.Path {
+	case "/genesis":
+		m.replay(w, "genesis.json")
+	case "/epochs/current":
+		m.replay(w, "epochs_current.json")
+	case "/protocol-parameters":
+		m.replay(w, "protocol_parameters.json")
+	case "/blocks/latest":
+		m.replay(w, "blocks_latest.json")
+	case "/addresses/" + testAddress + "/utxos":
+		if r.URL.Query().Get("cursor") == "c2xvdDo2MTk5MDAyMQ" {
+			m.replay(w, "utxos_page_2.json")
+		} else {
+			m.replay(w, "utxos_page_1.json")
+		}
+	case "/transactions/" + testTxHash + "/outputs/0/txo":
+		m.replay(w, "txo.json")
+	case "/scripts/" + testScript:
+		m.replay(w, "script.json")
+	case "/transactions/evaluate":
+		if err := json.NewDecoder(r.Body).Decode(&m.evaluation); err != nil {
+			m.t.Fatal(err)
+		}
+		m.replay(w, "evaluate.json")
+	case "/txmanager":
+		if r.Header.Get("Content-Type") != "application/cbor" {
+			m.t.Errorf("Expected a cbor submission, got %s", r.Header.Get("Content-Type"))
+		}
+		if m.rejectTx {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"Bad Request","message":"ValueNotConservedUTxO"}`))
+			return
+		}
+		m.submitted, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"Not Found","message":"not found"}`))
+	}
+}
+
+func newTestContext(t *testing.T) (*MaestroChainContext, *maestroServer) {
+	t.Helper()
+	handler := &maestroServer{t: t}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	mcc, err := NewMaestroChainContext(server.URL, 0, testApiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &mcc, handler
+}
+
+func TestInitialization(t *testing.T) {
+	mcc, _ := newTestContext(t)
+	genesis := mcc.GetGenesisParams()
+	if genesis.NetworkMagic != 1 || genesis.EpochLength != 432000 || genesis.SystemStart != 1654041600 {
+		t.Errorf("Unexpected genesis parameters: %v", genesis)
+	}
+	if mcc._epoch != 155 || mcc._epoch_info.EndTime != 1717977600+432000 {
+		t.Errorf("Unexpected epoch: %v", mcc._epoch_info)
+	}
+	pp := mcc._protocol_param
+	if pp.MinFeeConstant != 155381 || pp.MinFeeCoefficient != 44 || pp.MaxTxSize != 16384 {
+		t.Errorf("Unexpected fee parameters: %v", pp)
+	}
+	if pp.CoinsPerUtxoByte != "4310" || pp.CoinsPerUtxoWord != "" || pp.KeyDeposits != "2000000" || pp.MaxTxExMem != "14000000" || pp.MaxTxExSteps != "10000000000" {
+		t.Errorf("Unexpected parameters: %v", pp)
+	}
+	if pp.PriceMem != float32(577)/10000 || pp.PriceStep != float32(721)/10000000 || pp.MinFeeReferenceScripts != 15 {
+		t.Errorf("Unexpected prices: %v %v %v", pp.PriceMem, pp.PriceStep, pp.MinFeeReferenceScripts)
+	}
+	if len(pp.CostModels[Base.CostModelsPlutusV1]) != 5 || len(pp.CostModels[Base.CostModelsPlutusV2]) != 6 || len(pp.CostModels[Base.CostModelsPlutusV3]) != 7 {
+		t.Errorf("Unexpected cost models: %v", pp.CostModels)
+	}
+	if len(mcc.CostModelsV2()) != 6 {
+		t.Errorf("Unexpected V2 cost model: %v", mcc.CostModelsV2())
+	}
+	if mcc.LastBlockSlot() != 62003464 {
+		t.Errorf("Unexpected last block slot: %d", mcc.LastBlockSlot())
+	}
+
+	server := httptest.NewServer(&maestroServer{t: t})
+	defer server.Close()
+	if _, err := NewMaestroChainContext(server.URL, 0, "wrong"); !errors.As(err, new(MaestroError)) {
+		t.Errorf("Expected an unauthorized request to fail, got %v", err)
+	}
+}
+
+func TestEpochRefresh(t *testing.T) {
+	mcc, _ := newTestContext(t)
+	mcc._epoch = 154
+	mcc._epoch_info = Base.Epoch{Epoch: 154}
+	mcc._protocol_param = Base.ProtocolParameters{}
+	if pp := mcc.GetProtocolParams(); pp.MinFeeConstant != 155381 {
+		t.Errorf("Expected the protocol parameters to be refreshed, got %v", pp)
+	}
+	if mcc.Epoch() != 155 {
+		t.Errorf("Expected the epoch to be refreshed, got %d", mcc.Epoch())
+	}
+}
+
+func TestUtxos(t *testing.T) {
+	mcc, _ := newTestContext(t)
+	address, _ := Address.DecodeAddress(testAddress)
+	utxos := mcc.Utxos(address)
+	if len(utxos) != 2 {
+		t.Fatalf("Expected the UTxOs of both pages, got %d", len(utxos))
+	}
+
+	inline := utxos[0]
+	if inline.Output.GetAmount().GetCoin() != 2_000_000 || len(inline.Output.GetAmount().GetAssets()) != 1 {
+		t.Errorf("Unexpected value: %v", inline.Output.GetAmount())
+	}
+	if datum := inline.Output.GetDatum(); datum == nil || datum.TagNr != 121 {
+		t.Errorf("Expected an inline datum, got %v", datum)
+	}
+
+	withScript := utxos[1]
+	if datum := withScript.Output.GetDatumOption(); datum == nil || datum.DatumType != PlutusData.DatumTypeHash ||
+		hex.EncodeToString(datum.Hash) != "923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec" {
+		t.Errorf("Expected a datum hash, got %v", datum)
+	}
+	scriptRef := withScript.Output.GetScriptRef()
+	if scriptRef == nil || hex.EncodeToString(scriptRef.Script.Script) != "4e4d01000033222220051200120011" {
+		t.Errorf("Expected a reference script, got %v", scriptRef)
+	}
+
+	if len(mcc.AddressUtxos(testAddress, false)) != 1 {
+		t.Error("Expected the first page only")
+	}
+	addressUtxos := mcc.AddressUtxos(testAddress, true)
+	if len(addressUtxos) != 2 || addressUtxos[0].InlineDatum != "d8799fff" || addressUtxos[1].ReferenceScriptHash != testScript {
+		t.Errorf("Unexpected address UTxOs: %v", addressUtxos)
+	}
+
+	utxo, err := mcc.GetUtxoFromRef(testTxHash, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utxo.GetKey() != inline.GetKey() || utxo.Output.GetAmount().GetCoin() != 2_000_000 {
+		t.Errorf("Unexpected UTxO: %v", utxo)
+	}
+	if _, err := mcc.GetUtxoFromRef(testTxHash, 1); err == nil {
+		t.Error("Expected an unknown UTxO to fail")
+	}
+}
+
+func TestEvaluateTx(t *testing.T) {
+	mcc, server := newTestContext(t)
+	txId, _ := hex.DecodeString(testTxHash)
+	address, _ := Address.DecodeAddress(testAddress)
+	additional := UTxO.UTxO{
+		Input:  TransactionInput.TransactionInput{TransactionId: txId, Index: 3},
+		Output: TransactionOutput.SimpleTransactionOutput(address, Value.PureLovelaceValue(5_000_000)),
+	}
+	tx := []byte{0x84, 0xa0, 0xa0, 0xf5, 0xf6}
+	units, err := mcc.EvaluateTxWithAdditionalUtxos(tx, []UTxO.UTxO{additional})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spend := units[Redeemer.RedeemerTagNames[Redeemer.SPEND]+":0"]
+	mint := units[Redeemer.RedeemerTagNames[Redeemer.MINT]+":1"]
+	if len(units) != 2 || spend.Mem != 1700 || spend.Steps != 476468 || mint.Mem != 5124 {
+		t.Errorf("Unexpected execution units: %v", units)
+	}
+
+	request := server.evaluation
+	if request.Cbor != hex.EncodeToString(tx) || len(request.AdditionalUtxos) != 1 {
+		t.Fatalf("Unexpected evaluation request: %v", request)
+	}
+	output, _ := cbor.Marshal(&additional.Output)
+	if got := request.AdditionalUtxos[0]; got.TxHash != testTxHash || got.Index != 3 || got.TxoutCbor != hex.EncodeToString(output) {
+		t.Errorf("Unexpected additional UTxO: %v", got)
+	}
+}
+
+func TestSubmitTx(t *testing.T) {
+	mcc, server := newTestContext(t)
+	tx := Transaction.Transaction{Valid: true}
+	id, err := mcc.SubmitTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := cbor.Marshal(tx)
+	if hex.EncodeToString(server.submitted) != hex.EncodeToString(expected) {
+		t.Errorf("Unexpected submission: %x", server.submitted)
+	}
+	if hex.EncodeToString(id.Payload) != hex.EncodeToString(tx.TransactionBody.Id().Payload) {
+		t.Errorf("Unexpected transaction id: %x", id.Payload)
+	}
+
+	server.rejectTx = true
+	var maestroErr MaestroError
+	if _, err := mcc.SubmitTx(tx); !errors.As(err, &maestroErr) || maestroErr.StatusCode != http.StatusBadRequest || maestroErr.Message != "ValueNotConservedUTxO" {
+		t.Errorf("Expected the rejection to be surfaced, got %v", err)
+	}
+}
+
+func TestGetContractCbor(t *testing.T) {
+	mcc, _ := newTestContext(t)
+	if script := mcc.GetContractCbor(testScript); script != "4e4d01000033222220051200120011" {
+		t.Errorf("Unexpected script: %s", script)
+	}
+	if script := mcc.GetContractCbor("00"); script != "" {
+		t.Errorf("Expected an unknown script to be empty, got %s", script)
+	}
+}
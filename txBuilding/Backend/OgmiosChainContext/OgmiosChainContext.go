@@ -25,6 +25,7 @@ import (
 	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
 	"github.com/SundaeSwap-finance/apollo/serialization/Value"
 	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/SlotConfig"
 	"github.com/SundaeSwap-finance/kugo"
 	"github.com/SundaeSwap-finance/ogmigo/v6"
 	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/chainsync"
@@ -382,33 +383,70 @@ func (occ *OgmiosChainContext) LatestEpoch() Base.Epoch {
 	if err != nil {
 		log.Fatal(err, "OgmiosChainContext: LatestEpoch: failed to request current epoch")
 	}
+	startTime, err := occ.networkStartTime(ctx)
+	if err != nil {
+		log.Fatal(err, "OgmiosChainContext: LatestEpoch: failed to get network start time")
+	}
+	eraSummaries, err := occ.ogmigo.EraSummaries(ctx)
+	if err != nil {
+		log.Fatal(err, "OgmiosChainContext: LatestEpoch: failed to request era summaries")
+	}
+	endTime, err := computeEndTime(current, startTime, eraSummaries)
+	if err != nil {
+		log.Fatal(err, "OgmiosChainContext: LatestEpoch: failed to compute end time for epoch")
+	}
+	return Base.Epoch{
+		Epoch:   int(current),
+		EndTime: int(endTime),
+	}
+}
+
+// The network start time is only found in the byron genesis config
+func (occ *OgmiosChainContext) networkStartTime(ctx context.Context) (time.Time, error) {
 	genesisConfig, err := occ.ogmigo.GenesisConfig(ctx, "byron")
 	if err != nil {
-		log.Fatal(err, "OgmiosChainContext: LatestEpoch: failed to request genesis config")
+		return time.Time{}, fmt.Errorf("failed to request genesis config: %w", err)
 	}
 	var genesisInfo struct {
 		StartTime string
 	}
 	err = json.Unmarshal(genesisConfig, &genesisInfo)
 	if err != nil {
-		log.Fatal(err, "OgmiosChainContext: LatestEpoch: failed to parse genesis config")
+		return time.Time{}, fmt.Errorf("failed to parse genesis config: %w", err)
 	}
 	startTime, err := time.Parse(time.RFC3339, genesisInfo.StartTime)
 	if err != nil {
-		log.Fatal(err, "OgmiosChainContext: LatestEpoch: failed to parse genesis config startTime")
+		return time.Time{}, fmt.Errorf("failed to parse genesis config startTime: %w", err)
 	}
-	eraSummaries, err := occ.ogmigo.EraSummaries(ctx)
-	if err != nil {
-		log.Fatal(err, "OgmiosChainContext: LatestEpoch: failed to request era summaries")
+	return startTime, nil
+}
+
+// Build a slot config with one era per era summary
+func SlotConfigFromEraHistory(networkStartTime time.Time, history *ogmigo.EraHistory) SlotConfig.SlotConfig {
+	eras := make([]SlotConfig.Era, 0, len(history.Summaries))
+	for _, summary := range history.Summaries {
+		eras = append(eras, SlotConfig.Era{
+			StartSlot:  int64(summary.Start.Slot),
+			StartTime:  networkStartTime.Add(time.Second * time.Duration(summary.Start.Time.Seconds.Int64())),
+			SlotLength: time.Millisecond * time.Duration(summary.Parameters.SlotLength.Milliseconds.Int64()),
+		})
 	}
-	endTime, err := computeEndTime(current, startTime, eraSummaries)
+	return SlotConfig.SlotConfig{Eras: eras}
+}
+
+// SlotConfig queries the era summaries, which unlike the genesis
+// parameters also cover networks that were forked later on.
+func (occ *OgmiosChainContext) SlotConfig() (SlotConfig.SlotConfig, error) {
+	ctx := context.Background()
+	startTime, err := occ.networkStartTime(ctx)
 	if err != nil {
-		log.Fatal(err, "OgmiosChainContext: LatestEpoch: failed to compute end time for epoch")
+		return SlotConfig.SlotConfig{}, err
 	}
-	return Base.Epoch{
-		Epoch:   int(current),
-		EndTime: int(endTime),
+	eraSummaries, err := occ.ogmigo.EraSummaries(ctx)
+	if err != nil {
+		return SlotConfig.SlotConfig{}, fmt.Errorf("failed to request era summaries: %w", err)
 	}
+	return SlotConfigFromEraHistory(startTime, eraSummaries), nil
 }
 
 func (occ *OgmiosChainContext) KupoToUtxo(m kugo.Match) UTxO.UTxO {
@@ -851,6 +889,10 @@ func convertOgmiosRedeemerTag(tag string) (string, error) {
 		return Redeemer.RedeemerTagNames[2], nil
 	case "withdraw":
 		return Redeemer.RedeemerTagNames[3], nil
+	case "vote":
+		return Redeemer.RedeemerTagNames[4], nil
+	case "propose":
+		return Redeemer.RedeemerTagNames[5], nil
 	default:
 		return "", fmt.Errorf("Unexpected ogmios redeemer tag: %s", tag)
 	}
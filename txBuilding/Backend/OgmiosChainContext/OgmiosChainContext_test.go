@@ -2,10 +2,14 @@ package OgmiosChainContext
 
 import (
 	"bytes"
+	"math/big"
 	"testing"
+	"time"
 
+	"github.com/SundaeSwap-finance/ogmigo/v6"
 	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/chainsync/num"
 	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/shared"
+	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/statequery"
 )
 
 func TestRoundtripUtxo(t *testing.T) {
@@ -51,3 +55,27 @@ func TestRoundtripUtxo(t *testing.T) {
 		t.Fatalf("Scripts don't match: %v,%v", roundtrip.Script, ogmigoUtxo.Script)
 	}
 }
+
+func TestSlotConfigFromEraHistory(t *testing.T) {
+	eraSummary := func(slot, seconds, slotLengthMs int64) ogmigo.EraSummary {
+		summary := ogmigo.EraSummary{}
+		summary.Start.Slot = uint64(slot)
+		summary.Start.Time = statequery.EraSeconds{Seconds: *big.NewInt(seconds)}
+		summary.Parameters.SlotLength = statequery.EraMilliseconds{Milliseconds: *big.NewInt(slotLengthMs)}
+		return summary
+	}
+	history := ogmigo.EraHistory{Summaries: []ogmigo.EraSummary{
+		eraSummary(0, 0, 20_000),
+		eraSummary(4492800, 89856000, 1_000),
+	}}
+	config := SlotConfigFromEraHistory(time.Unix(1506203091, 0), &history)
+	if config.SlotToTime(4492800).Unix() != 1596059091 {
+		t.Fatalf("Shelley start doesn't match: %v", config.SlotToTime(4492800).Unix())
+	}
+	if config.SlotToTime(100).Unix() != 1506205091 {
+		t.Fatalf("Byron slot doesn't match: %v", config.SlotToTime(100).Unix())
+	}
+	if config.TimeToSlot(time.Unix(1596059191, 0)) != 4492900 {
+		t.Fatalf("Slot doesn't match: %v", config.TimeToSlot(time.Unix(1596059191, 0)))
+	}
+}
@@ -0,0 +1,247 @@
+package PendingChainContext
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+// DEFAULT_TTL is how long a submitted transaction is kept in the view
+// when the wrapped context doesn't show it confirmed.
+const DEFAULT_TTL = 10 * time.Minute
+
+// PendingTx is a transaction submitted through the context and not yet
+// seen confirmed.
+type PendingTx struct {
+	Id        serialization.TransactionId
+	Spent     []TransactionInput.TransactionInput
+	Outputs   []UTxO.UTxO
+	Submitted time.Time
+}
+
+/*
+PendingChainContext wraps a chain context to chain transactions over
+unconfirmed outputs. The transactions it submits have their inputs
+removed from and their outputs added to the UTxOs it returns, until the
+wrapped context shows them confirmed or TTL expires:
+
+	cc := PendingChainContext.NewPendingChainContext(backend)
+	apollob := apollo.New(cc)
+*/
+type PendingChainContext struct {
+	Base.ChainContext
+	TTL time.Duration
+	// Now returns the current time, time.Now unless overridden.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	pending []PendingTx
+}
+
+func NewPendingChainContext(ctx Base.ChainContext) *PendingChainContext {
+	return &PendingChainContext{ChainContext: ctx, TTL: DEFAULT_TTL, Now: time.Now}
+}
+
+func inputKey(input TransactionInput.TransactionInput) string {
+	return fmt.Sprintf("%s:%d", hex.EncodeToString(input.TransactionId), input.Index)
+}
+
+// Pending returns the transactions still in the view.
+func (p *PendingChainContext) Pending() []PendingTx {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expire()
+	return append([]PendingTx{}, p.pending...)
+}
+
+/*
+SubmitTx submits tx through the wrapped context and, once accepted,
+records it so that the next transactions can spend its outputs.
+*/
+func (p *PendingChainContext) SubmitTx(tx Transaction.Transaction) (serialization.TransactionId, error) {
+	id, err := p.ChainContext.SubmitTx(tx)
+	if err != nil {
+		return id, err
+	}
+	txId := tx.Id()
+	outputs := make([]UTxO.UTxO, 0, len(tx.TransactionBody.Outputs))
+	for index, output := range tx.TransactionBody.Outputs {
+		outputs = append(outputs, UTxO.UTxO{
+			Input:  TransactionInput.TransactionInput{TransactionId: txId.Payload, Index: index},
+			Output: output,
+		})
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = append(p.pending, PendingTx{
+		Id:        txId,
+		Spent:     append([]TransactionInput.TransactionInput{}, tx.TransactionBody.Inputs...),
+		Outputs:   outputs,
+		Submitted: p.Now(),
+	})
+	return id, nil
+}
+
+// expire drops the transactions submitted more than TTL ago. The caller
+// holds the lock.
+func (p *PendingChainContext) expire() {
+	if p.TTL <= 0 {
+		return
+	}
+	now := p.Now()
+	kept := p.pending[:0]
+	for _, tx := range p.pending {
+		if now.Sub(tx.Submitted) < p.TTL {
+			kept = append(kept, tx)
+		}
+	}
+	p.pending = kept
+}
+
+// confirm drops the transactions for which confirmed holds. The caller
+// holds the lock.
+func (p *PendingChainContext) confirm(confirmed func(PendingTx) bool) {
+	kept := p.pending[:0]
+	for _, tx := range p.pending {
+		if !confirmed(tx) {
+			kept = append(kept, tx)
+		}
+	}
+	p.pending = kept
+}
+
+/*
+Prune drops the transactions the wrapped context knows an output of,
+and those submitted more than TTL ago. Utxos already drops the ones
+whose outputs it sees, Prune also looks up the outputs since spent.
+*/
+func (p *PendingChainContext) Prune() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expire()
+	p.confirm(func(tx PendingTx) bool {
+		for _, output := range tx.Outputs {
+			utxo, err := p.ChainContext.GetUtxoFromRef(hex.EncodeToString(output.Input.TransactionId), output.Input.Index)
+			if err == nil && bytes.Equal(utxo.Input.TransactionId, output.Input.TransactionId) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// spent returns the inputs consumed by the pending transactions. The
+// caller holds the lock.
+func (p *PendingChainContext) spent() map[string]bool {
+	spent := make(map[string]bool)
+	for _, tx := range p.pending {
+		for _, input := range tx.Spent {
+			spent[inputKey(input)] = true
+		}
+	}
+	return spent
+}
+
+/*
+Utxos returns the UTxOs of address known to the wrapped context, less
+the ones spent by pending transactions, followed by the unspent outputs
+of pending transactions to address.
+*/
+func (p *PendingChainContext) Utxos(address Address.Address) []UTxO.UTxO {
+	utxos := p.ChainContext.Utxos(address)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expire()
+	seen := make(map[string]bool)
+	for _, utxo := range utxos {
+		seen[hex.EncodeToString(utxo.Input.TransactionId)] = true
+	}
+	p.confirm(func(tx PendingTx) bool {
+		return seen[hex.EncodeToString(tx.Id.Payload)]
+	})
+	spent := p.spent()
+	res := make([]UTxO.UTxO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if !spent[inputKey(utxo.Input)] {
+			res = append(res, utxo)
+		}
+	}
+	target := address.Bytes()
+	for _, tx := range p.pending {
+		for _, output := range tx.Outputs {
+			if !spent[inputKey(output.Input)] && bytes.Equal(output.Output.GetAddress().Bytes(), target) {
+				res = append(res, output)
+			}
+		}
+	}
+	return res
+}
+
+// GetUtxoFromRef resolves the outputs of pending transactions, and fails
+// for the ones they spend.
+func (p *PendingChainContext) GetUtxoFromRef(txHash string, txIndex int) (UTxO.UTxO, error) {
+	p.mu.Lock()
+	p.expire()
+	key := fmt.Sprintf("%s:%d", txHash, txIndex)
+	if p.spent()[key] {
+		p.mu.Unlock()
+		return UTxO.UTxO{}, fmt.Errorf("utxo %s is spent by a pending transaction", key)
+	}
+	for _, tx := range p.pending {
+		for _, output := range tx.Outputs {
+			if inputKey(output.Input) == key {
+				p.mu.Unlock()
+				return output, nil
+			}
+		}
+	}
+	p.mu.Unlock()
+	return p.ChainContext.GetUtxoFromRef(txHash, txIndex)
+}
+
+func (p *PendingChainContext) EvaluateTx(tx []uint8) (map[string]Redeemer.ExecutionUnits, error) {
+	return p.EvaluateTxWithAdditionalUtxos(tx, nil)
+}
+
+// EvaluateTxWithAdditionalUtxos evaluates tx with the wrapped context,
+// adding the pending outputs it spends or references to additionalUtxos.
+func (p *PendingChainContext) EvaluateTxWithAdditionalUtxos(tx []uint8, additionalUtxos []UTxO.UTxO) (map[string]Redeemer.ExecutionUnits, error) {
+	transaction := Transaction.Transaction{}
+	if err := cbor.Unmarshal(tx, &transaction); err != nil {
+		return nil, err
+	}
+	body := transaction.TransactionBody
+	used := make(map[string]bool)
+	for _, inputs := range [][]TransactionInput.TransactionInput{body.Inputs, body.ReferenceInputs, body.Collateral} {
+		for _, input := range inputs {
+			used[inputKey(input)] = true
+		}
+	}
+	for _, utxo := range additionalUtxos {
+		delete(used, inputKey(utxo.Input))
+	}
+	utxos := append([]UTxO.UTxO{}, additionalUtxos...)
+	p.mu.Lock()
+	p.expire()
+	for _, pending := range p.pending {
+		for _, output := range pending.Outputs {
+			if used[inputKey(output.Input)] {
+				utxos = append(utxos, output)
+			}
+		}
+	}
+	p.mu.Unlock()
+	return p.ChainContext.EvaluateTxWithAdditionalUtxos(tx, utxos)
+}
@@ -0,0 +1,455 @@
+package CoinSelection
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/AssetName"
+	"github.com/SundaeSwap-finance/apollo/serialization/Policy"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionOutput"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/Value"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Utils"
+)
+
+const (
+	DEFAULT_BNB_MAX_TRIES = 100_000
+	DEFAULT_BNB_ROUNDS    = 32
+
+	// rough serialized sizes used to price inputs and change outputs
+	bnbInputSize       = 40
+	bnbChangeBaseSize  = 65
+	bnbPolicySize      = 30
+	bnbAssetEntrySize  = 9
+	bnbChangeAddrBech  = "addr1q8m9x2zsux7va6w892g38tvchnzahvcd9tykqf3ygnmwta8k2v59pcduem5uw253zwke30x9mwes62kfvqnzg38kuh6q966kg7"
+	bnbNoSolutionCost  = int64(-1)
+	bnbUnlimitedInputs = -1
+)
+
+/*
+BranchAndBoundSelector looks for the input set with the lowest cost,
+where the cost is the fee paid for the inputs plus the fee of the change
+output, which grows with every asset that ends up in it.
+
+It first runs a depth first search for an exact match, a set of inputs
+that covers the request without needing a change output at all. If
+there is none it falls back to a number of seeded random rounds that
+are trimmed down to the cheapest valid selection. The result only
+depends on the inputs and Seed.
+*/
+type BranchAndBoundSelector struct {
+	Seed int64
+	// MaxTries bounds the exact match search, 0 uses DEFAULT_BNB_MAX_TRIES.
+	MaxTries int
+	// Rounds is the number of random rounds, 0 uses DEFAULT_BNB_ROUNDS.
+	Rounds int
+}
+
+type bnbAsset struct {
+	policy Policy.PolicyId
+	name   AssetName.AssetName
+}
+
+type bnbCandidate struct {
+	utxo     UTxO.UTxO
+	lovelace int64
+	assets   map[bnbAsset]int64
+}
+
+type bnbSelection struct {
+	lovelace int64
+	assets   map[bnbAsset]int64
+	picked   []int
+}
+
+func (s *bnbSelection) add(idx int, c bnbCandidate) {
+	s.lovelace += c.lovelace
+	for asset, amount := range c.assets {
+		s.assets[asset] += amount
+	}
+	s.picked = append(s.picked, idx)
+}
+
+func (s *bnbSelection) remove(c bnbCandidate) {
+	s.lovelace -= c.lovelace
+	for asset, amount := range c.assets {
+		s.assets[asset] -= amount
+	}
+	s.picked = s.picked[:len(s.picked)-1]
+}
+
+type bnbSearch struct {
+	candidates     []bnbCandidate
+	requested      Value.Value
+	target         int64
+	assets         map[bnbAsset]int64
+	assetKeys      []bnbAsset
+	maxInputCount  int
+	respectMinUtxo bool
+	context        Base.ChainContext
+	feeCoefficient int64
+
+	// exact match search state
+	pool           []int
+	suffixLovelace []int64
+	suffixAssets   [][]int64
+	tries          int
+	best           []int
+	bestCost       int64
+}
+
+func flattenValue(val Value.Value) (int64, map[bnbAsset]int64) {
+	assets := make(map[bnbAsset]int64)
+	for policy, asset := range val.GetAssets() {
+		for name, amount := range asset {
+			if amount != 0 {
+				assets[bnbAsset{policy, name}] += amount
+			}
+		}
+	}
+	return val.GetCoin(), assets
+}
+
+func (bnb BranchAndBoundSelector) Select(
+	utxos []UTxO.UTxO,
+	outputs []TransactionOutput.TransactionOutput,
+	context Base.ChainContext,
+	maxInputCount int,
+	includeMaxFee bool,
+	respectMinUtxo bool) (
+	[]UTxO.UTxO,
+	Value.Value,
+	error) {
+	var totalRequested = Value.Value{}
+	if includeMaxFee {
+		totalRequested.Coin = int64(context.MaxTxFee())
+	}
+	for _, output := range outputs {
+		totalRequested = totalRequested.Add(output.GetValue())
+	}
+	search := newBnbSearch(utxos, totalRequested, context, maxInputCount, respectMinUtxo)
+	available := bnbSelection{assets: make(map[bnbAsset]int64)}
+	for idx, candidate := range search.candidates {
+		available.add(idx, candidate)
+	}
+	if !search.covers(available) {
+		return nil, Value.Value{}, &InsufficientUtxoBalanceError{
+			Msg: fmt.Sprintf(" requested %v", totalRequested),
+		}
+	}
+
+	maxTries := bnb.MaxTries
+	if maxTries <= 0 {
+		maxTries = DEFAULT_BNB_MAX_TRIES
+	}
+	picked, cost := search.exactMatch(maxTries)
+
+	rounds := bnb.Rounds
+	if rounds <= 0 {
+		rounds = DEFAULT_BNB_ROUNDS
+	}
+	withChange, changeCost, err := search.withChange(rand.New(rand.NewSource(bnb.Seed)), rounds)
+	if err != nil && cost == bnbNoSolutionCost {
+		return nil, Value.Value{}, err
+	}
+	if cost == bnbNoSolutionCost || (changeCost != bnbNoSolutionCost && changeCost < cost) {
+		picked = withChange
+	}
+
+	sort.Ints(picked)
+	selected := make([]UTxO.UTxO, 0, len(picked))
+	selectedAmount := Value.Value{}
+	for _, idx := range picked {
+		selected = append(selected, search.candidates[idx].utxo)
+		selectedAmount = selectedAmount.Add(search.candidates[idx].utxo.Output.GetValue())
+	}
+	return selected, selectedAmount.Sub(totalRequested), nil
+}
+
+func newBnbSearch(
+	utxos []UTxO.UTxO,
+	requested Value.Value,
+	context Base.ChainContext,
+	maxInputCount int,
+	respectMinUtxo bool) *bnbSearch {
+	search := &bnbSearch{
+		requested:      requested,
+		maxInputCount:  maxInputCount,
+		respectMinUtxo: respectMinUtxo,
+		context:        context,
+		feeCoefficient: int64(context.GetProtocolParams().MinFeeCoefficient),
+		bestCost:       bnbNoSolutionCost,
+	}
+	if search.maxInputCount < 0 {
+		search.maxInputCount = bnbUnlimitedInputs
+	}
+	var requestedAssets map[bnbAsset]int64
+	search.target, requestedAssets = flattenValue(requested)
+	search.assets = make(map[bnbAsset]int64)
+	for asset, amount := range requestedAssets {
+		if amount > 0 {
+			search.assets[asset] = amount
+			search.assetKeys = append(search.assetKeys, asset)
+		}
+	}
+	sort.Slice(search.assetKeys, func(i, j int) bool {
+		if search.assetKeys[i].policy.Value != search.assetKeys[j].policy.Value {
+			return search.assetKeys[i].policy.Value < search.assetKeys[j].policy.Value
+		}
+		return search.assetKeys[i].name.HexString() < search.assetKeys[j].name.HexString()
+	})
+
+	for _, utxo := range utxos {
+		lovelace, assets := flattenValue(utxo.Output.GetValue())
+		search.candidates = append(search.candidates, bnbCandidate{utxo: utxo, lovelace: lovelace, assets: assets})
+	}
+	// a stable, input independent order keeps the search deterministic
+	sort.SliceStable(search.candidates, func(i, j int) bool {
+		if search.candidates[i].lovelace != search.candidates[j].lovelace {
+			return search.candidates[i].lovelace > search.candidates[j].lovelace
+		}
+		return search.candidates[i].utxo.GetKey() < search.candidates[j].utxo.GetKey()
+	})
+	return search
+}
+
+func (s *bnbSearch) covers(sel bnbSelection) bool {
+	if sel.lovelace < s.target {
+		return false
+	}
+	for asset, amount := range s.assets {
+		if sel.assets[asset] < amount {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *bnbSearch) tooManyInputs(count int) bool {
+	return s.maxInputCount != bnbUnlimitedInputs && count > s.maxInputCount
+}
+
+func (s *bnbSearch) inputCost(count int) int64 {
+	return int64(count) * bnbInputSize * s.feeCoefficient
+}
+
+// changeCost prices a change output holding the surplus assets of sel.
+func (s *bnbSearch) changeCost(sel bnbSelection) int64 {
+	size := int64(bnbChangeBaseSize)
+	policies := make(map[Policy.PolicyId]bool)
+	for asset, amount := range sel.assets {
+		if amount-s.assets[asset] <= 0 {
+			continue
+		}
+		if !policies[asset.policy] {
+			policies[asset.policy] = true
+			size += bnbPolicySize
+		}
+		size += bnbAssetEntrySize + int64(len(asset.name.HexString())/2)
+	}
+	return size * s.feeCoefficient
+}
+
+/*
+exactMatch searches for a selection that needs no change output: every
+requested asset is matched exactly and the lovelace surplus is smaller
+than what a change output would cost. Inputs holding assets that were
+not requested can never be part of such a selection and are skipped.
+*/
+func (s *bnbSearch) exactMatch(maxTries int) ([]int, int64) {
+	s.pool = s.pool[:0]
+	for idx, candidate := range s.candidates {
+		usable := true
+		for asset := range candidate.assets {
+			if _, ok := s.assets[asset]; !ok {
+				usable = false
+				break
+			}
+		}
+		if usable {
+			s.pool = append(s.pool, idx)
+		}
+	}
+	s.suffixLovelace = make([]int64, len(s.pool)+1)
+	s.suffixAssets = make([][]int64, len(s.pool)+1)
+	s.suffixAssets[len(s.pool)] = make([]int64, len(s.assetKeys))
+	for i := len(s.pool) - 1; i >= 0; i-- {
+		candidate := s.candidates[s.pool[i]]
+		s.suffixLovelace[i] = s.suffixLovelace[i+1] + candidate.lovelace
+		s.suffixAssets[i] = make([]int64, len(s.assetKeys))
+		for k, asset := range s.assetKeys {
+			s.suffixAssets[i][k] = s.suffixAssets[i+1][k] + candidate.assets[asset]
+		}
+	}
+	s.tries = maxTries
+	s.best = nil
+	s.bestCost = bnbNoSolutionCost
+	window := s.changeCost(bnbSelection{})
+	s.explore(0, bnbSelection{assets: make(map[bnbAsset]int64)}, window)
+	return s.best, s.bestCost
+}
+
+func (s *bnbSearch) explore(i int, sel bnbSelection, window int64) {
+	if s.tries <= 0 {
+		return
+	}
+	s.tries--
+	if sel.lovelace > s.target+window || s.tooManyInputs(len(sel.picked)) {
+		return
+	}
+	cost := s.inputCost(len(sel.picked)) + max(sel.lovelace-s.target, 0)
+	if s.bestCost != bnbNoSolutionCost && cost >= s.bestCost {
+		return
+	}
+	if s.covers(sel) {
+		// assets can only be exact here, surplus is never added below
+		s.best = append([]int{}, sel.picked...)
+		s.bestCost = cost
+		return
+	}
+	if i == len(s.pool) || sel.lovelace+s.suffixLovelace[i] < s.target {
+		return
+	}
+	for k, asset := range s.assetKeys {
+		if sel.assets[asset]+s.suffixAssets[i][k] < s.assets[asset] {
+			return
+		}
+	}
+	candidate := s.candidates[s.pool[i]]
+	fits := true
+	for asset, amount := range candidate.assets {
+		if sel.assets[asset]+amount > s.assets[asset] {
+			fits = false
+			break
+		}
+	}
+	if fits {
+		sel.add(s.pool[i], candidate)
+		s.explore(i+1, sel, window)
+		sel.remove(candidate)
+	}
+	s.explore(i+1, sel, window)
+}
+
+// validWithChange reports whether sel covers the request and leaves
+// enough lovelace to pay for its own change output.
+func (s *bnbSearch) validWithChange(sel bnbSelection) bool {
+	if !s.covers(sel) {
+		return false
+	}
+	if !s.respectMinUtxo {
+		return true
+	}
+	selectedAmount := Value.Value{}
+	for _, idx := range sel.picked {
+		selectedAmount = selectedAmount.Add(s.candidates[idx].utxo.Output.GetValue())
+	}
+	change := selectedAmount.Sub(s.requested).RemoveZeroAssets()
+	address, _ := Address.DecodeAddress(bnbChangeAddrBech)
+	minChangeAmount := Utils.MinLovelacePostAlonzo(
+		TransactionOutput.TransactionOutput{
+			IsPostAlonzo: false,
+			PreAlonzo:    TransactionOutput.TransactionOutputShelley{Address: address, Amount: change},
+		}, s.context)
+	return change.GetCoin() >= minChangeAmount
+}
+
+/*
+withChange builds a greedy selection followed by the given number of
+random ones, trims every one of them down to the inputs it actually
+needs and keeps the cheapest.
+*/
+func (s *bnbSearch) withChange(rng *rand.Rand, rounds int) ([]int, int64, error) {
+	var best []int
+	bestCost := bnbNoSolutionCost
+	tooMany := false
+	for round := 0; round <= rounds; round++ {
+		order := make([]int, len(s.candidates))
+		if round == 0 {
+			for i := range order {
+				order[i] = i
+			}
+		} else {
+			order = rng.Perm(len(s.candidates))
+		}
+		sel, ok := s.fill(order)
+		if !ok {
+			continue
+		}
+		sel = s.trim(sel)
+		if s.tooManyInputs(len(sel.picked)) {
+			tooMany = true
+			continue
+		}
+		cost := s.inputCost(len(sel.picked)) + s.changeCost(sel)
+		if bestCost == bnbNoSolutionCost || cost < bestCost {
+			best = sel.picked
+			bestCost = cost
+		}
+	}
+	if best == nil {
+		if tooMany {
+			return nil, bnbNoSolutionCost, &MaxInputCountExceededError{s.maxInputCount}
+		}
+		return nil, bnbNoSolutionCost, &InsufficientUtxoBalanceError{
+			Msg: fmt.Sprintf(" requested %v", s.requested),
+		}
+	}
+	return best, bestCost, nil
+}
+
+// fill picks inputs in order, first for every requested asset and then
+// for lovelace, until the selection is valid.
+func (s *bnbSearch) fill(order []int) (bnbSelection, bool) {
+	sel := bnbSelection{assets: make(map[bnbAsset]int64)}
+	used := make(map[int]bool)
+	for _, asset := range s.assetKeys {
+		for _, idx := range order {
+			if sel.assets[asset] >= s.assets[asset] {
+				break
+			}
+			if !used[idx] && s.candidates[idx].assets[asset] > 0 {
+				used[idx] = true
+				sel.add(idx, s.candidates[idx])
+			}
+		}
+	}
+	for _, idx := range order {
+		if sel.lovelace >= s.target && s.validWithChange(sel) {
+			return sel, true
+		}
+		if !used[idx] {
+			used[idx] = true
+			sel.add(idx, s.candidates[idx])
+		}
+	}
+	return sel, s.validWithChange(sel)
+}
+
+// trim drops inputs that are not needed, the ones carrying the most
+// unrequested assets and the least lovelace first.
+func (s *bnbSearch) trim(sel bnbSelection) bnbSelection {
+	order := append([]int{}, sel.picked...)
+	sort.SliceStable(order, func(i, j int) bool {
+		ci, cj := s.candidates[order[i]], s.candidates[order[j]]
+		if len(ci.assets) != len(cj.assets) {
+			return len(ci.assets) > len(cj.assets)
+		}
+		return ci.lovelace < cj.lovelace
+	})
+	for _, drop := range order {
+		trimmed := bnbSelection{assets: make(map[bnbAsset]int64)}
+		for _, idx := range sel.picked {
+			if idx != drop {
+				trimmed.add(idx, s.candidates[idx])
+			}
+		}
+		if s.validWithChange(trimmed) {
+			sel = trimmed
+		}
+	}
+	return sel
+}
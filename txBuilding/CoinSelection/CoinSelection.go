@@ -61,7 +61,7 @@ func (lfs LargestFirstSelector) Select(
 	Value.Value,
 	error) {
 	available := Utils.Copy(utxos)
-	sort.SliceStable(available, func(i, j int) bool { return utxos[i].Output.Lovelace() < utxos[j].Output.Lovelace() })
+	sort.SliceStable(available, func(i, j int) bool { return available[i].Output.Lovelace() < available[j].Output.Lovelace() })
 	var max_fee uint64 = 0
 	if includeMaxFee {
 		max_fee = uint64(context.MaxTxFee())
@@ -114,7 +114,7 @@ func (lfs LargestFirstSelector) Select(
 type RandomImproveMultiAsset struct{}
 
 func _splitByAsset(value Value.Value) []Value.Value {
-	assets := []Value.Value{{Coin: value.Coin, HasAssets: false}}
+	assets := []Value.Value{{Coin: value.GetCoin(), HasAssets: false}}
 	for policy, asset := range value.GetAssets() {
 		for name, amount := range asset {
 			assets = append(assets, Value.Value{
@@ -148,10 +148,10 @@ func _get_next_random(remaining []UTxO.UTxO) (UTxO.UTxO, []UTxO.UTxO) {
 	return remaining[idx], remainders
 }
 
-func _randomSelectSubset(amount Value.Value, remaining []UTxO.UTxO, selected []UTxO.UTxO, selectedAmount Value.Value) ([]UTxO.UTxO, Value.Value, error) {
+func _randomSelectSubset(amount Value.Value, remaining []UTxO.UTxO, selected []UTxO.UTxO, selectedAmount Value.Value) ([]UTxO.UTxO, Value.Value, []UTxO.UTxO, error) {
 	for !amount.LessOrEqual(selectedAmount) {
 		if len(remaining) == 0 {
-			return nil, Value.Value{}, &InputUTxoDepletedError{}
+			return nil, Value.Value{}, nil, &InputUTxoDepletedError{}
 		}
 		var toAdd UTxO.UTxO
 		toAdd, remaining = _get_next_random(remaining)
@@ -159,7 +159,22 @@ func _randomSelectSubset(amount Value.Value, remaining []UTxO.UTxO, selected []U
 		selectedAmount = selectedAmount.Add(toAdd.Output.GetValue())
 	}
 
-	return selected, selectedAmount, nil
+	return selected, selectedAmount, remaining, nil
+}
+
+// _unselected returns the utxos that are not in selected.
+func _unselected(utxos []UTxO.UTxO, selected []UTxO.UTxO) []UTxO.UTxO {
+	keys := make(map[string]bool, len(selected))
+	for _, utxo := range selected {
+		keys[utxo.GetKey()] = true
+	}
+	unselected := make([]UTxO.UTxO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if !keys[utxo.GetKey()] {
+			unselected = append(unselected, utxo)
+		}
+	}
+	return unselected
 }
 
 func _findDiffByFormer(ideal Value.Value, actual Value.Value) int {
@@ -193,7 +208,7 @@ func _improve(selected []UTxO.UTxO,
 		math.Abs(float64(_findDiffByFormer(ideal, selectedAmount))) &&
 		_findDiffByFormer(upperBound, selectedAmount.Add(utxo.Output.GetValue())) >= 0 {
 		selected = append(selected, utxo)
-		selectedAmount.Add(utxo.Output.GetValue())
+		selectedAmount = selectedAmount.Add(utxo.Output.GetValue())
 	}
 	return _improve(selected, selectedAmount, remaining, ideal, upperBound, maxInputCount)
 }
@@ -219,7 +234,9 @@ func (rims RandomImproveMultiAsset) Select(
 	}
 	assets := _splitByAsset(totalRequested)
 	requestSorted := Utils.Copy(assets)
-	sort.SliceStable(requestSorted, func(i, j int) bool { return _getSingleAssetVal(assets[i]) < _getSingleAssetVal(assets[j]) })
+	sort.SliceStable(requestSorted, func(i, j int) bool {
+		return _getSingleAssetVal(requestSorted[i]) < _getSingleAssetVal(requestSorted[j])
+	})
 	reverseSorted := Utils.Copy(requestSorted)
 	_reverse(reverseSorted)
 	var err error
@@ -227,7 +244,7 @@ func (rims RandomImproveMultiAsset) Select(
 	selected := make([]UTxO.UTxO, 0)
 	selectedAmount := Value.Value{}
 	for r := range requestSorted {
-		selected, selectedAmount, err = _randomSelectSubset(requestSorted[r], available, selected, selectedAmount)
+		selected, selectedAmount, available, err = _randomSelectSubset(requestSorted[r], available, selected, selectedAmount)
 		if err != nil {
 			return nil, Value.Value{}, err
 		}
@@ -251,9 +268,9 @@ func (rims RandomImproveMultiAsset) Select(
 		if err != nil {
 			continue
 		}
-		selectedAmount.Add(partialAmount)
-		selected = append(selected, partialSelected...)
-
+		// _improve returns the whole selection, the new utxos included
+		selected, selectedAmount = partialSelected, partialAmount
+		available = _unselected(available, selected)
 	}
 	if respectMinUtxo {
 		change := selectedAmount.Sub(totalRequested)
@@ -274,7 +291,7 @@ func (rims RandomImproveMultiAsset) Select(
 			}
 			for _, utxo := range additional {
 				selected = append(selected, utxo)
-				selectedAmount.Add(utxo.Output.GetValue())
+				selectedAmount = selectedAmount.Add(utxo.Output.GetValue())
 			}
 		}
 	}
@@ -1,10 +1,15 @@
 package Errors
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strings"
 
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
 	"github.com/SundaeSwap-finance/apollo/serialization/TransactionOutput"
 	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/Value"
 )
 
 type InvalidTransactionException struct {
@@ -37,3 +42,183 @@ type InputExclusionError struct {
 func (i *InputExclusionError) Error() string {
 	return i.Msg
 }
+
+// ValidationErrors collects every phase-1 rule a transaction broke.
+// The individual errors can be matched with errors.As.
+type ValidationErrors struct {
+	Errors []error
+}
+
+func (v *ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v.Errors))
+	for _, err := range v.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return "transaction failed validation: " + strings.Join(msgs, "; ")
+}
+
+func (v *ValidationErrors) Unwrap() []error {
+	return v.Errors
+}
+
+type UnresolvedInputError struct {
+	Input TransactionInput.TransactionInput
+}
+
+func (u *UnresolvedInputError) Error() string {
+	return fmt.Sprintf("input %s#%d was not resolved", hex.EncodeToString(u.Input.TransactionId), u.Input.Index)
+}
+
+type ValueNotConservedError struct {
+	Consumed Value.Value
+	Produced Value.Value
+}
+
+func (v *ValueNotConservedError) Error() string {
+	return fmt.Sprintf("value not conserved: consumed %v, produced %v", v.Consumed, v.Produced)
+}
+
+type OutputTooSmallError struct {
+	Index       int
+	Lovelace    int64
+	MinLovelace int64
+}
+
+func (o *OutputTooSmallError) Error() string {
+	return fmt.Sprintf("output %d holds %d lovelace, at least %d required", o.Index, o.Lovelace, o.MinLovelace)
+}
+
+type OutputValueTooBigError struct {
+	Index   int
+	Size    int
+	MaxSize int
+}
+
+func (o *OutputValueTooBigError) Error() string {
+	return fmt.Sprintf("output %d value is %d bytes, max is %d", o.Index, o.Size, o.MaxSize)
+}
+
+type FeeTooSmallError struct {
+	Fee    int64
+	MinFee int64
+}
+
+func (f *FeeTooSmallError) Error() string {
+	return fmt.Sprintf("fee %d is below the minimum fee %d", f.Fee, f.MinFee)
+}
+
+type MissingSignatureError struct {
+	KeyHash serialization.PubKeyHash
+}
+
+func (m *MissingSignatureError) Error() string {
+	return fmt.Sprintf("missing signature for key hash %s", hex.EncodeToString(m.KeyHash[:]))
+}
+
+type InvalidSignatureError struct {
+	Vkey []byte
+}
+
+func (i *InvalidSignatureError) Error() string {
+	return fmt.Sprintf("invalid signature for vkey %s", hex.EncodeToString(i.Vkey))
+}
+
+type NoCollateralInputsError struct{}
+
+func (n *NoCollateralInputsError) Error() string {
+	return "transaction runs scripts but has no collateral inputs"
+}
+
+type TooManyCollateralInputsError struct {
+	Count int
+	Max   int
+}
+
+func (t *TooManyCollateralInputsError) Error() string {
+	return fmt.Sprintf("%d collateral inputs, max is %d", t.Count, t.Max)
+}
+
+type InsufficientCollateralError struct {
+	Provided int64
+	Required int64
+}
+
+func (i *InsufficientCollateralError) Error() string {
+	return fmt.Sprintf("collateral of %d lovelace, at least %d required", i.Provided, i.Required)
+}
+
+type CollateralContainsNonAdaError struct {
+	Balance Value.Value
+}
+
+func (c *CollateralContainsNonAdaError) Error() string {
+	return fmt.Sprintf("collateral balance contains native assets: %v", c.Balance)
+}
+
+type IncorrectTotalCollateralError struct {
+	Declared int64
+	Actual   int64
+}
+
+func (i *IncorrectTotalCollateralError) Error() string {
+	return fmt.Sprintf("total collateral is declared as %d but is %d", i.Declared, i.Actual)
+}
+
+type OutsideValidityIntervalError struct {
+	Slot          int64
+	ValidityStart int64
+	Ttl           int64
+}
+
+func (o *OutsideValidityIntervalError) Error() string {
+	return fmt.Sprintf("slot %d is outside the validity interval [%d, %d)", o.Slot, o.ValidityStart, o.Ttl)
+}
+
+type ScriptDataHashMismatchError struct {
+	Expected []byte
+	Actual   []byte
+}
+
+func (s *ScriptDataHashMismatchError) Error() string {
+	return fmt.Sprintf("script data hash is %s, expected %s", hex.EncodeToString(s.Actual), hex.EncodeToString(s.Expected))
+}
+
+type WrongNetworkError struct {
+	Field    string
+	Expected byte
+	Actual   byte
+}
+
+func (w *WrongNetworkError) Error() string {
+	return fmt.Sprintf("%s is on network %d, expected %d", w.Field, w.Actual, w.Expected)
+}
+
+// ScriptFailureError is a script that failed while evaluating a
+// redeemer, along with the messages it traced.
+type ScriptFailureError struct {
+	Redeemer string
+	Err      error
+	Logs     []string
+}
+
+func (s *ScriptFailureError) Error() string {
+	msg := fmt.Sprintf("script of redeemer %s failed: %v", s.Redeemer, s.Err)
+	if len(s.Logs) > 0 {
+		msg += "\n" + strings.Join(s.Logs, "\n")
+	}
+	return msg
+}
+
+func (s *ScriptFailureError) Unwrap() error {
+	return s.Err
+}
+
+type NativeScriptIntervalError struct {
+	ScriptHash    serialization.ScriptHash
+	ValidityStart int64
+	Ttl           int64
+}
+
+func (n *NativeScriptIntervalError) Error() string {
+	return fmt.Sprintf("native script %s can't validate within the validity interval [%d, %d)", hex.EncodeToString(n.ScriptHash[:]), n.ValidityStart, n.Ttl)
+}
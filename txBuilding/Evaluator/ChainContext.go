@@ -0,0 +1,91 @@
+package Evaluator
+
+import (
+	"encoding/hex"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/SlotConfig"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+/*
+ChainContext wraps a chain context to evaluate transactions in process
+instead of asking the backend. Everything else is left to the wrapped
+context, which still resolves the inputs and provides the protocol
+parameters:
+
+	cc := Evaluator.NewChainContext(FixedChainContext.InitFixedChainContext())
+	apollob := apollo.New(cc)
+*/
+type ChainContext struct {
+	Base.ChainContext
+}
+
+func NewChainContext(ctx Base.ChainContext) *ChainContext {
+	return &ChainContext{ChainContext: ctx}
+}
+
+// SlotConfig returns the slot config of the wrapped context when it has
+// one, and the one of its genesis parameters otherwise.
+func (c *ChainContext) SlotConfig() (SlotConfig.SlotConfig, error) {
+	if provider, ok := c.ChainContext.(interface {
+		SlotConfig() (SlotConfig.SlotConfig, error)
+	}); ok {
+		if config, err := provider.SlotConfig(); err == nil {
+			return config, nil
+		}
+	}
+	return SlotConfig.FromGenesis(c.GetGenesisParams()), nil
+}
+
+func (c *ChainContext) EvaluateTx(tx []uint8) (map[string]Redeemer.ExecutionUnits, error) {
+	return c.EvaluateTxWithAdditionalUtxos(tx, nil)
+}
+
+// EvaluateTxWithAdditionalUtxos evaluates tx, resolving its inputs from
+// additionalUtxos first and from the wrapped context otherwise.
+func (c *ChainContext) EvaluateTxWithAdditionalUtxos(tx []uint8, additionalUtxos []UTxO.UTxO) (map[string]Redeemer.ExecutionUnits, error) {
+	transaction := Transaction.Transaction{}
+	if err := cbor.Unmarshal(tx, &transaction); err != nil {
+		return nil, err
+	}
+	known := make(map[string]UTxO.UTxO)
+	for _, utxo := range additionalUtxos {
+		known[inputKey(utxo.Input)] = utxo
+	}
+	body := transaction.TransactionBody
+	resolved := make([]UTxO.UTxO, 0)
+	for _, inputs := range [][]TransactionInput.TransactionInput{body.Inputs, body.ReferenceInputs} {
+		for _, input := range inputs {
+			utxo, ok := known[inputKey(input)]
+			if !ok {
+				fetched, err := c.GetUtxoFromRef(hex.EncodeToString(input.TransactionId), input.Index)
+				if err != nil || fetched.Input.TransactionId == nil {
+					continue
+				}
+				utxo = fetched
+			}
+			resolved = append(resolved, utxo)
+		}
+	}
+	pp := c.GetProtocolParams()
+	if pp.CostModels == nil {
+		pp.CostModels = make(map[Base.CostModelsPlutusVersion]PlutusData.CostModel)
+		for version, costModel := range []PlutusData.CostModel{c.CostModelsV1(), c.CostModelsV2(), c.CostModelsV3()} {
+			if len(costModel) > 0 {
+				pp.CostModels[Base.CostModelsPlutusVersion(version)] = costModel
+			}
+		}
+	}
+	slotConfig, err := c.SlotConfig()
+	if err != nil {
+		return nil, err
+	}
+	return EvaluateTx(transaction, resolved, pp, slotConfig)
+}
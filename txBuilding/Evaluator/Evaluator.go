@@ -0,0 +1,461 @@
+package Evaluator
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Certificate"
+	"github.com/SundaeSwap-finance/apollo/serialization/Governance"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
+	"github.com/SundaeSwap-finance/apollo/serialization/UPLC"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Errors"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/SlotConfig"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+// Mainnet per transaction execution limits, used when the protocol
+// parameters don't set them.
+const (
+	MAX_TX_EX_MEM   = 14000000
+	MAX_TX_EX_STEPS = 10000000000
+)
+
+/*
+EvaluateTx runs every script of a transaction and returns the execution
+units of each redeemer, keyed by "<purpose>:<index>" like the backends
+do. resolvedInputs must hold the UTxOs for every input and reference
+input of the transaction, the cost models are read from
+pp.CostModels.
+
+Scripts are given the whole per transaction budget. A failing script
+makes EvaluateTx return an *Errors.ScriptFailureError holding its trace
+logs.
+*/
+func EvaluateTx(tx Transaction.Transaction, resolvedInputs []UTxO.UTxO, pp Base.ProtocolParameters, slotConfig SlotConfig.SlotConfig) (map[string]Redeemer.ExecutionUnits, error) {
+	c := newTxContext(tx, resolvedInputs, slotConfig)
+	budget := maxBudget(pp)
+	res := make(map[string]Redeemer.ExecutionUnits)
+	for _, redeemer := range c.redeemers {
+		key := fmt.Sprintf("%s:%d", Redeemer.RedeemerTagNames[redeemer.Tag], redeemer.Index)
+		units, err := c.evaluate(redeemer, pp, budget)
+		if err != nil {
+			return nil, err
+		}
+		res[key] = units
+	}
+	return res, nil
+}
+
+func maxBudget(pp Base.ProtocolParameters) UPLC.ExBudget {
+	budget := UPLC.ExBudget{Mem: MAX_TX_EX_MEM, Steps: MAX_TX_EX_STEPS}
+	if mem, err := strconv.ParseInt(pp.MaxTxExMem, 10, 64); err == nil && mem > 0 {
+		budget.Mem = mem
+	}
+	if steps, err := strconv.ParseInt(pp.MaxTxExSteps, 10, 64); err == nil && steps > 0 {
+		budget.Steps = steps
+	}
+	return budget
+}
+
+type script struct {
+	language UPLC.Language
+	bytes    []byte
+}
+
+func (s script) hash() serialization.ScriptHash {
+	switch s.language {
+	case UPLC.PlutusV1:
+		return PlutusData.PlutusV1Script(s.bytes).Hash()
+	case UPLC.PlutusV2:
+		return PlutusData.PlutusV2Script(s.bytes).Hash()
+	}
+	return PlutusData.PlutusV3Script(s.bytes).Hash()
+}
+
+// purpose is what a redeemer is for, along with the part of the
+// transaction it points to.
+type purpose struct {
+	tag      Redeemer.RedeemerTag
+	index    int
+	input    TransactionInput.TransactionInput
+	policy   []byte
+	account  [29]byte
+	cert     *Certificate.Certificate
+	voter    Governance.Voter
+	proposal Governance.ProposalProcedure
+}
+
+// txContext holds a transaction with the parts redeemers point to in
+// ledger order.
+type txContext struct {
+	tx           Transaction.Transaction
+	resolved     map[string]UTxO.UTxO
+	slots        SlotConfig.SlotConfig
+	scripts      map[serialization.ScriptHash]script
+	datums       map[string]PlutusData.PlutusData
+	datumHashes  [][]byte
+	redeemers    []Redeemer.Redeemer
+	inputs       []TransactionInput.TransactionInput
+	policies     [][]byte
+	withdrawals  [][29]byte
+	certificates []*Certificate.Certificate
+	voters       []Governance.Voter
+	signers      []serialization.PubKeyHash
+	infos        map[UPLC.Language]PlutusData.PlutusData
+}
+
+func inputKey(input TransactionInput.TransactionInput) string {
+	return fmt.Sprintf("%s:%d", hex.EncodeToString(input.TransactionId), input.Index)
+}
+
+func sortedInputs(inputs []TransactionInput.TransactionInput) []TransactionInput.TransactionInput {
+	sorted := append([]TransactionInput.TransactionInput{}, inputs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if cmp := bytes.Compare(sorted[i].TransactionId, sorted[j].TransactionId); cmp != 0 {
+			return cmp < 0
+		}
+		return sorted[i].Index < sorted[j].Index
+	})
+	return sorted
+}
+
+func newTxContext(tx Transaction.Transaction, resolvedInputs []UTxO.UTxO, slotConfig SlotConfig.SlotConfig) *txContext {
+	body := tx.TransactionBody
+	witnessSet := tx.TransactionWitnessSet
+	c := &txContext{
+		tx:       tx,
+		resolved: make(map[string]UTxO.UTxO),
+		slots:    slotConfig,
+		scripts:  make(map[serialization.ScriptHash]script),
+		datums:   make(map[string]PlutusData.PlutusData),
+		inputs:   sortedInputs(body.Inputs),
+		infos:    make(map[UPLC.Language]PlutusData.PlutusData),
+	}
+	for _, utxo := range resolvedInputs {
+		c.resolved[inputKey(utxo.Input)] = utxo
+	}
+	for _, s := range witnessSet.PlutusV1Script {
+		c.addScript(script{UPLC.PlutusV1, s})
+	}
+	for _, s := range witnessSet.PlutusV2Script {
+		c.addScript(script{UPLC.PlutusV2, s})
+	}
+	for _, s := range witnessSet.PlutusV3Script {
+		c.addScript(script{UPLC.PlutusV3, s})
+	}
+	for _, inputs := range [][]TransactionInput.TransactionInput{body.Inputs, body.ReferenceInputs} {
+		for _, input := range inputs {
+			if utxo, ok := c.resolved[inputKey(input)]; ok {
+				if ref := utxo.Output.GetScriptRef(); ref != nil {
+					for _, s := range refScripts(ref) {
+						c.addScript(s)
+					}
+				}
+			}
+		}
+	}
+	for _, datum := range witnessSet.PlutusData {
+		hash := PlutusData.PlutusDataHash(&datum)
+		if _, ok := c.datums[hex.EncodeToString(hash.Payload)]; !ok {
+			c.datums[hex.EncodeToString(hash.Payload)] = datum
+			c.datumHashes = append(c.datumHashes, hash.Payload)
+		}
+	}
+	sort.Slice(c.datumHashes, func(i, j int) bool { return bytes.Compare(c.datumHashes[i], c.datumHashes[j]) < 0 })
+	c.redeemers = append(c.redeemers, witnessSet.Redeemer...)
+	sort.SliceStable(c.redeemers, func(i, j int) bool {
+		if c.redeemers[i].Tag != c.redeemers[j].Tag {
+			return c.redeemers[i].Tag < c.redeemers[j].Tag
+		}
+		return c.redeemers[i].Index < c.redeemers[j].Index
+	})
+	for policy := range body.Mint {
+		policyBytes, _ := hex.DecodeString(policy.Value)
+		c.policies = append(c.policies, policyBytes)
+	}
+	sort.Slice(c.policies, func(i, j int) bool { return bytes.Compare(c.policies[i], c.policies[j]) < 0 })
+	if body.Withdrawals != nil {
+		for account := range *body.Withdrawals {
+			c.withdrawals = append(c.withdrawals, account)
+		}
+	}
+	sort.Slice(c.withdrawals, func(i, j int) bool {
+		a, b := c.withdrawals[i], c.withdrawals[j]
+		if a[0]&0x0f != b[0]&0x0f {
+			return a[0]&0x0f < b[0]&0x0f
+		}
+		return accountCredential(a).Less(accountCredential(b))
+	})
+	if body.Certificates != nil {
+		c.certificates = *body.Certificates
+	}
+	if body.VotingProcedures != nil {
+		c.voters = body.VotingProcedures.Voters()
+	}
+	c.signers = append(c.signers, body.RequiredSigners...)
+	sort.Slice(c.signers, func(i, j int) bool { return bytes.Compare(c.signers[i][:], c.signers[j][:]) < 0 })
+	return c
+}
+
+// addScript registers s under its hash, witness scripts being added
+// first and taking precedence.
+func (c *txContext) addScript(s script) {
+	hash := s.hash()
+	if _, ok := c.scripts[hash]; !ok {
+		c.scripts[hash] = s
+	}
+}
+
+/*
+refScripts returns the scripts a reference script may be. Not every
+backend keeps the language of reference scripts, so unless it is given
+as [language, script] a script is tried as every plutus language and
+its redeemers pick the one with the hash they expect.
+*/
+func refScripts(ref *PlutusData.ScriptRef) []script {
+	var tagged struct {
+		_        struct{} `cbor:",toarray"`
+		Language int
+		Script   []byte
+	}
+	if err := cbor.Unmarshal(ref.Script.Script, &tagged); err == nil && tagged.Language >= 1 && tagged.Language <= 3 {
+		return []script{{UPLC.Language(tagged.Language), tagged.Script}}
+	}
+	return []script{
+		{UPLC.PlutusV1, ref.Script.Script},
+		{UPLC.PlutusV2, ref.Script.Script},
+		{UPLC.PlutusV3, ref.Script.Script},
+	}
+}
+
+// refScriptHash returns the hash of a reference script, guessing the
+// language from the transaction scripts and the script version when it
+// isn't known.
+func (c *txContext) refScriptHash(ref *PlutusData.ScriptRef) serialization.ScriptHash {
+	candidates := refScripts(ref)
+	if len(candidates) == 1 {
+		return candidates[0].hash()
+	}
+	for _, redeemer := range c.redeemers {
+		p, err := c.purpose(redeemer.Tag, redeemer.Index)
+		if err != nil {
+			continue
+		}
+		hash, err := c.scriptHash(p)
+		if err != nil {
+			continue
+		}
+		for _, candidate := range candidates {
+			if candidate.hash() == hash {
+				return hash
+			}
+		}
+	}
+	if program, _, err := UPLC.DecodeScript(ref.Script.Script); err == nil && program.Version != [3]uint64{1, 0, 0} {
+		return candidates[2].hash()
+	}
+	return candidates[1].hash()
+}
+
+func (c *txContext) purpose(tag Redeemer.RedeemerTag, index int) (purpose, error) {
+	body := c.tx.TransactionBody
+	p := purpose{tag: tag, index: index}
+	outOfRange := func(count int) error {
+		if index < 0 || index >= count {
+			return fmt.Errorf("redeemer %s:%d points past the %d items of the transaction", Redeemer.RedeemerTagNames[tag], index, count)
+		}
+		return nil
+	}
+	switch tag {
+	case Redeemer.SPEND:
+		if err := outOfRange(len(c.inputs)); err != nil {
+			return p, err
+		}
+		p.input = c.inputs[index]
+	case Redeemer.MINT:
+		if err := outOfRange(len(c.policies)); err != nil {
+			return p, err
+		}
+		p.policy = c.policies[index]
+	case Redeemer.CERT:
+		if err := outOfRange(len(c.certificates)); err != nil {
+			return p, err
+		}
+		p.cert = c.certificates[index]
+	case Redeemer.REWARD:
+		if err := outOfRange(len(c.withdrawals)); err != nil {
+			return p, err
+		}
+		p.account = c.withdrawals[index]
+	case Redeemer.VOTE:
+		if err := outOfRange(len(c.voters)); err != nil {
+			return p, err
+		}
+		p.voter = c.voters[index]
+	case Redeemer.PROPOSE:
+		if err := outOfRange(len(body.ProposalProcedures)); err != nil {
+			return p, err
+		}
+		p.proposal = body.ProposalProcedures[index]
+	default:
+		return p, fmt.Errorf("unknown redeemer tag %d", tag)
+	}
+	return p, nil
+}
+
+// scriptHash returns the hash of the script that has to validate p.
+func (c *txContext) scriptHash(p purpose) (serialization.ScriptHash, error) {
+	var hash serialization.ScriptHash
+	notAScript := fmt.Errorf("redeemer %s:%d doesn't point to a script", Redeemer.RedeemerTagNames[p.tag], p.index)
+	switch p.tag {
+	case Redeemer.SPEND:
+		utxo, ok := c.resolved[inputKey(p.input)]
+		if !ok {
+			return hash, &Errors.UnresolvedInputError{Input: p.input}
+		}
+		address := utxo.Output.GetAddress()
+		if address.AddressType >= Address.BYRON || address.AddressType&1 == 0 || len(address.PaymentPart) < len(hash) {
+			return hash, notAScript
+		}
+		copy(hash[:], address.PaymentPart)
+	case Redeemer.MINT:
+		copy(hash[:], p.policy)
+	case Redeemer.CERT:
+		witnesses := p.cert.Witnesses()
+		if len(witnesses) != 1 || !witnesses[0].IsScript() {
+			return hash, notAScript
+		}
+		hash = witnesses[0].Hash
+	case Redeemer.REWARD:
+		cred := accountCredential(p.account)
+		if !cred.IsScript() {
+			return hash, notAScript
+		}
+		hash = cred.Hash
+	case Redeemer.VOTE:
+		if !p.voter.IsScript() {
+			return hash, notAScript
+		}
+		hash = p.voter.Hash
+	case Redeemer.PROPOSE:
+		if len(p.proposal.GovAction.PolicyHash) != len(hash) {
+			return hash, notAScript
+		}
+		copy(hash[:], p.proposal.GovAction.PolicyHash)
+	}
+	return hash, nil
+}
+
+// spentDatum returns the datum of a spent input, nil if it has none.
+func (c *txContext) spentDatum(input TransactionInput.TransactionInput) (*PlutusData.PlutusData, error) {
+	utxo, ok := c.resolved[inputKey(input)]
+	if !ok {
+		return nil, &Errors.UnresolvedInputError{Input: input}
+	}
+	option := utxo.Output.GetDatumOption()
+	if option == nil {
+		return nil, nil
+	}
+	if option.DatumType == PlutusData.DatumTypeInline {
+		return option.Inline, nil
+	}
+	if len(option.Hash) == 0 {
+		return nil, nil
+	}
+	datum, ok := c.datums[hex.EncodeToString(option.Hash)]
+	if !ok {
+		return nil, fmt.Errorf("missing datum %x of input %s", option.Hash, inputKey(input))
+	}
+	return &datum, nil
+}
+
+/*
+ScriptArgs returns the arguments the script of the redeemer with tag
+and index is applied to when evaluating tx, the script context being
+the last one.
+*/
+func ScriptArgs(tx Transaction.Transaction, resolvedInputs []UTxO.UTxO, slotConfig SlotConfig.SlotConfig, tag Redeemer.RedeemerTag, index int) ([]PlutusData.PlutusData, error) {
+	c := newTxContext(tx, resolvedInputs, slotConfig)
+	for _, redeemer := range c.redeemers {
+		if redeemer.Tag != tag || redeemer.Index != index {
+			continue
+		}
+		p, s, err := c.redeemerScript(redeemer)
+		if err != nil {
+			return nil, err
+		}
+		return c.scriptArgs(p, redeemer, s.language)
+	}
+	return nil, fmt.Errorf("no redeemer %s:%d", Redeemer.RedeemerTagNames[tag], index)
+}
+
+// redeemerScript returns the purpose of a redeemer and the script
+// validating it.
+func (c *txContext) redeemerScript(redeemer Redeemer.Redeemer) (purpose, script, error) {
+	p, err := c.purpose(redeemer.Tag, redeemer.Index)
+	if err != nil {
+		return purpose{}, script{}, err
+	}
+	hash, err := c.scriptHash(p)
+	if err != nil {
+		return purpose{}, script{}, err
+	}
+	s, ok := c.scripts[hash]
+	if !ok {
+		return purpose{}, script{}, fmt.Errorf("missing script %s for redeemer %s:%d",
+			hex.EncodeToString(hash[:]), Redeemer.RedeemerTagNames[redeemer.Tag], redeemer.Index)
+	}
+	return p, s, nil
+}
+
+func (c *txContext) evaluate(redeemer Redeemer.Redeemer, pp Base.ProtocolParameters, budget UPLC.ExBudget) (Redeemer.ExecutionUnits, error) {
+	key := fmt.Sprintf("%s:%d", Redeemer.RedeemerTagNames[redeemer.Tag], redeemer.Index)
+	p, s, err := c.redeemerScript(redeemer)
+	if err != nil {
+		return Redeemer.ExecutionUnits{}, err
+	}
+	hash := s.hash()
+	program, _, err := UPLC.DecodeScript(s.bytes)
+	if err != nil {
+		return Redeemer.ExecutionUnits{}, fmt.Errorf("invalid script %s: %w", hex.EncodeToString(hash[:]), err)
+	}
+	if s.language < UPLC.PlutusV3 && program.Version != [3]uint64{1, 0, 0} {
+		return Redeemer.ExecutionUnits{}, fmt.Errorf("plutus core %d.%d.%d is not supported in %s",
+			program.Version[0], program.Version[1], program.Version[2], s.language)
+	}
+	params, ok := pp.CostModels[Base.CostModelsPlutusVersion(s.language-UPLC.PlutusV1)]
+	if !ok {
+		return Redeemer.ExecutionUnits{}, fmt.Errorf("no cost model for %s", s.language)
+	}
+	costs, err := UPLC.CostModelFromParams(s.language, params)
+	if err != nil {
+		return Redeemer.ExecutionUnits{}, err
+	}
+	args, err := c.scriptArgs(p, redeemer, s.language)
+	if err != nil {
+		return Redeemer.ExecutionUnits{}, err
+	}
+	machine := UPLC.NewMachine(costs, budget)
+	result, err := machine.Run(program.ApplyData(args...))
+	if err == nil && s.language == UPLC.PlutusV3 {
+		if constant, ok := result.(UPLC.Constant); !ok || constant.Value.Type.Tag != UPLC.TypeUnit {
+			err = fmt.Errorf("script returned %v instead of unit", result)
+		}
+	}
+	if err != nil {
+		return Redeemer.ExecutionUnits{}, &Errors.ScriptFailureError{Redeemer: key, Err: err, Logs: machine.Logs}
+	}
+	spent := machine.Consumed()
+	return Redeemer.ExecutionUnits{Mem: spent.Mem, Steps: spent.Steps}, nil
+}
@@ -0,0 +1,696 @@
+package Evaluator
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Certificate"
+	"github.com/SundaeSwap-finance/apollo/serialization/Credential"
+	"github.com/SundaeSwap-finance/apollo/serialization/Governance"
+	"github.com/SundaeSwap-finance/apollo/serialization/MultiAsset"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Policy"
+	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionOutput"
+	"github.com/SundaeSwap-finance/apollo/serialization/UPLC"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Errors"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+func constr(index uint64, fields ...PlutusData.PlutusData) PlutusData.PlutusData {
+	return PlutusData.NewConstr(index, fields...)
+}
+
+func integer(n int64) PlutusData.PlutusData {
+	return PlutusData.NewBigInt(big.NewInt(n))
+}
+
+func boolData(b bool) PlutusData.PlutusData {
+	if b {
+		return constr(1)
+	}
+	return constr(0)
+}
+
+func just(pd PlutusData.PlutusData) PlutusData.PlutusData {
+	return constr(0, pd)
+}
+
+func nothing() PlutusData.PlutusData {
+	return constr(1)
+}
+
+func maybeBytes(b []byte) PlutusData.PlutusData {
+	if len(b) == 0 {
+		return nothing()
+	}
+	return just(PlutusData.NewBytes(b))
+}
+
+func credentialData(cred Credential.Credential) PlutusData.PlutusData {
+	if cred.IsScript() {
+		return constr(1, PlutusData.NewBytes(cred.Bytes()))
+	}
+	return constr(0, PlutusData.NewBytes(cred.Bytes()))
+}
+
+// accountCredential returns the credential of a reward account.
+func accountCredential(account [29]byte) Credential.Credential {
+	cred, _ := Credential.FromBytes(account[1:], account[0]&0x10 != 0)
+	return cred
+}
+
+// pointerData decodes the variable length slot, transaction index and
+// certificate index of a pointer address.
+func pointerData(pointer []byte) (PlutusData.PlutusData, error) {
+	fields := make([]PlutusData.PlutusData, 0, 3)
+	n := new(big.Int)
+	for i, b := range pointer {
+		n.Lsh(n, 7).Or(n, big.NewInt(int64(b&0x7f)))
+		if b&0x80 == 0 {
+			fields = append(fields, PlutusData.NewBigInt(n))
+			n = new(big.Int)
+		} else if i == len(pointer)-1 {
+			return PlutusData.PlutusData{}, fmt.Errorf("truncated pointer %x", pointer)
+		}
+	}
+	if len(fields) != 3 {
+		return PlutusData.PlutusData{}, fmt.Errorf("invalid pointer %x", pointer)
+	}
+	return constr(1, fields...), nil
+}
+
+func addressData(addr Address.Address) (PlutusData.PlutusData, error) {
+	if addr.AddressType >= Address.BYRON || len(addr.PaymentPart) < 28 {
+		return PlutusData.PlutusData{}, fmt.Errorf("address %s can't be part of a script context", addr.String())
+	}
+	payment, _ := Credential.FromBytes(addr.PaymentPart[:28], addr.AddressType&1 == 1)
+	staking := nothing()
+	switch addr.AddressType {
+	case Address.KEY_KEY, Address.SCRIPT_KEY, Address.KEY_SCRIPT, Address.SCRIPT_SCRIPT:
+		cred, err := Credential.FromBytes(addr.StakingPart, addr.AddressType&2 != 0)
+		if err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+		staking = just(constr(0, credentialData(cred)))
+	case Address.KEY_POINTER, Address.SCRIPT_POINTER:
+		pointer, err := pointerData(addr.StakingPart)
+		if err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+		staking = just(pointer)
+	}
+	return constr(0, credentialData(payment), staking), nil
+}
+
+// canonicalLess orders byte strings shorter first, the way the ledger
+// orders asset names and canonical cbor map keys.
+func canonicalLess(a []byte, b []byte) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return bytes.Compare(a, b) < 0
+}
+
+/*
+valueData encodes a value as a map of policies to maps of asset names
+to quantities, ada being the empty policy and asset name. Zero
+quantities are dropped.
+*/
+func valueData(coin int64, assets MultiAsset.MultiAsset[int64], withAda bool) PlutusData.PlutusData {
+	entries := make([]PlutusData.MapEntry, 0, len(assets)+1)
+	if withAda {
+		entries = append(entries, PlutusData.MapEntry{
+			Key:   PlutusData.NewBytes([]byte{}),
+			Value: PlutusData.NewMap(PlutusData.MapEntry{Key: PlutusData.NewBytes([]byte{}), Value: integer(coin)}),
+		})
+	}
+	policies := make([]Policy.PolicyId, 0, len(assets))
+	for policy := range assets {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Value < policies[j].Value })
+	for _, policy := range policies {
+		policyBytes, _ := hex.DecodeString(policy.Value)
+		names := make([][]byte, 0, len(assets[policy]))
+		quantities := make(map[string]int64)
+		for name, quantity := range assets[policy] {
+			if quantity == 0 {
+				continue
+			}
+			nameBytes, _ := hex.DecodeString(name.HexString())
+			names = append(names, nameBytes)
+			quantities[string(nameBytes)] = quantity
+		}
+		if len(names) == 0 {
+			continue
+		}
+		sort.Slice(names, func(i, j int) bool { return canonicalLess(names[i], names[j]) })
+		tokens := make([]PlutusData.MapEntry, len(names))
+		for i, name := range names {
+			tokens[i] = PlutusData.MapEntry{Key: PlutusData.NewBytes(name), Value: integer(quantities[string(name)])}
+		}
+		entries = append(entries, PlutusData.MapEntry{Key: PlutusData.NewBytes(policyBytes), Value: PlutusData.NewMap(tokens...)})
+	}
+	return PlutusData.NewMap(entries...)
+}
+
+func (c *txContext) outRefData(input TransactionInput.TransactionInput, language UPLC.Language) PlutusData.PlutusData {
+	id := PlutusData.NewBytes(input.TransactionId)
+	if language < UPLC.PlutusV3 {
+		id = constr(0, id)
+	}
+	return constr(0, id, integer(int64(input.Index)))
+}
+
+func (c *txContext) outputData(output TransactionOutput.TransactionOutput, language UPLC.Language) (PlutusData.PlutusData, error) {
+	address, err := addressData(output.GetAddress())
+	if err != nil {
+		return PlutusData.PlutusData{}, err
+	}
+	amount := output.GetValue()
+	value := valueData(amount.GetCoin(), amount.GetAssets(), true)
+	option := output.GetDatumOption()
+	if language == UPLC.PlutusV1 {
+		if output.GetScriptRef() != nil {
+			return PlutusData.PlutusData{}, fmt.Errorf("outputs with reference scripts are not supported in %s", language)
+		}
+		datumHash := nothing()
+		if option != nil && option.DatumType == PlutusData.DatumTypeInline {
+			return PlutusData.PlutusData{}, fmt.Errorf("inline datums are not supported in %s", language)
+		} else if option != nil {
+			datumHash = maybeBytes(option.Hash)
+		}
+		return constr(0, address, value, datumHash), nil
+	}
+	datum := constr(0)
+	if option != nil && option.DatumType == PlutusData.DatumTypeInline && option.Inline != nil {
+		datum = constr(2, *option.Inline)
+	} else if option != nil && option.DatumType == PlutusData.DatumTypeHash && len(option.Hash) > 0 {
+		datum = constr(1, PlutusData.NewBytes(option.Hash))
+	}
+	scriptHash := nothing()
+	if ref := output.GetScriptRef(); ref != nil {
+		hash := c.refScriptHash(ref)
+		scriptHash = just(PlutusData.NewBytes(hash[:]))
+	}
+	return constr(0, address, value, datum, scriptHash), nil
+}
+
+func (c *txContext) inputsData(inputs []TransactionInput.TransactionInput, language UPLC.Language) (PlutusData.PlutusData, error) {
+	items := make([]PlutusData.PlutusData, 0, len(inputs))
+	for _, input := range inputs {
+		utxo, ok := c.resolved[inputKey(input)]
+		if !ok {
+			return PlutusData.PlutusData{}, &Errors.UnresolvedInputError{Input: input}
+		}
+		output, err := c.outputData(utxo.Output, language)
+		if err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+		items = append(items, constr(0, c.outRefData(input, language), output))
+	}
+	return PlutusData.NewList(items...), nil
+}
+
+// dcertData encodes a certificate for PlutusV1 and PlutusV2, which
+// only know about the Shelley certificates.
+func dcertData(cert Certificate.Certificate) (PlutusData.PlutusData, error) {
+	switch cert.Type {
+	case Certificate.StakeRegistration:
+		return constr(0, constr(0, credentialData(cert.StakeCredential))), nil
+	case Certificate.StakeDeregistration:
+		return constr(1, constr(0, credentialData(cert.StakeCredential))), nil
+	case Certificate.StakeDelegation:
+		return constr(2, constr(0, credentialData(cert.StakeCredential)), PlutusData.NewBytes(cert.PoolKeyHash[:])), nil
+	case Certificate.PoolRegistration:
+		return constr(3, PlutusData.NewBytes(cert.PoolParams.Operator[:]), PlutusData.NewBytes(cert.PoolParams.VrfKeyHash)), nil
+	case Certificate.PoolRetirement:
+		return constr(4, PlutusData.NewBytes(cert.PoolKeyHash[:]), integer(int64(cert.Epoch))), nil
+	case Certificate.GenesisKeyDelegation:
+		return constr(5), nil
+	case Certificate.MoveInstantaneousRewards:
+		return constr(6), nil
+	}
+	return PlutusData.PlutusData{}, fmt.Errorf("certificate %d is not supported before PlutusV3", cert.Type)
+}
+
+func drepData(drep Certificate.DRep) PlutusData.PlutusData {
+	switch drep.Type {
+	case Certificate.DRepKeyHash:
+		return constr(0, credentialData(Credential.Credential{Type: Credential.KeyHashCredential, Hash: drep.Hash}))
+	case Certificate.DRepScriptHash:
+		return constr(0, credentialData(Credential.Credential{Type: Credential.ScriptHashCredential, Hash: drep.Hash}))
+	case Certificate.AlwaysAbstain:
+		return constr(1)
+	}
+	return constr(2)
+}
+
+func txCertData(cert Certificate.Certificate) (PlutusData.PlutusData, error) {
+	stake := credentialData(cert.StakeCredential)
+	pool := PlutusData.NewBytes(cert.PoolKeyHash[:])
+	switch cert.Type {
+	case Certificate.StakeRegistration:
+		return constr(0, stake, nothing()), nil
+	case Certificate.StakeDeregistration:
+		return constr(1, stake, nothing()), nil
+	case Certificate.Registration:
+		return constr(0, stake, just(integer(cert.Deposit))), nil
+	case Certificate.Unregistration:
+		return constr(1, stake, just(integer(cert.Deposit))), nil
+	case Certificate.StakeDelegation:
+		return constr(2, stake, constr(0, pool)), nil
+	case Certificate.VoteDelegation:
+		return constr(2, stake, constr(1, drepData(cert.DRep))), nil
+	case Certificate.StakeVoteDelegation:
+		return constr(2, stake, constr(2, pool, drepData(cert.DRep))), nil
+	case Certificate.StakeRegistrationDelegation:
+		return constr(3, stake, constr(0, pool), integer(cert.Deposit)), nil
+	case Certificate.VoteRegistrationDelegation:
+		return constr(3, stake, constr(1, drepData(cert.DRep)), integer(cert.Deposit)), nil
+	case Certificate.StakeVoteRegistrationDelegation:
+		return constr(3, stake, constr(2, pool, drepData(cert.DRep)), integer(cert.Deposit)), nil
+	case Certificate.RegisterDRep:
+		return constr(4, credentialData(cert.DRepCredential), integer(cert.Deposit)), nil
+	case Certificate.UpdateDRep:
+		return constr(5, credentialData(cert.DRepCredential)), nil
+	case Certificate.UnregisterDRep:
+		return constr(6, credentialData(cert.DRepCredential), integer(cert.Deposit)), nil
+	case Certificate.PoolRegistration:
+		return constr(7, PlutusData.NewBytes(cert.PoolParams.Operator[:]), PlutusData.NewBytes(cert.PoolParams.VrfKeyHash)), nil
+	case Certificate.PoolRetirement:
+		return constr(8, pool, integer(int64(cert.Epoch))), nil
+	case Certificate.AuthCommitteeHot:
+		return constr(9, credentialData(cert.ColdCredential), credentialData(cert.HotCredential)), nil
+	case Certificate.ResignCommitteeCold:
+		return constr(10, credentialData(cert.ColdCredential)), nil
+	}
+	return PlutusData.PlutusData{}, fmt.Errorf("certificate %d is not supported in PlutusV3", cert.Type)
+}
+
+func voterData(voter Governance.Voter) PlutusData.PlutusData {
+	cred := Credential.Credential{Type: Credential.KeyHashCredential, Hash: voter.Hash}
+	if voter.IsScript() {
+		cred.Type = Credential.ScriptHashCredential
+	}
+	switch voter.Type {
+	case Governance.CommitteeHotKeyHash, Governance.CommitteeHotScriptHash:
+		return constr(0, credentialData(cred))
+	case Governance.DRepKeyHash, Governance.DRepScriptHash:
+		return constr(1, credentialData(cred))
+	}
+	return constr(2, PlutusData.NewBytes(voter.Hash[:]))
+}
+
+func govActionIdData(id Governance.GovActionId) PlutusData.PlutusData {
+	return constr(0, PlutusData.NewBytes(id.TransactionId[:]), integer(int64(id.Index)))
+}
+
+func maybeGovActionId(id *Governance.GovActionId) PlutusData.PlutusData {
+	if id == nil {
+		return nothing()
+	}
+	return just(govActionIdData(*id))
+}
+
+// cborData converts a decoded protocol parameter to plutus data,
+// rationals becoming a list of their numerator and denominator.
+func cborData(v any) (PlutusData.PlutusData, error) {
+	switch v := v.(type) {
+	case uint64:
+		return PlutusData.NewBigInt(new(big.Int).SetUint64(v)), nil
+	case int64:
+		return integer(v), nil
+	case big.Int:
+		return PlutusData.NewBigInt(&v), nil
+	case []byte:
+		return PlutusData.NewBytes(v), nil
+	case []any:
+		items := make([]PlutusData.PlutusData, len(v))
+		for i, item := range v {
+			pd, err := cborData(item)
+			if err != nil {
+				return PlutusData.PlutusData{}, err
+			}
+			items[i] = pd
+		}
+		return PlutusData.NewList(items...), nil
+	case map[any]any:
+		type entry struct {
+			key   []byte
+			entry PlutusData.MapEntry
+		}
+		entries := make([]entry, 0, len(v))
+		for key, value := range v {
+			encoded, err := cbor.Marshal(key)
+			if err != nil {
+				return PlutusData.PlutusData{}, err
+			}
+			keyData, err := cborData(key)
+			if err != nil {
+				return PlutusData.PlutusData{}, err
+			}
+			valueData, err := cborData(value)
+			if err != nil {
+				return PlutusData.PlutusData{}, err
+			}
+			entries = append(entries, entry{encoded, PlutusData.MapEntry{Key: keyData, Value: valueData}})
+		}
+		sort.Slice(entries, func(i, j int) bool { return canonicalLess(entries[i].key, entries[j].key) })
+		res := make([]PlutusData.MapEntry, len(entries))
+		for i, e := range entries {
+			res[i] = e.entry
+		}
+		return PlutusData.NewMap(res...), nil
+	case cbor.Tag:
+		if v.Number == 30 {
+			return cborData(v.Content)
+		}
+	}
+	return PlutusData.PlutusData{}, fmt.Errorf("unsupported protocol parameter value %v", v)
+}
+
+func parameterChangeData(update map[uint64]cbor.RawMessage) (PlutusData.PlutusData, error) {
+	keys := make([]uint64, 0, len(update))
+	for key := range update {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	entries := make([]PlutusData.MapEntry, len(keys))
+	for i, key := range keys {
+		var decoded any
+		if err := cbor.Unmarshal(update[key], &decoded); err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+		value, err := cborData(decoded)
+		if err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+		entries[i] = PlutusData.MapEntry{Key: PlutusData.NewBigInt(new(big.Int).SetUint64(key)), Value: value}
+	}
+	return PlutusData.NewMap(entries...), nil
+}
+
+func sortedCredentials[V any](m map[Credential.Credential]V) []Credential.Credential {
+	creds := make([]Credential.Credential, 0, len(m))
+	for cred := range m {
+		creds = append(creds, cred)
+	}
+	sort.Slice(creds, func(i, j int) bool { return creds[i].Less(creds[j]) })
+	return creds
+}
+
+func govActionData(action Governance.GovAction) (PlutusData.PlutusData, error) {
+	prev := maybeGovActionId(action.PrevActionId)
+	switch action.Type {
+	case Governance.ParameterChangeAction:
+		update, err := parameterChangeData(action.ParameterUpdate)
+		if err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+		return constr(0, prev, update, maybeBytes(action.PolicyHash)), nil
+	case Governance.HardForkInitiationAction:
+		version := action.ProtocolVersion
+		return constr(1, prev, constr(0, integer(int64(version.Major)), integer(int64(version.Minor)))), nil
+	case Governance.TreasuryWithdrawalsAction:
+		amounts := make(map[Credential.Credential]int64)
+		for account, amount := range action.Withdrawals {
+			amounts[accountCredential(account)] += int64(amount)
+		}
+		entries := make([]PlutusData.MapEntry, 0, len(amounts))
+		for _, cred := range sortedCredentials(amounts) {
+			entries = append(entries, PlutusData.MapEntry{Key: credentialData(cred), Value: integer(amounts[cred])})
+		}
+		return constr(2, PlutusData.NewMap(entries...), maybeBytes(action.PolicyHash)), nil
+	case Governance.NoConfidenceAction:
+		return constr(3, prev), nil
+	case Governance.UpdateCommitteeAction:
+		removed := append([]Credential.Credential{}, action.RemovedMembers...)
+		sort.Slice(removed, func(i, j int) bool { return removed[i].Less(removed[j]) })
+		removedData := make([]PlutusData.PlutusData, len(removed))
+		for i, cred := range removed {
+			removedData[i] = credentialData(cred)
+		}
+		added := make([]PlutusData.MapEntry, 0, len(action.AddedMembers))
+		for _, cred := range sortedCredentials(action.AddedMembers) {
+			added = append(added, PlutusData.MapEntry{Key: credentialData(cred), Value: integer(int64(action.AddedMembers[cred]))})
+		}
+		quorum := constr(0, integer(int64(action.Quorum.Numerator)), integer(int64(action.Quorum.Denominator)))
+		return constr(4, prev, PlutusData.NewList(removedData...), PlutusData.NewMap(added...), quorum), nil
+	case Governance.NewConstitutionAction:
+		return constr(5, prev, constr(0, maybeBytes(action.Constitution.ScriptHash))), nil
+	case Governance.InfoAction:
+		return constr(6), nil
+	}
+	return PlutusData.PlutusData{}, fmt.Errorf("unknown governance action %d", action.Type)
+}
+
+func proposalData(proposal Governance.ProposalProcedure) (PlutusData.PlutusData, error) {
+	action, err := govActionData(proposal.GovAction)
+	if err != nil {
+		return PlutusData.PlutusData{}, err
+	}
+	return constr(0, integer(proposal.Deposit), credentialData(accountCredential(proposal.RewardAccount)), action), nil
+}
+
+/*
+purposeData encodes what a redeemer is for. PlutusV1 and PlutusV2 only
+know about spending, minting, rewarding and certifying.
+*/
+func (c *txContext) purposeData(p purpose, language UPLC.Language) (PlutusData.PlutusData, error) {
+	switch p.tag {
+	case Redeemer.SPEND:
+		return constr(1, c.outRefData(p.input, language)), nil
+	case Redeemer.MINT:
+		return constr(0, PlutusData.NewBytes(p.policy)), nil
+	case Redeemer.REWARD:
+		cred := credentialData(accountCredential(p.account))
+		if language < UPLC.PlutusV3 {
+			cred = constr(0, cred)
+		}
+		return constr(2, cred), nil
+	case Redeemer.CERT:
+		if language < UPLC.PlutusV3 {
+			cert, err := dcertData(*p.cert)
+			if err != nil {
+				return PlutusData.PlutusData{}, err
+			}
+			return constr(3, cert), nil
+		}
+		cert, err := txCertData(*p.cert)
+		if err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+		return constr(3, integer(int64(p.index)), cert), nil
+	}
+	if language < UPLC.PlutusV3 {
+		return PlutusData.PlutusData{}, fmt.Errorf("%s redeemers are not supported in %s", Redeemer.RedeemerTagNames[p.tag], language)
+	}
+	if p.tag == Redeemer.VOTE {
+		return constr(4, voterData(p.voter)), nil
+	}
+	proposal, err := proposalData(p.proposal)
+	if err != nil {
+		return PlutusData.PlutusData{}, err
+	}
+	return constr(5, integer(int64(p.index)), proposal), nil
+}
+
+func (c *txContext) rangeData() PlutusData.PlutusData {
+	body := c.tx.TransactionBody
+	lower := constr(0, constr(0), boolData(true))
+	if body.ValidityStart != 0 {
+		lower = constr(0, constr(1, integer(c.slots.SlotToPosix(body.ValidityStart))), boolData(true))
+	}
+	upper := constr(0, constr(2), boolData(true))
+	if body.Ttl != 0 {
+		upper = constr(0, constr(1, integer(c.slots.SlotToPosix(body.Ttl))), boolData(false))
+	}
+	return constr(0, lower, upper)
+}
+
+/*
+txInfo builds the transaction part of the script context of a
+language. It fails on what the language can't represent, like inline
+datums in PlutusV1 or governance features before PlutusV3.
+*/
+func (c *txContext) txInfo(language UPLC.Language) (PlutusData.PlutusData, error) {
+	if info, ok := c.infos[language]; ok {
+		return info, nil
+	}
+	body := c.tx.TransactionBody
+	if language < UPLC.PlutusV3 && (len(c.voters) > 0 || len(body.ProposalProcedures) > 0 || body.TreasuryValue != nil || body.Donation != 0) {
+		return PlutusData.PlutusData{}, fmt.Errorf("governance features are not supported in %s", language)
+	}
+	if language == UPLC.PlutusV1 && len(body.ReferenceInputs) > 0 {
+		return PlutusData.PlutusData{}, fmt.Errorf("reference inputs are not supported in %s", language)
+	}
+	inputs, err := c.inputsData(c.inputs, language)
+	if err != nil {
+		return PlutusData.PlutusData{}, err
+	}
+	referenceInputs, err := c.inputsData(sortedInputs(body.ReferenceInputs), language)
+	if err != nil {
+		return PlutusData.PlutusData{}, err
+	}
+	outputs := make([]PlutusData.PlutusData, len(body.Outputs))
+	for i, output := range body.Outputs {
+		if outputs[i], err = c.outputData(output, language); err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+	}
+	fee := valueData(body.Fee, nil, true)
+	mint := valueData(0, body.Mint, true)
+	if language == UPLC.PlutusV3 {
+		fee = integer(body.Fee)
+		mint = valueData(0, body.Mint, false)
+	}
+	certs := make([]PlutusData.PlutusData, len(c.certificates))
+	for i, cert := range c.certificates {
+		if language < UPLC.PlutusV3 {
+			certs[i], err = dcertData(*cert)
+		} else {
+			certs[i], err = txCertData(*cert)
+		}
+		if err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+	}
+	withdrawals := make([]PlutusData.MapEntry, len(c.withdrawals))
+	for i, account := range c.withdrawals {
+		cred := credentialData(accountCredential(account))
+		if language < UPLC.PlutusV3 {
+			cred = constr(0, cred)
+		}
+		withdrawals[i] = PlutusData.MapEntry{Key: cred, Value: integer(int64((*body.Withdrawals)[account]))}
+	}
+	signers := make([]PlutusData.PlutusData, len(c.signers))
+	for i, signer := range c.signers {
+		signers[i] = PlutusData.NewBytes(signer[:])
+	}
+	datums := make([]PlutusData.MapEntry, len(c.datumHashes))
+	for i, hash := range c.datumHashes {
+		datums[i] = PlutusData.MapEntry{Key: PlutusData.NewBytes(hash), Value: c.datums[hex.EncodeToString(hash)]}
+	}
+	id := PlutusData.NewBytes(body.Hash())
+	var info PlutusData.PlutusData
+	if language == UPLC.PlutusV1 {
+		info = constr(0, inputs, PlutusData.NewList(outputs...), fee, mint, PlutusData.NewList(certs...),
+			tupleList(withdrawals), c.rangeData(), PlutusData.NewList(signers...), tupleList(datums), constr(0, id))
+		c.infos[language] = info
+		return info, nil
+	}
+	redeemers := make([]PlutusData.MapEntry, 0, len(c.redeemers))
+	for _, redeemer := range c.redeemers {
+		p, err := c.purpose(redeemer.Tag, redeemer.Index)
+		if err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+		key, err := c.purposeData(p, language)
+		if err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+		redeemers = append(redeemers, PlutusData.MapEntry{Key: key, Value: redeemer.Data})
+	}
+	if language == UPLC.PlutusV2 {
+		info = constr(0, inputs, referenceInputs, PlutusData.NewList(outputs...), fee, mint, PlutusData.NewList(certs...),
+			PlutusData.NewMap(withdrawals...), c.rangeData(), PlutusData.NewList(signers...),
+			PlutusData.NewMap(redeemers...), PlutusData.NewMap(datums...), constr(0, id))
+		c.infos[language] = info
+		return info, nil
+	}
+	votes := make([]PlutusData.MapEntry, 0, len(c.voters))
+	for _, voter := range c.voters {
+		procedures := (*body.VotingProcedures)[voter]
+		ids := make([]Governance.GovActionId, 0, len(procedures))
+		for id := range procedures {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i].Less(ids[j]) })
+		entries := make([]PlutusData.MapEntry, len(ids))
+		for i, id := range ids {
+			entries[i] = PlutusData.MapEntry{Key: govActionIdData(id), Value: constr(uint64(procedures[id].Vote))}
+		}
+		votes = append(votes, PlutusData.MapEntry{Key: voterData(voter), Value: PlutusData.NewMap(entries...)})
+	}
+	proposals := make([]PlutusData.PlutusData, len(body.ProposalProcedures))
+	for i, proposal := range body.ProposalProcedures {
+		if proposals[i], err = proposalData(proposal); err != nil {
+			return PlutusData.PlutusData{}, err
+		}
+	}
+	treasury, donation := nothing(), nothing()
+	if body.TreasuryValue != nil {
+		treasury = just(integer(*body.TreasuryValue))
+	}
+	if body.Donation != 0 {
+		donation = just(integer(body.Donation))
+	}
+	info = constr(0, inputs, referenceInputs, PlutusData.NewList(outputs...), fee, mint, PlutusData.NewList(certs...),
+		PlutusData.NewMap(withdrawals...), c.rangeData(), PlutusData.NewList(signers...),
+		PlutusData.NewMap(redeemers...), PlutusData.NewMap(datums...), id,
+		PlutusData.NewMap(votes...), PlutusData.NewList(proposals...), treasury, donation)
+	c.infos[language] = info
+	return info, nil
+}
+
+// tupleList encodes map entries as the list of pairs PlutusV1 uses.
+func tupleList(entries []PlutusData.MapEntry) PlutusData.PlutusData {
+	items := make([]PlutusData.PlutusData, len(entries))
+	for i, entry := range entries {
+		items[i] = constr(0, entry.Key, entry.Value)
+	}
+	return PlutusData.NewList(items...)
+}
+
+/*
+scriptArgs returns the arguments of the script run for a redeemer:
+the datum, redeemer and context for spending with PlutusV1 and
+PlutusV2, the redeemer and context for their other purposes, and only
+the context, holding both the redeemer and the datum, for PlutusV3.
+*/
+func (c *txContext) scriptArgs(p purpose, redeemer Redeemer.Redeemer, language UPLC.Language) ([]PlutusData.PlutusData, error) {
+	info, err := c.txInfo(language)
+	if err != nil {
+		return nil, err
+	}
+	var datum *PlutusData.PlutusData
+	if p.tag == Redeemer.SPEND {
+		if datum, err = c.spentDatum(p.input); err != nil {
+			return nil, err
+		}
+	}
+	if language < UPLC.PlutusV3 {
+		purpose, err := c.purposeData(p, language)
+		if err != nil {
+			return nil, err
+		}
+		ctx := constr(0, info, purpose)
+		if p.tag != Redeemer.SPEND {
+			return []PlutusData.PlutusData{redeemer.Data, ctx}, nil
+		}
+		if datum == nil {
+			return nil, fmt.Errorf("input %s has no datum", inputKey(p.input))
+		}
+		return []PlutusData.PlutusData{*datum, redeemer.Data, ctx}, nil
+	}
+	scriptInfo, err := c.purposeData(p, language)
+	if err != nil {
+		return nil, err
+	}
+	if p.tag == Redeemer.SPEND {
+		maybeDatum := nothing()
+		if datum != nil {
+			maybeDatum = just(*datum)
+		}
+		scriptInfo = constr(1, c.outRefData(p.input, language), maybeDatum)
+	}
+	return []PlutusData.PlutusData{constr(0, info, redeemer.Data, scriptInfo)}, nil
+}
@@ -0,0 +1,163 @@
+package SlotConfig
+
+import (
+	"time"
+
+	"github.com/SundaeSwap-finance/apollo/constants"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+)
+
+const (
+	BYRON_SLOT_LENGTH   = 20 * time.Second
+	SHELLEY_SLOT_LENGTH = time.Second
+)
+
+// Network magics of the public networks, as found in GenesisParameters.
+const (
+	MAINNET_MAGIC = 764824073
+	TESTNET_MAGIC = 1097911063
+	PREPROD_MAGIC = 1
+	PREVIEW_MAGIC = 2
+)
+
+// Era is a stretch of the chain with a fixed slot length.
+type Era struct {
+	StartSlot  int64
+	StartTime  time.Time
+	SlotLength time.Duration
+}
+
+// SlotConfig converts between slots and POSIX time over the eras of a
+// network, which are sorted by start slot. Without eras it converts like
+// mainnet.
+type SlotConfig struct {
+	Eras []Era
+}
+
+type networkInfo struct {
+	systemStart int64
+	shelleySlot int64
+}
+
+// Byron start times and the first Shelley slot of the public networks.
+var networks = map[int]networkInfo{
+	MAINNET_MAGIC: {systemStart: 1506203091, shelleySlot: 4492800},
+	TESTNET_MAGIC: {systemStart: 1563999616, shelleySlot: 1598400},
+	PREPROD_MAGIC: {systemStart: 1654041600, shelleySlot: 86400},
+	PREVIEW_MAGIC: {systemStart: 1666656000, shelleySlot: 0},
+}
+
+/*
+FromGenesis builds the slot config from the genesis system start and
+slot length, which defaults to the Shelley one. Known networks get
+their Byron era in front, every other network is assumed to start in
+Shelley.
+*/
+func FromGenesis(genesis Base.GenesisParameters) SlotConfig {
+	start := time.Unix(int64(genesis.SystemStart), 0).UTC()
+	slotLength := time.Duration(genesis.SlotLength) * time.Second
+	if slotLength <= 0 {
+		slotLength = SHELLEY_SLOT_LENGTH
+	}
+	info, ok := networks[genesis.NetworkMagic]
+	if !ok || info.shelleySlot == 0 {
+		return SlotConfig{Eras: []Era{{StartSlot: 0, StartTime: start, SlotLength: slotLength}}}
+	}
+	return SlotConfig{Eras: []Era{
+		{StartSlot: 0, StartTime: start, SlotLength: BYRON_SLOT_LENGTH},
+		{
+			StartSlot:  info.shelleySlot,
+			StartTime:  start.Add(time.Duration(info.shelleySlot) * BYRON_SLOT_LENGTH),
+			SlotLength: slotLength,
+		},
+	}}
+}
+
+// ForNetwork returns the slot config of one of the public networks.
+func ForNetwork(network constants.Network) SlotConfig {
+	magic := MAINNET_MAGIC
+	switch network {
+	case constants.TESTNET:
+		magic = TESTNET_MAGIC
+	case constants.PREPROD:
+		magic = PREPROD_MAGIC
+	case constants.PREVIEW:
+		magic = PREVIEW_MAGIC
+	}
+	return FromGenesis(Base.GenesisParameters{
+		NetworkMagic: magic,
+		SystemStart:  int(networks[magic].systemStart),
+		SlotLength:   1,
+	})
+}
+
+func (sc SlotConfig) eras() []Era {
+	if len(sc.Eras) == 0 {
+		return ForNetwork(constants.MAINNET).Eras
+	}
+	return sc.Eras
+}
+
+// withSlotLength gives eras without a slot length the Shelley one.
+func withSlotLength(era Era) Era {
+	if era.SlotLength <= 0 {
+		era.SlotLength = SHELLEY_SLOT_LENGTH
+	}
+	return era
+}
+
+func (sc SlotConfig) eraForSlot(slot int64) Era {
+	eras := sc.eras()
+	era := eras[0]
+	for _, e := range eras[1:] {
+		if slot < e.StartSlot {
+			break
+		}
+		era = e
+	}
+	return withSlotLength(era)
+}
+
+func (sc SlotConfig) eraForTime(t time.Time) Era {
+	eras := sc.eras()
+	era := eras[0]
+	for _, e := range eras[1:] {
+		if t.Before(e.StartTime) {
+			break
+		}
+		era = e
+	}
+	return withSlotLength(era)
+}
+
+// SlotToTime returns the time at which slot begins.
+func (sc SlotConfig) SlotToTime(slot int64) time.Time {
+	era := sc.eraForSlot(slot)
+	return era.StartTime.Add(time.Duration(slot-era.StartSlot) * era.SlotLength)
+}
+
+// SlotToPosix returns the POSIX time in milliseconds at which slot
+// begins, the way Plutus validators see it.
+func (sc SlotConfig) SlotToPosix(slot int64) int64 {
+	return sc.SlotToTime(slot).UnixMilli()
+}
+
+// TimeToSlot returns the slot that contains t, rounding down.
+func (sc SlotConfig) TimeToSlot(t time.Time) int64 {
+	era := sc.eraForTime(t)
+	return era.StartSlot + int64(t.Sub(era.StartTime)/era.SlotLength)
+}
+
+// TimeToSlotCeil returns the first slot that begins at or after t.
+func (sc SlotConfig) TimeToSlotCeil(t time.Time) int64 {
+	slot := sc.TimeToSlot(t)
+	if sc.SlotToTime(slot).Before(t) {
+		slot++
+	}
+	return slot
+}
+
+// PosixToSlot is TimeToSlot for a POSIX time in milliseconds.
+func (sc SlotConfig) PosixToSlot(posix int64) int64 {
+	return sc.TimeToSlot(time.UnixMilli(posix))
+}
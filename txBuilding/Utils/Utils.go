@@ -67,11 +67,9 @@ func Fee(context Base.ChainContext, txSize int, steps int64, mem int64, referenc
 			refScriptsSize += len(script.Script.Script)
 		}
 	}
-	fee := int64(txSize*pm.MinFeeCoefficient+
-		pm.MinFeeConstant+
-		int(float32(steps)*pm.PriceStep)+
-		int(float32(mem)*pm.PriceMem)+
-		refScriptsSize*pm.MinFeeReferenceScripts) + 10_000
+	fee := int64(txSize*pm.MinFeeCoefficient+pm.MinFeeConstant) +
+		pm.ScriptsFee(mem, steps) +
+		pm.ReferenceScriptsFee(refScriptsSize) + 10_000
 	return fee, nil
 }
 
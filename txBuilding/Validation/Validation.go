@@ -0,0 +1,671 @@
+package Validation
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/SundaeSwap-finance/apollo/serialization"
+	"github.com/SundaeSwap-finance/apollo/serialization/Address"
+	"github.com/SundaeSwap-finance/apollo/serialization/Asset"
+	"github.com/SundaeSwap-finance/apollo/serialization/AssetName"
+	"github.com/SundaeSwap-finance/apollo/serialization/Certificate"
+	"github.com/SundaeSwap-finance/apollo/serialization/Governance"
+	"github.com/SundaeSwap-finance/apollo/serialization/MultiAsset"
+	"github.com/SundaeSwap-finance/apollo/serialization/PlutusData"
+	"github.com/SundaeSwap-finance/apollo/serialization/Policy"
+	"github.com/SundaeSwap-finance/apollo/serialization/Redeemer"
+	"github.com/SundaeSwap-finance/apollo/serialization/Transaction"
+	"github.com/SundaeSwap-finance/apollo/serialization/TransactionInput"
+	"github.com/SundaeSwap-finance/apollo/serialization/UTxO"
+	"github.com/SundaeSwap-finance/apollo/serialization/Value"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Backend/Base"
+	"github.com/SundaeSwap-finance/apollo/txBuilding/Errors"
+
+	"github.com/Salvionied/cbor/v2"
+)
+
+// Babbage min-UTxO overhead, in bytes, added to the serialized output.
+const MIN_UTXO_OVERHEAD = 160
+
+/*
+Validate runs the phase-1 ledger rules that can be checked locally
+against a transaction. resolvedInputs must hold the UTxOs for every
+input, collateral input and reference input of the transaction.
+
+All broken rules are reported at once in an *Errors.ValidationErrors,
+the individual errors can be matched with errors.As.
+*/
+func Validate(tx Transaction.Transaction, resolvedInputs []UTxO.UTxO, pp Base.ProtocolParameters) error {
+	v := newValidator(tx, resolvedInputs, pp)
+	v.run()
+	return v.result()
+}
+
+// ValidateAtSlot is Validate with the validity interval of the
+// transaction also checked against currentSlot.
+func ValidateAtSlot(tx Transaction.Transaction, resolvedInputs []UTxO.UTxO, pp Base.ProtocolParameters, currentSlot int64) error {
+	v := newValidator(tx, resolvedInputs, pp)
+	v.run()
+	v.checkValidityInterval(currentSlot)
+	return v.result()
+}
+
+// parseDeposit parses the deposit protocol parameter called name.
+func parseDeposit(name string, deposit string) (int64, error) {
+	parsed, err := strconv.ParseInt(deposit, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s deposit %q: %w", name, deposit, err)
+	}
+	return parsed, nil
+}
+
+// Deposits returns the lovelace locked by the certificates and
+// proposals of a transaction.
+func Deposits(certificates *Certificate.Certificates, proposals Governance.ProposalProcedures, pp Base.ProtocolParameters) (int64, error) {
+	deposits := proposals.TotalDeposit()
+	if certificates == nil {
+		return deposits, nil
+	}
+	for _, cert := range *certificates {
+		switch cert.Type {
+		case Certificate.StakeRegistration:
+			keyDeposit, err := parseDeposit("key", pp.KeyDeposits)
+			if err != nil {
+				return 0, err
+			}
+			deposits += keyDeposit
+		case Certificate.PoolRegistration:
+			// Re-registering an existing pool does not take a new
+			// deposit, but we can't tell the two apart from here.
+			poolDeposit, err := parseDeposit("pool", pp.PoolDeposits)
+			if err != nil {
+				return 0, err
+			}
+			deposits += poolDeposit
+		case Certificate.Registration,
+			Certificate.StakeRegistrationDelegation,
+			Certificate.VoteRegistrationDelegation,
+			Certificate.StakeVoteRegistrationDelegation,
+			Certificate.RegisterDRep:
+			deposits += cert.Deposit
+		}
+	}
+	return deposits, nil
+}
+
+// Refunds returns the lovelace released by the deregistration
+// certificates of a transaction.
+func Refunds(certificates *Certificate.Certificates, pp Base.ProtocolParameters) (int64, error) {
+	refunds := int64(0)
+	if certificates == nil {
+		return refunds, nil
+	}
+	for _, cert := range *certificates {
+		switch cert.Type {
+		case Certificate.StakeDeregistration:
+			keyDeposit, err := parseDeposit("key", pp.KeyDeposits)
+			if err != nil {
+				return 0, err
+			}
+			refunds += keyDeposit
+		case Certificate.Unregistration, Certificate.UnregisterDRep:
+			refunds += cert.Deposit
+		}
+	}
+	return refunds, nil
+}
+
+// MinFee returns the minimum fee the ledger accepts for tx.
+func MinFee(tx Transaction.Transaction, resolvedInputs []UTxO.UTxO, pp Base.ProtocolParameters) int64 {
+	exUnits := Redeemer.ExecutionUnits{}
+	for _, redeemer := range tx.TransactionWitnessSet.Redeemer {
+		exUnits.Sum(redeemer.ExUnits)
+	}
+	resolved := resolve(resolvedInputs)
+	refScriptsSize := 0
+	spent := append(append([]TransactionInput.TransactionInput{}, tx.TransactionBody.Inputs...), tx.TransactionBody.ReferenceInputs...)
+	for _, input := range spent {
+		utxo, ok := resolved[inputKey(input)]
+		if !ok {
+			continue
+		}
+		if script := utxo.Output.GetScriptRef(); script != nil {
+			refScriptsSize += len(script.Script.Script)
+		}
+	}
+	return int64(len(tx.Bytes())*pp.MinFeeCoefficient+pp.MinFeeConstant) +
+		pp.ScriptsFee(exUnits.Mem, exUnits.Steps) +
+		pp.ReferenceScriptsFee(refScriptsSize)
+}
+
+/*
+ScriptDataHash computes the script integrity hash of the redeemers and
+datums of a transaction for the given plutus languages. It returns nil
+when there are neither redeemers nor datums.
+*/
+func ScriptDataHash(redeemers []Redeemer.Redeemer, datums PlutusData.PlutusIndefArray, languages []Base.CostModelsPlutusVersion, pp Base.ProtocolParameters) ([]byte, error) {
+	if len(redeemers) == 0 && datums.Len() == 0 {
+		return nil, nil
+	}
+	if redeemers == nil {
+		redeemers = []Redeemer.Redeemer{}
+	}
+	redeemerBytes, err := cbor.Marshal(redeemers)
+	if err != nil {
+		return nil, err
+	}
+	datumBytes := []byte{}
+	if datums.Len() > 0 {
+		datumBytes, err = cbor.Marshal(datums)
+		if err != nil {
+			return nil, err
+		}
+	}
+	costModels := map[cbor.Marshaler]cbor.Marshaler{}
+	for _, language := range languages {
+		cm, ok := pp.CostModels[language]
+		if !ok {
+			return nil, fmt.Errorf("no cost model for plutus language %d", language)
+		}
+		switch language {
+		case Base.CostModelsPlutusV1:
+			costModels[serialization.CustomBytes{Value: "00"}] = PlutusData.CostModelV1(cm)
+		case Base.CostModelsPlutusV2:
+			costModels[serialization.CustomBytesInt(1)] = PlutusData.CostModelV2(cm)
+		case Base.CostModelsPlutusV3:
+			costModels[serialization.CustomBytesInt(2)] = PlutusData.CostModelV3(cm)
+		}
+	}
+	em, err := cbor.CanonicalEncOptions().EncMode()
+	if err != nil {
+		return nil, err
+	}
+	costModelBytes, err := em.Marshal(costModels)
+	if err != nil {
+		return nil, err
+	}
+	total := append(redeemerBytes, datumBytes...)
+	total = append(total, costModelBytes...)
+	return serialization.Blake2bHash(total), nil
+}
+
+type validator struct {
+	tx       Transaction.Transaction
+	pp       Base.ProtocolParameters
+	resolved map[string]UTxO.UTxO
+	errs     []error
+}
+
+func newValidator(tx Transaction.Transaction, resolvedInputs []UTxO.UTxO, pp Base.ProtocolParameters) *validator {
+	return &validator{tx: tx, pp: pp, resolved: resolve(resolvedInputs)}
+}
+
+func resolve(utxos []UTxO.UTxO) map[string]UTxO.UTxO {
+	resolved := make(map[string]UTxO.UTxO)
+	for _, utxo := range utxos {
+		resolved[inputKey(utxo.Input)] = utxo
+	}
+	return resolved
+}
+
+func inputKey(input TransactionInput.TransactionInput) string {
+	return fmt.Sprintf("%s:%d", hex.EncodeToString(input.TransactionId), input.Index)
+}
+
+func (v *validator) fail(err error) {
+	v.errs = append(v.errs, err)
+}
+
+func (v *validator) result() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &Errors.ValidationErrors{Errors: v.errs}
+}
+
+func (v *validator) run() {
+	body := v.tx.TransactionBody
+	allResolved := true
+	for _, inputs := range [][]TransactionInput.TransactionInput{body.Inputs, body.Collateral, body.ReferenceInputs} {
+		for _, input := range inputs {
+			if _, ok := v.resolved[inputKey(input)]; !ok {
+				v.fail(&Errors.UnresolvedInputError{Input: input})
+				allResolved = false
+			}
+		}
+	}
+	if txSize := len(v.tx.Bytes()); txSize > v.pp.MaxTxSize {
+		v.fail(&Errors.TransactionTooBigError{
+			Msg: fmt.Sprintf("transaction is %d bytes, max is %d", txSize, v.pp.MaxTxSize),
+		})
+	}
+	if allResolved {
+		v.checkValueConserved()
+	}
+	v.checkOutputs()
+	v.checkFee()
+	v.checkSignatures()
+	v.checkCollateral()
+	v.checkScriptDataHash()
+	v.checkNetwork()
+}
+
+type assetKey struct {
+	policy Policy.PolicyId
+	name   AssetName.AssetName
+}
+
+// balance is a value that can go negative without the quirks of
+// Value.Sub, used to compare both sides of the transaction.
+type balance struct {
+	coin   int64
+	assets map[assetKey]int64
+}
+
+func newBalance() *balance {
+	return &balance{assets: make(map[assetKey]int64)}
+}
+
+func (b *balance) addAssets(ma MultiAsset.MultiAsset[int64], sign int64) {
+	for policy, asset := range ma {
+		for name, amount := range asset {
+			b.assets[assetKey{policy, name}] += sign * amount
+		}
+	}
+}
+
+func (b *balance) add(val Value.Value, sign int64) {
+	b.coin += sign * val.GetCoin()
+	b.addAssets(val.GetAssets(), sign)
+}
+
+func (b *balance) hasAssets() bool {
+	for _, amount := range b.assets {
+		if amount != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *balance) equal(other *balance) bool {
+	if b.coin != other.coin {
+		return false
+	}
+	for key, amount := range b.assets {
+		if other.assets[key] != amount {
+			return false
+		}
+	}
+	for key, amount := range other.assets {
+		if b.assets[key] != amount {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *balance) value() Value.Value {
+	ma := MultiAsset.MultiAsset[int64]{}
+	for key, amount := range b.assets {
+		if amount == 0 {
+			continue
+		}
+		if _, ok := ma[key.policy]; !ok {
+			ma[key.policy] = Asset.Asset[int64]{}
+		}
+		ma[key.policy][key.name] = amount
+	}
+	return Value.SimpleValue(b.coin, ma)
+}
+
+func (v *validator) checkValueConserved() {
+	body := v.tx.TransactionBody
+	consumed := newBalance()
+	for _, input := range body.Inputs {
+		utxo := v.resolved[inputKey(input)]
+		consumed.add(utxo.Output.GetValue(), 1)
+	}
+	// burns are negative mints, so they come off the consumed side
+	consumed.addAssets(body.Mint, 1)
+	if body.Withdrawals != nil {
+		for _, amount := range *body.Withdrawals {
+			consumed.coin += int64(amount)
+		}
+	}
+	refunds, err := Refunds(body.Certificates, v.pp)
+	if err != nil {
+		v.fail(err)
+		return
+	}
+	consumed.coin += refunds
+
+	produced := newBalance()
+	for i := range body.Outputs {
+		produced.add(body.Outputs[i].GetValue(), 1)
+	}
+	produced.coin += body.Fee
+	deposits, err := Deposits(body.Certificates, body.ProposalProcedures, v.pp)
+	if err != nil {
+		v.fail(err)
+		return
+	}
+	produced.coin += deposits
+	produced.coin += body.Donation
+
+	if !consumed.equal(produced) {
+		v.fail(&Errors.ValueNotConservedError{Consumed: consumed.value(), Produced: produced.value()})
+	}
+}
+
+func (v *validator) checkOutputs() {
+	maxValSize, _ := strconv.Atoi(v.pp.MaxValSize)
+	coinsPerUtxoByte := int64(v.pp.GetCoinsPerUtxoByte())
+	outputs := v.tx.TransactionBody.Outputs
+	for i := range outputs {
+		output := &outputs[i]
+		encoded, err := cbor.Marshal(output)
+		if err != nil {
+			v.fail(err)
+			continue
+		}
+		minLovelace := (MIN_UTXO_OVERHEAD + int64(len(encoded))) * coinsPerUtxoByte
+		if output.Lovelace() < minLovelace {
+			v.fail(&Errors.OutputTooSmallError{Index: i, Lovelace: output.Lovelace(), MinLovelace: minLovelace})
+		}
+		if maxValSize > 0 {
+			amount := output.GetValue()
+			encodedValue, err := cbor.Marshal(&amount)
+			if err != nil {
+				v.fail(err)
+				continue
+			}
+			if len(encodedValue) > maxValSize {
+				v.fail(&Errors.OutputValueTooBigError{Index: i, Size: len(encodedValue), MaxSize: maxValSize})
+			}
+		}
+	}
+}
+
+func (v *validator) checkFee() {
+	resolved := make([]UTxO.UTxO, 0, len(v.resolved))
+	for _, utxo := range v.resolved {
+		resolved = append(resolved, utxo)
+	}
+	minFee := MinFee(v.tx, resolved, v.pp)
+	if v.tx.TransactionBody.Fee < minFee {
+		v.fail(&Errors.FeeTooSmallError{Fee: v.tx.TransactionBody.Fee, MinFee: minFee})
+	}
+}
+
+/*
+RequiredKeyHashes returns the keys that must sign tx: its required
+signers and the key credentials of the resolved inputs and collaterals,
+withdrawals, certificates and voters. Bootstrap inputs require the root
+of their address, which their bootstrap witness hashes to. Native
+script keys are not included.
+*/
+func RequiredKeyHashes(tx Transaction.Transaction, resolvedInputs []UTxO.UTxO) []serialization.PubKeyHash {
+	return newValidator(tx, resolvedInputs, Base.ProtocolParameters{}).requiredKeyHashes()
+}
+
+// requiredKeyHashes returns the key hashes whose signatures are needed
+// by the inputs, withdrawals, certificates, votes and required signers.
+func (v *validator) requiredKeyHashes() []serialization.PubKeyHash {
+	body := v.tx.TransactionBody
+	required := make(map[serialization.PubKeyHash]bool)
+	for _, signer := range body.RequiredSigners {
+		required[signer] = true
+	}
+	for _, inputs := range [][]TransactionInput.TransactionInput{body.Inputs, body.Collateral} {
+		for _, input := range inputs {
+			utxo, ok := v.resolved[inputKey(input)]
+			if !ok {
+				continue
+			}
+			address := utxo.Output.GetAddress()
+			switch address.AddressType {
+			case Address.KEY_KEY, Address.KEY_SCRIPT, Address.KEY_POINTER, Address.KEY_NONE:
+				var pkh serialization.PubKeyHash
+				copy(pkh[:], address.PaymentPart)
+				required[pkh] = true
+			case Address.BYRON:
+				if byron, err := address.Byron(); err == nil {
+					var pkh serialization.PubKeyHash
+					copy(pkh[:], byron.Root)
+					required[pkh] = true
+				}
+			}
+		}
+	}
+	if body.Withdrawals != nil {
+		for account := range *body.Withdrawals {
+			if account[0]>>4 == Address.NONE_KEY {
+				var pkh serialization.PubKeyHash
+				copy(pkh[:], account[1:])
+				required[pkh] = true
+			}
+		}
+	}
+	if body.Certificates != nil {
+		for _, cert := range *body.Certificates {
+			for _, cred := range cert.Witnesses() {
+				if !cred.IsScript() {
+					required[cred.Hash] = true
+				}
+			}
+		}
+	}
+	if body.VotingProcedures != nil {
+		for _, voter := range body.VotingProcedures.Voters() {
+			if !voter.IsScript() {
+				required[voter.Hash] = true
+			}
+		}
+	}
+	hashes := make([]serialization.PubKeyHash, 0, len(required))
+	for pkh := range required {
+		hashes = append(hashes, pkh)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+	return hashes
+}
+
+func (v *validator) checkSignatures() {
+	bodyHash := v.tx.TransactionBody.Hash()
+	signed := make(map[serialization.PubKeyHash]bool)
+	for _, witness := range v.tx.TransactionWitnessSet.VkeyWitnesses {
+		if !witness.Vkey.Verify(bodyHash, witness.Signature) {
+			v.fail(&Errors.InvalidSignatureError{Vkey: witness.Vkey.Payload})
+			continue
+		}
+		pkh, err := witness.Vkey.Hash()
+		if err != nil {
+			v.fail(err)
+			continue
+		}
+		signed[pkh] = true
+	}
+	for _, witness := range v.tx.TransactionWitnessSet.BootstrapWitnesses {
+		if !witness.Verify(bodyHash) {
+			v.fail(&Errors.InvalidSignatureError{Vkey: witness.PublicKey})
+			continue
+		}
+		var root serialization.PubKeyHash
+		copy(root[:], witness.Root())
+		signed[root] = true
+	}
+	for _, pkh := range v.requiredKeyHashes() {
+		if !signed[pkh] {
+			v.fail(&Errors.MissingSignatureError{KeyHash: pkh})
+		}
+	}
+}
+
+func (v *validator) checkCollateral() {
+	body := v.tx.TransactionBody
+	if len(v.tx.TransactionWitnessSet.Redeemer) == 0 {
+		return
+	}
+	if len(body.Collateral) == 0 {
+		v.fail(&Errors.NoCollateralInputsError{})
+		return
+	}
+	if v.pp.MaxCollateralInuts > 0 && len(body.Collateral) > v.pp.MaxCollateralInuts {
+		v.fail(&Errors.TooManyCollateralInputsError{Count: len(body.Collateral), Max: v.pp.MaxCollateralInuts})
+	}
+	collateral := newBalance()
+	for _, input := range body.Collateral {
+		utxo, ok := v.resolved[inputKey(input)]
+		if !ok {
+			return
+		}
+		collateral.add(utxo.Output.GetValue(), 1)
+	}
+	if body.CollateralReturn != nil {
+		collateral.add(body.CollateralReturn.GetValue(), -1)
+	}
+	if collateral.hasAssets() {
+		v.fail(&Errors.CollateralContainsNonAdaError{Balance: collateral.value()})
+	}
+	required := (body.Fee*int64(v.pp.CollateralPercent) + 99) / 100
+	if collateral.coin < required {
+		v.fail(&Errors.InsufficientCollateralError{Provided: collateral.coin, Required: required})
+	}
+	if body.TotalCollateral != 0 && int64(body.TotalCollateral) != collateral.coin {
+		v.fail(&Errors.IncorrectTotalCollateralError{Declared: int64(body.TotalCollateral), Actual: collateral.coin})
+	}
+}
+
+/*
+checkScriptDataHash recomputes the script data hash. Languages of the
+scripts in the witness set are always part of it, scripts used through
+reference inputs can't be told apart by version so every combination
+of the remaining languages is accepted when any are present.
+*/
+func (v *validator) checkScriptDataHash() {
+	witnessSet := v.tx.TransactionWitnessSet
+	actual := v.tx.TransactionBody.ScriptDataHash
+	if len(witnessSet.Redeemer) == 0 && witnessSet.PlutusData.Len() == 0 {
+		if len(actual) > 0 {
+			v.fail(&Errors.ScriptDataHashMismatchError{Expected: nil, Actual: actual})
+		}
+		return
+	}
+	required := make([]Base.CostModelsPlutusVersion, 0)
+	optional := make([]Base.CostModelsPlutusVersion, 0)
+	present := []bool{
+		len(witnessSet.PlutusV1Script) > 0,
+		len(witnessSet.PlutusV2Script) > 0,
+		len(witnessSet.PlutusV3Script) > 0,
+	}
+	for i, version := range []Base.CostModelsPlutusVersion{Base.CostModelsPlutusV1, Base.CostModelsPlutusV2, Base.CostModelsPlutusV3} {
+		if present[i] {
+			required = append(required, version)
+		} else {
+			optional = append(optional, version)
+		}
+	}
+	if !v.hasReferenceScripts() {
+		optional = nil
+	}
+	var expected []byte
+	for subset := 0; subset < 1<<len(optional); subset++ {
+		languages := append([]Base.CostModelsPlutusVersion{}, required...)
+		for i, version := range optional {
+			if subset&(1<<i) != 0 {
+				languages = append(languages, version)
+			}
+		}
+		hash, err := ScriptDataHash(witnessSet.Redeemer, witnessSet.PlutusData, languages, v.pp)
+		if err != nil {
+			// without the cost models there is nothing to compare against
+			continue
+		}
+		if bytes.Equal(hash, actual) {
+			return
+		}
+		if expected == nil {
+			expected = hash
+		}
+	}
+	if expected != nil {
+		v.fail(&Errors.ScriptDataHashMismatchError{Expected: expected, Actual: actual})
+	}
+}
+
+func (v *validator) hasReferenceScripts() bool {
+	body := v.tx.TransactionBody
+	for _, inputs := range [][]TransactionInput.TransactionInput{body.Inputs, body.ReferenceInputs} {
+		for _, input := range inputs {
+			utxo, ok := v.resolved[inputKey(input)]
+			if ok && utxo.Output.GetScriptRef() != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkNetwork compares output addresses and withdrawals against the
+// body network id, or the network of the spent inputs when unset.
+func (v *validator) checkNetwork() {
+	body := v.tx.TransactionBody
+	var network byte
+	known := false
+	if len(body.NetworkId) > 0 {
+		network, known = body.NetworkId[0], true
+	} else {
+		for _, input := range body.Inputs {
+			utxo, ok := v.resolved[inputKey(input)]
+			if !ok {
+				continue
+			}
+			address := utxo.Output.GetAddress()
+			if address.AddressType != Address.BYRON {
+				network, known = address.Network, true
+				break
+			}
+		}
+	}
+	if !known {
+		return
+	}
+	checkAddress := func(field string, address Address.Address) {
+		if address.AddressType != Address.BYRON && address.Network != network {
+			v.fail(&Errors.WrongNetworkError{Field: field, Expected: network, Actual: address.Network})
+		}
+	}
+	for i := range body.Outputs {
+		checkAddress(fmt.Sprintf("output %d", i), body.Outputs[i].GetAddress())
+	}
+	if body.CollateralReturn != nil {
+		checkAddress("collateral return", body.CollateralReturn.GetAddress())
+	}
+	if body.Withdrawals != nil {
+		for account := range *body.Withdrawals {
+			if account[0]&0x0F != network {
+				v.fail(&Errors.WrongNetworkError{
+					Field:    "withdrawal " + hex.EncodeToString(account[:]),
+					Expected: network,
+					Actual:   account[0] & 0x0F,
+				})
+			}
+		}
+	}
+}
+
+func (v *validator) checkValidityInterval(currentSlot int64) {
+	body := v.tx.TransactionBody
+	if (body.ValidityStart != 0 && currentSlot < body.ValidityStart) ||
+		(body.Ttl != 0 && currentSlot >= body.Ttl) {
+		v.fail(&Errors.OutsideValidityIntervalError{
+			Slot:          currentSlot,
+			ValidityStart: body.ValidityStart,
+			Ttl:           body.Ttl,
+		})
+	}
+}